@@ -0,0 +1,215 @@
+// Package analyze reports the documentation status of the symbols in a
+// codebase without generating any documentation. It is intended for tools such
+// as dashboards and IDE plugins that only need insights into the documentation
+// coverage of a codebase and do not configure a [generate.Service].
+//
+// [generate.Service]: https://pkg.go.dev/github.com/modernice/jotbot/generate#Service
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/modernice/jotbot/find"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Language is implemented by languages that can report the documentation
+// status of the public symbols in their source files.
+type Language interface {
+	// Extensions returns the file extensions of the language, including the
+	// leading dot.
+	Extensions() []string
+
+	// Symbols returns the public symbols in the given file, documented or not,
+	// sorted by identifier. The identifiers are the same as those that are
+	// reported by the Find method of the language.
+	Symbols(ctx context.Context, file string, code []byte) ([]Symbol, error)
+}
+
+// Symbol is a public symbol in a source file.
+type Symbol struct {
+	// Identifier is the identifier of the symbol, e.g. "func:Foo".
+	Identifier string `json:"identifier"`
+
+	// Documented reports whether the symbol has documentation.
+	Documented bool `json:"documented"`
+
+	// Doc is the text of the documentation of the symbol, without comment
+	// markers. It is empty if the symbol is undocumented.
+	Doc string `json:"doc,omitempty"`
+}
+
+// File is the analysis of a single source file.
+type File struct {
+	// Path is the path of the file, relative to the analyzed directory.
+	Path string `json:"path"`
+
+	// Language is the name of the language of the file.
+	Language string `json:"language"`
+
+	// Symbols are the public symbols in the file, sorted by identifier.
+	Symbols []Symbol `json:"symbols"`
+}
+
+// Documented returns the number of documented symbols in the file.
+func (f File) Documented() int {
+	var n int
+	for _, s := range f.Symbols {
+		if s.Documented {
+			n++
+		}
+	}
+	return n
+}
+
+// Coverage returns the share of documented symbols in the file, between 0 and
+// 1. A file without symbols is fully covered.
+func (f File) Coverage() float64 {
+	return coverage(f.Documented(), len(f.Symbols))
+}
+
+// Result is the analysis of a directory.
+type Result struct {
+	// Files are the analyzed files, sorted by path.
+	Files []File `json:"files"`
+}
+
+// Symbols returns the number of symbols in all files.
+func (r Result) Symbols() int {
+	var n int
+	for _, f := range r.Files {
+		n += len(f.Symbols)
+	}
+	return n
+}
+
+// Documented returns the number of documented symbols in all files.
+func (r Result) Documented() int {
+	var n int
+	for _, f := range r.Files {
+		n += f.Documented()
+	}
+	return n
+}
+
+// Coverage returns the share of documented symbols in all files, between 0
+// and 1.
+func (r Result) Coverage() float64 {
+	return coverage(r.Documented(), r.Symbols())
+}
+
+func coverage(documented, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(documented) / float64(total)
+}
+
+// Analyzer reports the documentation status of the symbols in source files,
+// using the configured languages.
+type Analyzer struct {
+	languages     map[string]Language
+	extToLanguage map[string]string
+	log           *slog.Logger
+}
+
+// Option configures an [*Analyzer].
+type Option func(*Analyzer)
+
+// WithLanguage configures the Analyzer to analyze the files with the
+// extensions of the given language.
+func WithLanguage(name string, lang Language) Option {
+	return func(a *Analyzer) {
+		a.languages[name] = lang
+		for _, ext := range lang.Extensions() {
+			a.extToLanguage[ext] = name
+		}
+	}
+}
+
+// WithLogger configures the Analyzer to log to the given handler.
+func WithLogger(h slog.Handler) Option {
+	return func(a *Analyzer) {
+		a.log = slog.New(h)
+	}
+}
+
+// New returns an Analyzer that is configured by the given options.
+func New(opts ...Option) *Analyzer {
+	a := &Analyzer{
+		languages:     make(map[string]Language),
+		extToLanguage: make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.log == nil {
+		a.log = internal.NopLogger()
+	}
+	return a
+}
+
+// Extensions returns the file extensions of the configured languages.
+func (a *Analyzer) Extensions() []string {
+	exts := maps.Keys(a.extToLanguage)
+	slices.Sort(exts)
+	return exts
+}
+
+// File analyzes the given code of a file. The language of the file is
+// determined by the extension of its path.
+func (a *Analyzer) File(ctx context.Context, path string, code []byte) (File, error) {
+	ext := filepath.Ext(path)
+	name, ok := a.extToLanguage[ext]
+	if !ok {
+		return File{}, fmt.Errorf("no language configured for file extension %q", ext)
+	}
+
+	symbols, err := a.languages[name].Symbols(ctx, path, code)
+	if err != nil {
+		return File{}, fmt.Errorf("analyze %s: %w", path, err)
+	}
+
+	return File{Path: path, Language: name, Symbols: symbols}, nil
+}
+
+// Dir analyzes the files in fsys that have the extension of a configured
+// language. The files are searched using [find.Files], so the default exclude
+// patterns apply unless they are overridden by opts.
+func (a *Analyzer) Dir(ctx context.Context, fsys fs.FS, opts ...find.Option) (Result, error) {
+	opts = append([]find.Option{find.Extensions(a.Extensions()...)}, opts...)
+
+	paths, err := find.Files(ctx, fsys, opts...)
+	if err != nil {
+		return Result{}, fmt.Errorf("find files: %w", err)
+	}
+	slices.Sort(paths)
+
+	var result Result
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		code, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return result, fmt.Errorf("read file %s: %w", path, err)
+		}
+
+		file, err := a.File(ctx, path, code)
+		if err != nil {
+			return result, err
+		}
+		a.log.Debug(fmt.Sprintf("Analyzed %s: %d/%d symbols documented", path, file.Documented(), len(file.Symbols)))
+
+		result.Files = append(result.Files, file)
+	}
+
+	return result, nil
+}
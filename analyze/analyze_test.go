@@ -0,0 +1,64 @@
+package analyze_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/analyze"
+	"github.com/modernice/jotbot/langs/golang"
+)
+
+var _ analyze.Language = (*golang.Service)(nil)
+
+func TestAnalyzer_Dir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.go":     {Data: []byte("package foo\n\n// Foo is a foo.\nfunc Foo() {}\n\nfunc Bar() {}\n")},
+		"bar/bar.go": {Data: []byte("package bar\n\n// Baz is a baz.\ntype Baz struct{}\n")},
+		"empty.go":   {Data: []byte("package foo\n\nfunc foo() {}\n")},
+		"readme.md":  {Data: []byte("# Foo\n")},
+	}
+
+	a := analyze.New(analyze.WithLanguage("go", golang.Must()))
+
+	result, err := a.Dir(context.Background(), fsys)
+	if err != nil {
+		t.Fatalf("Dir() failed: %v", err)
+	}
+
+	want := []analyze.File{
+		{Path: "bar/bar.go", Language: "go", Symbols: []analyze.Symbol{
+			{Identifier: "type:Baz", Documented: true, Doc: "Baz is a baz."},
+		}},
+		{Path: "empty.go", Language: "go", Symbols: []analyze.Symbol{}},
+		{Path: "foo.go", Language: "go", Symbols: []analyze.Symbol{
+			{Identifier: "func:Bar"},
+			{Identifier: "func:Foo", Documented: true, Doc: "Foo is a foo."},
+		}},
+	}
+
+	if diff := cmp.Diff(want, result.Files); diff != "" {
+		t.Fatalf("unexpected files (-want +got):\n%s", diff)
+	}
+
+	if got := result.Files[2].Coverage(); got != 0.5 {
+		t.Errorf("foo.go should have a coverage of 0.5; got %v", got)
+	}
+
+	if got := result.Files[1].Coverage(); got != 1 {
+		t.Errorf("empty.go should have a coverage of 1; got %v", got)
+	}
+
+	if got, want := result.Coverage(), 2.0/3; got != want {
+		t.Errorf("Coverage() should return %v; got %v", want, got)
+	}
+}
+
+func TestAnalyzer_File_unknownExtension(t *testing.T) {
+	a := analyze.New(analyze.WithLanguage("go", golang.Must()))
+
+	if _, err := a.File(context.Background(), "foo.ts", nil); err == nil {
+		t.Fatalf("File() should fail for files without a configured language")
+	}
+}
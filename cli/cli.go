@@ -8,6 +8,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/alecthomas/kong"
@@ -16,9 +19,36 @@ import (
 	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/git"
 	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/slice"
+	"github.com/modernice/jotbot/internal/tracing"
+	"github.com/modernice/jotbot/langs/cpp"
+	_ "github.com/modernice/jotbot/langs/csharp"
 	"github.com/modernice/jotbot/langs/golang"
+	_ "github.com/modernice/jotbot/langs/graphql"
+	_ "github.com/modernice/jotbot/langs/groovy"
+	_ "github.com/modernice/jotbot/langs/haskell"
+	_ "github.com/modernice/jotbot/langs/hcl"
+	_ "github.com/modernice/jotbot/langs/ipynb"
+	"github.com/modernice/jotbot/langs/objc"
+	"github.com/modernice/jotbot/langs/plugin"
+	_ "github.com/modernice/jotbot/langs/powershell"
+	_ "github.com/modernice/jotbot/langs/r"
+	_ "github.com/modernice/jotbot/langs/scala"
+	_ "github.com/modernice/jotbot/langs/sh"
+	sqllang "github.com/modernice/jotbot/langs/sql"
+	_ "github.com/modernice/jotbot/langs/swift"
 	"github.com/modernice/jotbot/langs/ts"
+	_ "github.com/modernice/jotbot/langs/zig"
+	"github.com/modernice/jotbot/services/cache"
+	"github.com/modernice/jotbot/services/fallback"
+	"github.com/modernice/jotbot/services/huggingface"
+	"github.com/modernice/jotbot/services/llamacpp"
+	"github.com/modernice/jotbot/services/mistral"
 	"github.com/modernice/jotbot/services/openai"
+	"github.com/modernice/jotbot/services/router"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
@@ -31,36 +61,119 @@ const internalDirectoriesGlob = "**/internal/**/*.go"
 // API key and logging verbosity.
 type Config struct {
 	Generate struct {
-		Root            string      `arg:"" default:"." help:"Root directory of the repository."`
-		Include         []string    `name:"include" short:"i" env:"JOTBOT_INCLUDE" help:"Glob pattern(s) to include files"`
-		IncludeTests    bool        `name:"include-tests" short:"T" default:"false" env:"JOTBOT_INCLUDE_TESTS" help:"Include TestXXX() functions. (Go-specific)"`
-		Exclude         []string    `name:"exclude" short:"e" env:"JOTBOT_EXCLUDE" help:"Glob pattern(s) to exclude files"`
-		ExcludeInternal bool        `name:"exclude-internal" short:"E" default:"true" env:"JOTBOT_EXCLUDE_INTERNAL" help:"Exclude 'internal' directories (Go-specific)"`
-		Match           []string    `name:"match" env:"JOTBOT_MATCH" help:"Regular expression(s) to match identifiers"`
-		Symbols         []ts.Symbol `name:"symbol" short:"s" env:"JOTBOT_SYMBOLS" help:"Symbol(s) to search for in code (TS/JS-specific)"`
-		Clear           bool        `name:"clear" short:"c" default:"false" env:"JOTBOT_CLEAR" help:"Force-clear comments in generation prompt (Go-specific)"`
-		Branch          string      `name:"branch" env:"JOTBOT_BRANCH" help:"Branch name to commit changes to. Leave empty to not commit changes"`
-		Limit           int         `name:"limit" default:"0" env:"JOTBOT_LIMIT" help:"Limit the number of files to generate documentation for"`
-		DryRun          bool        `name:"dry" default:"false" env:"JOTBOT_DRY_RUN" help:"Print the changes without applying them"`
-		Model           string      `name:"model" short:"m" default:"gpt-3.5-turbo" env:"JOTBOT_MODEL" help:"OpenAI model used to generate documentation"`
-		MaxTokens       int         `name:"maxTokens" default:"${maxTokens=512}" env:"JOTBOT_MAX_TOKENS" help:"Maximum number of tokens to generate for a single documentation"`
-		Parallel        int         `name:"parallel" short:"p" default:"${parallel=4}" env:"JOTBOT_PARALLEL" help:"Number of files to handle concurrently"`
-		Workers         int         `name:"workers" default:"${workers=2}" env:"JOTBOT_WORKERS" help:"Number of workers to use per file"`
-		Override        bool        `name:"override" short:"o" env:"JOTBOT_OVERRIDE" help:"Override existing documentation (Go-specific)"`
+		Root             string            `arg:"" default:"." help:"Root directory of the repository."`
+		ConfigFile       string            `name:"config" type:"existingfile" env:"JOTBOT_CONFIG" help:"Path to a JSON configuration file. Defaults to .jotbot.json in the root directory"`
+		Policy           string            `name:"policy" type:"existingfile" env:"JOTBOT_POLICY" help:"Path to a JSON policy file that restricts the run"`
+		OTLPEndpoint     string            `name:"otlp-endpoint" env:"JOTBOT_OTLP_ENDPOINT" help:"Export OpenTelemetry traces to the given OTLP/HTTP endpoint (e.g. http://localhost:4318)"`
+		Report           string            `name:"report" type:"path" env:"JOTBOT_REPORT" help:"Write a JSON report of the run to the given file"`
+		Include          []string          `name:"include" short:"i" env:"JOTBOT_INCLUDE" help:"Glob pattern(s) to include files"`
+		IncludeTests     bool              `name:"include-tests" short:"T" default:"false" env:"JOTBOT_INCLUDE_TESTS" help:"Include TestXXX() functions. (Go-specific)"`
+		IncludeBench     bool              `name:"include-benchmarks" default:"false" env:"JOTBOT_INCLUDE_BENCHMARKS" help:"Include BenchmarkXXX() functions. (Go-specific)"`
+		IncludeFuzz      bool              `name:"include-fuzz" default:"false" env:"JOTBOT_INCLUDE_FUZZ" help:"Include FuzzXXX() functions. (Go-specific)"`
+		IncludeExamples  bool              `name:"include-examples" default:"false" env:"JOTBOT_INCLUDE_EXAMPLES" help:"Include ExampleXXX() functions. (Go-specific)"`
+		TestsAnywhere    bool              `name:"tests-in-any-file" default:"false" env:"JOTBOT_TESTS_IN_ANY_FILE" help:"Treat TestXXX() functions as tests outside of _test.go files. (Go-specific)"`
+		Unexported       bool              `name:"unexported" default:"false" env:"JOTBOT_UNEXPORTED" help:"Include unexported functions, types, variables and constants. (Go-specific)"`
+		CLIHelp          bool              `name:"cli-help" default:"false" env:"JOTBOT_CLI_HELP" help:"Generate missing help strings of kong, cobra and urfave/cli commands and flags. (Go-specific)"`
+		Exclude          []string          `name:"exclude" short:"e" env:"JOTBOT_EXCLUDE" help:"Glob pattern(s) to exclude files"`
+		ExcludeInternal  bool              `name:"exclude-internal" short:"E" default:"true" env:"JOTBOT_EXCLUDE_INTERNAL" help:"Exclude 'internal' directories (Go-specific)"`
+		MaxFileSize      int64             `name:"max-file-size" default:"1048576" env:"JOTBOT_MAX_FILE_SIZE" help:"Skip files that are larger than the given size in bytes (0 = no limit)"`
+		IncludeDeps      bool              `name:"include-dependencies" default:"false" env:"JOTBOT_INCLUDE_DEPENDENCIES" help:"Include vendored dependencies (vendor/, pkg/mod/, bazel-*/)"`
+		IncludeGenerated bool              `name:"include-generated" default:"false" env:"JOTBOT_INCLUDE_GENERATED" help:"Include files that are marked as generated code (// Code generated ... DO NOT EDIT.)"`
+		Match            []string          `name:"match" env:"JOTBOT_MATCH" help:"Regular expression(s) to match identifiers"`
+		Symbols          []ts.Symbol       `name:"symbol" short:"s" env:"JOTBOT_SYMBOLS" help:"Symbol(s) to search for in code (TS/JS-specific)"`
+		Focus            bool              `name:"focus" env:"JOTBOT_FOCUS" help:"Remove unrelated declarations from files that are too large for the context window, even after minification (Go-specific)"`
+		Clear            bool              `name:"clear" short:"c" default:"false" env:"JOTBOT_CLEAR" help:"Force-clear comments in generation prompt (Go-specific)"`
+		Branch           string            `name:"branch" env:"JOTBOT_BRANCH" help:"Branch name to commit changes to. Leave empty to not commit changes"`
+		MaxDuration      time.Duration     `name:"max-duration" env:"JOTBOT_MAX_DURATION" help:"Stop starting new generations when the run approaches the given duration, and apply the documentation that was generated so far"`
+		Limit            int               `name:"limit" default:"0" env:"JOTBOT_LIMIT" help:"Limit the number of files to generate documentation for"`
+		DryRun           bool              `name:"dry" default:"false" env:"JOTBOT_DRY_RUN" help:"Print the changes without applying them"`
+		PrintPrompts     bool              `name:"print-prompts" env:"JOTBOT_PRINT_PROMPTS" help:"Print the prompts, including the minified code, without generating documentation"`
+		Provider         string            `name:"provider" default:"openai" enum:"openai,mistral,huggingface,llamacpp" env:"JOTBOT_PROVIDER" help:"Service used to generate documentation (openai, mistral, huggingface, llamacpp)"`
+		Fallback         []string          `name:"fallback" enum:"openai,mistral,huggingface,llamacpp" env:"JOTBOT_FALLBACK" help:"Provider(s) to fall back to, in order, when the provider is rate-limited or unavailable"`
+		Model            string            `name:"model" short:"m" env:"JOTBOT_MODEL" help:"Model used to generate documentation. Defaults to the provider's default model"`
+		MaxTokens        int               `name:"maxTokens" default:"${maxTokens=512}" env:"JOTBOT_MAX_TOKENS" help:"Maximum number of tokens to generate for a single documentation"`
+		ContextWindow    int               `name:"context-window" env:"JOTBOT_CONTEXT_WINDOW" help:"Context window of the model in tokens. Defaults to the known context window of the model"`
+		Encoding         string            `name:"encoding" env:"JOTBOT_ENCODING" help:"Tokenizer encoding used to count tokens (e.g. cl100k_base). Defaults to the encoding of the model"`
+		MaxCost          float64           `name:"max-cost" env:"JOTBOT_MAX_COST" help:"Stop sending requests once the estimated cost in USD is reached; fails for models with unknown prices (OpenAI-specific)"`
+		Timeout          time.Duration     `name:"timeout" default:"${timeout}" env:"JOTBOT_TIMEOUT" help:"Timeout of a single generation (OpenAI and Mistral)"`
+		Retries          int               `name:"retries" default:"${retries}" env:"JOTBOT_RETRIES" help:"Number of retries for rate-limited, failed or timed out requests (OpenAI-specific)"`
+		RetryBackoff     time.Duration     `name:"retry-backoff" default:"${retryBackoff}" env:"JOTBOT_RETRY_BACKOFF" help:"Initial delay between retries, doubled for each retry (OpenAI-specific)"`
+		Temperature      float32           `name:"temperature" default:"${temperature}" env:"JOTBOT_TEMPERATURE" help:"Sampling temperature; lower is more deterministic (OpenAI-specific)"`
+		TopP             float32           `name:"top-p" default:"${topP}" env:"JOTBOT_TOP_P" help:"Nucleus sampling probability (OpenAI-specific)"`
+		Footer           string            `name:"footer" env:"JOTBOT_FOOTER" help:"Footer appended to each documentation. Supports {{.Model}}, {{.Date}}, {{.Identifier}}, {{.Language}} and {{.File}}"`
+		DocLanguage      string            `name:"doc-language" env:"JOTBOT_DOC_LANGUAGE" help:"Human language of the generated documentation, e.g. de or pt-BR. Defaults to English"`
+		SystemPrompt     string            `name:"system-prompt" env:"JOTBOT_SYSTEM_PROMPT" help:"Instructions that are sent as the system prompt of each generation"`
+		PromptTemplates  map[string]string `name:"prompt-template" env:"JOTBOT_PROMPT_TEMPLATE" help:"Go template file that replaces the built-in prompt of a language, e.g. go=./prompts/go.tmpl"`
+		Examples         int               `name:"examples" env:"JOTBOT_EXAMPLES" help:"Number of well-documented symbols from the same package that are included in the prompt as examples (Go-specific)"`
+		Usages           int               `name:"usages" env:"JOTBOT_USAGES" help:"Number of call sites of the symbol in the repository that are included in the prompt (Go-specific)"`
+		PackageDoc       bool              `name:"package-doc" env:"JOTBOT_PACKAGE_DOC" help:"Include the documentation of the package (doc.go or README.md) in the prompt"`
+		NoLinkCheck      bool              `name:"no-link-check" env:"JOTBOT_NO_LINK_CHECK" help:"Keep references to symbols that do not exist in generated documentation (Go/TS-specific)"`
+		Seed             *int              `name:"seed" env:"JOTBOT_SEED" help:"Seed for reproducible generations (OpenAI-specific)"`
+		Parallel         int               `name:"parallel" short:"p" default:"${parallel=4}" env:"JOTBOT_PARALLEL" help:"Number of files to handle concurrently"`
+		Workers          int               `name:"workers" default:"${workers=2}" env:"JOTBOT_WORKERS" help:"Number of workers to use per file"`
+		MaxInflight      int               `name:"max-inflight" env:"JOTBOT_MAX_INFLIGHT" help:"Maximum number of concurrent requests to the model, independently of the number of workers (0 = one per worker)"`
+		ErrorRate        float64           `name:"error-rate" default:"0.5" env:"JOTBOT_ERROR_RATE" help:"Error rate of the provider at which generation is paused. 0 disables the circuit breaker"`
+		ErrorWindow      int               `name:"error-window" default:"10" env:"JOTBOT_ERROR_WINDOW" help:"Number of recent generations used to compute the error rate"`
+		ErrorCooldown    time.Duration     `name:"error-cooldown" default:"1m" env:"JOTBOT_ERROR_COOLDOWN" help:"Pause after reaching the error rate. 0 aborts the run instead"`
+		NoCache          bool              `name:"no-cache" env:"JOTBOT_NO_CACHE" help:"Bypass the response cache in ~/.cache/jotbot"`
+		Stream           bool              `name:"stream" env:"JOTBOT_STREAM" help:"Stream completions and report live progress (OpenAI-specific)"`
+		JSON             bool              `name:"json" env:"JOTBOT_JSON" help:"Request documentation as structured JSON output (OpenAI-specific)"`
+		Batch            bool              `name:"batch" env:"JOTBOT_BATCH" help:"Use the OpenAI Batch API (slower, but cheaper)"`
+		DocHeaders       bool              `name:"doc-headers" env:"JOTBOT_DOC_HEADERS" help:"Document methods and functions that are declared in a header file only in the header, not in the implementation file (Objective-C and C/C++-specific)"`
+		SQLInline        bool              `name:"sql-inline" env:"JOTBOT_SQL_INLINE" help:"Document SQL tables and columns using -- comments instead of COMMENT ON statements (SQL-specific)"`
+		Override         bool              `name:"override" short:"o" env:"JOTBOT_OVERRIDE" help:"Override existing documentation (Go-specific)"`
+		Augment          bool              `name:"augment" env:"JOTBOT_AUGMENT" help:"Complete existing documentation instead of overriding it, e.g. with missing explanations of parameters (Go-specific)"`
+		KeepSimilar      float64           `name:"keep-similar" env:"JOTBOT_KEEP_SIMILAR" help:"Keep existing documentation when overriding if the generated documentation is at least this similar (0-1). 0 always overrides (Go-specific)"`
+		CommentStyle     string            `name:"comment-style" default:"line" enum:"line,block" env:"JOTBOT_COMMENT_STYLE" help:"Write documentation as // line comments or /* */ block comments (Go-specific)"`
 	} `cmd:"" help:"Generate missing documentation."`
 
-	APIKey  string `name:"key" env:"OPENAI_API_KEY" help:"OpenAI API key."`
-	Verbose bool   `name:"verbose" short:"v" env:"JOTBOT_VERBOSE" help:"Enable verbose logging."`
+	Daemon Daemon `cmd:"" help:"Generate missing documentation on a schedule."`
+
+	Examples Examples `cmd:"" help:"Generate runnable example functions for exported Go functions, methods and types."`
+
+	Fixtures Fixtures `cmd:"" help:"Create a test fixture from a snapshot of a repository (for JotBot development)."`
+
+	APIKey     string `name:"key" env:"OPENAI_API_KEY" help:"OpenAI API key."`
+	BaseURL    string `name:"base-url" env:"OPENAI_BASE_URL" help:"Base URL of an OpenAI-compatible API."`
+	OrgID      string `name:"org" env:"OPENAI_ORG_ID" help:"OpenAI organization that requests are billed to."`
+	ProjectID  string `name:"project" env:"OPENAI_PROJECT_ID" help:"OpenAI project that requests are billed to."`
+	MistralKey string `name:"mistral-key" env:"MISTRAL_API_KEY" help:"Mistral API key."`
+	Verbose    bool   `name:"verbose" short:"v" env:"JOTBOT_VERBOSE" help:"Enable verbose logging."`
+
+	HuggingFace struct {
+		Token    string   `name:"token" env:"HF_TOKEN" help:"Hugging Face access token."`
+		Endpoint string   `name:"endpoint" env:"JOTBOT_HF_ENDPOINT" help:"URL of a dedicated Hugging Face Inference Endpoint."`
+		Chat     bool     `name:"chat" env:"JOTBOT_HF_CHAT" help:"Use the chat template of the Hugging Face model."`
+		Stop     []string `name:"stop" env:"JOTBOT_HF_STOP" help:"Stop sequence(s) for Hugging Face models."`
+	} `embed:"" prefix:"hf-"`
+
+	LlamaCPP struct {
+		URL           string `name:"url" default:"${llamaURL}" env:"JOTBOT_LLAMA_URL" help:"URL of the llama.cpp server."`
+		ContextWindow int    `name:"ctx-size" default:"${llamaContext}" env:"JOTBOT_LLAMA_CTX_SIZE" help:"Context window of the model served by llama.cpp."`
+		Grammar       string `name:"grammar" type:"existingfile" env:"JOTBOT_LLAMA_GRAMMAR" help:"Path to a GBNF grammar file that constrains the llama.cpp output."`
+	} `embed:"" prefix:"llama-"`
 }
 
 // Run generates missing documentation for a codebase, based on the provided
 // configuration. It finds undocumented code, generates documentation using
 // OpenAI, and applies the generated documentation as a patch. It can also
 // commit the changes to a specified branch.
-func (cfg *Config) Run(kctx *kong.Context) error {
+func (cfg *Config) Run(kctx *kong.Context) (err error) {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer cancel()
 
+	if strings.HasPrefix(kctx.Command(), "daemon") {
+		return cfg.Daemon.run(ctx, slog.New(cfg.newLogHandler()))
+	}
+
+	if strings.HasPrefix(kctx.Command(), "fixtures") {
+		return cfg.Fixtures.run(ctx, kctx.Stdout)
+	}
+
+	if strings.HasPrefix(kctx.Command(), "examples") {
+		return cfg.Examples.run(ctx, cfg, kctx.Stdout)
+	}
+
 	if !filepath.IsAbs(cfg.Generate.Root) {
 		wd, err := os.Getwd()
 		if err != nil {
@@ -69,31 +182,79 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 		cfg.Generate.Root = filepath.Join(wd, cfg.Generate.Root)
 	}
 
-	var level slog.Level
-	if cfg.Verbose {
-		level = slog.LevelDebug
+	logHandler := cfg.newLogHandler()
+	logger := slog.New(logHandler)
+
+	usage := openai.NewUsageTracker()
+	defer logUsage(logger, usage)
+
+	report := Report{
+		Started:  time.Now(),
+		Root:     cfg.Generate.Root,
+		Provider: cfg.Generate.Provider,
+		Model:    modelOf(cfg.Generate.Provider, cfg.Generate.Model),
 	}
-	logHandler := internal.PrettyLogger(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-			if a.Key == slog.TimeKey {
-				a.Key = ""
+	if cfg.Generate.OTLPEndpoint != "" {
+		shutdown, err := setupTracing(ctx, cfg.Generate.OTLPEndpoint)
+		if err != nil {
+			return fmt.Errorf("setup tracing: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				logger.Warn(fmt.Sprintf("Failed to export traces: %v", err))
 			}
-			return a
-		},
-	}))
-	logger := slog.New(logHandler)
+		}()
+	}
+
+	ctx, span := tracing.Start(ctx, "jotbot.Run",
+		attribute.String("root", cfg.Generate.Root),
+		attribute.String("provider", cfg.Generate.Provider),
+		attribute.String("model", modelOf(cfg.Generate.Provider, cfg.Generate.Model)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if cfg.Generate.Report != "" {
+		defer func() {
+			report.finish(usage, err)
+			if err := report.write(cfg.Generate.Report); err != nil {
+				logger.Warn(fmt.Sprintf("Failed to write report: %v", err))
+			}
+		}()
+	}
 
 	logger.Info(fmt.Sprintf("Root: %s", cfg.Generate.Root))
 
+	file, err := LoadConfigFile(cfg.Generate.Root, cfg.Generate.ConfigFile)
+	if err != nil {
+		return err
+	}
+
 	goFinder := golang.NewFinder(
 		golang.FindTests(cfg.Generate.IncludeTests),
-		golang.IncludeDocumented(cfg.Generate.Override),
+		golang.FindBenchmarks(cfg.Generate.IncludeBench),
+		golang.FindFuzz(cfg.Generate.IncludeFuzz),
+		golang.FindExamples(cfg.Generate.IncludeExamples),
+		golang.TestsInAnyFile(cfg.Generate.TestsAnywhere),
+		golang.FindHelp(cfg.Generate.CLIHelp),
+		golang.IncludeUnexported(cfg.Generate.Unexported),
+		golang.IncludeDocumented(cfg.Generate.Override || cfg.Generate.Augment),
+		golang.IncludeDocumentedFunc(func(path string) bool {
+			return file.Overrides(path, cfg.Generate.Override || cfg.Generate.Augment)
+		}),
 	)
 	gosvc, err := golang.New(
 		golang.WithFinder(goFinder),
+		golang.Root(os.DirFS(cfg.Generate.Root)),
 		golang.Model(cfg.Generate.Model),
+		golang.Encoding(cfg.Generate.Encoding),
+		golang.ContextWindow(cfg.Generate.ContextWindow),
 		golang.ClearComments(cfg.Generate.Clear),
+		golang.Focus(cfg.Generate.Focus),
+		golang.Augment(cfg.Generate.Augment),
+		golang.CommentStyle(goCommentStyle(cfg.Generate.CommentStyle)),
+		golang.KeepSimilar(cfg.Generate.KeepSimilar),
 	)
 	if err != nil {
 		return fmt.Errorf("create Go language service: %w", err)
@@ -108,6 +269,18 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 	)
 	tssvc := ts.New(ts.Model(cfg.Generate.Model), ts.WithFinder(tsFinder))
 
+	var objcFinderOpts []objc.FinderOption
+	if cfg.Generate.DocHeaders {
+		objcFinderOpts = append(objcFinderOpts, objc.Headers(os.DirFS(cfg.Generate.Root)))
+	}
+	objcsvc := objc.New(objc.WithFinder(objc.NewFinder(objcFinderOpts...)))
+
+	var cppFinderOpts []cpp.FinderOption
+	if cfg.Generate.DocHeaders {
+		cppFinderOpts = append(cppFinderOpts, cpp.Headers(os.DirFS(cfg.Generate.Root)))
+	}
+	cppsvc := cpp.New(cpp.WithFinder(cpp.NewFinder(cppFinderOpts...)))
+
 	matchers, err := parseMatchers(cfg.Generate.Match)
 	if err != nil {
 		return fmt.Errorf("parse matchers: %w", err)
@@ -116,20 +289,114 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 	bot := jotbot.New(
 		cfg.Generate.Root,
 		jotbot.WithLogger(logHandler),
+		jotbot.WithAllLanguages(),
 		jotbot.WithLanguage("go", gosvc),
 		jotbot.WithLanguage("ts", tssvc),
+		// C/C++ is configured before Objective-C, which keeps handling ".h"
+		// files unless they are mapped to "cpp".
+		jotbot.WithLanguage("cpp", cppsvc),
+		jotbot.WithLanguage("objc", objcsvc),
+		jotbot.WithLanguage("sql", sqllang.New(sqllang.InlineComments(cfg.Generate.SQLInline))),
 		jotbot.Match(matchers...),
+		jotbot.MaxFileSize(cfg.Generate.MaxFileSize),
+		jotbot.IncludeGenerated(cfg.Generate.IncludeGenerated),
 	)
 
-	openaiOpts := []openai.Option{
-		openai.Model(cfg.Generate.Model),
-		openai.MaxTokens(cfg.Generate.MaxTokens),
-		openai.WithLogger(logHandler),
+	plugins := maps.Keys(file.Plugins)
+	slices.Sort(plugins)
+	for _, name := range plugins {
+		p := file.Plugins[name]
+		bot.ConfigureLanguage(name, plugin.New(
+			p.Command[0],
+			p.Extensions,
+			plugin.Args(p.Command[1:]...),
+			plugin.Dir(cfg.Generate.Root),
+			plugin.WithLogger(logger),
+		))
+	}
+
+	templates, err := loadPromptTemplates(cfg.Generate.PromptTemplates, bot.Languages())
+	if err != nil {
+		return err
+	}
+
+	exts := maps.Keys(file.Extensions)
+	slices.Sort(exts)
+	for _, ext := range exts {
+		if err := bot.MapExtension(ext, file.Extensions[ext]); err != nil {
+			return fmt.Errorf("config file: extensions: %w", err)
+		}
+	}
+
+	footer := cfg.Generate.Footer
+	if cfg.Generate.Policy != "" {
+		policy, err := LoadPolicy(cfg.Generate.Policy)
+		if err != nil {
+			return err
+		}
+
+		report.Policy = &ReportPolicy{File: cfg.Generate.Policy, Policy: policy}
+
+		models := []string{modelOf(cfg.Generate.Provider, cfg.Generate.Model)}
+		for _, rule := range file.Routing {
+			models = append(models, rule.Model)
+		}
+
+		if err := policy.Validate(cfg.Generate.Provider, models...); err != nil {
+			return fmt.Errorf("validate policy: %w", err)
+		}
+
+		for _, provider := range cfg.Generate.Fallback {
+			if err := policy.Validate(provider, modelOf(provider, "")); err != nil {
+				return fmt.Errorf("validate policy: fallback: %w", err)
+			}
+		}
+
+		if policy.MaxCost > 0 {
+			for _, provider := range append([]string{cfg.Generate.Provider}, cfg.Generate.Fallback...) {
+				if provider != "openai" {
+					return fmt.Errorf("validate policy: cost limits are only supported by the %q provider", "openai")
+				}
+			}
+			if cfg.Generate.MaxCost <= 0 || cfg.Generate.MaxCost > policy.MaxCost {
+				cfg.Generate.MaxCost = policy.MaxCost
+			}
+		}
+
+		if policy.RequiredFooter != "" {
+			footer = policy.RequiredFooter
+		}
+
+		logger.Info(fmt.Sprintf("Policy: %s", cfg.Generate.Policy))
 	}
 
-	oai, err := openai.New(cfg.APIKey, openaiOpts...)
+	if _, err := template.New("footer").Parse(footer); err != nil {
+		return fmt.Errorf("parse footer template: %w", err)
+	}
+
+	svc, err := cfg.newService(logHandler, cfg.Generate.Provider, cfg.Generate.Model, usage)
 	if err != nil {
-		return fmt.Errorf("create OpenAI service: %w", err)
+		return err
+	}
+
+	if len(file.Routing) > 0 {
+		if cfg.Generate.Batch {
+			return fmt.Errorf("batch mode does not support routing rules")
+		}
+
+		if svc, err = cfg.newRouter(logHandler, svc, file.Routing, usage); err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.Generate.Fallback) > 0 {
+		if cfg.Generate.Batch {
+			return fmt.Errorf("batch mode does not support fallback providers")
+		}
+
+		if svc, err = cfg.newFallback(logHandler, svc, usage); err != nil {
+			return err
+		}
 	}
 
 	if cfg.Generate.ExcludeInternal {
@@ -142,21 +409,115 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 		ctx,
 		find.Include(cfg.Generate.Include...),
 		find.Exclude(cfg.Generate.Exclude...),
+		find.IncludeDependencies(cfg.Generate.IncludeDeps),
+		find.OnSkip(func(s find.Skip) {
+			report.Skipped = append(report.Skipped, s)
+		}),
 	)
 	if err != nil {
 		return fmt.Errorf("find uncommented code: %w", err)
 	}
 
-	patch, err := bot.Generate(
-		ctx,
-		findings,
-		oai,
+	genOpts := []generate.Option{
 		generate.Limit(cfg.Generate.Limit),
 		generate.Workers(cfg.Generate.Parallel, cfg.Generate.Workers),
-	)
+		generate.MaxInflight(cfg.Generate.MaxInflight),
+		generate.CircuitBreaker(cfg.Generate.ErrorRate, cfg.Generate.ErrorWindow, cfg.Generate.ErrorCooldown),
+	}
+	if footer != "" {
+		genOpts = append(genOpts, generate.Footer(footer))
+	}
+	if cfg.Generate.SystemPrompt != "" {
+		genOpts = append(genOpts, generate.SystemPrompt(cfg.Generate.SystemPrompt))
+	}
+	if d := cfg.Generate.MaxDuration; d > 0 {
+		// Stop early enough that generations in progress complete in time,
+		// including their retries.
+		margin := deadlineMargin(d, cfg.Generate.Timeout, cfg.Generate.Retries, openai.DefaultMaxBackoff)
+		genOpts = append(genOpts, generate.Deadline(start.Add(d-margin)))
+	}
+	if cfg.Generate.DocLanguage != "" {
+		genOpts = append(genOpts, generate.DocLanguage(cfg.Generate.DocLanguage))
+	}
+	if cfg.Generate.Examples > 0 {
+		genOpts = append(genOpts, generate.FewShot(os.DirFS(cfg.Generate.Root), cfg.Generate.Examples))
+	}
+	if cfg.Generate.Usages > 0 {
+		genOpts = append(genOpts, generate.Usages(os.DirFS(cfg.Generate.Root), cfg.Generate.Usages))
+	}
+	if cfg.Generate.PackageDoc {
+		genOpts = append(genOpts, generate.PackageDoc(os.DirFS(cfg.Generate.Root)))
+	}
+	if !cfg.Generate.NoLinkCheck {
+		genOpts = append(genOpts, generate.ValidateLinks(os.DirFS(cfg.Generate.Root)))
+	}
+	genOpts = append(genOpts, generate.Declarations(os.DirFS(cfg.Generate.Root)))
+	genOpts = append(genOpts, templates...)
+
+	if cfg.Generate.PrintPrompts {
+		prompts, err := bot.Prompts(ctx, findings, genOpts...)
+		if err != nil {
+			return fmt.Errorf("render prompts: %w", err)
+		}
+
+		for _, p := range prompts {
+			fmt.Printf("=== %s (%s) ===\n\n", p.Input.Identifier, p.Input.File)
+			if p.System != "" {
+				fmt.Printf("System:\n%s\n\n", p.System)
+			}
+			fmt.Printf("%s\n\n", p.Prompt)
+		}
+
+		logger.Info(fmt.Sprintf("Rendered %d prompts in %s.", len(prompts), time.Since(start)))
+
+		return nil
+	}
+
+	if cfg.Generate.Batch {
+		oai, ok := svc.(*openai.Service)
+		if !ok {
+			return fmt.Errorf("batch mode is only supported by the %q provider", "openai")
+		}
+
+		batch := oai.Batch()
+
+		collect, err := bot.Generate(ctx, findings, batch, genOpts...)
+		if err != nil {
+			return fmt.Errorf("collect batch requests: %w", err)
+		}
+
+		collected, err := collect.Files()
+		if err != nil {
+			return fmt.Errorf("collect batch requests: %w", err)
+		}
+
+		// Only the collected files have results, which may be fewer than the
+		// findings if a limit is configured.
+		files := make(map[string]bool, len(collected))
+		for _, file := range collected {
+			files[file.Path] = true
+		}
+		findings = slice.Filter(findings, func(f jotbot.Finding) bool {
+			return files[f.File]
+		})
+
+		if err := batch.Run(ctx); err != nil {
+			return fmt.Errorf("run batch: %w", err)
+		}
+
+		svc = batch
+	}
+
+	patch, err := bot.Generate(ctx, findings, svc, genOpts...)
 	if err != nil {
 		return fmt.Errorf("generate documentation: %w", err)
 	}
+	defer func() {
+		report.Overrides = patch.Overrides()
+		if n := len(report.Overrides); n > 0 && !cfg.Generate.DryRun {
+			logger.Info(fmt.Sprintf("Replaced %d existing comments.", n))
+		}
+	}()
 
 	if cfg.Generate.DryRun {
 		patched, err := patch.DryRun(ctx, cfg.Generate.Root)
@@ -168,6 +529,10 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 			fmt.Printf("Patched %q:\n\n%s\n", file, code)
 		}
 
+		for _, o := range patch.Overrides() {
+			fmt.Printf("Replaced documentation of %s in %q:\n\n%s\n", o.Identifier, o.File, o.Diff())
+		}
+
 		took := time.Since(start)
 		logger.Info(fmt.Sprintf("Done in %s.", took))
 
@@ -196,6 +561,204 @@ func (cfg *Config) Run(kctx *kong.Context) error {
 	return nil
 }
 
+// modelOf returns the given model, or the default model of the given provider
+// if model is empty. The model served by llama.cpp is unknown, so an empty
+// model is returned as-is for the "llamacpp" provider.
+func modelOf(provider, model string) string {
+	if model != "" {
+		return model
+	}
+
+	switch provider {
+	case "mistral":
+		return mistral.DefaultModel
+	case "huggingface":
+		return huggingface.DefaultModel
+	case "llamacpp":
+		return ""
+	default:
+		return openai.DefaultModel
+	}
+}
+
+func (cfg *Config) newRouter(logHandler slog.Handler, defaultService generate.Service, routing []RoutingRule, usage *openai.UsageTracker) (generate.Service, error) {
+	services := make(map[string]generate.Service)
+	rules := make([]router.Rule, len(routing))
+	for i, r := range routing {
+		svc, ok := services[r.Model]
+		if !ok {
+			var err error
+			if svc, err = cfg.newService(logHandler, cfg.Generate.Provider, r.Model, usage); err != nil {
+				return nil, err
+			}
+			services[r.Model] = svc
+		}
+
+		rules[i] = router.Rule{
+			Kinds:     r.Kinds,
+			MinTokens: r.MinTokens,
+			MaxTokens: r.MaxTokens,
+			Service:   svc,
+			Name:      r.Model,
+		}
+	}
+
+	svc, err := router.New(defaultService, rules, router.WithLogger(logHandler))
+	if err != nil {
+		return nil, fmt.Errorf("create router: %w", err)
+	}
+
+	return svc, nil
+}
+
+// newFallback returns a service that falls back to the configured fallback
+// providers when primary is unavailable. Fallback providers use their default
+// model.
+func (cfg *Config) newFallback(logHandler slog.Handler, primary generate.Service, usage *openai.UsageTracker) (generate.Service, error) {
+	providers := []fallback.Provider{{Name: cfg.Generate.Provider, Service: primary}}
+	for _, provider := range cfg.Generate.Fallback {
+		svc, err := cfg.newService(logHandler, provider, "", usage)
+		if err != nil {
+			return nil, fmt.Errorf("fallback provider %q: %w", provider, err)
+		}
+		providers = append(providers, fallback.Provider{Name: provider, Service: svc})
+	}
+
+	svc, err := fallback.New(providers, fallback.WithLogger(logHandler))
+	if err != nil {
+		return nil, fmt.Errorf("create fallback chain: %w", err)
+	}
+
+	return svc, nil
+}
+
+// newService returns the service of the given provider. Unless disabled,
+// responses of the service are cached on disk.
+func (cfg *Config) newService(logHandler slog.Handler, provider, model string, usage *openai.UsageTracker) (generate.Service, error) {
+	svc, err := cfg.newProvider(logHandler, provider, model, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Batch API defers generations, so there are no responses to cache.
+	if cfg.Generate.NoCache || cfg.Generate.Batch {
+		return svc, nil
+	}
+
+	cached, err := cache.New(svc, cfg.cacheKey(provider, model, svc), cache.WithLogger(logHandler))
+	if err != nil {
+		return nil, fmt.Errorf("create response cache: %w", err)
+	}
+
+	return cached, nil
+}
+
+// cacheKey identifies the model of the given provider in the response cache.
+// The model served by llama.cpp is unknown, so it is identified by the URL of
+// the server instead. The fingerprint of svc is appended, so that changing
+// options such as the temperature or the seed misses the cache.
+func (cfg *Config) cacheKey(provider, model string, svc generate.Service) string {
+	key := provider + ":" + modelOf(provider, model)
+	switch provider {
+	case "openai":
+		if cfg.BaseURL != "" {
+			key += "@" + cfg.BaseURL
+		}
+	case "huggingface":
+		if cfg.HuggingFace.Endpoint != "" {
+			key += "@" + cfg.HuggingFace.Endpoint
+		}
+	case "llamacpp":
+		key += "@" + cfg.LlamaCPP.URL
+	}
+	if f, ok := svc.(cache.Fingerprinter); ok {
+		key += " " + f.Fingerprint()
+	}
+	return key
+}
+
+func (cfg *Config) newProvider(logHandler slog.Handler, provider, model string, usage *openai.UsageTracker) (generate.Service, error) {
+	// The configured context window is that of the default model, not of the
+	// models of routing rules or fallback providers.
+	var contextWindow int
+	if provider == cfg.Generate.Provider && model == cfg.Generate.Model {
+		contextWindow = cfg.Generate.ContextWindow
+	}
+
+	switch provider {
+	case "mistral":
+		svc, err := mistral.New(
+			cfg.MistralKey,
+			mistral.Model(model),
+			mistral.MaxTokens(cfg.Generate.MaxTokens),
+			mistral.Encoding(cfg.Generate.Encoding),
+			mistral.ContextWindow(contextWindow),
+			mistral.Timeout(cfg.Generate.Timeout),
+			mistral.WithLogger(logHandler),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create Mistral service: %w", err)
+		}
+		return svc, nil
+	case "huggingface":
+		return huggingface.New(
+			cfg.HuggingFace.Token,
+			huggingface.Model(model),
+			huggingface.Endpoint(cfg.HuggingFace.Endpoint),
+			huggingface.MaxTokens(cfg.Generate.MaxTokens),
+			huggingface.Chat(cfg.HuggingFace.Chat),
+			huggingface.Stop(cfg.HuggingFace.Stop...),
+			huggingface.WithLogger(logHandler),
+		), nil
+	case "llamacpp":
+		var grammar string
+		if cfg.LlamaCPP.Grammar != "" {
+			b, err := os.ReadFile(cfg.LlamaCPP.Grammar)
+			if err != nil {
+				return nil, fmt.Errorf("read grammar: %w", err)
+			}
+			grammar = string(b)
+		}
+		return llamacpp.New(
+			llamacpp.URL(cfg.LlamaCPP.URL),
+			llamacpp.ContextWindow(cfg.LlamaCPP.ContextWindow),
+			llamacpp.MaxTokens(cfg.Generate.MaxTokens),
+			llamacpp.Grammar(grammar),
+			llamacpp.WithLogger(logHandler),
+		), nil
+	default:
+		opts := []openai.Option{
+			openai.Model(model),
+			openai.BaseURL(cfg.BaseURL),
+			openai.Organization(cfg.OrgID),
+			openai.Project(cfg.ProjectID),
+			openai.MaxTokens(cfg.Generate.MaxTokens),
+			openai.Encoding(cfg.Generate.Encoding),
+			openai.ContextWindow(contextWindow),
+			openai.Timeout(cfg.Generate.Timeout),
+			openai.MaxRetries(cfg.Generate.Retries),
+			openai.Backoff(cfg.Generate.RetryBackoff, openai.DefaultMaxBackoff),
+			openai.Temperature(cfg.Generate.Temperature),
+			openai.TopP(cfg.Generate.TopP),
+			openai.Stream(cfg.Generate.Stream),
+			openai.JSONMode(cfg.Generate.JSON),
+			openai.Progress(progressLogger(slog.New(logHandler))),
+			openai.TrackUsage(usage),
+			openai.MaxCost(cfg.Generate.MaxCost),
+			openai.WithLogger(logHandler),
+		}
+		if cfg.Generate.Seed != nil {
+			opts = append(opts, openai.Seed(*cfg.Generate.Seed))
+		}
+
+		svc, err := openai.New(cfg.APIKey, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("create OpenAI service: %w", err)
+		}
+		return svc, nil
+	}
+}
+
 // New initializes and returns a new kong.Context with a parsed configuration
 // from command line arguments, default values, and environment variables. The
 // returned context is used to run the JotBot application, which generates
@@ -206,12 +769,125 @@ func New() *kong.Context {
 	}
 	var cfg Config
 	return kong.Parse(&cfg, kong.Vars{
-		"maxTokens": strconv.Itoa(openai.DefaultMaxTokens),
-		"parallel":  strconv.Itoa(generate.DefaultFileWorkers),
-		"workers":   strconv.Itoa(generate.DefaultSymbolWorkers),
+		"maxTokens":    strconv.Itoa(openai.DefaultMaxTokens),
+		"timeout":      openai.DefaultTimeout.String(),
+		"retries":      strconv.Itoa(openai.DefaultMaxRetries),
+		"retryBackoff": openai.DefaultBackoff.String(),
+		"temperature":  strconv.FormatFloat(openai.DefaultTemperature, 'g', -1, 32),
+		"topP":         strconv.FormatFloat(openai.DefaultTopP, 'g', -1, 32),
+		"parallel":     strconv.Itoa(generate.DefaultFileWorkers),
+		"workers":      strconv.Itoa(generate.DefaultSymbolWorkers),
+		"llamaURL":     llamacpp.DefaultURL,
+		"llamaContext": strconv.Itoa(llamacpp.DefaultContextWindow),
 	})
 }
 
+func (cfg *Config) newLogHandler() slog.Handler {
+	var level slog.Level
+	if cfg.Verbose {
+		level = slog.LevelDebug
+	}
+	return internal.PrettyLogger(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				a.Key = ""
+			}
+			return a
+		},
+	}))
+}
+
+// progressLogger returns a callback for streaming generations that logs the
+// progress of each generation at most once every few seconds.
+func progressLogger(logger *slog.Logger) func(generate.PromptInput, string) {
+	var (
+		mux    sync.Mutex
+		logged = make(map[string]time.Time)
+	)
+	return func(input generate.PromptInput, partial string) {
+		key := input.File + "@" + input.Identifier
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		if last, ok := logged[key]; ok && time.Since(last) < 3*time.Second {
+			return
+		}
+		logged[key] = time.Now()
+
+		logger.Info(fmt.Sprintf("Generating %s ... (%d characters)", input.Input, len(partial)))
+	}
+}
+
+// logUsage logs a summary of the token usage and estimated cost per model.
+func logUsage(logger *slog.Logger, tracker *openai.UsageTracker) {
+	usage := tracker.Usage()
+	if len(usage) == 0 {
+		return
+	}
+
+	var prompt, completion, unpriced int
+	for _, u := range usage {
+		prompt += u.PromptTokens
+		completion += u.CompletionTokens
+
+		model := u.Model
+		if u.Batch {
+			model += " (batch)"
+		}
+
+		cost := "unknown cost"
+		if c, ok := u.Cost(); ok {
+			cost = fmt.Sprintf("~$%.4f", c)
+		} else {
+			unpriced++
+		}
+
+		logger.Info(fmt.Sprintf("Usage of %s: %d requests, %d prompt tokens, %d completion tokens, %s", model, u.Requests, u.PromptTokens, u.CompletionTokens, cost))
+
+		if len(u.Fingerprints) > 0 {
+			logger.Info(fmt.Sprintf("System fingerprints of %s: %s", model, strings.Join(u.Fingerprints, ", ")))
+		}
+	}
+
+	// The total cost only includes models with a known price.
+	cost := fmt.Sprintf("~$%.4f", tracker.Cost())
+	switch {
+	case unpriced == len(usage):
+		cost = "unknown cost"
+	case unpriced > 0:
+		cost += " (partial, excludes models with unknown cost)"
+	}
+
+	logger.Info(fmt.Sprintf("Total usage: %d prompt tokens, %d completion tokens, %s", prompt, completion, cost))
+}
+
+// maxGenerationTime returns the longest time that a single generation may take
+// if each of its attempts times out and every retry waits for the maximum
+// backoff.
+func maxGenerationTime(timeout time.Duration, retries int, maxBackoff time.Duration) time.Duration {
+	if retries < 0 {
+		retries = 0
+	}
+	return time.Duration(retries+1)*timeout + time.Duration(retries)*maxBackoff
+}
+
+// deadlineMargin returns how long before the maximum duration d a run must
+// stop starting new generations. It is the [maxGenerationTime] if that fits
+// into d. Otherwise, it is a single timeout, or half of d if even a single
+// timeout does not fit, so that short runs still leave running generations
+// some time to finish.
+func deadlineMargin(d, timeout time.Duration, retries int, maxBackoff time.Duration) time.Duration {
+	if margin := maxGenerationTime(timeout, retries, maxBackoff); margin < d {
+		return margin
+	}
+	if timeout > 0 && timeout < d {
+		return timeout
+	}
+	return d / 2
+}
+
 func parseMatchers(raw []string) ([]*regexp.Regexp, error) {
 	out := make([]*regexp.Regexp, len(raw))
 	var err error
@@ -224,10 +900,13 @@ func parseMatchers(raw []string) ([]*regexp.Regexp, error) {
 }
 
 var defaultTSSymbols = []ts.Symbol{
+	ts.Accessor,
 	ts.Class,
+	ts.Enum,
 	ts.Func,
 	ts.Interface,
 	ts.Method,
+	ts.Type,
 	ts.Var,
 }
 
@@ -237,3 +916,12 @@ func parseTSSymbols(cfg []ts.Symbol) []ts.Symbol {
 	}
 	return cfg
 }
+
+// goCommentStyle returns the Go comment style for the value of the
+// --comment-style flag.
+func goCommentStyle(style string) golang.Style {
+	if style == "block" {
+		return golang.Block
+	}
+	return golang.Line
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/openai"
+	goopenai "github.com/sashabaranov/go-openai"
+	"golang.org/x/exp/slog"
+)
+
+func TestLogUsage(t *testing.T) {
+	tests := map[string]struct {
+		models []string
+		want   string
+	}{
+		"priced":   {models: []string{"gpt-4o", "gpt-4o-mini"}, want: "Total usage: 2000000 prompt tokens, 2000000 completion tokens, ~$20.7500"},
+		"partial":  {models: []string{"gpt-4o", "my-model"}, want: "Total usage: 2000000 prompt tokens, 2000000 completion tokens, ~$20.0000 (partial, excludes models with unknown cost)"},
+		"unpriced": {models: []string{"my-model"}, want: "Total usage: 1000000 prompt tokens, 1000000 completion tokens, unknown cost"},
+	}
+
+	for name, tt := range tests {
+		tracker := openai.NewUsageTracker()
+		for _, model := range tt.models {
+			tracker.Add(model, false, goopenai.Usage{PromptTokens: 1e6, CompletionTokens: 1e6})
+		}
+
+		var buf bytes.Buffer
+		logUsage(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: onlyMessage})), tracker)
+
+		if !strings.Contains(buf.String(), "msg=\""+tt.want+"\"\n") {
+			t.Errorf("%s: logUsage() should log %q\n\n%s", name, tt.want, buf.String())
+		}
+	}
+}
+
+func TestMaxGenerationTime(t *testing.T) {
+	tests := []struct {
+		timeout    time.Duration
+		retries    int
+		maxBackoff time.Duration
+		want       time.Duration
+	}{
+		{timeout: 30 * time.Second, retries: 0, maxBackoff: 30 * time.Second, want: 30 * time.Second},
+		{timeout: 30 * time.Second, retries: 3, maxBackoff: 30 * time.Second, want: 210 * time.Second},
+		{timeout: time.Minute, retries: 1, maxBackoff: 10 * time.Second, want: 130 * time.Second},
+		{timeout: time.Minute, retries: -1, maxBackoff: 10 * time.Second, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := maxGenerationTime(tt.timeout, tt.retries, tt.maxBackoff); got != tt.want {
+			t.Errorf("maxGenerationTime(%s, %d, %s) should return %s; got %s", tt.timeout, tt.retries, tt.maxBackoff, tt.want, got)
+		}
+	}
+}
+
+func TestDeadlineMargin(t *testing.T) {
+	tests := []struct {
+		d          time.Duration
+		timeout    time.Duration
+		retries    int
+		maxBackoff time.Duration
+		want       time.Duration
+	}{
+		{d: 10 * time.Minute, timeout: 30 * time.Second, retries: 3, maxBackoff: 30 * time.Second, want: 210 * time.Second},
+		{d: 210 * time.Second, timeout: 30 * time.Second, retries: 3, maxBackoff: 30 * time.Second, want: 30 * time.Second},
+		{d: 2 * time.Minute, timeout: 30 * time.Second, retries: 3, maxBackoff: 30 * time.Second, want: 30 * time.Second},
+		{d: 30 * time.Second, timeout: 30 * time.Second, retries: 3, maxBackoff: 30 * time.Second, want: 15 * time.Second},
+		{d: 10 * time.Second, timeout: 0, retries: 0, maxBackoff: 30 * time.Second, want: 0},
+	}
+
+	for _, tt := range tests {
+		got := deadlineMargin(tt.d, tt.timeout, tt.retries, tt.maxBackoff)
+		if got != tt.want {
+			t.Errorf("deadlineMargin(%s, %s, %d, %s) should return %s; got %s", tt.d, tt.timeout, tt.retries, tt.maxBackoff, tt.want, got)
+		}
+		if got >= tt.d {
+			t.Errorf("deadlineMargin(%s, %s, %d, %s) should return less than %s; got %s", tt.d, tt.timeout, tt.retries, tt.maxBackoff, tt.d, got)
+		}
+	}
+}
+
+func TestConfig_newService_cacheKey(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Foo returns foo."},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	generateDoc := func(temperature float32) {
+		t.Helper()
+
+		var cfg Config
+		cfg.APIKey = "key"
+		cfg.BaseURL = srv.URL
+		cfg.Generate.Provider = "openai"
+		cfg.Generate.Model = "gpt-4o"
+		cfg.Generate.MaxTokens = 512
+		cfg.Generate.Temperature = temperature
+		cfg.Generate.TopP = openai.DefaultTopP
+
+		svc, err := cfg.newService(slog.NewTextHandler(io.Discard, nil), "openai", "gpt-4o", openai.NewUsageTracker())
+		if err != nil {
+			t.Fatalf("newService() failed: %v", err)
+		}
+
+		if _, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."}); err != nil {
+			t.Fatalf("GenerateDoc() failed: %v", err)
+		}
+	}
+
+	generateDoc(0.2)
+	generateDoc(0.2)
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("identical generations should be served from the cache; got %d requests", n)
+	}
+
+	generateDoc(0.8)
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("generations with another temperature should not be served from the cache; got %d requests", n)
+	}
+}
+
+func onlyMessage(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.MessageKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() string { return \"foo\" }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
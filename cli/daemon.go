@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	igit "github.com/modernice/jotbot/internal/git"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/exp/slog"
+)
+
+// LockFile is the name of the file that is created in the Git directory of a
+// repository while the daemon runs JotBot for it, to prevent overlapping runs.
+// The lock file is not created in the working tree, so that it is never
+// committed.
+const LockFile = "jotbot.lock"
+
+// Daemon runs "jotbot generate" on a schedule for one or more repositories.
+type Daemon struct {
+	Cron   string   `name:"cron" required:"" env:"JOTBOT_CRON" help:"Cron schedule of the runs, e.g. \"0 3 * * *\" for every night at 3am"`
+	Repos  []string `name:"repo" type:"existingdir" default:"." env:"JOTBOT_REPOS" help:"Root directory of a repository to generate documentation for. Can be repeated"`
+	Status string   `name:"status" type:"path" env:"JOTBOT_STATUS" help:"JSON file that the status of the runs is written to"`
+	Args   []string `arg:"" optional:"" passthrough:"" help:"Flags that are passed to \"jotbot generate\", e.g. -- --branch docs --model gpt-4o"`
+}
+
+// DaemonStatus is the persistent status of a daemon. It is written to the file
+// that is specified by the "--status" flag after each run.
+type DaemonStatus struct {
+	Schedule string                 `json:"schedule"`
+	NextRun  time.Time              `json:"nextRun"`
+	Repos    map[string]*RepoStatus `json:"repos"`
+}
+
+// RepoStatus is the status of the runs of a repository.
+type RepoStatus struct {
+	LastStarted  time.Time `json:"lastStarted,omitempty"`
+	LastFinished time.Time `json:"lastFinished,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
+	Runs         int       `json:"runs"`
+	Failures     int       `json:"failures"`
+	Skipped      int       `json:"skipped"`
+}
+
+// run runs "jotbot generate" for each repository whenever the schedule is
+// due, until ctx is canceled. Runs of the repositories are sequential, and a
+// repository whose lock file exists, e.g. because another daemon is running
+// JotBot for it, is skipped.
+func (d *Daemon) run(ctx context.Context, logger *slog.Logger) error {
+	schedule, err := cron.ParseStandard(d.Cron)
+	if err != nil {
+		return fmt.Errorf("parse cron schedule %q: %w", d.Cron, err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("get executable: %w", err)
+	}
+
+	repos := make([]string, len(d.Repos))
+	for i, repo := range d.Repos {
+		if repos[i], err = filepath.Abs(repo); err != nil {
+			return fmt.Errorf("get absolute path of %s: %w", repo, err)
+		}
+	}
+
+	status, err := d.loadStatus()
+	if err != nil {
+		return err
+	}
+	status.Schedule = d.Cron
+	for _, repo := range repos {
+		if status.Repos[repo] == nil {
+			status.Repos[repo] = &RepoStatus{}
+		}
+	}
+
+	for {
+		status.NextRun = schedule.Next(time.Now())
+		if err := d.writeStatus(status); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to write status: %v", err))
+		}
+
+		logger.Info(fmt.Sprintf("Next run at %s.", status.NextRun.Format(time.RFC3339)))
+
+		timer := time.NewTimer(time.Until(status.NextRun))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		for _, repo := range repos {
+			if ctx.Err() != nil {
+				return nil
+			}
+			d.runRepo(ctx, logger, exe, repo, status.Repos[repo])
+		}
+	}
+}
+
+func (d *Daemon) runRepo(ctx context.Context, logger *slog.Logger, exe, repo string, status *RepoStatus) {
+	unlock, err := lockRepo(repo)
+	if err != nil {
+		status.Skipped++
+		logger.Warn(fmt.Sprintf("Skipping %s: %v", repo, err))
+		return
+	}
+	defer unlock()
+
+	logger.Info(fmt.Sprintf("Running JotBot for %s ...", repo))
+
+	status.LastStarted = time.Now()
+	status.Runs++
+
+	cmd := exec.CommandContext(ctx, exe, append([]string{"generate", repo}, d.Args...)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	err = cmd.Run()
+
+	status.LastFinished = time.Now()
+	status.LastError = ""
+	if err != nil {
+		status.Failures++
+		status.LastError = err.Error()
+		logger.Error(fmt.Sprintf("Run for %s failed: %v", repo, err))
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Run for %s done in %s.", repo, status.LastFinished.Sub(status.LastStarted)))
+}
+
+// lockRepo creates the [LockFile] in the Git directory of the given
+// repository and returns a function that removes it. lockRepo fails if the
+// lock file already exists.
+func lockRepo(repo string) (func(), error) {
+	_, output, err := igit.Git(repo).Cmd("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, fmt.Errorf("find git directory: %w", err)
+	}
+	path := filepath.Join(strings.TrimSpace(string(output)), LockFile)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("locked by another run (remove %s if no run is in progress)", path)
+		}
+		return nil, fmt.Errorf("create lock file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("write lock file: %w", err)
+	}
+
+	return func() { os.Remove(path) }, nil
+}
+
+func (d *Daemon) loadStatus() (*DaemonStatus, error) {
+	status := &DaemonStatus{Repos: make(map[string]*RepoStatus)}
+	if d.Status == "" {
+		return status, nil
+	}
+
+	b, err := os.ReadFile(d.Status)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return status, nil
+		}
+		return nil, fmt.Errorf("read status: %w", err)
+	}
+
+	if err := json.Unmarshal(b, status); err != nil {
+		return nil, fmt.Errorf("parse status %s: %w", d.Status, err)
+	}
+	if status.Repos == nil {
+		status.Repos = make(map[string]*RepoStatus)
+	}
+
+	return status, nil
+}
+
+func (d *Daemon) writeStatus(status *DaemonStatus) error {
+	if d.Status == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal status: %w", err)
+	}
+
+	return os.WriteFile(d.Status, append(b, '\n'), 0o644)
+}
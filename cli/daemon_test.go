@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modernice/jotbot/git"
+	igit "github.com/modernice/jotbot/internal/git"
+	"github.com/modernice/jotbot/internal/patch"
+)
+
+func TestLockRepo(t *testing.T) {
+	dir := initRepo(t)
+
+	unlock, err := lockRepo(dir)
+	if err != nil {
+		t.Fatalf("lockRepo() failed: %v", err)
+	}
+
+	if _, err := lockRepo(dir); err == nil {
+		t.Fatalf("lockRepo() should fail while the repository is locked")
+	}
+
+	unlock()
+
+	if _, err := os.Stat(filepath.Join(dir, ".git", LockFile)); !os.IsNotExist(err) {
+		t.Fatalf("lock file should be removed after unlocking")
+	}
+
+	unlock, err = lockRepo(dir)
+	if err != nil {
+		t.Fatalf("lockRepo() should succeed after unlocking: %v", err)
+	}
+	unlock()
+}
+
+func TestLockRepo_commit(t *testing.T) {
+	dir := initRepo(t)
+
+	unlock, err := lockRepo(dir)
+	if err != nil {
+		t.Fatalf("lockRepo() failed: %v", err)
+	}
+	defer unlock()
+
+	p := patch.Mock(map[string]string{"foo.go": "package foo\n\n// Foo does nothing.\nfunc Foo() {}\n"})
+	if err := git.Repo(dir).Commit(context.Background(), p); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+
+	_, output, err := igit.Git(dir).Cmd("ls-tree", "-r", "--name-only", "HEAD")
+	if err != nil {
+		t.Fatalf("list committed files: %v", err)
+	}
+
+	if files := strings.Fields(string(output)); len(files) != 1 || files[0] != "foo.go" {
+		t.Fatalf("only foo.go should be committed while the repository is locked; got %v", files)
+	}
+}
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.name", "jotbot"},
+		{"config", "user.email", "jotbot@example.com"},
+	} {
+		if _, _, err := igit.Git(dir).Cmd(args...); err != nil {
+			t.Fatalf("init repository: %v", err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/modernice/jotbot/find"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/langs/golang"
+	"github.com/modernice/jotbot/services/openai"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Examples generates runnable example functions ("ExampleXxx") for the
+// exported functions, methods and types of Go packages that have none. The
+// examples of a source file are written to a "_example_test.go" file next to
+// it, and only examples that pass "go vet" are written.
+type Examples struct {
+	Root            string   `arg:"" default:"." help:"Root directory of the repository."`
+	Include         []string `name:"include" short:"i" help:"Glob pattern(s) to include files"`
+	Exclude         []string `name:"exclude" short:"e" help:"Glob pattern(s) to exclude files"`
+	ExcludeInternal bool     `name:"exclude-internal" short:"E" default:"true" help:"Exclude 'internal' directories"`
+	Match           []string `name:"match" help:"Regular expression(s) to match identifiers"`
+	Limit           int      `name:"limit" default:"0" help:"Limit the number of examples to generate"`
+	DryRun          bool     `name:"dry" default:"false" help:"Print the test files without writing them"`
+	Provider        string   `name:"provider" default:"openai" enum:"openai,mistral,huggingface,llamacpp" help:"Service used to generate examples (openai, mistral, huggingface, llamacpp)"`
+	Model           string   `name:"model" short:"m" help:"Model used to generate examples. Defaults to the provider's default model"`
+}
+
+// examplePackage is a Go package that examples are generated for.
+type examplePackage struct {
+	dir        string
+	name       string
+	importPath string
+
+	// examples are the names of the example functions of the package,
+	// including the generated ones.
+	examples map[string]bool
+
+	// files are the generated test files, keyed by their paths.
+	files map[string][]byte
+}
+
+// run generates the examples and writes the test files, or prints them to out
+// in a dry run. Examples that fail to generate or do not pass "go vet" are
+// skipped.
+func (e *Examples) run(ctx context.Context, cfg *Config, out io.Writer) error {
+	root, err := filepath.Abs(e.Root)
+	if err != nil {
+		return fmt.Errorf("get absolute path of %s: %w", e.Root, err)
+	}
+
+	logHandler := cfg.newLogHandler()
+	logger := slog.New(logHandler)
+
+	matchers, err := parseMatchers(e.Match)
+	if err != nil {
+		return fmt.Errorf("parse matchers: %w", err)
+	}
+
+	exclude := append([]string{"**/*_test.go"}, e.Exclude...)
+	if e.ExcludeInternal {
+		exclude = append(exclude, internalDirectoriesGlob)
+	}
+
+	files, err := find.Files(ctx, os.DirFS(root), find.Extensions(golang.FileExtensions...), find.Include(e.Include...), find.Exclude(exclude...))
+	if err != nil {
+		return fmt.Errorf("find files: %w", err)
+	}
+
+	gosvc, err := golang.New(golang.Model(e.Model))
+	if err != nil {
+		return fmt.Errorf("create Go language service: %w", err)
+	}
+
+	// The provider is configured by the flags of the "examples" command, and
+	// by the environment variables of the "generate" command otherwise.
+	cfg.Generate.Provider, cfg.Generate.Model = e.Provider, e.Model
+
+	usage := openai.NewUsageTracker()
+	defer logUsage(logger, usage)
+
+	svc, err := cfg.newService(logHandler, e.Provider, e.Model, usage)
+	if err != nil {
+		return err
+	}
+
+	gen := generate.New(svc, generate.WithLanguage("go", golang.ExampleLanguage(gosvc)), generate.Declarations(os.DirFS(root)), generate.WithLogger(logHandler))
+	finder := golang.NewFinder(golang.IncludeDocumented(true))
+	packages := make(map[string]*examplePackage)
+
+	start := time.Now()
+	var generated int
+
+	for _, file := range files {
+		if e.Limit > 0 && generated >= e.Limit {
+			break
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(file))
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file, err)
+		}
+
+		pkg, err := loadExamplePackage(ctx, packages, filepath.Dir(path), code)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Skipping %s: %v", file, err))
+			continue
+		}
+		if pkg == nil {
+			continue
+		}
+
+		identifiers, err := finder.Find(ctx, file, code)
+		if err != nil {
+			return fmt.Errorf("find identifiers in %s: %w", file, err)
+		}
+
+		for _, identifier := range identifiers {
+			name, ok := golang.ExampleName(identifier)
+			if !ok || pkg.examples[name] || !matchesAny(matchers, identifier) {
+				continue
+			}
+
+			if e.Limit > 0 && generated >= e.Limit {
+				break
+			}
+
+			logger.Info(fmt.Sprintf("Generating %s for %s in %s ...", name, identifier, file))
+
+			example, err := gen.Generate(ctx, generate.PromptInput{
+				Input: generate.Input{Code: code, Language: "go", Identifier: identifier},
+				File:  file,
+			})
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, generate.ErrBudgetExceeded) {
+					return fmt.Errorf("generate %s: %w", name, err)
+				}
+				logger.Warn(fmt.Sprintf("Failed to generate %s: %v", name, err))
+				continue
+			}
+
+			testFile := strings.TrimSuffix(path, ".go") + "_example_test.go"
+			if err := pkg.add(ctx, testFile, name, example); err != nil {
+				logger.Warn(fmt.Sprintf("Skipping %s: %v", name, err))
+				continue
+			}
+			generated++
+		}
+	}
+
+	var written []string
+	for _, pkg := range packages {
+		for path, code := range pkg.files {
+			if e.DryRun {
+				fmt.Fprintf(out, "Generated %q:\n\n%s\n", path, code)
+				continue
+			}
+			if err := os.WriteFile(path, code, 0644); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			written = append(written, path)
+		}
+	}
+	slices.Sort(written)
+	for _, path := range written {
+		logger.Info(fmt.Sprintf("Wrote %s", path))
+	}
+
+	logger.Info(fmt.Sprintf("Generated %d examples in %s.", generated, time.Since(start)))
+
+	return nil
+}
+
+// loadExamplePackage returns the package in dir, given the code of one of its
+// files. Packages are loaded once and cached in packages. Commands cannot be
+// imported by tests, so loadExamplePackage returns nil for "main" packages.
+func loadExamplePackage(ctx context.Context, packages map[string]*examplePackage, dir string, code []byte) (*examplePackage, error) {
+	if pkg, ok := packages[dir]; ok {
+		return pkg, nil
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("parse package clause: %w", err)
+	}
+	if file.Name.Name == "main" {
+		return nil, nil
+	}
+
+	importPath, err := golang.ImportPath(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &examplePackage{
+		dir:        dir,
+		name:       file.Name.Name,
+		importPath: importPath,
+		examples:   make(map[string]bool),
+		files:      make(map[string][]byte),
+	}
+
+	tests, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, fmt.Errorf("find test files: %w", err)
+	}
+	for _, test := range tests {
+		code, err := os.ReadFile(test)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", test, err)
+		}
+		names, _ := golang.ExampleNames(code)
+		for _, name := range names {
+			pkg.examples[name] = true
+		}
+	}
+
+	packages[dir] = pkg
+
+	return pkg, nil
+}
+
+// add adds the generated example to the test file at the given path, if the
+// example declares exactly the example function with the given name and the
+// package passes "go vet" with it.
+func (pkg *examplePackage) add(ctx context.Context, path, name, example string) error {
+	current, ok := pkg.files[path]
+	if !ok {
+		var err error
+		if current, err = os.ReadFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+
+	var before []string
+	if len(current) > 0 {
+		before, _ = golang.ExampleNames(current)
+	}
+
+	code, err := golang.AddExamples(current, pkg.name, pkg.importPath, example)
+	if err != nil {
+		return err
+	}
+
+	after, err := golang.ExampleNames(code)
+	if err != nil {
+		return err
+	}
+	if len(after) != len(before)+1 || !slices.Contains(after, name) {
+		return fmt.Errorf("generated code does not declare exactly the example function %s", name)
+	}
+
+	files := maps.Clone(pkg.files)
+	files[path] = code
+	if err := golang.VetFiles(ctx, pkg.dir, files); err != nil {
+		return fmt.Errorf("generated example does not compile:\n%w", err)
+	}
+
+	pkg.files[path] = code
+	pkg.examples[name] = true
+
+	return nil
+}
+
+func matchesAny(matchers []*regexp.Regexp, identifier string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, m := range matchers {
+		if m.MatchString(identifier) {
+			return true
+		}
+	}
+	return false
+}
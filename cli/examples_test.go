@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestExamplePackage_add(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir := t.TempDir()
+	for file, content := range map[string]string{
+		"go.mod":       "module example.com/foo\n\ngo 1.20\n",
+		"foo.go":       "package foo\n\nfunc Foo() string { return \"foo\" }\n\nfunc Bar() string { return \"bar\" }\n",
+		"foo_test.go":  "package foo_test\n\nfunc ExampleBar() {}\n",
+		"cmd/main.go":  "package main\n\nfunc main() {}\n",
+		"cmd/other.go": "package main\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	packages := make(map[string]*examplePackage)
+
+	if pkg, err := loadExamplePackage(ctx, packages, filepath.Join(dir, "cmd"), []byte("package main\n")); err != nil || pkg != nil {
+		t.Fatalf("loadExamplePackage() should skip main packages; got (%v, %v)", pkg, err)
+	}
+
+	pkg, err := loadExamplePackage(ctx, packages, dir, []byte("package foo\n"))
+	if err != nil {
+		t.Fatalf("loadExamplePackage() failed: %v", err)
+	}
+	if pkg.importPath != "example.com/foo" || !pkg.examples["ExampleBar"] {
+		t.Fatalf("loadExamplePackage() should load the import path and the existing examples; got %q, %v", pkg.importPath, pkg.examples)
+	}
+
+	testFile := filepath.Join(dir, "foo_example_test.go")
+
+	if err := pkg.add(ctx, testFile, "ExampleFoo", "func ExampleFoo() {\n\tfoo.Baz()\n}"); err == nil {
+		t.Errorf("add() should fail for an example that does not compile")
+	}
+
+	if err := pkg.add(ctx, testFile, "ExampleFoo", "func ExampleFooBar() {}"); err == nil {
+		t.Errorf("add() should fail for an example with another name")
+	}
+
+	if err := pkg.add(ctx, testFile, "ExampleFoo", "import \"fmt\"\n\nfunc ExampleFoo() {\n\tfmt.Println(foo.Foo())\n\t// Output: foo\n}"); err != nil {
+		t.Fatalf("add() failed: %v", err)
+	}
+
+	if !pkg.examples["ExampleFoo"] {
+		t.Errorf("add() should record the name of the example")
+	}
+	if _, ok := pkg.files[testFile]; !ok {
+		t.Errorf("add() should record the test file")
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Errorf("add() should not write the test file")
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// DefaultConfigFile is the name of the configuration file that is loaded from
+// the root directory of the repository if no configuration file is specified.
+const DefaultConfigFile = ".jotbot.json"
+
+// ConfigFile is the JSON configuration file of JotBot. It holds settings that
+// cannot be expressed as command-line flags.
+type ConfigFile struct {
+	// Routing configures rules that route the generations of matching symbols
+	// to other models than the default model. Rules are evaluated in order.
+	Routing []RoutingRule `json:"routing"`
+
+	// Extensions maps file extensions to the names of the languages that
+	// handle them, e.g. ".mts" to "ts". The mapping overrides the built-in file
+	// extensions of the languages.
+	Extensions map[string]string `json:"extensions"`
+
+	// Override configures rules that decide per path whether existing
+	// documentation is overridden, regardless of the "--override" flag. Rules
+	// are evaluated in order, and the first matching rule applies.
+	Override []OverrideRule `json:"override"`
+
+	// Plugins configures languages that are implemented by external programs,
+	// by the names of the languages. See package plugin for the protocol.
+	Plugins map[string]Plugin `json:"plugins"`
+}
+
+// Plugin configures a language that is implemented by an external program.
+type Plugin struct {
+	// Command is the command that runs the plugin, followed by its arguments.
+	// Relative paths are relative to the root directory.
+	Command []string `json:"command"`
+
+	// Extensions are the file extensions that the plugin handles, e.g. ".lua".
+	// A missing leading dot is added.
+	Extensions []string `json:"extensions"`
+}
+
+// RoutingRule routes the generations of matching symbols to a model. Empty or
+// zero fields match any symbol.
+type RoutingRule struct {
+	// Kinds are the kinds of matching symbols ("func", "type" or "var").
+	Kinds []string `json:"kinds"`
+
+	// MinTokens is the minimum number of prompt tokens of matching symbols.
+	MinTokens int `json:"minTokens"`
+
+	// MaxTokens is the maximum number of prompt tokens of matching symbols.
+	MaxTokens int `json:"maxTokens"`
+
+	// Model is the model that documents matching symbols.
+	Model string `json:"model"`
+}
+
+// OverrideRule decides whether the existing documentation of the files that
+// match a glob pattern is overridden.
+type OverrideRule struct {
+	// Path is the glob pattern of matching files, relative to the root
+	// directory, e.g. "internal/**".
+	Path string `json:"path"`
+
+	// Override reports whether the documentation of matching files is
+	// overridden.
+	Override bool `json:"override"`
+}
+
+// Overrides reports whether the existing documentation of the given file is
+// overridden, according to the first matching override rule. If no rule
+// matches, fallback is returned.
+func (file ConfigFile) Overrides(path string, fallback bool) bool {
+	path = filepath.ToSlash(path)
+	for _, rule := range file.Override {
+		if ok, err := doublestar.Match(rule.Path, path); err == nil && ok {
+			return rule.Override
+		}
+	}
+	return fallback
+}
+
+// LoadConfigFile reads the configuration file at the given path. If path is
+// empty, [DefaultConfigFile] is loaded from root, if it exists.
+func LoadConfigFile(root, path string) (ConfigFile, error) {
+	var file ConfigFile
+
+	optional := path == ""
+	if optional {
+		path = filepath.Join(root, DefaultConfigFile)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return file, nil
+		}
+		return file, fmt.Errorf("read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &file); err != nil {
+		return file, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	for i, rule := range file.Routing {
+		if rule.Model == "" {
+			return file, fmt.Errorf("config file %s: routing rule #%d has no model", path, i+1)
+		}
+	}
+
+	for i, rule := range file.Override {
+		if !doublestar.ValidatePattern(rule.Path) {
+			return file, fmt.Errorf("config file %s: override rule #%d has an invalid path pattern %q", path, i+1, rule.Path)
+		}
+	}
+
+	for name, p := range file.Plugins {
+		if len(p.Command) == 0 || p.Command[0] == "" {
+			return file, fmt.Errorf("config file %s: plugin %q has no command", path, name)
+		}
+		if len(p.Extensions) == 0 {
+			return file, fmt.Errorf("config file %s: plugin %q has no extensions", path, name)
+		}
+		for i, ext := range p.Extensions {
+			if !strings.HasPrefix(ext, ".") {
+				p.Extensions[i] = "." + ext
+			}
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,36 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/modernice/jotbot/cli"
+)
+
+func TestConfigFile_Overrides(t *testing.T) {
+	file := cli.ConfigFile{
+		Override: []cli.OverrideRule{
+			{Path: "api/**", Override: false},
+			{Path: "internal/**", Override: true},
+			{Path: "**/*_gen.go", Override: true},
+		},
+	}
+
+	tests := []struct {
+		path     string
+		fallback bool
+		want     bool
+	}{
+		{path: "internal/foo/foo.go", want: true},
+		{path: "api/v1/api.go", fallback: true, want: false},
+		{path: "api/v1/api_gen.go", want: false},
+		{path: "pkg/foo_gen.go", want: true},
+		{path: "pkg/foo.go", fallback: true, want: true},
+		{path: "pkg/foo.go", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := file.Overrides(tt.path, tt.fallback); got != tt.want {
+			t.Errorf("Overrides(%q, %v) should return %v; got %v", tt.path, tt.fallback, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/modernice/jotbot/find"
+	"github.com/modernice/jotbot/langs/cpp"
+	"github.com/modernice/jotbot/langs/csharp"
+	"github.com/modernice/jotbot/langs/golang"
+	"github.com/modernice/jotbot/langs/graphql"
+	"github.com/modernice/jotbot/langs/groovy"
+	"github.com/modernice/jotbot/langs/haskell"
+	"github.com/modernice/jotbot/langs/hcl"
+	"github.com/modernice/jotbot/langs/ipynb"
+	"github.com/modernice/jotbot/langs/objc"
+	"github.com/modernice/jotbot/langs/powershell"
+	rlang "github.com/modernice/jotbot/langs/r"
+	"github.com/modernice/jotbot/langs/scala"
+	"github.com/modernice/jotbot/langs/sh"
+	sqllang "github.com/modernice/jotbot/langs/sql"
+	"github.com/modernice/jotbot/langs/swift"
+	"github.com/modernice/jotbot/langs/ts"
+	"github.com/modernice/jotbot/langs/zig"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// languageExtensions are the file extensions of the built-in languages.
+var languageExtensions = map[string][]string{
+	"go":      golang.FileExtensions,
+	"ts":      ts.FileExtensions,
+	"hs":      haskell.FileExtensions,
+	"ps":      powershell.FileExtensions,
+	"r":       rlang.FileExtensions,
+	"ipynb":   ipynb.FileExtensions,
+	"scala":   scala.FileExtensions,
+	"zig":     zig.FileExtensions,
+	"cpp":     cpp.FileExtensions,
+	"objc":    objc.FileExtensions,
+	"groovy":  groovy.FileExtensions,
+	"cs":      csharp.FileExtensions,
+	"swift":   swift.FileExtensions,
+	"tf":      hcl.FileExtensions,
+	"graphql": graphql.FileExtensions,
+	"sh":      sh.FileExtensions,
+	"sql":     sqllang.FileExtensions,
+}
+
+// Fixtures copies a snapshot of the source files of a repository into a new
+// test fixture, such as the fixtures in internal/tests/testdata/fixtures, so
+// that new language support can be tested against realistic code.
+type Fixtures struct {
+	Repo     string   `arg:"" type:"existingdir" help:"Root directory of the repository to take the snapshot from"`
+	Name     string   `name:"name" short:"n" required:"" help:"Name of the fixture"`
+	Out      string   `name:"out" type:"path" default:"internal/tests/testdata/fixtures" help:"Directory that the fixture is created in"`
+	Lang     []string `name:"lang" short:"l" help:"Language(s) whose files are copied, e.g. go or ts. Defaults to all built-in languages"`
+	Include  []string `name:"include" short:"i" help:"Glob pattern(s) to include files"`
+	Exclude  []string `name:"exclude" short:"e" help:"Glob pattern(s) to exclude files"`
+	MaxFiles int      `name:"max-files" default:"20" help:"Maximum number of files that are copied"`
+	MaxSize  int64    `name:"max-size" default:"16384" help:"Maximum size of a copied file in bytes"`
+	Force    bool     `name:"force" short:"f" help:"Replace an existing fixture with the same name"`
+}
+
+// run creates the fixture and writes a summary to out, including the code that
+// registers the fixture in internal/tests. Files that go:embed would skip,
+// i.e. files in directories that start with "." or "_", are not copied.
+func (f *Fixtures) run(ctx context.Context, out io.Writer) error {
+	if !validFixtureName(f.Name) {
+		return fmt.Errorf("invalid fixture name %q: use lowercase letters, digits and dashes", f.Name)
+	}
+
+	exts, err := f.extensions()
+	if err != nil {
+		return err
+	}
+
+	repo := os.DirFS(f.Repo)
+	files, err := find.Files(ctx, repo, find.Extensions(exts...), find.Include(f.Include...), find.Exclude(f.Exclude...))
+	if err != nil {
+		return fmt.Errorf("find files: %w", err)
+	}
+	slices.Sort(files)
+
+	target := filepath.Join(f.Out, f.Name)
+	if _, err := os.Stat(target); err == nil {
+		if !f.Force {
+			return fmt.Errorf("fixture %q already exists in %s (use --force to replace it)", f.Name, f.Out)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("remove existing fixture: %w", err)
+		}
+	}
+
+	var copied []string
+	for _, file := range files {
+		if len(copied) >= f.MaxFiles {
+			break
+		}
+		if !embeddable(file) {
+			continue
+		}
+
+		info, err := fs.Stat(repo, file)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", file, err)
+		}
+		if info.Size() > f.MaxSize {
+			continue
+		}
+
+		if err := copyFixtureFile(repo, file, filepath.Join(target, filepath.FromSlash(file))); err != nil {
+			return err
+		}
+		copied = append(copied, file)
+	}
+
+	if len(copied) == 0 {
+		return errors.New("no files to copy")
+	}
+
+	fmt.Fprintf(out, "Created fixture %q with %d files in %s:\n\n", f.Name, len(copied), target)
+	for _, file := range copied {
+		fmt.Fprintf(out, "  %s\n", file)
+	}
+
+	embedPath := path.Join("testdata/fixtures", f.Name)
+	fsName := fixtureVar(f.Name)
+	fmt.Fprintf(out, "\nRegister the fixture in internal/tests/repo.go:\n\n")
+	fmt.Fprintf(out, "\t//go:embed %s\n\t%s embed.FS\n\n", embedPath, fsName)
+	fmt.Fprintf(out, "\t%q: Must(fs.Sub(%s, %q)),\n", f.Name, fsName, embedPath)
+
+	return nil
+}
+
+// extensions returns the file extensions of the languages of the fixture.
+func (f *Fixtures) extensions() ([]string, error) {
+	langs := f.Lang
+	if len(langs) == 0 {
+		langs = maps.Keys(languageExtensions)
+	}
+
+	var exts []string
+	for _, lang := range langs {
+		e, ok := languageExtensions[lang]
+		if !ok {
+			known := maps.Keys(languageExtensions)
+			slices.Sort(known)
+			return nil, fmt.Errorf("unknown language %q (known languages: %s)", lang, strings.Join(known, ", "))
+		}
+		exts = append(exts, e...)
+	}
+	slices.Sort(exts)
+
+	return slices.Compact(exts), nil
+}
+
+func copyFixtureFile(repo fs.FS, file, target string) error {
+	code, err := fs.ReadFile(repo, file)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", file, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("create directory %q: %w", filepath.Dir(target), err)
+	}
+	if err := os.WriteFile(target, code, 0644); err != nil {
+		return fmt.Errorf("write %q: %w", target, err)
+	}
+	return nil
+}
+
+// embeddable reports whether go:embed includes the file when the directory of
+// the fixture is embedded.
+func embeddable(file string) bool {
+	for _, elem := range strings.Split(file, "/") {
+		if strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return false
+		}
+	}
+	return true
+}
+
+func validFixtureName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// fixtureVar returns the name of the variable that embeds the fixture with the
+// given name, e.g. "onlyGoFilesFS" for "only-go-files".
+func fixtureVar(name string) string {
+	parts := strings.Split(name, "-")
+	for i, p := range parts {
+		if i > 0 && p != "" {
+			parts[i] = string(unicode.ToUpper(rune(p[0]))) + p[1:]
+		}
+	}
+	v := strings.Join(parts, "")
+	if v[0] >= '0' && v[0] <= '9' {
+		v = "fixture" + v
+	}
+	return v + "FS"
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/find"
+)
+
+func TestFixtures_run(t *testing.T) {
+	repo := t.TempDir()
+	for file, content := range map[string]string{
+		"foo.go":            "package foo\n",
+		"bar/bar.go":        "package bar\n",
+		"bar/bar.ts":        "export const bar = 1\n",
+		"_internal/skip.go": "package internal\n",
+		"large.go":          "package large\n" + strings.Repeat("// padding\n", 100),
+		"README.md":         "# Repo\n",
+	} {
+		path := filepath.Join(repo, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := t.TempDir()
+	f := Fixtures{
+		Repo:     repo,
+		Name:     "my-repo",
+		Out:      out,
+		Lang:     []string{"go"},
+		MaxFiles: 10,
+		MaxSize:  512,
+	}
+
+	var summary bytes.Buffer
+	if err := f.run(context.Background(), &summary); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	files, err := find.Files(context.Background(), os.DirFS(filepath.Join(out, "my-repo")), find.Extensions(".go", ".ts", ".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"bar/bar.go", "foo.go"}; !cmp.Equal(want, files) {
+		t.Fatalf("unexpected fixture files:\n\n%s", cmp.Diff(want, files))
+	}
+
+	if !strings.Contains(summary.String(), `"my-repo": Must(fs.Sub(myRepoFS, "testdata/fixtures/my-repo")),`) {
+		t.Fatalf("summary should contain the registration of the fixture:\n\n%s", summary.String())
+	}
+
+	if err := f.run(context.Background(), &summary); err == nil {
+		t.Fatalf("run() should fail if the fixture already exists")
+	}
+
+	f.Force = true
+	if err := f.run(context.Background(), &summary); err != nil {
+		t.Fatalf("run() with --force failed: %v", err)
+	}
+}
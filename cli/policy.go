@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Policy is an organization-wide policy that restricts how JotBot may be run.
+// A policy is loaded from a JSON file using the "--policy" flag and validated
+// before any documentation is generated.
+type Policy struct {
+	// MaxCost is the maximum estimated cost of a run in USD. Once the limit
+	// is reached, no further requests are sent.
+	MaxCost float64 `json:"maxCost,omitempty"`
+
+	// AllowedModels are the models that may be used. Patterns such as
+	// "gpt-4o*" are supported. An empty list allows all models.
+	AllowedModels []string `json:"allowedModels,omitempty"`
+
+	// RequiredFooter is appended to each generated documentation.
+	RequiredFooter string `json:"requiredFooter,omitempty"`
+
+	// BannedProviders are the providers that must not be used.
+	BannedProviders []string `json:"bannedProviders,omitempty"`
+}
+
+// LoadPolicy reads the policy file at the given path. Unknown fields are
+// rejected, so that misspelled restrictions are not silently ignored.
+func LoadPolicy(file string) (Policy, error) {
+	var p Policy
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return p, fmt.Errorf("read policy file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("parse policy file %s: %w", file, err)
+	}
+
+	for _, pattern := range p.AllowedModels {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return p, fmt.Errorf("policy file %s: invalid model pattern %q: %w", file, pattern, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Validate returns an error if running the given provider with the given
+// models violates the policy. An empty model stands for a model that cannot be
+// determined before the run, which is only valid if the policy allows all
+// models.
+func (p Policy) Validate(provider string, models ...string) error {
+	for _, banned := range p.BannedProviders {
+		if strings.EqualFold(banned, provider) {
+			return fmt.Errorf("provider %q is banned by policy", provider)
+		}
+	}
+
+	if len(p.AllowedModels) == 0 {
+		return nil
+	}
+
+	for _, model := range models {
+		if model == "" {
+			return fmt.Errorf("model of provider %q must be specified explicitly to be validated against the policy", provider)
+		}
+		if !p.allowsModel(model) {
+			return fmt.Errorf("model %q is not allowed by policy", model)
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) allowsModel(model string) bool {
+	for _, pattern := range p.AllowedModels {
+		if ok, _ := path.Match(pattern, model); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/modernice/jotbot/cli"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := cli.Policy{
+		AllowedModels:   []string{"gpt-4o*", "gpt-3.5-turbo"},
+		BannedProviders: []string{"huggingface"},
+	}
+
+	tests := []struct {
+		provider string
+		models   []string
+		valid    bool
+	}{
+		{provider: "openai", models: []string{"gpt-3.5-turbo"}, valid: true},
+		{provider: "openai", models: []string{"gpt-4o", "gpt-4o-mini"}, valid: true},
+		{provider: "openai", models: []string{"gpt-3.5-turbo", "gpt-4"}},
+		{provider: "huggingface", models: []string{"gpt-4o"}},
+		{provider: "llamacpp", models: []string{""}},
+	}
+
+	for _, tt := range tests {
+		err := policy.Validate(tt.provider, tt.models...)
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%q, %v) should succeed; got %v", tt.provider, tt.models, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Validate(%q, %v) should fail", tt.provider, tt.models)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/modernice/jotbot/generate"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// loadPromptTemplates parses the prompt templates that are configured by the
+// "--prompt-template" flag, which maps language names to template files, and
+// returns the options that configure them. languages are the names of the
+// languages that templates may be configured for.
+func loadPromptTemplates(files map[string]string, languages []string) ([]generate.Option, error) {
+	names := maps.Keys(files)
+	slices.Sort(names)
+
+	opts := make([]generate.Option, 0, len(files))
+	for _, lang := range names {
+		if !slices.Contains(languages, lang) {
+			return nil, fmt.Errorf("prompt template for unknown language %q (known languages: %s)", lang, strings.Join(languages, ", "))
+		}
+
+		path := files[lang]
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read prompt template: %w", err)
+		}
+
+		tmpl, err := template.New(path).Parse(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("parse prompt template %s: %w", path, err)
+		}
+
+		opts = append(opts, generate.PromptTemplate(lang, tmpl))
+	}
+
+	return opts, nil
+}
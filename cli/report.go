@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/modernice/jotbot/find"
+	"github.com/modernice/jotbot/patch"
+	"github.com/modernice/jotbot/services/openai"
+)
+
+// Report summarizes a run of JotBot. It is written as JSON to the file that is
+// specified by the "--report" flag.
+type Report struct {
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Root     string         `json:"root"`
+	Provider string         `json:"provider"`
+	Model    string         `json:"model,omitempty"`
+	Policy   *ReportPolicy  `json:"policy,omitempty"`
+	Usage    []openai.Usage `json:"usage,omitempty"`
+	Cost     float64        `json:"cost"`
+	Error    string         `json:"error,omitempty"`
+
+	// Overrides are the existing comments that were replaced by the run.
+	Overrides []patch.Override `json:"overrides,omitempty"`
+
+	// Skipped are the files and directories that were not searched for
+	// undocumented code, and why.
+	Skipped []find.Skip `json:"skipped,omitempty"`
+}
+
+// ReportPolicy records the [Policy] that a run was validated against.
+type ReportPolicy struct {
+	File string `json:"file"`
+	Policy
+}
+
+func (r *Report) finish(usage *openai.UsageTracker, err error) {
+	r.Finished = time.Now()
+	r.Usage = usage.Usage()
+	r.Cost = usage.Cost()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (r *Report) write(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	return nil
+}
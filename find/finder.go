@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/modernice/jotbot/internal/slice"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -16,22 +18,32 @@ var (
 		".ts",
 	}
 
+	// DependencyExclude represents the glob patterns that match vendored copies
+	// of dependencies: vendor folders, module caches in the layout of
+	// GOPATH/pkg/mod, and Bazel output directories. These patterns are part of
+	// [DefaultExclude] and can be re-included using [IncludeDependencies].
+	DependencyExclude = []string{
+		"**/vendor/**",
+		"**/pkg/mod/**/*@*/**",
+		"**/pkg/mod/cache/**",
+		"**/bazel-*/**",
+	}
+
 	// DefaultExclude represents a list of glob patterns used to identify file paths
 	// that should be omitted from search or processing operations. These patterns
 	// are designed to match common directories and files that are typically not of
 	// interest, such as hidden directories, distribution folders, dependency
-	// directories, vendor folders, test-related files, and generated protocol
-	// buffer code files.
-	DefaultExclude = []string{
+	// directories, vendored dependencies, test-related files, and generated
+	// protocol buffer code files.
+	DefaultExclude = append([]string{
 		"**/.*/**",
 		"**/dist/**",
 		"**/node_modules/**",
-		"**/vendor/**",
 		"**/testdata/**",
 		"**/test/**",
 		"**/tests/**",
 		"**/*.pb.go",
-	}
+	}, DependencyExclude...)
 
 	// Default represents the standard configuration for file searching,
 	// encompassing common file extensions to include and patterns to exclude.
@@ -50,9 +62,14 @@ var (
 // performing a file search to determine which files are considered matches
 // based on the criteria defined by the Options instance.
 type Options struct {
-	Extensions []string
-	Include    []string
-	Exclude    []string
+	Extensions          []string
+	Include             []string
+	Exclude             []string
+	IncludeDependencies bool
+
+	// OnSkip is called for each file and directory that is skipped. See
+	// [OnSkip].
+	OnSkip func(Skip)
 }
 
 // Option represents a configuration modifier which applies custom settings to
@@ -92,6 +109,14 @@ func Exclude(patterns ...string) Option {
 	}
 }
 
+// IncludeDependencies configures whether vendored dependencies that match the
+// patterns in [DependencyExclude] are included in the search.
+func IncludeDependencies(include bool) Option {
+	return func(o *Options) {
+		o.IncludeDependencies = include
+	}
+}
+
 // Files searches for files within a given file system that match specified
 // patterns, taking into account inclusion and exclusion criteria. It applies
 // options to configure the search behavior, such as filtering by file
@@ -119,6 +144,12 @@ func (f Options) Find(ctx context.Context, files fs.FS) ([]string, error) {
 		f.Extensions = DefaultExtensions
 	}
 
+	if f.IncludeDependencies {
+		f.Exclude = slice.Filter(f.Exclude, func(pattern string) bool {
+			return !slices.Contains(DependencyExclude, pattern)
+		})
+	}
+
 	var found []string
 	if err := fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -126,17 +157,29 @@ func (f Options) Find(ctx context.Context, files fs.FS) ([]string, error) {
 		}
 
 		if d.IsDir() {
-			if f.excluded(path) {
+			if pattern, ok := f.excluded(path); ok {
+				f.skip(path, SkipExcluded, pattern)
 				return fs.SkipDir
 			}
 			return nil
 		}
 
+		if ext := filepath.Ext(path); !f.extensionIncluded(ext) {
+			// Files of other languages, such as READMEs or images, are only
+			// reported if an include pattern selects them explicitly.
+			if _, excluded := f.excluded(path); f.explicitlyIncluded(path) && !excluded {
+				f.skip(path, SkipNoLanguage, ext)
+			}
+			return nil
+		}
+
 		if !f.included(path) {
+			f.skip(path, SkipNotIncluded, "")
 			return nil
 		}
 
-		if f.excluded(path) {
+		if pattern, ok := f.excluded(path); ok {
+			f.skip(path, SkipExcluded, pattern)
 			return nil
 		}
 
@@ -155,29 +198,30 @@ func (f Options) included(path string) bool {
 	}
 
 	if len(f.Include) > 0 {
-		for _, pattern := range f.Include {
-			if ok, err := doublestar.Match(pattern, path); err == nil && ok {
-				return true
-			}
-		}
-		return false
+		return f.explicitlyIncluded(path)
 	}
 
 	return true
 }
 
-func (f Options) excluded(path string) bool {
-	if len(f.Exclude) == 0 {
-		return false
+// explicitlyIncluded reports whether one of the include patterns matches path.
+func (f Options) explicitlyIncluded(path string) bool {
+	for _, pattern := range f.Include {
+		if ok, err := doublestar.Match(pattern, path); err == nil && ok {
+			return true
+		}
 	}
+	return false
+}
 
+// excluded returns the first exclude pattern that matches path.
+func (f Options) excluded(path string) (string, bool) {
 	for _, pattern := range f.Exclude {
 		if ok, err := doublestar.Match(pattern, path); err == nil && ok {
-			return true
+			return pattern, true
 		}
 	}
-
-	return false
+	return "", false
 }
 
 func (f Options) extensionIncluded(ext string) bool {
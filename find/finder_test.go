@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
 
 	"github.com/modernice/jotbot/find"
 	"github.com/modernice/jotbot/internal/tests"
@@ -95,3 +98,100 @@ func TestOptions_Exclude(t *testing.T) {
 		}, got)
 	})
 }
+
+func TestOptions_Find_excludesDependencies(t *testing.T) {
+	repoFS := fstest.MapFS{
+		"foo.go":                           {},
+		"vendor/github.com/foo/bar/bar.go": {},
+		"pkg/mod/github.com/foo/bar@v1.0.0/bar.go": {},
+		"pkg/mod/cache/download/github.com/foo.go": {},
+		"pkg/mod/mod.go":                          {},
+		"bazel-out/k8-fastbuild/bin/foo/foo.go":   {},
+		"sub/bazel-bin/external/foo/foo.go":       {},
+		"third_party/pkg/mod/x.org/y@v0.1.0/y.go": {},
+	}
+
+	got, err := find.Files(context.Background(), repoFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests.ExpectFiles(t, []string{"foo.go", "pkg/mod/mod.go"}, got)
+
+	got, err = find.Files(context.Background(), repoFS, find.IncludeDependencies(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests.ExpectFiles(t, []string{
+		"foo.go",
+		"vendor/github.com/foo/bar/bar.go",
+		"pkg/mod/github.com/foo/bar@v1.0.0/bar.go",
+		"pkg/mod/cache/download/github.com/foo.go",
+		"pkg/mod/mod.go",
+		"bazel-out/k8-fastbuild/bin/foo/foo.go",
+		"sub/bazel-bin/external/foo/foo.go",
+		"third_party/pkg/mod/x.org/y@v0.1.0/y.go",
+	}, got)
+}
+
+func TestOnSkip(t *testing.T) {
+	repoFS := fstest.MapFS{
+		"foo.go":              {},
+		"foo.txt":             {},
+		"bar/bar.go":          {},
+		"vendor/foo/foo.go":   {},
+		"internal/baz.go":     {},
+		"internal/baz_gen.go": {},
+		"internal/notes.txt":  {},
+	}
+
+	var skipped []find.Skip
+	got, err := find.Files(
+		context.Background(),
+		repoFS,
+		find.Include("*.go", "internal/**"),
+		find.Exclude("**/*_gen.go"),
+		find.OnSkip(func(s find.Skip) { skipped = append(skipped, s) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests.ExpectFiles(t, []string{"foo.go", "internal/baz.go"}, got)
+
+	want := []find.Skip{
+		{Path: "bar/bar.go", Reason: find.SkipNotIncluded},
+		{Path: "internal/baz_gen.go", Reason: find.SkipExcluded, Detail: "**/*_gen.go"},
+		{Path: "internal/notes.txt", Reason: find.SkipNoLanguage, Detail: ".txt"},
+		{Path: "vendor", Reason: find.SkipExcluded, Detail: "**/vendor/**"},
+	}
+	if !cmp.Equal(want, skipped) {
+		t.Fatalf("unexpected skips:\n%s", cmp.Diff(want, skipped))
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		code   string
+		want   bool
+		marker string
+	}{
+		{name: "go", code: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage foo\n", want: true, marker: "// Code generated by protoc-gen-go. DO NOT EDIT."},
+		{name: "go after license", code: "// Copyright\n\n// Code generated by mockgen. DO NOT EDIT.\npackage foo\n", want: true, marker: "// Code generated by mockgen. DO NOT EDIT."},
+		{name: "@generated", code: "/**\n * @generated\n */\nexport const foo = 1\n", want: true, marker: "* @generated"},
+		{name: "@generated in body", code: "package foo\n\n\n\n\n\n\n\n\n\n\n// @generated\n", want: false},
+		{name: "handwritten", code: "package foo\n\n// Code generated elsewhere.\nfunc Foo() {}\n", want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			marker, ok := find.Generated([]byte(tt.code))
+			if ok != tt.want {
+				t.Fatalf("Generated() should return %t; got %t", tt.want, ok)
+			}
+			if marker != tt.marker {
+				t.Fatalf("Generated() should return marker %q; got %q", tt.marker, marker)
+			}
+		})
+	}
+}
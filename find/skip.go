@@ -0,0 +1,92 @@
+package find
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// SkipReason describes why a file was not searched for identifiers.
+type SkipReason string
+
+const (
+	// SkipExcluded means that the file or one of its parent directories matches
+	// an exclude pattern.
+	SkipExcluded SkipReason = "excluded"
+
+	// SkipNotIncluded means that include patterns are configured and the file
+	// matches none of them.
+	SkipNotIncluded SkipReason = "not-included"
+
+	// SkipNoLanguage means that no language handles the extension of the file.
+	// Files with unknown extensions are only reported if an include pattern
+	// selects them explicitly.
+	SkipNoLanguage SkipReason = "no-language"
+
+	// SkipTooLarge means that the file exceeds the maximum file size.
+	SkipTooLarge SkipReason = "too-large"
+
+	// SkipGenerated means that the file is marked as generated code.
+	SkipGenerated SkipReason = "generated"
+)
+
+// Skip is a file, or a directory and all its files, that was not searched for
+// identifiers.
+type Skip struct {
+	Path   string     `json:"path"`
+	Reason SkipReason `json:"reason"`
+
+	// Detail describes the reason, e.g. the exclude pattern that matched.
+	Detail string `json:"detail,omitempty"`
+}
+
+// String returns the path and reason of the skip, e.g.
+// "vendor (excluded: **/vendor/**)".
+func (s Skip) String() string {
+	if s.Detail == "" {
+		return s.Path + " (" + string(s.Reason) + ")"
+	}
+	return s.Path + " (" + string(s.Reason) + ": " + s.Detail + ")"
+}
+
+// OnSkip configures a function that is called for each file and directory
+// that is skipped, together with the reason for skipping it. Directories that
+// are skipped are reported once, without their files.
+func OnSkip(fn func(Skip)) Option {
+	return func(o *Options) {
+		o.OnSkip = fn
+	}
+}
+
+func (f Options) skip(path string, reason SkipReason, detail string) {
+	if f.OnSkip != nil {
+		f.OnSkip(Skip{Path: path, Reason: reason, Detail: detail})
+	}
+}
+
+var generatedRE = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedHeaderLines is the number of lines at the beginning of a file that
+// are searched for the "@generated" marker.
+const generatedHeaderLines = 10
+
+// Generated reports whether code is marked as generated, and returns the line
+// that marks it. Recognized are the Go convention ("// Code generated ... DO
+// NOT EDIT.") anywhere in the file and the "@generated" marker within the
+// first lines of the file, which is used by many code generators for other
+// languages.
+func Generated(code []byte) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(code))
+	scanner.Buffer(nil, len(code)+1)
+	for line := 0; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimSuffix(scanner.Text(), "\r"))
+		if generatedRE.MatchString(text) {
+			return text, true
+		}
+		if line < generatedHeaderLines && strings.Contains(text, "@generated") {
+			return text, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,133 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// ErrCircuitOpen is returned by a [*Generator] for all remaining generations
+// after its circuit breaker has aborted the run because the [Service] failed
+// too often.
+var ErrCircuitOpen = errors.New("circuit breaker open: too many service errors")
+
+// CircuitBreaker configures a Generator to stop sending requests to its
+// [Service] when the ratio of failed generations within the last window
+// generations reaches threshold, which protects providers from being hammered
+// with requests that fail anyway (e.g. because of an invalid API key or rate
+// limits). If cooldown is positive, the Generator pauses all generations for
+// the cooldown and then resumes. If the next generation after a pause fails as
+// well, or if cooldown is zero, all remaining generations fail with
+// [ErrCircuitOpen]. A threshold or window of zero disables the circuit breaker.
+func CircuitBreaker(threshold float64, window int, cooldown time.Duration) Option {
+	return func(g *Generator) {
+		if threshold <= 0 || window <= 0 {
+			g.breaker = nil
+			return
+		}
+		g.breaker = &breaker{
+			threshold: threshold,
+			window:    window,
+			cooldown:  cooldown,
+		}
+	}
+}
+
+type breaker struct {
+	threshold float64
+	window    int
+	cooldown  time.Duration
+	log       *slog.Logger
+
+	mux         sync.Mutex
+	failures    []bool
+	pausedUntil time.Time
+	halfOpen    bool
+	open        bool
+}
+
+// wait blocks while the breaker is paused and returns [ErrCircuitOpen] if the
+// breaker has aborted the run.
+func (b *breaker) wait(ctx context.Context) error {
+	for {
+		b.mux.Lock()
+		open, pause := b.open, time.Until(b.pausedUntil)
+		b.mux.Unlock()
+
+		if open {
+			return ErrCircuitOpen
+		}
+
+		if pause <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// record records the outcome of a generation and trips the breaker if the
+// error rate within the window reaches the threshold.
+func (b *breaker) record(err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrBudgetExceeded) {
+		return
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.open {
+		return
+	}
+
+	if err == nil {
+		b.halfOpen = false
+	} else if b.halfOpen {
+		b.open = true
+		b.log.Error(fmt.Sprintf("Service is still failing after a pause. Aborting remaining generations: %v", err))
+		return
+	}
+
+	b.failures = append(b.failures, err != nil)
+	if len(b.failures) > b.window {
+		b.failures = b.failures[len(b.failures)-b.window:]
+	}
+
+	if err == nil || len(b.failures) < b.window {
+		return
+	}
+
+	var failed int
+	for _, f := range b.failures {
+		if f {
+			failed++
+		}
+	}
+
+	rate := float64(failed) / float64(len(b.failures))
+	if rate < b.threshold {
+		return
+	}
+
+	b.failures = b.failures[:0]
+
+	if b.cooldown <= 0 {
+		b.open = true
+		b.log.Error(fmt.Sprintf("%d of the last %d generations failed. Aborting remaining generations: %v", failed, b.window, err))
+		return
+	}
+
+	b.halfOpen = true
+	b.pausedUntil = time.Now().Add(b.cooldown)
+	b.log.Warn(fmt.Sprintf("%d of the last %d generations failed. Pausing for %s ...", failed, b.window, b.cooldown))
+}
@@ -0,0 +1,50 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+)
+
+// Conformer is implemented by languages with conventions for the wording of
+// documentation that models tend to ignore, such as the convention to begin Go
+// doc comments with the name of the documented symbol. The [Generator] passes
+// each generated documentation to Conform and generates the documentation
+// once more if Conform cannot fix it.
+type Conformer interface {
+	// Conform returns doc rewritten to follow the conventions of the language
+	// for the symbol identified by identifier, or an error that describes the
+	// violated convention if doc cannot be rewritten.
+	Conform(identifier, doc string) (string, error)
+}
+
+// conform fixes the documentation that was generated for the input if the
+// language implements [Conformer]. If the documentation cannot be fixed, it
+// is generated once more, with the violated convention appended to the
+// prompt. Documentation of [Deferred] services is returned as-is.
+func (g *Generator) conform(ctx context.Context, input PromptInput, prompt, doc string) (string, error) {
+	c, ok := g.languages[input.Language].(Conformer)
+	if !ok {
+		return doc, nil
+	}
+
+	if d, ok := g.svc.(Deferred); ok && d.Deferred() {
+		return doc, nil
+	}
+
+	conformed, err := c.Conform(input.Identifier, doc)
+	if err == nil {
+		return conformed, nil
+	}
+
+	g.log.Debug(fmt.Sprintf("Regenerating documentation of %s in %s: %v", input.Identifier, input.File, err))
+
+	prompt = fmt.Sprintf("%s\n\nYour previous answer was rejected because it violated this rule: %v. Follow the instructions exactly.", prompt, err)
+	if doc, err = g.complete(ctx, input, prompt); err != nil {
+		return "", err
+	}
+
+	if conformed, err = c.Conform(input.Identifier, doc); err != nil {
+		return "", fmt.Errorf("conform documentation: %w", err)
+	}
+	return conformed, nil
+}
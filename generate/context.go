@@ -9,13 +9,15 @@ type genCtx struct {
 
 	input  PromptInput
 	prompt string
+	system string
 }
 
-func newCtx(parent context.Context, input PromptInput, prompt string) *genCtx {
+func newCtx(parent context.Context, input PromptInput, prompt, system string) *genCtx {
 	return &genCtx{
 		Context: parent,
 		input:   input,
 		prompt:  prompt,
+		system:  system,
 	}
 }
 
@@ -30,6 +32,11 @@ func (ctx *genCtx) Prompt() string {
 	return ctx.prompt
 }
 
+// SystemPrompt returns the system prompt that is configured by [SystemPrompt].
+func (ctx *genCtx) SystemPrompt() string {
+	return ctx.system
+}
+
 // File returns the code content of the input as a byte slice.
 func (ctx *genCtx) File() []byte {
 	return ctx.input.Code
@@ -0,0 +1,99 @@
+package generate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Declarer is implemented by languages that can look up the declarations that
+// the documentation of a symbol depends on, such as the type declaration of
+// the receiver of a method. The [Generator] adds these declarations to the
+// prompt, because minification strips their documentation, and the code of
+// the file does not contain them at all if they are declared in another file
+// of the package. See [Declarations].
+type Declarer interface {
+	// Declarations returns the source code of the declarations in code that
+	// the symbol identified by identifier depends on, including their
+	// documentation. Declarations that code does not contain are omitted.
+	Declarations(identifier string, code []byte) ([]string, error)
+}
+
+// Declarations configures the Generator to look up the declarations that a
+// symbol depends on in the other files of its package if they are not
+// declared in the file of the symbol. The package of a file consists of the
+// files with the same extension in its directory, which are read from fsys
+// using the file paths of the inputs. Without this option, only the file of
+// the symbol is searched. Only languages that implement [Declarer] support
+// this.
+func Declarations(fsys fs.FS) Option {
+	return func(g *Generator) {
+		g.declarationsFS = fsys
+	}
+}
+
+// declarations returns the declarations that the symbol of the input depends
+// on. input must contain the original, unminified code of the file.
+func (g *Generator) declarations(lang Language, input PromptInput) []string {
+	d, ok := lang.(Declarer)
+	if !ok {
+		return nil
+	}
+
+	decls, err := d.Declarations(input.Identifier, input.Code)
+	if err != nil {
+		g.log.Debug(fmt.Sprintf("Failed to look up declarations of %s in %s: %v", input.Identifier, input.File, err))
+	}
+	if len(decls) > 0 || g.declarationsFS == nil {
+		return decls
+	}
+
+	dir, ext := path.Dir(input.File), path.Ext(input.File)
+	entries, err := fs.ReadDir(g.declarationsFS, dir)
+	if err != nil {
+		g.log.Debug(fmt.Sprintf("Failed to read package %s for declarations: %v", dir, err))
+	}
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() || path.Ext(name) != ext || name == path.Clean(input.File) {
+			continue
+		}
+
+		code, err := fs.ReadFile(g.declarationsFS, name)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to read %s for declarations: %v", name, err))
+			continue
+		}
+
+		if decls, err = d.Declarations(input.Identifier, code); err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to look up declarations of %s in %s: %v", input.Identifier, name, err))
+			continue
+		}
+		if len(decls) > 0 {
+			return decls
+		}
+	}
+
+	return nil
+}
+
+// withDeclarations appends the declarations that the documented symbol depends
+// on to a prompt.
+func withDeclarations(prompt string, decls []string) string {
+	if len(decls) == 0 {
+		return prompt
+	}
+
+	code := make([]string, len(decls))
+	for i, d := range decls {
+		code[i] = strings.TrimSpace(d)
+	}
+
+	return fmt.Sprintf(
+		"%s\n\nThe documented symbol depends on these declarations:\n---\n%s\n---",
+		strings.TrimRight(prompt, "\n"),
+		strings.Join(code, "\n\n"),
+	)
+}
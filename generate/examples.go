@@ -0,0 +1,139 @@
+package generate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math/rand"
+	"path"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Example is a documented symbol that is shown to the model as a few-shot
+// example of the documentation style of the codebase.
+type Example struct {
+	// Identifier is the identifier of the symbol, e.g. "func:Foo".
+	Identifier string
+
+	// Code is the declaration of the symbol, including its documentation.
+	Code string
+}
+
+// Exampler is implemented by languages that can extract well-documented
+// symbols from code, which the [Generator] uses as few-shot examples. See
+// [FewShot].
+type Exampler interface {
+	// Examples returns the well-documented public symbols in the given file.
+	Examples(file string, code []byte) ([]Example, error)
+}
+
+// FewShot configures the Generator to include up to n well-documented symbols
+// from the same package in the prompt, so that the generated documentation
+// matches the existing voice of the codebase. The package of a file consists
+// of the files with the same extension in its directory, which are read from
+// fsys using the file paths of the inputs. Only languages that implement
+// [Exampler] support few-shot examples. The examples are sampled
+// deterministically for each symbol, so that repeated runs send the same
+// prompts.
+func FewShot(fsys fs.FS, n int) Option {
+	return func(g *Generator) {
+		g.examplesFS = fsys
+		g.examples = n
+	}
+}
+
+// packageExamples returns the few-shot examples for the given input.
+func (g *Generator) packageExamples(lang Language, input PromptInput) []Example {
+	ex, ok := lang.(Exampler)
+	if !ok || g.examplesFS == nil || g.examples <= 0 {
+		return nil
+	}
+
+	var candidates []Example
+	for _, e := range g.loadExamples(ex, input.File) {
+		if e.Identifier != input.Identifier {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) <= g.examples {
+		return candidates
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(input.File + "\x00" + input.Identifier))
+	picked := rand.New(rand.NewSource(int64(h.Sum64()))).Perm(len(candidates))[:g.examples]
+	slices.Sort(picked)
+
+	out := make([]Example, len(picked))
+	for i, p := range picked {
+		out[i] = candidates[p]
+	}
+	return out
+}
+
+// loadExamples returns the examples of the package of the given file. The
+// examples of each package are loaded only once.
+func (g *Generator) loadExamples(ex Exampler, file string) []Example {
+	dir, ext := path.Dir(file), path.Ext(file)
+	key := dir + "\x00" + ext
+
+	g.examplesMux.Lock()
+	defer g.examplesMux.Unlock()
+
+	if examples, ok := g.examplesCache[key]; ok {
+		return examples
+	}
+
+	entries, err := fs.ReadDir(g.examplesFS, dir)
+	if err != nil {
+		g.log.Debug(fmt.Sprintf("Failed to read package %s for examples: %v", dir, err))
+	}
+
+	var examples []Example
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ext {
+			continue
+		}
+
+		name := path.Join(dir, entry.Name())
+		code, err := fs.ReadFile(g.examplesFS, name)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to read %s for examples: %v", name, err))
+			continue
+		}
+
+		found, err := ex.Examples(name, code)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to extract examples from %s: %v", name, err))
+			continue
+		}
+		examples = append(examples, found...)
+	}
+
+	if g.examplesCache == nil {
+		g.examplesCache = make(map[string][]Example)
+	}
+	g.examplesCache[key] = examples
+
+	return examples
+}
+
+// withExamples prepends the given few-shot examples to a prompt.
+func withExamples(prompt string, examples []Example) string {
+	if len(examples) == 0 {
+		return prompt
+	}
+
+	code := make([]string, len(examples))
+	for i, e := range examples {
+		code[i] = strings.TrimSpace(e.Code)
+	}
+
+	return fmt.Sprintf(
+		"Here are documented declarations from the same package. Match their writing style and level of detail:\n---\n%s\n---\n\n%s",
+		strings.Join(code, "\n\n"),
+		prompt,
+	)
+}
@@ -2,14 +2,21 @@ package generate
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
@@ -47,6 +54,73 @@ type Service interface {
 	GenerateDoc(Context) (string, error)
 }
 
+// ErrBudgetExceeded is returned by services that refuse to send further
+// requests because a spending limit has been reached. The [Generator] keeps the
+// documentation that was generated before the limit was reached and skips all
+// remaining generations without reporting them as failed, so that the partial
+// results can still be applied.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// ErrDeadlineReached is returned by a [*Generator] for all generations that
+// would start after its deadline. Like [ErrBudgetExceeded], it does not fail
+// the run: the documentation that was generated before the deadline is kept.
+// See [Deadline].
+var ErrDeadlineReached = errors.New("deadline reached")
+
+// ErrSkipped is returned by services that could not generate the
+// documentation of a single symbol, e.g. because its request within a batch
+// failed. The [Generator] skips the symbol and reports it as undocumented
+// instead of failing the run.
+var ErrSkipped = errors.New("generation skipped")
+
+// ErrUnavailable is matched by errors of services that failed because their
+// provider is rate-limited or unavailable, e.g. because of an outage or a
+// network failure. Such errors are created using [Unavailable] and allow
+// wrappers to retry the generation using another provider.
+var ErrUnavailable = errors.New("service unavailable")
+
+// Unavailable marks err as caused by a rate limit or an outage of the provider,
+// so that errors.Is(err, ErrUnavailable) reports true. The message of err is
+// kept as-is. Unavailable returns nil if err is nil.
+func Unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return unavailableError{err}
+}
+
+type unavailableError struct{ err error }
+
+func (err unavailableError) Error() string { return err.err.Error() }
+
+func (err unavailableError) Unwrap() error { return err.err }
+
+func (err unavailableError) Is(target error) bool { return target == ErrUnavailable }
+
+// Structured is implemented by services that may return documentation decoded
+// from structured output, such as JSON. If StructuredOutput reports true, the
+// [Generator] uses the returned documentation as-is instead of trimming
+// surrounding quotes, which is only necessary for plain text responses.
+type Structured interface {
+	StructuredOutput() bool
+}
+
+// Modeler is implemented by services that report the model that generates the
+// documentation. The model is available to footer templates as
+// [FooterData.Model].
+type Modeler interface {
+	Model() string
+}
+
+// Deferred is implemented by services that defer the generation of
+// documentation, such as services that collect requests to submit them as a
+// batch. While Deferred reports true, the documentation returned by the service
+// is a placeholder, so the [Generator] returns it as-is instead of conforming
+// it to the conventions of the language.
+type Deferred interface {
+	Deferred() bool
+}
+
 // Language represents a mechanism for generating textual prompts based on
 // structured input. It operates on the given input to produce a string that can
 // be used as a directive or guide in subsequent operations. This interface is
@@ -73,6 +147,16 @@ type Minifier interface {
 	Minify([]byte) ([]byte, error)
 }
 
+// TargetMinifier is implemented by languages that can minify code without
+// minifying the declaration of the documented symbol. The [Generator] prefers
+// it over [Minifier], so that the prompt contains the full code of the symbol,
+// including its body and comments, while the rest of the code is minified.
+type TargetMinifier interface {
+	// MinifyTarget minifies code like [Minifier.Minify], but keeps the
+	// declaration identified by identifier as it is.
+	MinifyTarget(identifier string, code []byte) ([]byte, error)
+}
+
 // Input represents a unit of source code to be processed for documentation
 // generation. It includes the raw code, the programming language of the code,
 // and an identifier for referencing the specific piece of code within a larger
@@ -154,13 +238,35 @@ type Documentation struct {
 // language handlers. Generator emits generated documentation along with any
 // errors encountered during the process.
 type Generator struct {
-	svc           Service
-	languages     map[string]Language
-	limit         int
-	fileWorkers   int
-	symbolWorkers int
-	footer        string
-	log           *slog.Logger
+	svc             Service
+	languages       map[string]Language
+	limit           int
+	fileWorkers     int
+	symbolWorkers   int
+	inflight        chan struct{}
+	footer          *template.Template
+	footerErr       error
+	system          string
+	docLanguage     string
+	deadline        time.Time
+	templates       map[string]*template.Template
+	examples        int
+	examplesFS      fs.FS
+	examplesMux     sync.Mutex
+	examplesCache   map[string][]Example
+	linksFS         fs.FS
+	linksMux        sync.Mutex
+	linksCache      map[string][]string
+	declarationsFS  fs.FS
+	usages          int
+	usagesFS        fs.FS
+	usagesMux       sync.Mutex
+	usagesCache     map[string][]string
+	packageDocFS    fs.FS
+	packageDocMux   sync.Mutex
+	packageDocCache map[string]string
+	breaker         *breaker
+	log             *slog.Logger
 }
 
 // Option configures a Generator by setting various parameters such as the
@@ -182,10 +288,47 @@ func WithLogger(h slog.Handler) Option {
 // Footer sets a custom footer text that is appended to the generated
 // documentation by a Generator instance. The text is provided as an argument
 // and can be used to include additional information or a signature at the end
-// of documentation output.
+// of documentation output. The footer is a [text/template] that is executed
+// with a [FooterData] for each symbol, e.g. "Generated by jotbot ({{.Model}})
+// on {{.Date}}".
 func Footer(msg string) Option {
 	return func(g *Generator) {
-		g.footer = msg
+		if msg == "" {
+			g.footer, g.footerErr = nil, nil
+			return
+		}
+		g.footer, g.footerErr = template.New("footer").Parse(msg)
+	}
+}
+
+// SystemPrompt configures instructions that are sent as the system prompt of
+// each generation, such as organization-wide style rules ("Use British
+// English."). Services that support chat completions send the system prompt as
+// a system message; other services prepend it to the prompt. The system prompt
+// can be retrieved from a [Context] using [SystemPromptOf].
+func SystemPrompt(prompt string) Option {
+	return func(g *Generator) {
+		g.system = prompt
+	}
+}
+
+// SystemPromptOf returns the system prompt of the given context, as
+// configured by [SystemPrompt]. SystemPromptOf returns an empty string if no
+// system prompt is configured, or if ctx was not created by a Generator.
+func SystemPromptOf(ctx Context) string {
+	if c, ok := ctx.(interface{ SystemPrompt() string }); ok {
+		return c.SystemPrompt()
+	}
+	return ""
+}
+
+// Deadline configures the Generator to not start new generations after t, so
+// that a run fits into a time window, e.g. of a nightly CI job. Generations that
+// are in progress at the deadline are completed, and the remaining generations
+// fail with [ErrDeadlineReached]. A zero t disables the deadline.
+func Deadline(t time.Time) Option {
+	return func(g *Generator) {
+		g.deadline = t
 	}
 }
 
@@ -212,6 +355,21 @@ func Workers(files, symbols int) Option {
 	}
 }
 
+// MaxInflight limits the number of concurrent requests to the [Service] to n,
+// independently of the number of file and symbol workers. Workers that wait
+// for a free slot have already minified the code and rendered the prompt, so
+// more workers than in-flight requests keep the requests busy without
+// exceeding the rate limits of the provider. A value smaller than 1 removes
+// the limit, which is the default.
+func MaxInflight(n int) Option {
+	return func(g *Generator) {
+		g.inflight = nil
+		if n > 0 {
+			g.inflight = make(chan struct{}, n)
+		}
+	}
+}
+
 // WithLanguage associates a language implementation with a given file extension
 // within the Generator. It accepts an extension string and a Language interface
 // implementation, registering them so that the Generator can use the
@@ -249,6 +407,9 @@ func New(svc Service, opts ...Option) *Generator {
 	if g.log == nil {
 		g.log = internal.NopLogger()
 	}
+	if g.breaker != nil {
+		g.breaker.log = g.log
+	}
 	return g
 }
 
@@ -277,6 +438,18 @@ func (g *Generator) Files(ctx context.Context, files map[string][]Input) (<-chan
 		}
 	}
 
+	// Once the circuit breaker is open, the budget is exceeded or the deadline
+	// is reached, every remaining generation fails immediately, so the error is
+	// reported only once.
+	var reportStop sync.Once
+
+	// Symbols that are skipped because of the budget or the deadline are
+	// reported when all files are done.
+	var (
+		remainingMux sync.Mutex
+		remaining    []string
+	)
+
 	work, done := g.distributeWork(files)
 	go work(ctx, func(file string, inputs []Input) bool {
 		docs := make(chan Documentation)
@@ -310,6 +483,26 @@ func (g *Generator) Files(ctx context.Context, files map[string][]Input) (<-chan
 						Input: input,
 						File:  file,
 					})
+					if errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrDeadlineReached) {
+						reportStop.Do(func() {
+							g.log.Warn(fmt.Sprintf("Stopping generation: %v", err))
+						})
+						remainingMux.Lock()
+						remaining = append(remaining, fmt.Sprintf("%s: %s", file, input.Identifier))
+						remainingMux.Unlock()
+						continue
+					}
+					if errors.Is(err, ErrSkipped) {
+						g.log.Warn(fmt.Sprintf("Skipping %s in %s: %v", input.Identifier, file, err))
+						remainingMux.Lock()
+						remaining = append(remaining, fmt.Sprintf("%s: %s", file, input.Identifier))
+						remainingMux.Unlock()
+						continue
+					}
+					if errors.Is(err, ErrCircuitOpen) {
+						reportStop.Do(func() { fail(err) })
+						continue
+					}
 					if err != nil {
 						fail(fmt.Errorf("generate %q: %w", input.Identifier, err))
 						continue
@@ -335,6 +528,15 @@ func (g *Generator) Files(ctx context.Context, files map[string][]Input) (<-chan
 
 	go func() {
 		<-done
+
+		if len(remaining) > 0 {
+			slices.Sort(remaining)
+			g.log.Info(fmt.Sprintf("%d symbols remain undocumented:", len(remaining)))
+			for _, r := range remaining {
+				g.log.Log(ctx, internal.LogLevelNaked, fmt.Sprintf("- %s", r))
+			}
+		}
+
 		close(out)
 		close(errs)
 	}()
@@ -428,35 +630,141 @@ func (g *Generator) distributeWork(files map[string][]Input) (func(context.Conte
 // Generate orchestrates the creation of documentation for a given input within
 // the context. It resolves the appropriate language handler, optionally
 // minifies the code if supported, and invokes the associated service to produce
-// documentation. The result is post-processed with any configured footer before
-// being returned. If an unknown language is specified or a service error
-// occurs, Generate will return an error detailing the failure.
-func (g *Generator) Generate(ctx context.Context, input PromptInput) (string, error) {
+// documentation. The result is post-processed by fixing it according to the
+// conventions of the language (see [Conformer]), by validating its links, if
+// configured, and with any configured footer before being returned. If an
+// unknown language is specified or a service error occurs, Generate will
+// return an error detailing the failure. If a
+// [CircuitBreaker] is configured, Generate waits while the breaker is paused
+// and returns [ErrCircuitOpen] once it has aborted the run.
+func (g *Generator) Generate(ctx context.Context, input PromptInput) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "generate.Generate",
+		attribute.String("file", input.File),
+		attribute.String("identifier", input.Identifier),
+		attribute.String("language", input.Language),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !g.deadline.IsZero() && !time.Now().Before(g.deadline) {
+		return "", ErrDeadlineReached
+	}
+
+	if g.footerErr != nil {
+		return "", fmt.Errorf("parse footer template: %w", g.footerErr)
+	}
+
+	original := input
+	input, prompt, err := g.render(input)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := g.complete(ctx, input, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if doc, err = g.conform(ctx, input, prompt, doc); err != nil {
+		return "", err
+	}
+
+	doc = g.resolveLinks(g.languages[input.Language], original, doc)
+
+	if g.footer != nil {
+		footer, err := g.executeFooter(input)
+		if err != nil {
+			return "", fmt.Errorf("execute footer template: %w", err)
+		}
+		doc = fmt.Sprintf("%s\n\n%s", doc, footer)
+	}
+
+	return doc, nil
+}
+
+// render minifies the code of the input, if the language supports it, and
+// returns the minified input and its prompt.
+func (g *Generator) render(input PromptInput) (PromptInput, string, error) {
 	lang, ok := g.languages[input.Language]
 	if !ok {
-		return "", fmt.Errorf("unknown language %q", input.Language)
+		return input, "", fmt.Errorf("unknown language %q", input.Language)
 	}
 
-	if min, ok := lang.(Minifier); ok {
-		code, err := min.Minify(input.Code)
+	decls := g.declarations(lang, input)
+	usages := g.usageSnippets(lang, input)
+
+	var minify func([]byte) ([]byte, error)
+	switch min := lang.(type) {
+	case TargetMinifier:
+		minify = func(code []byte) ([]byte, error) {
+			return min.MinifyTarget(input.Identifier, code)
+		}
+	case Minifier:
+		minify = min.Minify
+	}
+	if minify != nil {
+		code, err := minify(input.Code)
 		if err != nil {
-			return "", fmt.Errorf("minify code: %w", err)
+			return input, "", fmt.Errorf("minify code: %w", err)
 		}
 		input.Code = code
 	}
 
-	genCtx := newCtx(ctx, input, lang.Prompt(input))
+	prompt, err := g.prompt(lang, input)
+	if err != nil {
+		return input, "", fmt.Errorf("execute prompt template: %w", err)
+	}
+	prompt = withUsages(withDeclarations(prompt, decls), usages)
+
+	prompt = withPackageDoc(withExamples(prompt, g.packageExamples(lang, input)), g.packageDoc(lang, input.File))
+
+	return input, g.withDocLanguage(prompt), nil
+}
+
+// complete sends the prompt of the input to the service and returns the
+// generated documentation.
+func (g *Generator) complete(ctx context.Context, input PromptInput, prompt string) (string, error) {
+	genCtx := newCtx(ctx, input, prompt, g.system)
+
+	if g.breaker != nil {
+		if err := g.breaker.wait(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	if g.inflight != nil {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case g.inflight <- struct{}{}:
+		}
+	}
 
-	doc, err := g.svc.GenerateDoc(genCtx)
+	doc, err := g.generateDoc(genCtx)
+	if g.inflight != nil {
+		<-g.inflight
+	}
+	if g.breaker != nil {
+		g.breaker.record(err)
+	}
 	if err != nil {
 		return "", fmt.Errorf("service: %w", err)
 	}
 
-	doc = strings.Trim(doc, `"' `)
-
-	if g.footer != "" {
-		doc = fmt.Sprintf("%s\n\n%s", doc, g.footer)
+	if s, ok := g.svc.(Structured); !ok || !s.StructuredOutput() {
+		doc = strings.Trim(doc, `"' `)
 	}
 
 	return doc, nil
 }
+
+func (g *Generator) generateDoc(ctx *genCtx) (string, error) {
+	spanCtx, span := tracing.Start(ctx, "generate.Service.GenerateDoc", attribute.String("service", fmt.Sprintf("%T", g.svc)))
+
+	traced := *ctx
+	traced.Context = spanCtx
+
+	doc, err := g.svc.GenerateDoc(&traced)
+	tracing.End(span, err)
+
+	return doc, err
+}
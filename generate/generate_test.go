@@ -2,8 +2,14 @@ package generate_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"text/template"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/google/go-cmp/cmp"
@@ -144,6 +150,572 @@ func TestFooter(t *testing.T) {
 	}
 }
 
+func TestFooter_template(t *testing.T) {
+	svc := modelService{MockService: mockgenerate.NewMockService()}
+	svc.GenerateDocFunc.PushReturn("Foo is a dummy function.", nil)
+
+	g := generate.New(
+		svc,
+		generate.Footer("Generated by {{.Model}} for {{.Identifier}} in {{.File}} on {{.Date}}."),
+		generate.WithLanguage("go", golang.Must()),
+	)
+
+	doc, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() {}"),
+			Language:   "go",
+			Identifier: "Foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	want := fmt.Sprintf("Foo is a dummy function.\n\nGenerated by gpt-4o for Foo in foo.go on %s.", time.Now().Format("2006-01-02"))
+
+	if doc != want {
+		t.Fatalf("Generate() returned wrong documentation\n%s", cmp.Diff(want, doc))
+	}
+}
+
+func TestFooter_invalidTemplate(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	g := generate.New(svc, generate.Footer("{{.Model"), generate.WithLanguage("go", golang.Must()))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() {}"),
+			Language:   "go",
+			Identifier: "Foo",
+		},
+	}); err == nil {
+		t.Fatalf("Generate() should fail for an invalid footer template")
+	}
+}
+
+func TestGenerator_Generate_structuredOutput(t *testing.T) {
+	doc := `Foo returns "foo".`
+	svc := structuredService{MockService: mockgenerate.NewMockService()}
+	svc.GenerateDocFunc.PushReturn(doc, nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()))
+
+	got, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() string { return \"foo\" }"),
+			Language:   "go",
+			Identifier: "Foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	if got != doc {
+		t.Fatalf("Generate() returned wrong documentation\n%s", cmp.Diff(doc, got))
+	}
+}
+
+func TestPromptTemplate(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo returns 42.", nil)
+
+	tmpl := template.Must(template.New("go.tmpl").Parse(`Document the {{.Target}} ({{.Identifier}}) in {{.File}} in British English.{{"\n"}}{{.Code}}`))
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.PromptTemplate("go", tmpl))
+
+	code := "package foo\n\nfunc Foo() int { return 42 }\n"
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte(code),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	want := "Document the function \"Foo()\" (func:Foo) in foo.go in British English.\n" + code
+	if got := svc.GenerateDocFunc.History()[0].Arg0.Prompt(); got != want {
+		t.Fatalf("prompt template not used\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestSystemPrompt(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo returns 42.", nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.SystemPrompt("Use British English."))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() int { return 42 }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	if got := generate.SystemPromptOf(svc.GenerateDocFunc.History()[0].Arg0); got != "Use British English." {
+		t.Fatalf("SystemPromptOf() returned %q; want %q", got, "Use British English.")
+	}
+}
+
+func TestFewShot(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo returns 42.", nil)
+
+	fsys := fstest.MapFS{
+		"foo/foo.go": &fstest.MapFile{Data: []byte("package foo\n\nfunc Foo() int { return 42 }\n")},
+		"foo/bar.go": &fstest.MapFile{Data: []byte(heredoc.Doc(`
+			package foo
+
+			// Bar returns the answer to the ultimate question of life.
+			func Bar() int { return 42 }
+
+			// Baz is short.
+			func Baz() {}
+		`))},
+		"other/other.go": &fstest.MapFile{Data: []byte(heredoc.Doc(`
+			package other
+
+			// Other is documented, but it belongs to another package.
+			func Other() {}
+		`))},
+	}
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.FewShot(fsys, 3))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo/foo.go",
+		Input: generate.Input{
+			Code:       fsys["foo/foo.go"].Data,
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+
+	want := "// Bar returns the answer to the ultimate question of life.\nfunc Bar() int"
+	if !strings.Contains(prompt, want) {
+		t.Fatalf("prompt should contain example %q\n\n%s", want, prompt)
+	}
+
+	for _, unwanted := range []string{"Baz is short.", "Other is documented"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Fatalf("prompt should not contain %q\n\n%s", unwanted, prompt)
+		}
+	}
+}
+
+func TestValidateLinks(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo returns a [Bar] from the [Cache], using [*Baz.Qux] and an [io.Reader]. See [*Qux], [BAR], [json.Decoder], [json.Foo], [io.Foo] and [dst.Node].", nil)
+
+	fsys := fstest.MapFS{
+		"foo/foo.go":     &fstest.MapFile{Data: []byte("package foo\n\nimport (\n\t\"encoding/json\"\n\n\t\"github.com/dave/dst\"\n)\n\nfunc Foo() Bar { return Bar{} }\n")},
+		"foo/bar.go":     &fstest.MapFile{Data: []byte("package foo\n\ntype Bar struct{}\n\ntype Baz struct{}\n\nfunc (*Baz) Qux() {}\n")},
+		"other/cache.go": &fstest.MapFile{Data: []byte("package other\n\ntype Cache struct{}\n")},
+	}
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.ValidateLinks(fsys))
+
+	doc, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo/foo.go",
+		Input: generate.Input{
+			Code:       fsys["foo/foo.go"].Data,
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	if want := "Foo returns a [Bar] from the Cache, using [*Baz.Qux] and an [io.Reader]. See [*Baz.Qux], [Bar], [json.Decoder], json.Foo, io.Foo and [dst.Node]."; doc != want {
+		t.Fatalf("Generate() should return %q; got %q", want, doc)
+	}
+}
+
+func TestDeclarations(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Bar returns the bar of the foo.", nil)
+
+	fsys := fstest.MapFS{
+		"foo/foo.go": &fstest.MapFile{Data: []byte("package foo\n\n// Foo is a foo.\ntype Foo struct {\n\t// Bar is the bar.\n\tBar string\n}\n")},
+		"foo/bar.go": &fstest.MapFile{Data: []byte("package foo\n\nfunc (f *Foo) Bar() string { return f.Bar }\n")},
+	}
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.Declarations(fsys))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo/bar.go",
+		Input: generate.Input{
+			Code:       fsys["foo/bar.go"].Data,
+			Language:   "go",
+			Identifier: "func:(*Foo).Bar",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+
+	want := "---\n// Foo is a foo.\ntype Foo struct {\n\t// Bar is the bar.\n\tBar string\n}\n---"
+	if !strings.Contains(prompt, want) {
+		t.Fatalf("prompt should contain the declaration of the receiver %q\n\n%s", want, prompt)
+	}
+}
+
+func TestDeclarations_genericReceiver(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Add adds v to the set.", nil)
+
+	fsys := fstest.MapFS{
+		"set/set.go": &fstest.MapFile{Data: []byte("package set\n\n// Set is a set.\ntype Set[T comparable] map[T]struct{}\n")},
+		"set/add.go": &fstest.MapFile{Data: []byte("package set\n\nfunc (s Set[T]) Add(v T) { s[v] = struct{}{} }\n")},
+	}
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.Declarations(fsys))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "set/add.go",
+		Input: generate.Input{
+			Code:       fsys["set/add.go"].Data,
+			Language:   "go",
+			Identifier: "func:Set.Add",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+
+	want := "---\n// Set is a set.\ntype Set[T comparable] map[T]struct{}\n---"
+	if !strings.Contains(prompt, want) {
+		t.Fatalf("prompt should contain the declaration of the generic receiver %q\n\n%s", want, prompt)
+	}
+}
+
+func TestPackageDoc(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo/doc.go":    &fstest.MapFile{Data: []byte("// Package foo manages widgets.\npackage foo\n")},
+		"foo/foo.go":    &fstest.MapFile{Data: []byte("// Package foo is undocumented here.\npackage foo\n\nfunc Foo() {}\n")},
+		"bar/bar.go":    &fstest.MapFile{Data: []byte("package bar\n\nfunc Bar() {}\n")},
+		"bar/README.md": &fstest.MapFile{Data: []byte("# bar\n\nBar handles gadgets.\n\n## Install\n\ngo get bar\n")},
+	}
+
+	tests := []struct {
+		file       string
+		identifier string
+		want       string
+	}{
+		{file: "foo/foo.go", identifier: "func:Foo", want: "---\nPackage foo manages widgets.\n---"},
+		{file: "bar/bar.go", identifier: "func:Bar", want: "---\n# bar\n\nBar handles gadgets.\n---"},
+	}
+
+	for _, tt := range tests {
+		svc := mockgenerate.NewMockService()
+		svc.GenerateDocFunc.PushReturn(strings.TrimPrefix(tt.identifier, "func:")+" does it.", nil)
+
+		g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.PackageDoc(fsys))
+
+		if _, err := g.Generate(context.Background(), generate.PromptInput{
+			File: tt.file,
+			Input: generate.Input{
+				Code:       fsys[tt.file].Data,
+				Language:   "go",
+				Identifier: tt.identifier,
+			},
+		}); err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
+
+		prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+		if !strings.Contains(prompt, tt.want) {
+			t.Errorf("prompt for %s should contain the package documentation %q\n\n%s", tt.file, tt.want, prompt)
+		}
+	}
+}
+
+func TestGenerator_Generate_conform(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("This is foo.", nil)
+	svc.GenerateDocFunc.PushReturn("Returns foo.", nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()))
+
+	doc, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() string { return \"foo\" }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("Generate() should return %q; got %q", want, doc)
+	}
+
+	history := svc.GenerateDocFunc.History()
+	if len(history) != 2 {
+		t.Fatalf("documentation should be generated twice; got %d generations", len(history))
+	}
+	if prompt := history[1].Arg0.Prompt(); !strings.Contains(prompt, `the comment must begin with "Foo "`) {
+		t.Errorf("second prompt should state the violated convention\n\n%s", prompt)
+	}
+}
+
+func TestGenerator_Generate_deferred(t *testing.T) {
+	svc := &deferredService{MockService: mockgenerate.NewMockService(), deferred: true}
+	svc.GenerateDocFunc.PushReturn("", nil)
+	svc.GenerateDocFunc.PushReturn("Returns foo.", nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()))
+
+	input := generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() string { return \"foo\" }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}
+
+	doc, err := g.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if doc != "" {
+		t.Errorf("Generate() should return the placeholder of the deferred service; got %q", doc)
+	}
+	if n := len(svc.GenerateDocFunc.History()); n != 1 {
+		t.Fatalf("deferred documentation should be generated once; got %d generations", n)
+	}
+
+	svc.deferred = false
+
+	if doc, err = g.Generate(context.Background(), input); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("Generate() should conform the result of the deferred service to %q; got %q", want, doc)
+	}
+}
+
+func TestUsages(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo returns foo.", nil)
+
+	fsys := fstest.MapFS{
+		"foo/foo.go":          &fstest.MapFile{Data: []byte("package foo\n\nfunc Foo() string { return \"foo\" }\n")},
+		"foo/bar.go":          &fstest.MapFile{Data: []byte("package foo\n\nfunc Bar() string {\n\treturn Foo() + \"bar\"\n}\n")},
+		"foo/baz.go":          &fstest.MapFile{Data: []byte("package foo\n\nfunc Baz() string {\n\treturn Foo() + \"baz\"\n}\n")},
+		"vendor/foo/other.go": &fstest.MapFile{Data: []byte("package foo\n\nfunc Other() string {\n\treturn Foo()\n}\n")},
+	}
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.Usages(fsys, 1))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo/foo.go",
+		Input: generate.Input{
+			Code:       fsys["foo/foo.go"].Data,
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+
+	want := "---\n# foo/bar.go\nfunc Bar() string {\n\treturn Foo() + \"bar\"\n}\n---"
+	if !strings.Contains(prompt, want) {
+		t.Fatalf("prompt should contain the call site %q\n\n%s", want, prompt)
+	}
+	if strings.Contains(prompt, "func Baz()") {
+		t.Fatalf("prompt should contain only 1 call site\n\n%s", prompt)
+	}
+}
+
+func TestGenerator_Prompts(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.SystemPrompt("Use British English."))
+
+	files := map[string][]generate.Input{
+		"foo.go": {{Identifier: "func:Foo", Language: "go", Code: []byte("package foo\n\nfunc Foo() {}\n")}},
+		"bar.go": {
+			{Identifier: "func:Bar", Language: "go", Code: []byte("package foo\n\nfunc Bar() {}\n")},
+			{Identifier: "func:Baz", Language: "go", Code: []byte("package foo\n\nfunc Baz() {}\n")},
+		},
+	}
+
+	prompts, err := g.Prompts(context.Background(), files)
+	if err != nil {
+		t.Fatalf("Prompts() failed: %v", err)
+	}
+
+	var got []string
+	for _, p := range prompts {
+		got = append(got, p.Input.File+":"+p.Input.Identifier)
+
+		if p.System != "Use British English." {
+			t.Errorf("Prompt.System should be %q; is %q", "Use British English.", p.System)
+		}
+
+		if want := golang.Prompt(p.Input); p.Prompt != want {
+			t.Errorf("Prompt.Prompt returned wrong prompt\n%s", cmp.Diff(want, p.Prompt))
+		}
+	}
+
+	want := []string{"bar.go:func:Bar", "bar.go:func:Baz", "foo.go:func:Foo"}
+	if !cmp.Equal(want, got) {
+		t.Fatalf("Prompts() returned wrong prompts\n%s", cmp.Diff(want, got))
+	}
+
+	if n := len(svc.GenerateDocFunc.History()); n != 0 {
+		t.Fatalf("Prompts() should not call the service; called %d times", n)
+	}
+}
+
+func TestDocLanguage(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("Foo gibt 42 zurück.", nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.DocLanguage("de"))
+
+	if _, err := g.Generate(context.Background(), generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() int { return 42 }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	prompt := svc.GenerateDocFunc.History()[0].Arg0.Prompt()
+	if want := "Write the documentation in German."; !strings.Contains(prompt, want) {
+		t.Fatalf("prompt should contain %q\n\n%s", want, prompt)
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := map[string]string{
+		"de":               "German",
+		"pt-BR":            "Brazilian Portuguese",
+		"German":           "German",
+		"Klingon tlhIngan": "Klingon tlhIngan",
+	}
+
+	for lang, want := range tests {
+		if got := generate.LanguageName(lang); got != want {
+			t.Errorf("LanguageName(%q) should return %q; got %q", lang, want, got)
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.SetDefaultReturn("Foo is a dummy.", nil)
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()), generate.Deadline(time.Now().Add(-time.Second)))
+
+	input := generate.PromptInput{
+		File:  "foo.go",
+		Input: generate.Input{Code: []byte("package foo\n\nfunc Foo() {}\n"), Language: "go", Identifier: "func:Foo"},
+	}
+
+	if _, err := g.Generate(context.Background(), input); !errors.Is(err, generate.ErrDeadlineReached) {
+		t.Fatalf("Generate() should fail with %q; got %v", generate.ErrDeadlineReached, err)
+	}
+
+	files, errs, err := g.Files(context.Background(), map[string][]generate.Input{"foo.go": {input.Input}})
+	if err != nil {
+		t.Fatalf("Files() failed: %v", err)
+	}
+
+	result, err := internal.Drain(files, errs)
+	if err != nil {
+		t.Fatalf("Files() should not fail when the deadline is reached; got %v", err)
+	}
+
+	if len(result) != 1 || len(result[0].Docs) != 0 {
+		t.Fatalf("Files() should return no documentation after the deadline; got %v", result)
+	}
+
+	if n := len(svc.GenerateDocFunc.History()); n != 0 {
+		t.Fatalf("service should not be called after the deadline; called %d times", n)
+	}
+}
+
+func TestMaxInflight(t *testing.T) {
+	var inflight, peak int32
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.SetDefaultHook(func(ctx generate.Context) (string, error) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return strings.TrimPrefix(ctx.Input().Identifier, "func:") + " is a dummy.", nil
+	})
+
+	g := generate.New(
+		svc,
+		generate.WithLanguage("go", golang.Must()),
+		generate.Workers(4, 4),
+		generate.MaxInflight(2),
+	)
+
+	files := make(map[string][]generate.Input)
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		for _, fn := range []string{"A", "B", "C", "D"} {
+			files[name] = append(files[name], generate.Input{
+				Code:       []byte(fmt.Sprintf("package foo\n\nfunc %s() {}\n", fn)),
+				Language:   "go",
+				Identifier: "func:" + fn,
+			})
+		}
+	}
+
+	out, errs, err := g.Files(context.Background(), files)
+	if err != nil {
+		t.Fatalf("Files() failed: %v", err)
+	}
+
+	result, err := internal.Drain(out, errs)
+	if err != nil {
+		t.Fatalf("Files() failed: %v", err)
+	}
+
+	if n := len(generate.Flatten(result)); n != 16 {
+		t.Fatalf("Files() should generate 16 docs; got %d", n)
+	}
+
+	if peak := atomic.LoadInt32(&peak); peak > 2 {
+		t.Fatalf("at most 2 requests should be in flight; got %d", peak)
+	}
+}
+
 func TestLimit(t *testing.T) {
 	svc := mockgenerate.NewMockService()
 	svc.GenerateDocFunc.SetDefaultHook(func(ctx generate.Context) (string, error) {
@@ -178,6 +750,135 @@ func TestLimit(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.SetDefaultHook(func(generate.Context) (string, error) {
+		return "", errors.New("429 Too Many Requests")
+	})
+
+	g := generate.New(svc, generate.CircuitBreaker(0.5, 2, 0), generate.WithLanguage("go", golang.Must()))
+
+	in := generate.PromptInput{
+		File:  "foo.go",
+		Input: generate.Input{Code: []byte("package foo\n\nfunc Foo() {}"), Language: "go", Identifier: "func:Foo"},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Generate(context.Background(), in); err == nil || errors.Is(err, generate.ErrCircuitOpen) {
+			t.Fatalf("Generate() should fail with the service error; got %v", err)
+		}
+	}
+
+	if _, err := g.Generate(context.Background(), in); !errors.Is(err, generate.ErrCircuitOpen) {
+		t.Fatalf("Generate() should fail with %q; got %v", generate.ErrCircuitOpen, err)
+	}
+
+	if n := len(svc.GenerateDocFunc.History()); n != 2 {
+		t.Fatalf("service should have been called 2 times; was called %d times", n)
+	}
+}
+
+func TestCircuitBreaker_cooldown(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.PushReturn("", errors.New("503 Service Unavailable"))
+	svc.GenerateDocFunc.PushReturn("", errors.New("503 Service Unavailable"))
+	svc.GenerateDocFunc.PushReturn("Foo is a foo.", nil)
+	svc.GenerateDocFunc.SetDefaultReturn("", errors.New("503 Service Unavailable"))
+
+	cooldown := 50 * time.Millisecond
+	g := generate.New(svc, generate.CircuitBreaker(1, 2, cooldown), generate.WithLanguage("go", golang.Must()))
+
+	in := generate.PromptInput{
+		File:  "foo.go",
+		Input: generate.Input{Code: []byte("package foo\n\nfunc Foo() {}"), Language: "go", Identifier: "func:Foo"},
+	}
+
+	g.Generate(context.Background(), in)
+	g.Generate(context.Background(), in)
+
+	start := time.Now()
+	doc, err := g.Generate(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Generate() failed after cooldown: %v", err)
+	}
+	if doc != "Foo is a foo." {
+		t.Fatalf("Generate() returned %q; want %q", doc, "Foo is a foo.")
+	}
+	if took := time.Since(start); took < cooldown {
+		t.Fatalf("Generate() should have paused for %s; took %s", cooldown, took)
+	}
+}
+
+func TestGenerator_Files_budgetExceeded(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.SetDefaultHook(func(ctx generate.Context) (string, error) {
+		if ctx.Input().Identifier == "func:Foo" {
+			return "Foo is a function.", nil
+		}
+		return "", fmt.Errorf("mock: %w", generate.ErrBudgetExceeded)
+	})
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()))
+
+	files := map[string][]generate.Input{
+		"foo.go": {{Identifier: "func:Foo", Language: "go"}, {Identifier: "func:Bar", Language: "go"}},
+		"bar.go": {{Identifier: "var:Bar", Language: "go"}},
+	}
+
+	gens, errs, err := g.Files(context.Background(), files)
+	if err != nil {
+		t.Fatalf("Files() failed: %v", err)
+	}
+
+	got := drain(t, gens, errs)
+
+	expectGenerated(t, got, "foo.go", "func:Foo", "Foo is a function.")
+}
+
+func TestGenerator_Files_skipped(t *testing.T) {
+	svc := mockgenerate.NewMockService()
+	svc.GenerateDocFunc.SetDefaultHook(func(ctx generate.Context) (string, error) {
+		if ctx.Input().Identifier == "func:Foo" {
+			return "Foo is a function.", nil
+		}
+		return "", fmt.Errorf("mock: %w", generate.ErrSkipped)
+	})
+
+	g := generate.New(svc, generate.WithLanguage("go", golang.Must()))
+
+	files := map[string][]generate.Input{
+		"foo.go": {{Identifier: "func:Foo", Language: "go"}, {Identifier: "func:Bar", Language: "go"}},
+	}
+
+	gens, errs, err := g.Files(context.Background(), files)
+	if err != nil {
+		t.Fatalf("Files() failed: %v", err)
+	}
+
+	got := drain(t, gens, errs)
+
+	expectGenerated(t, got, "foo.go", "func:Foo", "Foo is a function.")
+}
+
+type modelService struct {
+	*mockgenerate.MockService
+}
+
+func (modelService) Model() string { return "gpt-4o" }
+
+type structuredService struct {
+	*mockgenerate.MockService
+}
+
+func (structuredService) StructuredOutput() bool { return true }
+
+type deferredService struct {
+	*mockgenerate.MockService
+	deferred bool
+}
+
+func (svc *deferredService) Deferred() bool { return svc.deferred }
+
 func expectGenerated(t *testing.T, gens []generate.File, file, identifier, doc string) {
 	t.Helper()
 
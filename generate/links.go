@@ -0,0 +1,188 @@
+package generate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Link is a reference to a symbol in documentation, such as "[Foo]" in Go or
+// "{@link Foo}" in TypeScript.
+type Link struct {
+	// Text is the link as it appears in the documentation.
+	Text string
+
+	// Target is the name of the referenced symbol, e.g. "Foo" or "Foo.Bar".
+	Target string
+
+	// Plain is the text that replaces the link if its target does not exist.
+	Plain string
+}
+
+// Linker is implemented by languages whose documentation can reference
+// symbols. The [Generator] uses it to validate the links in generated
+// documentation. See [ValidateLinks].
+type Linker interface {
+	// Links returns the links in doc that can be validated. Links to symbols
+	// that cannot be resolved from the source code of the package, such as
+	// symbols of other packages, are omitted.
+	Links(doc string) []Link
+
+	// Names returns the names that links in the documentation of the given
+	// file may refer to, i.e. the symbols that are declared in the file and,
+	// depending on the language, the symbols that it imports. A name of the
+	// form "pkg.*" matches all targets within "pkg", for packages whose symbols
+	// cannot be listed.
+	Names(file string, code []byte) ([]string, error)
+}
+
+// LinkRewriter is implemented by [Linker]s that can correct links. If the
+// target of a link does not exist, but a single name matches it except for
+// case, or is a member whose name is the target, such as "Foo.Bar" for "Bar",
+// the [Generator] replaces the link by a link to that name instead of plain
+// text.
+type LinkRewriter interface {
+	// RewriteLink returns the text of link with its target replaced by target.
+	RewriteLink(link Link, target string) string
+}
+
+// ValidateLinks configures the Generator to check that the symbols referenced
+// by links in generated documentation exist, because models tend to invent
+// references to symbols that do not exist. A link is valid if its target is
+// declared in the package of the documented file, which consists of the files
+// with the same extension in its directory, read from fsys using the file
+// paths of the inputs. Invalid links are corrected if the language implements
+// [LinkRewriter] and the intended target is unambiguous, and replaced by plain
+// text otherwise. Both are logged as warnings. Only languages that implement
+// [Linker] support link validation.
+func ValidateLinks(fsys fs.FS) Option {
+	return func(g *Generator) {
+		g.linksFS = fsys
+	}
+}
+
+// resolveLinks corrects the links in doc whose targets do not exist, or
+// replaces them with plain text. input must contain the original, unminified
+// code of the file.
+func (g *Generator) resolveLinks(lang Language, input PromptInput, doc string) string {
+	linker, ok := lang.(Linker)
+	if !ok || g.linksFS == nil {
+		return doc
+	}
+
+	links := linker.Links(doc)
+	if len(links) == 0 {
+		return doc
+	}
+
+	names := make(map[string]bool)
+	for _, name := range g.loadNames(linker, input.File) {
+		names[name] = true
+	}
+	if current, err := linker.Names(input.File, input.Code); err == nil {
+		for _, name := range current {
+			names[name] = true
+		}
+	} else {
+		g.log.Debug(fmt.Sprintf("Failed to extract names from %s: %v", input.File, err))
+	}
+
+	rewriter, _ := linker.(LinkRewriter)
+	for _, l := range links {
+		if resolves(names, l.Target) {
+			continue
+		}
+		if target := closestName(names, l.Target); rewriter != nil && target != "" {
+			text := rewriter.RewriteLink(l, target)
+			g.log.Warn(fmt.Sprintf("Unresolved reference %s in documentation of %s (%s). Replacing with %s.", l.Text, input.Identifier, input.File, text))
+			doc = strings.ReplaceAll(doc, l.Text, text)
+			continue
+		}
+		g.log.Warn(fmt.Sprintf("Unresolved reference %s in documentation of %s (%s). Replacing with %q.", l.Text, input.Identifier, input.File, l.Plain))
+		doc = strings.ReplaceAll(doc, l.Text, l.Plain)
+	}
+
+	return doc
+}
+
+// resolves reports whether target is one of names, or within a package
+// "pkg" for which names contains "pkg.*".
+func resolves(names map[string]bool, target string) bool {
+	if names[target] {
+		return true
+	}
+	for i := strings.LastIndex(target, "."); i > 0; i = strings.LastIndex(target[:i], ".") {
+		if names[target[:i]+".*"] {
+			return true
+		}
+	}
+	return false
+}
+
+// closestName returns the name that target most likely refers to: the only
+// name that equals target except for case or that ends with "."+target.
+// closestName returns an empty string if there is no such name, or more than
+// one.
+func closestName(names map[string]bool, target string) string {
+	var match string
+	for name := range names {
+		if strings.HasSuffix(name, ".*") {
+			continue
+		}
+		if strings.EqualFold(name, target) || strings.HasSuffix(name, "."+target) {
+			if match != "" {
+				return ""
+			}
+			match = name
+		}
+	}
+	return match
+}
+
+// loadNames returns the names that are declared in the package of the given
+// file. The names of each package are loaded only once.
+func (g *Generator) loadNames(linker Linker, file string) []string {
+	dir, ext := path.Dir(file), path.Ext(file)
+	key := dir + "\x00" + ext
+
+	g.linksMux.Lock()
+	defer g.linksMux.Unlock()
+
+	if names, ok := g.linksCache[key]; ok {
+		return names
+	}
+
+	entries, err := fs.ReadDir(g.linksFS, dir)
+	if err != nil {
+		g.log.Debug(fmt.Sprintf("Failed to read package %s for link validation: %v", dir, err))
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ext {
+			continue
+		}
+
+		name := path.Join(dir, entry.Name())
+		code, err := fs.ReadFile(g.linksFS, name)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to read %s for link validation: %v", name, err))
+			continue
+		}
+
+		found, err := linker.Names(name, code)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to extract names from %s: %v", name, err))
+			continue
+		}
+		names = append(names, found...)
+	}
+
+	if g.linksCache == nil {
+		g.linksCache = make(map[string][]string)
+	}
+	g.linksCache[key] = names
+
+	return names
+}
@@ -0,0 +1,46 @@
+package generate
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// DocLanguage configures the Generator to instruct the model to write the
+// documentation in the given human language, for codebases that are not
+// documented in English. lang is either a BCP 47 language tag such as "de" or
+// "pt-BR", or the name of a language such as "German". An empty lang or
+// English keeps the built-in prompts unchanged.
+func DocLanguage(lang string) Option {
+	return func(g *Generator) {
+		g.docLanguage = LanguageName(lang)
+	}
+}
+
+// LanguageName returns the English name of the human language identified by
+// the given BCP 47 language tag, e.g. "German" for "de". If lang is not a
+// valid language tag, it is returned as-is.
+func LanguageName(lang string) string {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return lang
+	}
+	if name := display.English.Tags().Name(tag); name != "" {
+		return name
+	}
+	return lang
+}
+
+// withDocLanguage appends the instruction to write the documentation in the
+// configured human language to a prompt.
+func (g *Generator) withDocLanguage(prompt string) string {
+	if g.docLanguage == "" || g.docLanguage == "English" {
+		return prompt
+	}
+	return fmt.Sprintf(
+		"%s\n\nWrite the documentation in %s. Keep identifiers, code and tags such as @param in their original form.",
+		prompt,
+		g.docLanguage,
+	)
+}
@@ -0,0 +1,140 @@
+package generate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// readmeExcerptLines is the maximum number of lines of a README that are used
+// as the documentation of a package.
+const readmeExcerptLines = 20
+
+// PackageDocumenter is implemented by languages that can extract the
+// documentation of a package from the code of one of its files, such as the
+// package comment of a Go file. See [PackageDoc].
+type PackageDocumenter interface {
+	// PackageDoc returns the documentation of the package that code belongs
+	// to, or an empty string if code does not document the package.
+	PackageDoc(code []byte) (string, error)
+}
+
+// PackageDoc configures the Generator to prepend the documentation of the
+// package of a file to the prompt, so that the generated documentation uses
+// the terminology of the package consistently. The package of a file consists
+// of the files with the same extension in its directory, which are read from
+// fsys using the file paths of the inputs. The documentation is extracted
+// from "doc" files such as doc.go first, and then from the other files of the
+// package, if the language implements [PackageDocumenter]. Otherwise, or if no
+// file documents the package, the beginning of the README.md of the directory
+// is used.
+func PackageDoc(fsys fs.FS) Option {
+	return func(g *Generator) {
+		g.packageDocFS = fsys
+	}
+}
+
+// packageDoc returns the documentation of the package of the given file. The
+// documentation of each package is loaded only once.
+func (g *Generator) packageDoc(lang Language, file string) string {
+	if g.packageDocFS == nil {
+		return ""
+	}
+
+	dir, ext := path.Dir(file), path.Ext(file)
+	key := dir + "\x00" + ext
+
+	g.packageDocMux.Lock()
+	defer g.packageDocMux.Unlock()
+
+	if doc, ok := g.packageDocCache[key]; ok {
+		return doc
+	}
+
+	doc := g.loadPackageDoc(lang, dir, ext)
+
+	if g.packageDocCache == nil {
+		g.packageDocCache = make(map[string]string)
+	}
+	g.packageDocCache[key] = doc
+
+	return doc
+}
+
+func (g *Generator) loadPackageDoc(lang Language, dir, ext string) string {
+	if pd, ok := lang.(PackageDocumenter); ok {
+		entries, err := fs.ReadDir(g.packageDocFS, dir)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to read package %s for package documentation: %v", dir, err))
+		}
+
+		var names []string
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ext {
+				continue
+			}
+			if entry.Name() == "doc"+ext {
+				names = append([]string{entry.Name()}, names...)
+				continue
+			}
+			names = append(names, entry.Name())
+		}
+
+		for _, name := range names {
+			name = path.Join(dir, name)
+			code, err := fs.ReadFile(g.packageDocFS, name)
+			if err != nil {
+				g.log.Debug(fmt.Sprintf("Failed to read %s for package documentation: %v", name, err))
+				continue
+			}
+
+			doc, err := pd.PackageDoc(code)
+			if err != nil {
+				g.log.Debug(fmt.Sprintf("Failed to extract package documentation from %s: %v", name, err))
+				continue
+			}
+			if doc = strings.TrimSpace(doc); doc != "" {
+				return doc
+			}
+		}
+	}
+
+	readme, err := fs.ReadFile(g.packageDocFS, path.Join(dir, "README.md"))
+	if err != nil {
+		return ""
+	}
+	return readmeExcerpt(string(readme))
+}
+
+// readmeExcerpt returns the beginning of a README, up to its second heading
+// and at most readmeExcerptLines lines.
+func readmeExcerpt(readme string) string {
+	var lines []string
+	var headings int
+	for _, line := range strings.Split(readme, "\n") {
+		if strings.HasPrefix(line, "#") {
+			if headings++; headings > 1 {
+				break
+			}
+		}
+		if lines = append(lines, line); len(lines) == readmeExcerptLines {
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// withPackageDoc prepends the documentation of the package of the documented
+// symbol to a prompt.
+func withPackageDoc(prompt, doc string) string {
+	if doc == "" {
+		return prompt
+	}
+
+	return fmt.Sprintf(
+		"The documented symbol belongs to a package with the following documentation. Use the same terminology:\n---\n%s\n---\n\n%s",
+		doc,
+		prompt,
+	)
+}
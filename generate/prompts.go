@@ -0,0 +1,55 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Prompt is a prompt that would be sent to the [Service] to generate the
+// documentation of a symbol. See [*Generator.Prompts].
+type Prompt struct {
+	// Input is the input of the symbol, with the code minified like it is
+	// for generation.
+	Input PromptInput
+
+	// System is the system prompt, as configured by [SystemPrompt].
+	System string
+
+	// Prompt is the rendered prompt, including prompt templates and few-shot
+	// examples.
+	Prompt string
+}
+
+// Prompts renders the prompts of the given files exactly like [*Generator.Files]
+// would, but returns them instead of calling the [Service], which allows to
+// debug why a model produces bad documentation. The prompts are sorted by file
+// and keep the order of the inputs of each file. If a [Limit] is configured,
+// only the prompts of the first files are returned.
+func (g *Generator) Prompts(ctx context.Context, files map[string][]Input) ([]Prompt, error) {
+	paths := maps.Keys(files)
+	slices.Sort(paths)
+	if g.limit > 0 && len(paths) > g.limit {
+		paths = paths[:g.limit]
+	}
+
+	var out []Prompt
+	for _, file := range paths {
+		for _, input := range files[file] {
+			if err := ctx.Err(); err != nil {
+				return out, err
+			}
+
+			rendered, prompt, err := g.render(PromptInput{Input: input, File: file})
+			if err != nil {
+				return out, fmt.Errorf("render prompt of %q: %w", input.Identifier, err)
+			}
+
+			out = append(out, Prompt{Input: rendered, System: g.system, Prompt: prompt})
+		}
+	}
+
+	return out, nil
+}
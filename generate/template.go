@@ -0,0 +1,122 @@
+package generate
+
+import (
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Targeter is implemented by languages that can describe an identifier in
+// natural language, e.g. `function "Foo()"` for "func:Foo". The description is
+// available to prompt templates as [TemplateData.Target].
+type Targeter interface {
+	// Target returns the description of the given identifier.
+	Target(identifier string) string
+}
+
+// TemplateData is the data that prompt templates are executed with. See
+// [PromptTemplate].
+type TemplateData struct {
+	// Identifier is the identifier of the symbol to document, e.g. "func:Foo".
+	Identifier string
+
+	// Target is the natural language description of the symbol, e.g.
+	// `function "Foo()"`. If the language does not implement [Targeter],
+	// Target is the identifier.
+	Target string
+
+	// Language is the name of the language of the file.
+	Language string
+
+	// File is the path of the file that contains the symbol.
+	File string
+
+	// Code is the (minified) source code of the file.
+	Code string
+
+	// Prompt is the built-in prompt of the language, so that a template can
+	// extend the built-in prompt instead of replacing it.
+	Prompt string
+}
+
+// PromptTemplate configures a template that replaces the built-in prompt of
+// the given language. The template is executed with a [TemplateData] for each
+// symbol, which gives access to the identifier, file and code of the symbol.
+func PromptTemplate(language string, tmpl *template.Template) Option {
+	return func(g *Generator) {
+		if g.templates == nil {
+			g.templates = make(map[string]*template.Template)
+		}
+		g.templates[language] = tmpl
+	}
+}
+
+// prompt returns the prompt for the given input, using the prompt template of
+// the language if one is configured.
+func (g *Generator) prompt(lang Language, input PromptInput) (string, error) {
+	prompt := lang.Prompt(input)
+
+	tmpl, ok := g.templates[input.Language]
+	if !ok {
+		return prompt, nil
+	}
+
+	target := input.Identifier
+	if t, ok := lang.(Targeter); ok {
+		target = t.Target(input.Identifier)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, TemplateData{
+		Identifier: input.Identifier,
+		Target:     target,
+		Language:   input.Language,
+		File:       input.File,
+		Code:       string(input.Code),
+		Prompt:     prompt,
+	}); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// FooterData is the data that footer templates are executed with. See
+// [Footer].
+type FooterData struct {
+	// Model is the model that generated the documentation, if the service
+	// implements [Modeler].
+	Model string
+
+	// Date is the current date in the format "2006-01-02".
+	Date string
+
+	// Identifier is the identifier of the documented symbol.
+	Identifier string
+
+	// Language is the name of the language of the file.
+	Language string
+
+	// File is the path of the file that contains the symbol.
+	File string
+}
+
+func (g *Generator) executeFooter(input PromptInput) (string, error) {
+	var model string
+	if m, ok := g.svc.(Modeler); ok {
+		model = m.Model()
+	}
+
+	var out strings.Builder
+	if err := g.footer.Execute(&out, FooterData{
+		Model:      model,
+		Date:       time.Now().Format("2006-01-02"),
+		Identifier: input.Identifier,
+		Language:   input.Language,
+		File:       input.File,
+	}); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
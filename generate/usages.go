@@ -0,0 +1,109 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/modernice/jotbot/find"
+)
+
+// Usager is implemented by languages that can find the call sites of a
+// symbol, which the [Generator] includes in the prompt, because the usage of
+// a symbol often tells more about its purpose than its implementation,
+// especially for thin wrappers. See [Usages].
+type Usager interface {
+	// Usages returns snippets of code that call the symbol of the input, which
+	// contains the original code of the file that declares the symbol. file is
+	// the path of code, relative to the same root as the file of the input.
+	Usages(input PromptInput, file string, code []byte) ([]string, error)
+}
+
+// Usages configures the Generator to include up to n snippets of code that
+// call the documented symbol in the prompt. The call sites are searched in the
+// files of fsys with the same extension as the file of the symbol, except for
+// the file itself and the files that are excluded by [find.DefaultExclude],
+// using the file paths of the inputs. Only languages that implement [Usager]
+// support this.
+func Usages(fsys fs.FS, n int) Option {
+	return func(g *Generator) {
+		g.usagesFS = fsys
+		g.usages = n
+	}
+}
+
+// usageSnippets returns the call sites of the symbol of the input, prefixed by
+// the paths of their files. input must contain the original, unminified code
+// of the file.
+func (g *Generator) usageSnippets(lang Language, input PromptInput) []string {
+	u, ok := lang.(Usager)
+	if !ok || g.usagesFS == nil || g.usages <= 0 {
+		return nil
+	}
+
+	var snippets []string
+	for _, file := range g.usageFiles(path.Ext(input.File)) {
+		if file == path.Clean(input.File) {
+			continue
+		}
+
+		code, err := fs.ReadFile(g.usagesFS, file)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to read %s for usages: %v", file, err))
+			continue
+		}
+
+		found, err := u.Usages(input, file, code)
+		if err != nil {
+			g.log.Debug(fmt.Sprintf("Failed to find usages of %s in %s: %v", input.Identifier, file, err))
+			continue
+		}
+
+		for _, snippet := range found {
+			snippets = append(snippets, fmt.Sprintf("# %s\n%s", file, strings.TrimSpace(snippet)))
+			if len(snippets) >= g.usages {
+				return snippets
+			}
+		}
+	}
+
+	return snippets
+}
+
+// usageFiles returns the files with the given extension that are searched for
+// usages. The files are listed only once.
+func (g *Generator) usageFiles(ext string) []string {
+	g.usagesMux.Lock()
+	defer g.usagesMux.Unlock()
+
+	if files, ok := g.usagesCache[ext]; ok {
+		return files
+	}
+
+	files, err := find.Files(context.Background(), g.usagesFS, find.Extensions(ext))
+	if err != nil {
+		g.log.Debug(fmt.Sprintf("Failed to find files for usages: %v", err))
+	}
+
+	if g.usagesCache == nil {
+		g.usagesCache = make(map[string][]string)
+	}
+	g.usagesCache[ext] = files
+
+	return files
+}
+
+// withUsages appends the usage snippets of the documented symbol to a prompt.
+func withUsages(prompt string, snippets []string) string {
+	if len(snippets) == 0 {
+		return prompt
+	}
+
+	return fmt.Sprintf(
+		"%s\n\nHere is how the documented symbol is used in the codebase:\n---\n%s\n---",
+		strings.TrimRight(prompt, "\n"),
+		strings.Join(snippets, "\n\n"),
+	)
+}
@@ -0,0 +1,9 @@
+package internal
+
+import "net/http"
+
+// UnavailableStatus reports whether the given HTTP status code indicates that
+// the provider is rate-limited (429) or unavailable (5xx).
+func UnavailableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,71 @@
+// Package lines provides helpers for languages whose declarations are found
+// and documented line by line, without a full parser.
+package lines
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/modernice/jotbot/internal"
+)
+
+// Split splits code into lines. A trailing carriage return is kept as part of
+// each line, so that joining the lines restores the original code.
+func Split(code []byte) []string {
+	return strings.Split(string(code), "\n")
+}
+
+// Join joins lines that were split using [Split].
+func Join(lines []string) []byte {
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Indent returns the leading whitespace of a line.
+func Indent(line string) string {
+	return line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+}
+
+// BlockStart returns the index of the first line of the contiguous block of
+// lines directly above the line at index i for which isComment reports true.
+// If the line above is not a comment, BlockStart returns i.
+func BlockStart(lines []string, i int, isComment func(string) bool) int {
+	start := i
+	for start > 0 && isComment(lines[start-1]) {
+		start--
+	}
+	return start
+}
+
+// Replace replaces the lines in the range [start, end) with repl and returns
+// the resulting lines.
+func Replace(lines []string, start, end int, repl []string) []string {
+	out := make([]string, 0, len(lines)-(end-start)+len(repl))
+	out = append(out, lines[:start]...)
+	out = append(out, repl...)
+	return append(out, lines[end:]...)
+}
+
+// Comment formats doc as a line comment, wrapped at width columns including
+// the indent and prefix. Each line is prefixed with indent and prefix, and
+// empty lines (paragraph breaks) with indent and the trimmed prefix. If first
+// is not empty, it is used as the prefix of the first line instead.
+func Comment(doc, indent, prefix, first string, width int) []string {
+	if first == "" {
+		first = prefix
+	}
+
+	var out []string
+	for i, line := range internal.Columns(doc, width-len(indent)-len(prefix)) {
+		p := prefix
+		if i == 0 {
+			p = first
+		}
+		if line == "" {
+			out = append(out, strings.TrimRightFunc(indent+p, unicode.IsSpace))
+			continue
+		}
+		out = append(out, indent+p+line)
+	}
+
+	return out
+}
@@ -2,8 +2,11 @@ package nodes
 
 import (
 	"go/token"
+	"strings"
 
 	"github.com/dave/dst"
+	"github.com/modernice/jotbot/internal/slice"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -49,6 +52,19 @@ var (
 		StructComment:  true,
 		Exported:       true,
 	}
+
+	// MinifyFocus minifies like [MinifyAll] and additionally removes all
+	// top-level declarations that are unrelated to the preserved declaration,
+	// for files that are too large even after stripping all comments and
+	// function bodies. See [MinifyOptions.Focus].
+	MinifyFocus = MinifyOptions{
+		PackageComment: true,
+		FuncComment:    true,
+		FuncBody:       true,
+		StructComment:  true,
+		Exported:       true,
+		Focus:          true,
+	}
 )
 
 // MinifyOptions represents a set of configurable behaviors to control the
@@ -63,6 +79,16 @@ type MinifyOptions struct {
 	FuncBody       bool
 	StructComment  bool
 	Exported       bool
+
+	// Preserve is the identifier of a declaration that is never minified,
+	// e.g. "func:Foo" or "type:Bar".
+	Preserve string
+
+	// Focus removes all top-level declarations except the imports, the
+	// declaration identified by Preserve and the declarations of the types
+	// that it refers to directly. Focus has no effect if Preserve is empty or
+	// the declaration does not exist.
+	Focus bool
 }
 
 // Minify applies the specified minification options to the given syntax tree
@@ -75,6 +101,10 @@ type MinifyOptions struct {
 func (opts MinifyOptions) Minify(node dst.Node) dst.Node {
 	out := dst.Clone(node)
 
+	if file, ok := out.(*dst.File); ok && opts.Focus && opts.Preserve != "" {
+		focus(file, opts.Preserve)
+	}
+
 	patch := func(node dst.Node) {
 		switch node := node.(type) {
 		case *dst.FuncDecl:
@@ -93,7 +123,11 @@ func (opts MinifyOptions) Minify(node dst.Node) dst.Node {
 	}
 
 	dst.Inspect(out, func(node dst.Node) bool {
-		if _, exported := Identifier(node); exported && opts.Exported {
+		identifier, exported := Identifier(node)
+		if opts.Preserve != "" && identifier == opts.Preserve {
+			return true
+		}
+		if exported && opts.Exported {
 			patch(node)
 		} else if !exported {
 			patch(node)
@@ -118,6 +152,105 @@ func Minify[Node dst.Node](node Node, opts MinifyOptions) Node {
 	return opts.Minify(node).(Node)
 }
 
+// focus removes the top-level declarations of file that are unrelated to the
+// declaration identified by identifier. Interface methods and fields keep the
+// declaration of their type.
+func focus(file *dst.File, identifier string) {
+	targets := slice.Filter(file.Decls, func(decl dst.Decl) bool {
+		fn, ok := decl.(*dst.FuncDecl)
+		if !ok {
+			return false
+		}
+		id, _ := Identifier(fn)
+		return id == identifier
+	})
+	if len(targets) == 0 {
+		targets = slice.Filter(file.Decls, func(decl dst.Decl) bool {
+			gen, ok := decl.(*dst.GenDecl)
+			return ok && declares(gen, identifier)
+		})
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	names := make(map[string]bool)
+	for _, decl := range targets {
+		dst.Inspect(decl, func(node dst.Node) bool {
+			switch node := node.(type) {
+			case *dst.SelectorExpr:
+				// The selected name is a field, method or the member of
+				// another package.
+				dst.Inspect(node.X, func(node dst.Node) bool {
+					if ident, ok := node.(*dst.Ident); ok && ident.Path == "" {
+						names[ident.Name] = true
+					}
+					return true
+				})
+				return false
+			case *dst.Ident:
+				if node.Path == "" {
+					names[node.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var decls []dst.Decl
+	for _, decl := range file.Decls {
+		if slices.Contains(targets, decl) {
+			decls = append(decls, decl)
+			continue
+		}
+
+		gen, ok := decl.(*dst.GenDecl)
+		if !ok {
+			continue
+		}
+
+		switch gen.Tok {
+		case token.IMPORT:
+			decls = append(decls, gen)
+		case token.TYPE:
+			specs := slice.Filter(gen.Specs, func(spec dst.Spec) bool {
+				return names[spec.(*dst.TypeSpec).Name.Name]
+			})
+			if len(specs) > 0 {
+				gen.Specs = specs
+				decls = append(decls, gen)
+			}
+		}
+	}
+	file.Decls = decls
+}
+
+// declares reports whether decl declares the type, variable or constant
+// identified by identifier, or the type of the interface method or field
+// identified by identifier.
+func declares(decl *dst.GenDecl, identifier string) bool {
+	name := StripIdentifierPrefix(identifier)
+	if owner, _, ok := strings.Cut(name, "."); ok {
+		name = owner
+	}
+
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *dst.TypeSpec:
+			if spec.Name.Name == name {
+				return true
+			}
+		case *dst.ValueSpec:
+			for _, n := range spec.Names {
+				if n.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func isStruct(decl *dst.GenDecl) bool {
 	if decl.Tok != token.TYPE {
 		return false
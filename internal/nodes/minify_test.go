@@ -389,3 +389,66 @@ func TestMinify(t *testing.T) {
 		})
 	}
 }
+
+func TestMinify_preserve(t *testing.T) {
+	code := "package foo\n\n// Foo is a foo.\nfunc Foo() {\n\tbar()\n}\n\n// Bar is a bar.\ntype Bar struct{}\n\n// Baz is a baz.\nfunc (*Bar) Baz() {\n\tbar()\n}\n"
+
+	tests := map[string]string{
+		"func:Foo":        "package foo\n\n// Foo is a foo.\nfunc Foo() {\n\tbar()\n}\n\ntype Bar struct{}\n\nfunc (*Bar) Baz()\n",
+		"type:Bar":        "package foo\n\nfunc Foo()\n\n// Bar is a bar.\ntype Bar struct{}\n\nfunc (*Bar) Baz()\n",
+		"func:(*Bar).Baz": "package foo\n\nfunc Foo()\n\ntype Bar struct{}\n\n// Baz is a baz.\nfunc (*Bar) Baz() {\n\tbar()\n}\n",
+	}
+
+	for preserve, want := range tests {
+		t.Run(preserve, func(t *testing.T) {
+			opts := nodes.MinifyAll
+			opts.Preserve = preserve
+
+			node, err := nodes.Parse(code)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			minified, err := nodes.Format(nodes.Minify(node, opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(minified) != want {
+				t.Fatalf("unexpected minified code\n\nwant:\n%s\n\ngot:\n%s", want, string(minified))
+			}
+		})
+	}
+}
+
+func TestMinify_focus(t *testing.T) {
+	code := "package foo\n\nimport \"fmt\"\n\ntype (\n\tFoo struct{ Bar Bar }\n\n\tBar string\n\n\tBaz int\n)\n\ntype Qux interface {\n\tQux() Bar\n}\n\nvar x = 1\n\nfunc (f *Foo) String() string {\n\treturn fmt.Sprint(f.Bar)\n}\n\nfunc Other() Baz { return 0 }\n"
+
+	tests := map[string]string{
+		"func:(*Foo).String": "package foo\n\nimport \"fmt\"\n\ntype (\n\tFoo struct{ Bar Bar }\n)\n\nfunc (f *Foo) String() string {\n\treturn fmt.Sprint(f.Bar)\n}\n",
+		"func:Qux.Qux":       "package foo\n\nimport \"fmt\"\n\ntype (\n\tBar string\n)\n\ntype Qux interface {\n\tQux() Bar\n}\n",
+		"func:Other":         "package foo\n\nimport \"fmt\"\n\ntype (\n\tBaz int\n)\n\nfunc Other() Baz { return 0 }\n",
+		"func:Missing":       "package foo\n\nimport \"fmt\"\n\ntype (\n\tFoo struct{ Bar Bar }\n\n\tBar string\n\n\tBaz int\n)\n\ntype Qux interface {\n\tQux() Bar\n}\n\nvar x = 1\n\nfunc (f *Foo) String() string\n\nfunc Other() Baz\n",
+	}
+
+	for preserve, want := range tests {
+		t.Run(preserve, func(t *testing.T) {
+			opts := nodes.MinifyFocus
+			opts.Preserve = preserve
+
+			node, err := nodes.Parse(code)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			minified, err := nodes.Format(nodes.Minify(node, opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(minified) != want {
+				t.Fatalf("unexpected minified code\n\nwant:\n%s\n\ngot:\n%s", want, string(minified))
+			}
+		})
+	}
+}
@@ -42,6 +42,23 @@ func HasDoc(decs dst.Decorations) bool {
 	return len(decs.All()) > 0
 }
 
+// IsCgoImport reports whether node is an import declaration of the
+// pseudo-package "C". The comment of such a declaration is the cgo preamble,
+// which is C code rather than documentation, so it must neither be minified
+// nor removed.
+func IsCgoImport(node dst.Node) bool {
+	decl, ok := node.(*dst.GenDecl)
+	if !ok || decl.Tok != token.IMPORT {
+		return false
+	}
+	for _, spec := range decl.Specs {
+		if spec, ok := spec.(*dst.ImportSpec); ok && spec.Path.Value == `"C"` {
+			return true
+		}
+	}
+	return false
+}
+
 // Doc extracts the leading comment from the specified node, concatenating all
 // lines of the comment into a single string. If removeSlash is true, the "//"
 // prefix is removed from each line of the comment before concatenation.
@@ -84,7 +101,8 @@ func MustParse[Code ~string | ~[]byte](code Code) dst.Node {
 // Identifier extracts the name and determines the export status of the given
 // node. It returns an identifier string with a prefix indicating the kind of
 // node, such as "func:", "type:", or "var:", along with a boolean indicating
-// whether the identifier is exported.
+// whether the identifier is exported. Type aliases ("type Foo = Bar") are
+// identified like type definitions.
 func Identifier(node dst.Node) (identifier string, exported bool) {
 	switch node := node.(type) {
 	case *dst.FuncDecl:
@@ -272,7 +290,8 @@ func FindType(identifier string, root dst.Node) (spec *dst.TypeSpec, decl *dst.G
 // declaration node to find where a comment should be associated, typically
 // returning the node that represents the closest syntactic construct to which
 // the comment applies. If no specific association is found, it defaults to
-// using the provided outer node.
+// using the provided outer node. The specs of a parenthesized type declaration
+// are always their own targets, even if the declaration has a single spec.
 func CommentTarget(spec dst.Spec, outer dst.Node) dst.Node {
 	if spec == nil {
 		return outer
@@ -280,7 +299,7 @@ func CommentTarget(spec dst.Spec, outer dst.Node) dst.Node {
 
 	switch spec := spec.(type) {
 	case *dst.TypeSpec:
-		if decl, ok := outer.(*dst.GenDecl); ok && len(decl.Specs) == 1 {
+		if decl, ok := outer.(*dst.GenDecl); ok && len(decl.Specs) == 1 && !decl.Lparen {
 			return decl
 		}
 		return spec
@@ -2,10 +2,15 @@ package internal
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/tiktoken-go/tokenizer"
 )
 
+// DefaultEncoding is the encoding that is used for models that are unknown to
+// the tokenizer, such as custom or non-OpenAI models.
+const DefaultEncoding = tokenizer.Cl100kBase
+
 // OpenAITokenizer initializes and returns a tokenizer codec based on the
 // specified model. If the model is not supported, it falls back to the default
 // CL100kBase tokenizer. It may return an error if initializing the tokenizer
@@ -13,9 +18,28 @@ import (
 // used to tokenize or detokenize text according to the OpenAI specifications
 // associated with the model.
 func OpenAITokenizer(model string) (tokenizer.Codec, error) {
-	codec, err := tokenizer.ForModel(tokenizer.Model(model))
+	codec, _, err := Tokenizer(model, "")
+	return codec, err
+}
+
+// Tokenizer returns the tokenizer codec for the given model. If encoding is
+// not empty, the codec of that encoding (e.g. "cl100k_base") is returned
+// instead, regardless of the model. If the model is unknown to the tokenizer,
+// the codec of [DefaultEncoding] is returned and fallback is true, so that
+// callers can warn about inaccurate token counts.
+func Tokenizer(model, encoding string) (codec tokenizer.Codec, fallback bool, err error) {
+	if encoding != "" {
+		codec, err := tokenizer.Get(tokenizer.Encoding(encoding))
+		if err != nil {
+			return nil, false, fmt.Errorf("get tokenizer for encoding %q: %w", encoding, err)
+		}
+		return codec, false, nil
+	}
+
+	codec, err = tokenizer.ForModel(tokenizer.Model(model))
 	if errors.Is(err, tokenizer.ErrModelNotSupported) {
-		return tokenizer.Get(tokenizer.Cl100kBase)
+		codec, err = tokenizer.Get(DefaultEncoding)
+		return codec, true, err
 	}
-	return codec, err
+	return codec, false, err
 }
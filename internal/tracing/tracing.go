@@ -0,0 +1,32 @@
+// Package tracing instruments JotBot with OpenTelemetry spans. Spans are
+// created using the global tracer provider, so they are no-ops unless a tracer
+// provider is installed using [otel.SetTracerProvider].
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer that creates the spans of
+// JotBot.
+const InstrumentationName = "github.com/modernice/jotbot"
+
+// Start starts a span with the given name and attributes as a child of the
+// span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if err is not nil, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -7,13 +7,16 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/modernice/jotbot/find"
 	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/internal"
 	"github.com/modernice/jotbot/internal/slice"
+	"github.com/modernice/jotbot/internal/tracing"
 	"github.com/modernice/jotbot/patch"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
@@ -33,11 +36,13 @@ type Language interface {
 	// extensions typically do not include the leading dot.
 	Extensions() []string
 
-	// Find locates and returns all identifiers within a given byte slice according
-	// to the rules of the implementing language, or an error if the search cannot
-	// be completed. It returns a slice of strings representing the found
+	// Find locates and returns all identifiers within the code of the given file
+	// according to the rules of the implementing language, or an error if the
+	// search cannot be completed. The file path, relative to the repository root,
+	// allows languages to apply file-based rules, such as for test files or
+	// generated files. It returns a slice of strings representing the found
 	// identifiers and an error, if any occurred during the search process.
-	Find([]byte) ([]string, error)
+	Find(ctx context.Context, file string, code []byte) ([]string, error)
 }
 
 // JotBot orchestrates the process of searching, analyzing, and transforming
@@ -50,12 +55,14 @@ type Language interface {
 // changes, and apply those changes as patches. Additionally, it supports
 // logging for traceability of operations.
 type JotBot struct {
-	root          string
-	filters       []*regexp.Regexp
-	fs            afero.Fs
-	languages     map[string]Language
-	extToLanguage map[string]string
-	log           *slog.Logger
+	root             string
+	filters          []*regexp.Regexp
+	fs               afero.Fs
+	languages        map[string]Language
+	extToLanguage    map[string]string
+	maxFileSize      int64
+	includeGenerated bool
+	log              *slog.Logger
 }
 
 // Option configures a [*JotBot] instance with custom settings, such as
@@ -124,6 +131,25 @@ func Match(filters ...*regexp.Regexp) Option {
 	}
 }
 
+// MaxFileSize configures the maximum size of the files that are searched for
+// identifiers, in bytes. Larger files are skipped with the reason
+// [find.SkipTooLarge]. A size of 0 disables the limit.
+func MaxFileSize(bytes int64) Option {
+	return func(bot *JotBot) {
+		bot.maxFileSize = bytes
+	}
+}
+
+// IncludeGenerated configures whether files that are marked as generated code
+// (see [find.Generated]) are searched for identifiers. By default, they are
+// skipped with the reason [find.SkipGenerated], because changes to generated
+// code are overwritten the next time it is generated.
+func IncludeGenerated(include bool) Option {
+	return func(bot *JotBot) {
+		bot.includeGenerated = include
+	}
+}
+
 // New initializes and returns a new instance of JotBot configured with the
 // provided root directory and options.
 func New(root string, opts ...Option) *JotBot {
@@ -156,6 +182,25 @@ func (bot *JotBot) ConfigureLanguage(name string, lang Language) {
 	}
 }
 
+// MapExtension configures the language with the given name to handle files
+// with the given extension, e.g. ".mts" to "ts". The mapping overrides the
+// extensions that are reported by the configured languages. A missing leading
+// dot is added to ext. MapExtension returns an error if no language with the
+// given name is configured.
+func (bot *JotBot) MapExtension(ext, language string) error {
+	if _, ok := bot.languages[language]; !ok {
+		return fmt.Errorf("unknown language %q (known languages: %s)", language, strings.Join(bot.Languages(), ", "))
+	}
+	if ext == "" || ext == "." {
+		return fmt.Errorf("empty file extension for language %q", language)
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	bot.extToLanguage[ext] = language
+	return nil
+}
+
 // Extensions returns a slice of all file extensions that are associated with
 // configured languages within the JotBot instance. These extensions can be used
 // to filter files for processing based on the languages that the JotBot is
@@ -164,17 +209,43 @@ func (bot *JotBot) Extensions() []string {
 	return maps.Keys(bot.extToLanguage)
 }
 
+// Languages returns the sorted names of the configured languages.
+func (bot *JotBot) Languages() []string {
+	names := maps.Keys(bot.languages)
+	slices.Sort(names)
+	return names
+}
+
 // Find performs a search for identifiers within the files of a repository based
 // on the configured languages and file extensions. It accepts a context and
 // variadic find options to customize the search behavior. The function returns
 // a slice of Findings, which contain the identifier, file, and language of each
 // found item, or an error if the search could not be completed. The Findings
 // are sorted by file and then by identifier. If filters are configured, only
-// findings matching those filters are included in the results.
-func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) ([]Finding, error) {
+// findings matching those filters are included in the results. Files that
+// are skipped are logged at debug level and reported to the function that is
+// configured using [find.OnSkip]. In addition to the skips of [find.Files],
+// Find skips files that are too large (see [MaxFileSize]) and files that are
+// marked as generated code (see [find.Generated] and [IncludeGenerated]).
+func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) (_ []Finding, err error) {
+	ctx, span := tracing.Start(ctx, "jotbot.Find", attribute.String("root", bot.root))
+	defer func() { tracing.End(span, err) }()
+
 	bot.log.Info(fmt.Sprintf("Searching for files in %s ...", bot.root))
 
+	var findOpts find.Options
+	for _, opt := range opts {
+		opt(&findOpts)
+	}
+	skip := func(s find.Skip) {
+		bot.log.Debug(fmt.Sprintf("Skipping %s", s))
+		if findOpts.OnSkip != nil {
+			findOpts.OnSkip(s)
+		}
+	}
+
 	opts = append([]find.Option{find.Extensions(bot.Extensions()...)}, opts...)
+	opts = append(opts, find.OnSkip(skip))
 
 	repo := os.DirFS(bot.root)
 	files, err := find.Files(ctx, repo, opts...)
@@ -187,7 +258,7 @@ func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) ([]Finding, er
 		ext := filepath.Ext(file)
 		langName, ok := bot.extToLanguage[ext]
 		if !ok {
-			bot.log.Warn(fmt.Sprintf("no language configured for file extension %q", ext))
+			skip(find.Skip{Path: file, Reason: find.SkipNoLanguage, Detail: ext})
 			continue
 		}
 
@@ -199,12 +270,28 @@ func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) ([]Finding, er
 
 		path := filepath.Clean(filepath.Join(bot.root, file))
 
+		if bot.maxFileSize > 0 {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("stat file %s: %w", path, err)
+			}
+			if info.Size() > bot.maxFileSize {
+				skip(find.Skip{Path: file, Reason: find.SkipTooLarge, Detail: fmt.Sprintf("%d bytes, max %d", info.Size(), bot.maxFileSize)})
+				continue
+			}
+		}
+
 		b, err := os.ReadFile(path)
 		if err != nil {
 			return nil, fmt.Errorf("read file %s: %w", path, err)
 		}
 
-		findings, err := lang.Find(b)
+		if marker, ok := find.Generated(b); ok && !bot.includeGenerated {
+			skip(find.Skip{Path: file, Reason: find.SkipGenerated, Detail: marker})
+			continue
+		}
+
+		findings, err := bot.findInFile(ctx, lang, langName, file, b)
 		if err != nil {
 			return nil, fmt.Errorf("find in %s: %w", path, err)
 		}
@@ -227,6 +314,8 @@ func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) ([]Finding, er
 		return 1
 	})
 
+	span.SetAttributes(attribute.Int("files", len(files)), attribute.Int("findings", len(out)))
+
 	if len(out) == 0 {
 		bot.log.Info("No identifiers found in files.")
 	} else {
@@ -240,6 +329,16 @@ func (bot *JotBot) Find(ctx context.Context, opts ...find.Option) ([]Finding, er
 	return out, nil
 }
 
+func (bot *JotBot) findInFile(ctx context.Context, lang Language, langName, file string, code []byte) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "jotbot.FindFile", attribute.String("file", file), attribute.String("language", langName))
+
+	findings, err := lang.Find(ctx, file, code)
+	span.SetAttributes(attribute.Int("findings", len(findings)))
+	tracing.End(span, err)
+
+	return findings, err
+}
+
 func (bot *JotBot) filterFindings(findings []string) []string {
 	if len(bot.filters) == 0 {
 		return findings
@@ -271,14 +370,44 @@ func (bot *JotBot) languageForExtension(ext string) (Language, error) {
 // an error is encountered during the preparation of inputs or generation
 // process, it returns an error detailing the failure.
 func (bot *JotBot) Generate(ctx context.Context, findings []Finding, svc generate.Service, opts ...generate.Option) (*Patch, error) {
+	g := bot.newGenerator(svc, opts...)
+
+	files, err := bot.makeInputs(ctx, findings)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, errs, err := g.Files(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Patch{
+		Patch:       patch.New(generated, patch.WithErrors(errs), patch.WithLogger(bot.log.Handler())),
+		getLanguage: bot.languageForExtension,
+	}, nil
+}
+
+// Prompts renders the prompts that [*JotBot.Generate] would send for the
+// given findings, without generating any documentation. See
+// [*generate.Generator.Prompts].
+func (bot *JotBot) Prompts(ctx context.Context, findings []Finding, opts ...generate.Option) ([]generate.Prompt, error) {
+	files, err := bot.makeInputs(ctx, findings)
+	if err != nil {
+		return nil, err
+	}
+	return bot.newGenerator(nil, opts...).Prompts(ctx, files)
+}
+
+func (bot *JotBot) newGenerator(svc generate.Service, opts ...generate.Option) *generate.Generator {
 	baseOpts := []generate.Option{generate.WithLogger(bot.log.Handler())}
 	for name, lang := range bot.languages {
 		baseOpts = append(baseOpts, generate.WithLanguage(name, lang))
 	}
-	opts = append(baseOpts, opts...)
-
-	g := generate.New(svc, opts...)
+	return generate.New(svc, append(baseOpts, opts...)...)
+}
 
+func (bot *JotBot) makeInputs(ctx context.Context, findings []Finding) (map[string][]generate.Input, error) {
 	files := make(map[string][]generate.Input)
 	for _, finding := range findings {
 		input, err := bot.makeInput(ctx, finding)
@@ -287,16 +416,7 @@ func (bot *JotBot) Generate(ctx context.Context, findings []Finding, svc generat
 		}
 		files[finding.File] = append(files[finding.File], input)
 	}
-
-	generated, errs, err := g.Files(ctx, files)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Patch{
-		Patch:       patch.New(generated, patch.WithErrors(errs), patch.WithLogger(bot.log.Handler())),
-		getLanguage: bot.languageForExtension,
-	}, nil
+	return files, nil
 }
 
 func (bot *JotBot) makeInput(ctx context.Context, finding Finding) (generate.Input, error) {
@@ -7,13 +7,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/modernice/jotbot"
+	"github.com/modernice/jotbot/find"
 	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/generate/mockgenerate"
 	"github.com/modernice/jotbot/internal/tests"
 	"github.com/modernice/jotbot/langs/golang"
+	"golang.org/x/exp/slices"
 )
 
 func TestJotBot_Find(t *testing.T) {
@@ -84,6 +88,83 @@ func TestJotBot_Generate(t *testing.T) {
 	})
 }
 
+func TestJotBot_MapExtension(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "foo.gox"), []byte("package foo\n\nfunc Foo() {}\n"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	bot := newJotBot(root)
+
+	if err := bot.MapExtension("gox", "go"); err != nil {
+		t.Fatalf("MapExtension() failed: %v", err)
+	}
+
+	if err := bot.MapExtension(".mts", "ts"); err == nil {
+		t.Fatalf("MapExtension() should fail for an unknown language")
+	}
+
+	findings, err := bot.Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectFound(t, []jotbot.Finding{
+		{File: "foo.gox", Identifier: "func:Foo", Language: "go"},
+	}, findings)
+}
+
+func TestJotBot_Find_skip(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"foo.go":     "package foo\n\nfunc Foo() {}\n",
+		"foo_gen.go": "// Code generated by foo. DO NOT EDIT.\n\npackage foo\n\nfunc Gen() {}\n",
+		"large.go":   "package foo\n\n// " + strings.Repeat("x", 256) + "\nvar _ = 1\n\nfunc Large() {}\n",
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(code), 0644); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+
+	bot := newJotBot(root, jotbot.MaxFileSize(128))
+
+	var skipped []find.Skip
+	findings, err := bot.Find(context.Background(), find.OnSkip(func(s find.Skip) {
+		skipped = append(skipped, s)
+	}))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectFound(t, []jotbot.Finding{
+		{File: "foo.go", Identifier: "func:Foo", Language: "go"},
+	}, findings)
+
+	reasons := make(map[string]find.SkipReason)
+	for _, s := range skipped {
+		reasons[s.Path] = s.Reason
+	}
+
+	want := map[string]find.SkipReason{
+		"foo_gen.go": find.SkipGenerated,
+		"large.go":   find.SkipTooLarge,
+	}
+	if !cmp.Equal(want, reasons) {
+		t.Fatalf("unexpected skips:\n%s", cmp.Diff(want, reasons))
+	}
+
+	findings, err = newJotBot(root, jotbot.MaxFileSize(128), jotbot.IncludeGenerated(true)).Find(context.Background())
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectFound(t, []jotbot.Finding{
+		{File: "foo.go", Identifier: "func:Foo", Language: "go"},
+		{File: "foo_gen.go", Identifier: "func:Gen", Language: "go"},
+	}, findings)
+}
+
 func TestMatch(t *testing.T) {
 	root := filepath.Join(tests.Must(os.Getwd()), "testdata", "gen", "filter")
 	tests.InitRepo("basic", root)
@@ -108,6 +189,31 @@ func TestMatch(t *testing.T) {
 	}, findings)
 }
 
+func TestWithAllLanguages(t *testing.T) {
+	if !slices.Contains(jotbot.Registered(), "go") {
+		t.Fatalf("importing a language package should register the language; got %v", jotbot.Registered())
+	}
+
+	bot := jotbot.New(t.TempDir(), jotbot.WithAllLanguages())
+
+	if !slices.Contains(bot.Languages(), "go") {
+		t.Errorf("WithAllLanguages() should configure the registered languages; got %v", bot.Languages())
+	}
+
+	if !slices.Contains(bot.Extensions(), ".go") {
+		t.Errorf("WithAllLanguages() should configure the extensions of the registered languages; got %v", bot.Extensions())
+	}
+}
+
+func TestRegister_duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() should panic if the language is already registered")
+		}
+	}()
+	jotbot.Register("go", func() jotbot.Language { return golang.Must() })
+}
+
 func makeFindings(file string, findings ...string) []jotbot.Finding {
 	out := make([]jotbot.Finding, len(findings))
 	for i, id := range findings {
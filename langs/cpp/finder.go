@@ -0,0 +1,553 @@
+package cpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	namespaceRE = regexp.MustCompile(`^\s*(?:inline\s+)?namespace\s*([\w:]*)\s*(?:\{|$)`)
+	externRE    = regexp.MustCompile(`^\s*extern\s*(?:\{|$)`)
+	typeRE      = regexp.MustCompile(`^\s*(typedef\s+)?(?:template\s*<.*>\s*)?(class|struct)\s+(?:\[\[[^\]]*\]\]\s*)?(?:[A-Z_][A-Z0-9_]*\s+)?(\w*)\s*(?:final\s*)?(?::[^;{]*)?(?:\{|$)`)
+	typedefRE   = regexp.MustCompile(`^\s*\}\s*(\w+)`)
+	functionRE  = regexp.MustCompile(`^\s*(?:template\s*<.*>\s*)?((?:[\w:]+(?:<[^(){};]*>)?[\s*&]+)*?)((?:\w+::)*(?:~?\w+|operator\s*(?:\(\)|[^\s(]+)))\s*\(`)
+	accessRE    = regexp.MustCompile(`^\s*(public|protected|private)\s*(?:\w+\s*)?:([^:].*)?$`)
+	prefixRE    = regexp.MustCompile(`^\s*(?:template\s*<|\[\[)`)
+	docLineRE   = regexp.MustCompile(`^\s*//[/!]`)
+	identRE     = regexp.MustCompile(`[A-Za-z_]\w*`)
+	pointerRE   = regexp.MustCompile(`\(\s*[*&^]\s*(\w+)`)
+)
+
+// keywords are the keywords that the function regular expression may match
+// as the name of a function.
+var keywords = []string{"if", "for", "while", "switch", "return", "sizeof", "alignof", "alignas", "decltype", "static_assert", "catch", "defined", "typedef", "using", "throw", "new", "delete", "case", "do", "else", "noexcept", "requires"}
+
+// types are the keywords that name or qualify builtin types, which are not
+// parameter names.
+var types = []string{"void", "int", "char", "short", "long", "signed", "unsigned", "float", "double", "bool", "const", "volatile", "struct", "union", "enum", "class", "auto", "restrict"}
+
+// sourceExtensions are the file extensions of C and C++ source files, and
+// headerExtensions the extensions of their header files.
+var (
+	sourceExtensions = []string{".c", ".cc", ".cpp", ".cxx"}
+	headerExtensions = []string{".h", ".hh", ".hpp", ".hxx"}
+)
+
+// Finder searches C and C++ source code for functions, structs and classes
+// that have no Doxygen comment.
+type Finder struct {
+	headers fs.FS
+}
+
+// FinderOption configures a [*Finder].
+type FinderOption func(*Finder)
+
+// Headers configures the Finder to place documentation in header files. When
+// a source file ("foo.c" or "foo.cpp") is searched, the declarations that are
+// also declared in its header file ("foo.h", "foo.hpp", …) are skipped,
+// because their documentation belongs in the header. Header files are read
+// from fsys, using the file paths that are passed to [*Finder.Find].
+func Headers(fsys fs.FS) FinderOption {
+	return func(f *Finder) {
+		f.headers = fsys
+	}
+}
+
+// NewFinder returns a Finder.
+func NewFinder(opts ...FinderOption) *Finder {
+	var f Finder
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return &f
+}
+
+// Find returns the sorted identifiers of the functions ("func:name"), structs
+// ("struct:Name") and classes ("class:Name") in code that have no Doxygen
+// comment. Declarations in namespaces and classes are identified by their
+// qualified name, e.g. "func:geo::Point::move", which is also the identifier
+// of out-of-line definitions. Static functions, functions in anonymous
+// namespaces and private members of classes are skipped. Overloads share
+// their identifier.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	declaredInHeader, err := f.headerDeclarations(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if declaredInHeader[d.identifier] {
+			continue
+		}
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return slices.Compact(findings), nil
+}
+
+// headerDeclarations returns the identifiers of the declarations in the header
+// file of the given source file. It returns nil if header placement is
+// disabled, file is not a source file, or it has no header file.
+func (f *Finder) headerDeclarations(file string) (map[string]bool, error) {
+	if f.headers == nil || !slices.Contains(sourceExtensions, path.Ext(file)) {
+		return nil, nil
+	}
+
+	base := strings.TrimSuffix(file, path.Ext(file))
+	for _, ext := range headerExtensions {
+		header := base + ext
+		code, err := fs.ReadFile(f.headers, header)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read header file %s: %w", header, err)
+		}
+
+		out := make(map[string]bool)
+		for _, d := range declarations(lines.Split(code)) {
+			out[d.identifier] = true
+		}
+		return out, nil
+	}
+
+	return nil, nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+
+	// returns reports whether the declaration is a function that returns a
+	// value.
+	returns bool
+}
+
+// container is a namespace, extern "C" block, class or struct whose body
+// contains declarations.
+type container struct {
+	path    string
+	depth   int
+	kind    string
+	access  string
+	visible bool
+
+	// typedef reports whether the container is an anonymous struct that is
+	// named by a typedef at its end, e.g. "typedef struct { ... } Point;".
+	typedef bool
+	line    int
+
+	// open reports whether the body of the container has been opened. The
+	// opening brace is often on the line after the declaration.
+	open bool
+}
+
+// declarations returns the documentable declarations in src.
+func declarations(src []string) []declaration {
+	var (
+		decls      []declaration
+		containers []container
+		depth      int
+		state      lineState
+	)
+	for i, line := range src {
+		code := state.strip(line)
+
+		var owner *container
+		if n := len(containers); n > 0 && containers[n-1].open && containers[n-1].depth == depth {
+			owner = &containers[n-1]
+		}
+
+		if owner != nil || depth == 0 {
+			if d, c := parseDeclaration(code, owner); d != nil || c != nil {
+				if d != nil {
+					d.line = i
+					decls = append(decls, *d)
+				}
+				if c != nil {
+					c.depth, c.line = depth+1, i
+					containers = append(containers, *c)
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+
+		if n := len(containers); n > 0 && !containers[n-1].open {
+			switch {
+			case strings.Contains(code, "{"):
+				containers[n-1].open = true
+			case strings.Contains(code, ";"):
+				// Forward declarations, such as "class Foo;".
+				containers = containers[:n-1]
+			}
+		}
+
+		for len(containers) > 0 {
+			top := containers[len(containers)-1]
+			if !top.open || top.depth <= depth {
+				break
+			}
+			containers = containers[:len(containers)-1]
+
+			if top.typedef && top.visible {
+				if m := typedefRE.FindStringSubmatch(code); m != nil {
+					decls = append(decls, declaration{identifier: "struct:" + qualify(top.path, m[1]), line: top.line})
+				}
+			}
+		}
+	}
+
+	return decls
+}
+
+// parseDeclaration parses the declaration in the given line of code, which is
+// either at the top level of the file (owner is nil) or in the body of the
+// owner. It returns the declaration if it is part of the API, and the
+// container that the declaration opens, if any.
+func parseDeclaration(code string, owner *container) (*declaration, *container) {
+	if owner != nil && (owner.kind == "class" || owner.kind == "struct") {
+		if m := accessRE.FindStringSubmatch(code); m != nil {
+			owner.access = m[1]
+			code = m[2]
+		}
+	}
+
+	visible := owner == nil || owner.visible
+	if owner != nil && (owner.kind == "class" || owner.kind == "struct") {
+		visible = visible && owner.access != "private"
+	}
+
+	var path string
+	if owner != nil {
+		path = owner.path
+	}
+
+	if trimmed := strings.TrimSpace(code); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return nil, nil
+	}
+
+	if m := namespaceRE.FindStringSubmatch(code); m != nil {
+		return nil, &container{
+			kind:    "namespace",
+			path:    qualify(path, m[1]),
+			visible: visible && m[1] != "",
+		}
+	}
+
+	// The string literal of extern "C" blocks is stripped from code.
+	if externRE.MatchString(code) {
+		return nil, &container{kind: "namespace", path: path, visible: visible}
+	}
+
+	if m := typeRE.FindStringSubmatch(code); m != nil {
+		c := container{
+			kind:    m[2],
+			path:    qualify(path, m[3]),
+			access:  "private",
+			visible: visible,
+		}
+		if m[2] == "struct" {
+			c.access = "public"
+		}
+		if m[3] == "" {
+			// Anonymous structs are documented by the name of their typedef.
+			c.typedef = m[1] != ""
+			c.path = path
+			return nil, &c
+		}
+		if !visible {
+			return nil, &c
+		}
+		return &declaration{identifier: m[2] + ":" + c.path}, &c
+	}
+
+	m := functionRE.FindStringSubmatch(code)
+	if m == nil || !visible {
+		return nil, nil
+	}
+
+	specifiers, name := m[1], m[2]
+	segments := strings.Split(name, "::")
+	base := segments[len(segments)-1]
+	if slices.Contains(keywords, base) || slices.Contains(types, base) {
+		return nil, nil
+	}
+
+	words := identRE.FindAllString(specifiers, -1)
+	if len(words) > 0 && slices.Contains(keywords, words[0]) {
+		return nil, nil
+	}
+	if slices.Contains(words, "friend") {
+		return nil, nil
+	}
+	if slices.Contains(words, "static") && (owner == nil || owner.kind == "namespace") {
+		// Static functions have internal linkage.
+		return nil, nil
+	}
+
+	constructor := strings.HasPrefix(base, "~") ||
+		(owner != nil && owner.kind != "namespace" && base == lastSegment(owner.path)) ||
+		(len(segments) > 1 && segments[len(segments)-2] == base)
+	if returnType(words) == "" && !constructor {
+		// Calls of macros, such as "MODULE_LICENSE(...);".
+		return nil, nil
+	}
+	if strings.Contains(code, "= delete") || strings.Contains(code, "= default") {
+		return nil, nil
+	}
+
+	return &declaration{
+		identifier: "func:" + qualify(path, name),
+		returns:    !constructor && (returnType(words) != "void" || strings.ContainsAny(specifiers, "*&")),
+	}, nil
+}
+
+// returnType returns the return type of a function, given the words that
+// precede its name, without specifiers such as "static" or "inline".
+func returnType(words []string) string {
+	var out []string
+	for _, w := range words {
+		switch w {
+		case "static", "inline", "extern", "virtual", "explicit", "constexpr", "consteval", "friend", "template", "typename", "const":
+			continue
+		}
+		out = append(out, w)
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return out[len(out)-1]
+}
+
+func qualify(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "" {
+		return path
+	}
+	return path + "::" + name
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "::"); i >= 0 {
+		return path[i+2:]
+	}
+	return path
+}
+
+// lineState is the state of a multiline construct, such as a block comment,
+// a raw string literal or a preprocessor directive, at the end of a line.
+type lineState struct {
+	comment   bool
+	raw       string
+	directive bool
+}
+
+// strip removes comments, string and character literals and preprocessor
+// directives from a line of code, so that the braces within them are not
+// counted.
+func (s *lineState) strip(line string) string {
+	if s.directive || strings.HasPrefix(strings.TrimSpace(line), "#") {
+		// Directives continue on the next line if the line ends with "\".
+		s.directive = strings.HasSuffix(strings.TrimRight(line, " \t\r"), `\`)
+		return ""
+	}
+
+	var (
+		out   strings.Builder
+		quote byte
+		esc   bool
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case s.comment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.comment = false
+				i++
+			}
+		case s.raw != "":
+			if strings.HasPrefix(line[i:], s.raw) {
+				i += len(s.raw) - 1
+				s.raw = ""
+			}
+		case quote != 0:
+			switch {
+			case esc:
+				esc = false
+			case line[i] == '\\':
+				esc = true
+			case line[i] == quote:
+				quote = 0
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return out.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comment = true
+			i++
+		case strings.HasPrefix(line[i:], `R"`):
+			delim, _, _ := strings.Cut(line[i+2:], "(")
+			s.raw = ")" + delim + `"`
+			i += len(delim) + 2
+		case line[i] == '"' || (line[i] == '\'' && !isDigitSeparator(line, i)):
+			quote = line[i]
+		default:
+			out.WriteByte(line[i])
+		}
+	}
+	return out.String()
+}
+
+// isDigitSeparator reports whether the quote at index i of line separates the
+// digits of a number literal, e.g. 1'000'000.
+func isDigitSeparator(line string, i int) bool {
+	return i > 0 && i+1 < len(line) && isAlnum(line[i-1]) && isAlnum(line[i+1])
+}
+
+func isAlnum(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// findDeclaration returns the declaration identified by identifier.
+// Overloads and prototypes share an identifier, so the first declaration
+// without a Doxygen comment is preferred.
+func findDeclaration(src []string, identifier string) (declaration, bool) {
+	var (
+		found declaration
+		ok    bool
+	)
+	for _, d := range declarations(src) {
+		if d.identifier != identifier {
+			continue
+		}
+		if !documented(src, d.line) {
+			return d, true
+		}
+		if !ok {
+			found, ok = d, true
+		}
+	}
+	return found, ok
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a Doxygen comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < prefixStart(src, line)
+}
+
+// prefixStart returns the index of the first line of the template
+// declarations and attributes that directly precede the given line, or line if
+// there are none.
+func prefixStart(src []string, line int) int {
+	return lines.BlockStart(src, line, prefixRE.MatchString)
+}
+
+// docStart returns the index of the first line of the Doxygen comment ("/**",
+// "/*!", "///" or "//!") that directly precedes the declaration at the given
+// line and its template declarations and attributes. If there is none, the
+// index of the first template declaration or attribute is returned.
+func docStart(src []string, line int) int {
+	start := prefixStart(src, line)
+	if doc := lines.BlockStart(src, start, docLineRE.MatchString); doc < start {
+		return doc
+	}
+
+	if start == 0 || !strings.HasSuffix(strings.TrimSpace(src[start-1]), "*/") {
+		return start
+	}
+	for i := start - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(src[i])
+		if strings.HasPrefix(trimmed, "/**") || strings.HasPrefix(trimmed, "/*!") {
+			return i
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			return start
+		}
+	}
+	return start
+}
+
+// parameters returns the names of the parameters of the function that is
+// declared at the given line, whose parameter list may span multiple lines.
+// Unnamed parameters are skipped.
+func parameters(src []string, line int) []string {
+	var (
+		sig    strings.Builder
+		parens int
+		state  lineState
+	)
+	for i := line; i < len(src); i++ {
+		code := state.strip(src[i])
+		sig.WriteString(code + "\n")
+		parens += strings.Count(code, "(") - strings.Count(code, ")")
+		if parens <= 0 && strings.Contains(code, ")") {
+			break
+		}
+	}
+
+	s := sig.String()
+	loc := functionRE.FindStringIndex(s)
+	if loc == nil {
+		return nil
+	}
+
+	var (
+		params []string
+		depth  int
+		item   strings.Builder
+	)
+	flush := func() {
+		decl, _, _ := strings.Cut(item.String(), "=")
+		item.Reset()
+		if m := pointerRE.FindStringSubmatch(decl); m != nil {
+			params = append(params, m[1])
+			return
+		}
+		decl, _, _ = strings.Cut(decl, "[")
+		words := identRE.FindAllString(decl, -1)
+		if len(words) < 2 || slices.Contains(types, words[len(words)-1]) {
+			return
+		}
+		params = append(params, words[len(words)-1])
+	}
+	for _, r := range s[loc[1]:] {
+		switch r {
+		case '(', '<', '[':
+			depth++
+		case ')', '>', ']':
+			if depth == 0 && r == ')' {
+				flush()
+				return params
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		item.WriteRune(r)
+	}
+	flush()
+
+	return params
+}
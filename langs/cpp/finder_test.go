@@ -0,0 +1,159 @@
+package cpp_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/cpp"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		#pragma once
+		#define SQUARE(x) \
+		    ((x) * (x))
+
+		#include <string>
+
+		namespace geo {
+
+		/** A point in the plane. */
+		struct Vec {
+		    double x, y;
+		    double length() const;
+		};
+
+		class Point
+		{
+		public:
+		    Point(double x, double y);
+		    ~Point();
+		    Point(const Point&) = delete;
+
+		    /// Moves the point.
+		    void move(double dx, double dy);
+		    void move(const Vec& by);
+
+		    bool operator==(const Point& other) const;
+
+		protected:
+		    virtual std::string describe() const { return "{"; }
+
+		private:
+		    void normalize();
+		    double x_, y_;
+		};
+
+		template <typename T>
+		T clamp(T value, T lo, T hi);
+
+		namespace {
+		int helper(int x) { return x; }
+		}
+
+		} // namespace geo
+
+		extern "C" {
+		int geo_version(void);
+		}
+
+		typedef struct {
+		    int r, g, b;
+		} Color;
+
+		static int internal_counter(void);
+
+		class Forward;
+	`)
+
+	findings, err := cpp.NewFinder().Find(context.Background(), "geo.hpp", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"class:geo::Point",
+		"func:geo::Point::Point",
+		"func:geo::Point::describe",
+		"func:geo::Point::move",
+		"func:geo::Point::operator==",
+		"func:geo::Point::~Point",
+		"func:geo::Vec::length",
+		"func:geo::clamp",
+		"func:geo_version",
+		"struct:Color",
+	}, findings)
+}
+
+func TestFinder_Find_source(t *testing.T) {
+	code := heredoc.Doc(`
+		#include "geo.hpp"
+
+		namespace geo {
+
+		Point::Point(double x, double y) : x_(x), y_(y) {
+		    if (x < 0) {
+		        normalize();
+		    }
+		}
+
+		void Point::move(double dx, double dy) {
+		    x_ += dx;
+		}
+
+		}
+
+		double geo::Vec::length() const {
+		    return 0;
+		}
+
+		int main(int argc, char** argv) {
+		    return 0;
+		}
+	`)
+
+	findings, err := cpp.NewFinder().Find(context.Background(), "src/geo.cpp", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:geo::Point::Point",
+		"func:geo::Point::move",
+		"func:geo::Vec::length",
+		"func:main",
+	}, findings)
+}
+
+func TestHeaders(t *testing.T) {
+	header := heredoc.Doc(`
+		/** Returns the sum of a and b. */
+		int add(int a, int b);
+	`)
+
+	source := heredoc.Doc(`
+		#include "math.h"
+
+		int add(int a, int b) {
+		    return a + b;
+		}
+
+		int sub(int a, int b) {
+		    return a - b;
+		}
+	`)
+
+	f := cpp.NewFinder(cpp.Headers(fstest.MapFS{
+		"src/math.h": &fstest.MapFile{Data: []byte(header)},
+	}))
+
+	findings, err := f.Find(context.Background(), "src/math.c", []byte(source))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:sub"}, findings)
+}
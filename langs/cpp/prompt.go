@@ -0,0 +1,76 @@
+package cpp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the Doxygen comment of the
+// declaration identified by the input. The prompt lists the parameters of
+// functions, so that each of them is documented using a @param command.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+	if kind == "func" {
+		kind = "function"
+	}
+
+	var tags string
+	if kind == "function" {
+		src := lines.Split(input.Code)
+		if d, ok := findDeclaration(src, input.Identifier); ok {
+			for _, p := range parameters(src, d.line) {
+				tags += fmt.Sprintf("\n@param %s <description of %s>", p, p)
+			}
+			if d.returns {
+				tags += "\n@return <description of the return value>"
+			}
+		}
+	}
+
+	return heredoc.Docf(`
+		Write a Doxygen comment for the %s %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two numbers, you must not describe it as a "function that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format:
+		---
+		<short description>
+		%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (/** or */).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		language(input.File),
+		kind,
+		name,
+		name,
+		name,
+		tags,
+		input.File,
+		input.Code,
+	)
+}
+
+// language returns the name of the language of the given file.
+func language(file string) string {
+	switch path.Ext(file) {
+	case ".c":
+		return "C"
+	case ".h":
+		return "C/C++"
+	default:
+		return "C++"
+	}
+}
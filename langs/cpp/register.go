@@ -0,0 +1,7 @@
+package cpp
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("cpp", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,168 @@
+package cpp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of C and C++ source and header
+// files. Objective-C also uses ".h" for its header files, so the language
+// that is configured last handles them, unless the extension is mapped
+// explicitly.
+var FileExtensions = append(append([]string{}, sourceExtensions...), headerExtensions...)
+
+// Service documents functions, structs and classes of C and C++ code using
+// Doxygen comments ("/** ... */").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for C and C++ code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of C and C++ source and header files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented declarations in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the Doxygen comment of the declaration identified by
+// identifier, replacing its existing Doxygen comment. The comment is placed
+// above the template declaration and attributes of the declaration.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	d, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		var params []string
+		if strings.HasPrefix(identifier, "func:") {
+			params = parameters(src, d.line)
+		}
+		comment = formatDoc(doc, lines.Indent(src[d.line]), params)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, d.line), prefixStart(src, d.line), comment)), nil
+}
+
+// formatDoc formats a generated comment as a Doxygen comment. The description
+// is wrapped, and each command is written on its own line. @param commands
+// are ordered like the parameters of the function.
+func formatDoc(doc, indent string, params []string) []string {
+	var (
+		description []string
+		tags        []string
+	)
+	for _, l := range strings.Split(normalize(doc), "\n") {
+		switch {
+		case strings.HasPrefix(l, "@"):
+			tags = append(tags, l)
+		case len(tags) > 0 && l != "":
+			tags[len(tags)-1] += " " + l
+		case len(tags) == 0:
+			description = append(description, l)
+		}
+	}
+
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return tagOrder(a, params) - tagOrder(b, params)
+	})
+
+	text := internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n")))
+	if len(tags) == 0 && !strings.Contains(text, "\n") && len(indent)+len(text)+len("/**  */") <= 80 {
+		return []string{indent + "/** " + text + " */"}
+	}
+
+	out := append([]string{indent + "/**"}, lines.Comment(text, indent, " * ", "", 80)...)
+	if len(tags) > 0 {
+		out = append(out, indent+" *")
+	}
+	for _, tag := range tags {
+		out = append(out, lines.Comment(tag, indent, " *   ", " * ", 80)...)
+	}
+
+	return append(out, indent+" */")
+}
+
+// tagOrder returns the sort key of a Doxygen command: template parameters
+// first, then parameters in the order of the signature, then all other
+// commands.
+func tagOrder(tag string, params []string) int {
+	fields := strings.Fields(tag)
+	switch {
+	case fields[0] == "@tparam":
+		return -1
+	case fields[0] == "@param" || strings.HasPrefix(fields[0], "@param["):
+		if len(fields) > 1 {
+			if i := slices.Index(params, fields[1]); i >= 0 {
+				return i
+			}
+		}
+		return len(params)
+	default:
+		return len(params) + 1
+	}
+}
+
+// normalize removes comment markers from a generated Doxygen comment and trims
+// its lines. Commands that use a backslash, such as "\param", are rewritten to
+// use "@".
+func normalize(doc string) string {
+	doc = strings.TrimSpace(doc)
+	doc = strings.TrimPrefix(doc, "/**")
+	doc = strings.TrimPrefix(doc, "/*!")
+	doc = strings.TrimSuffix(doc, "*/")
+
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "///")
+		l = strings.TrimPrefix(l, "//!")
+		l = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+		if strings.HasPrefix(l, `\`) {
+			l = "@" + l[1:]
+		}
+		docLines[i] = l
+	}
+
+	return strings.Join(docLines, "\n")
+}
@@ -0,0 +1,74 @@
+package cpp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/cpp"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		namespace util {
+		    /* Outdated. */
+		    /** Outdated. */
+		    template <typename T>
+		    [[nodiscard]]
+		    T clamp(T value, const T& lo, const T& hi, int (*compare)(const T&, const T&));
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Clamps a value to a range.
+
+		@return The clamped value.
+		@param hi The upper bound.
+		\param lo The lower bound.
+		@param compare The function that compares two values.
+		@param value The value to clamp.
+		@tparam T The type of the value.
+	`)
+
+	patched, err := cpp.New().Patch(context.Background(), "func:util::clamp", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		namespace util {
+		    /* Outdated. */
+		    /**
+		     * Clamps a value to a range.
+		     *
+		     * @tparam T The type of the value.
+		     * @param value The value to clamp.
+		     * @param lo The lower bound.
+		     * @param hi The upper bound.
+		     * @param compare The function that compares two values.
+		     * @return The clamped value.
+		     */
+		    template <typename T>
+		    [[nodiscard]]
+		    T clamp(T value, const T& lo, const T& hi, int (*compare)(const T&, const T&));
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_short(t *testing.T) {
+	code := "typedef struct {\n    int r, g, b;\n} Color;\n"
+
+	patched, err := cpp.New().Patch(context.Background(), "struct:Color", "An RGB color.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if want := "/** An RGB color. */\n" + code; string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s", cmp.Diff(want, string(patched)))
+	}
+}
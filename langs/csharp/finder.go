@@ -0,0 +1,406 @@
+package csharp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	attributes = `(?:\[[^\]]*\]\s*)*`
+	modifiers  = `((?:(?:public|protected|internal|private|static|readonly|sealed|abstract|virtual|override|new|partial|async|extern|unsafe|const|volatile|required|file|event)\s+)*)`
+	typeName   = `([\w.]+(?:<[\w<>?,.\s\[\]]*>)?(?:\[[,\s]*\])*\??)`
+)
+
+var (
+	typeRE      = regexp.MustCompile(`^\s*` + attributes + modifiers + `(?:class|struct|interface|enum|record(?:\s+class|\s+struct)?)\s+(\w+)`)
+	delegateRE  = regexp.MustCompile(`^\s*` + attributes + modifiers + `delegate\s+` + typeName + `\s+(\w+)\s*[<(]`)
+	namespaceRE = regexp.MustCompile(`^\s*namespace\s+[\w.]+\s*(?:\{|$)`)
+	methodRE    = regexp.MustCompile(`^\s*` + attributes + modifiers + `(?:` + typeName + `\s+)?(\w+)\s*(?:<[^>(]*>)?\s*\(`)
+	propertyRE  = regexp.MustCompile(`^\s*` + attributes + modifiers + typeName + `\s+(\w+)\s*(?:\{|=>|$)`)
+	fieldRE     = regexp.MustCompile(`^\s*` + attributes + modifiers + typeName + `\s+(\w+)\s*(?:=|;|,)`)
+	privateRE   = regexp.MustCompile(`\bprivate\b`)
+	publicRE    = regexp.MustCompile(`\b(?:public|protected)\b`)
+	eventRE     = regexp.MustCompile(`\bevent\b`)
+	identRE     = regexp.MustCompile(`@?[A-Za-z_]\w*`)
+	attributeRE = regexp.MustCompile(`^\s*\[`)
+	docRE       = regexp.MustCompile(`^\s*///`)
+)
+
+var keywords = []string{"if", "for", "foreach", "while", "switch", "catch", "using", "lock", "fixed", "return", "new", "throw", "else", "await", "yield", "var", "nameof", "typeof", "sizeof", "default", "operator"}
+
+// Finder searches C# source code for public types and members that have no
+// XML documentation comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public types ("type:Name"),
+// methods and constructors ("method:Type.Name"), properties
+// ("property:Type.Name"), fields and constants ("field:Type.Name") and events
+// ("event:Type.Name") in code that have no XML documentation comment. Nested
+// types are identified by their path, e.g. "type:Outer.Inner". Namespaces are
+// not part of the identifiers. Members are public if they are declared public
+// or protected, or if they are members of an interface.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return slices.Compact(findings), nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+}
+
+// container is a namespace or type whose body contains declarations.
+type container struct {
+	path      string
+	depth     int
+	public    bool
+	namespace bool
+	iface     bool
+
+	// open reports whether the body of the container has been opened. The
+	// opening brace of a C# type is usually on the line after its declaration.
+	open bool
+}
+
+// declarations returns the public declarations in src.
+func declarations(src []string) []declaration {
+	var (
+		decls      []declaration
+		containers []container
+		depth      int
+		state      lineState
+	)
+	for i, line := range src {
+		code := state.strip(line)
+
+		for len(containers) > 0 {
+			top := containers[len(containers)-1]
+			if !top.open || top.depth <= depth {
+				break
+			}
+			containers = containers[:len(containers)-1]
+		}
+
+		var owner *container
+		if n := len(containers); n > 0 && containers[n-1].open && containers[n-1].depth == depth {
+			owner = &containers[n-1]
+		}
+
+		if owner != nil || depth == 0 {
+			if owner != nil && owner.namespace {
+				// Declarations in namespaces are top-level declarations.
+				owner = nil
+			}
+			if d, c, ok := parseDeclaration(code, owner); ok {
+				if d != "" {
+					decls = append(decls, declaration{identifier: d, line: i})
+				}
+				if c != nil {
+					c.depth = depth + 1
+					containers = append(containers, *c)
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+
+		if n := len(containers); n > 0 && !containers[n-1].open {
+			switch {
+			case strings.Contains(code, "{"):
+				containers[n-1].open = true
+			case strings.Contains(code, ";"):
+				// Declarations without a body, such as "record Point(int X, int Y);".
+				containers = containers[:n-1]
+			}
+		}
+	}
+
+	return decls
+}
+
+// parseDeclaration parses the declaration in the given line of code, which is
+// either at the top level of the file or a namespace (owner is nil) or in the
+// body of the owner type. It returns the identifier of the declaration, which
+// is empty if the declaration is not public, and the container that the
+// declaration opens, if any.
+func parseDeclaration(code string, owner *container) (string, *container, bool) {
+	if namespaceRE.MatchString(code) {
+		return "", &container{namespace: true, public: true}, true
+	}
+
+	if m := typeRE.FindStringSubmatch(code); m != nil {
+		c := container{
+			path:   m[2],
+			public: isPublic(m[1], owner),
+			iface:  strings.Contains(m[0], "interface"),
+		}
+		if owner != nil {
+			c.path = owner.path + "." + m[2]
+		}
+		if !c.public {
+			return "", &c, true
+		}
+		return "type:" + c.path, &c, true
+	}
+
+	if m := delegateRE.FindStringSubmatch(code); m != nil {
+		if !isPublic(m[1], owner) {
+			return "", nil, true
+		}
+		if owner != nil {
+			return "type:" + owner.path + "." + m[3], nil, true
+		}
+		return "type:" + m[3], nil, true
+	}
+
+	if owner == nil {
+		// Members can only be declared in types.
+		return "", nil, false
+	}
+
+	kind, mods, name := parseMember(code, owner)
+	if kind == "" {
+		return "", nil, false
+	}
+	if !isPublic(mods, owner) {
+		return "", nil, true
+	}
+
+	return kind + ":" + owner.path + "." + name, nil, true
+}
+
+// parseMember parses the member declaration in the given line of code. It
+// returns the kind of the member, its modifiers and its name, or an empty kind
+// if the line does not declare a member.
+func parseMember(code string, owner *container) (kind, mods, name string) {
+	if m := methodRE.FindStringSubmatch(code); m != nil && !slices.Contains(keywords, m[3]) && !slices.Contains(keywords, m[2]) {
+		isConstructor := m[2] == "" && m[3] == lastSegment(owner.path)
+		if m[2] != "" || isConstructor {
+			return "method", m[1], m[3]
+		}
+	}
+
+	for _, re := range []*regexp.Regexp{propertyRE, fieldRE} {
+		m := re.FindStringSubmatch(code)
+		if m == nil || slices.Contains(keywords, m[2]) || slices.Contains(keywords, m[3]) {
+			continue
+		}
+		switch {
+		case eventRE.MatchString(m[1]):
+			kind = "event"
+		case re == propertyRE:
+			kind = "property"
+		default:
+			kind = "field"
+		}
+		return kind, m[1], m[3]
+	}
+
+	return "", "", ""
+}
+
+// isPublic reports whether a declaration with the given modifiers is part of
+// the public API, given its owner type (nil for top-level types).
+func isPublic(mods string, owner *container) bool {
+	if owner != nil && !owner.public {
+		return false
+	}
+	if privateRE.MatchString(mods) {
+		return false
+	}
+	if owner != nil && owner.iface {
+		return true
+	}
+	return publicRE.MatchString(mods)
+}
+
+// returnsValue reports whether the method that is declared in the given line
+// returns a value. Constructors and methods that return void or a plain Task
+// do not.
+func returnsValue(line string) bool {
+	m := methodRE.FindStringSubmatch(line)
+	return m != nil && m[2] != "" && m[2] != "void" && m[2] != "Task" && m[2] != "ValueTask"
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// parameters returns the names of the parameters of the method, delegate or
+// record that is declared at the given line, whose parameter list may span
+// multiple lines.
+func parameters(src []string, line int) []string {
+	if !strings.Contains(src[line], "(") {
+		return nil
+	}
+
+	var (
+		sig    strings.Builder
+		parens int
+		state  lineState
+	)
+	for i := line; i < len(src); i++ {
+		code := state.strip(src[i])
+		sig.WriteString(code + "\n")
+		parens += strings.Count(code, "(") - strings.Count(code, ")")
+		if parens <= 0 && strings.Contains(code, ")") {
+			break
+		}
+	}
+
+	s := sig.String()
+	start := strings.Index(s, "(")
+
+	var (
+		params []string
+		depth  int
+		item   strings.Builder
+	)
+	flush := func() {
+		decl, _, _ := strings.Cut(item.String(), "=")
+		if names := identRE.FindAllString(decl, -1); len(names) > 0 {
+			params = append(params, strings.TrimPrefix(names[len(names)-1], "@"))
+		}
+		item.Reset()
+	}
+	for _, r := range s[start+1:] {
+		switch r {
+		case '(', '<', '[':
+			depth++
+		case ')', '>', ']':
+			if depth == 0 && r == ')' {
+				flush()
+				return params
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		item.WriteRune(r)
+	}
+	flush()
+
+	return params
+}
+
+// lineState is the state of a multiline construct, such as a block comment,
+// a verbatim string or a raw string literal, at the end of a line.
+type lineState struct {
+	comment bool
+	quote   string
+}
+
+// strip removes comments, string literals and character literals from a line
+// of code, so that the braces within them are not counted.
+func (s *lineState) strip(line string) string {
+	var (
+		out strings.Builder
+		esc bool
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case s.comment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.comment = false
+				i++
+			}
+		case s.quote != "":
+			switch {
+			case esc:
+				esc = false
+			case s.quote == `"` && line[i] == '\\', s.quote == "'" && line[i] == '\\':
+				esc = true
+			case s.quote == `@"` && strings.HasPrefix(line[i:], `""`):
+				i++
+			case strings.HasPrefix(line[i:], strings.TrimPrefix(s.quote, "@")):
+				i += len(strings.TrimPrefix(s.quote, "@")) - 1
+				s.quote = ""
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return out.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comment = true
+			i++
+		case strings.HasPrefix(line[i:], `"""`):
+			s.quote = `"""`
+			i += 2
+		case strings.HasPrefix(line[i:], `@"`), strings.HasPrefix(line[i:], `$@"`), strings.HasPrefix(line[i:], `@$"`):
+			s.quote = `@"`
+			i += strings.Index(line[i:], `"`)
+		case line[i] == '"' || line[i] == '\'':
+			s.quote = line[i : i+1]
+		default:
+			out.WriteByte(line[i])
+		}
+	}
+	if s.quote == `"` || s.quote == "'" {
+		// Only verbatim and raw strings can span multiple lines.
+		s.quote = ""
+	}
+	return out.String()
+}
+
+// findDeclaration returns the line of the declaration identified by
+// identifier. Overloaded methods share an identifier, so the first overload
+// without a documentation comment is preferred.
+func findDeclaration(src []string, identifier string) (int, bool) {
+	line, found := 0, false
+	for _, d := range declarations(src) {
+		if d.identifier != identifier {
+			continue
+		}
+		if !documented(src, d.line) {
+			return d.line, true
+		}
+		if !found {
+			line, found = d.line, true
+		}
+	}
+	return line, found
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// an XML documentation comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < attributeStart(src, line)
+}
+
+// attributeStart returns the index of the first line of the attributes that
+// directly precede the given line, or line if there are none.
+func attributeStart(src []string, line int) int {
+	return lines.BlockStart(src, line, attributeRE.MatchString)
+}
+
+// docStart returns the index of the first line of the XML documentation
+// comment that directly precedes the declaration at the given line and its
+// attributes. If there is none, the index of the first attribute is returned.
+func docStart(src []string, line int) int {
+	return lines.BlockStart(src, attributeStart(src, line), docRE.MatchString)
+}
@@ -0,0 +1,138 @@
+package csharp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/csharp"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		using System;
+
+		namespace Shop
+		{
+		    /// <summary>A shopping cart.</summary>
+		    public class Cart : IDisposable
+		    {
+		        private readonly List<Item> _items = new();
+
+		        public const int MaxItems = 100;
+
+		        public event EventHandler? Changed;
+
+		        public Cart(IEnumerable<Item> items)
+		        {
+		            _items.AddRange(items);
+		        }
+
+		        /// <summary>Adds an item to the cart.</summary>
+		        [Obsolete("Use AddRange")]
+		        public void Add(Item item)
+		        {
+		            if (item.Price > 0)
+		            {
+		                _items.Add(item);
+		            }
+		        }
+
+		        [JsonIgnore]
+		        public decimal Total => _items.Sum(i => i.Price);
+
+		        public string Name { get; set; } = "{";
+
+		        public IReadOnlyList<T> Filter<T>(Func<T, bool> predicate) where T : Item
+		        {
+		            return _items.OfType<T>().Where(predicate).ToList();
+		        }
+
+		        protected virtual void OnChanged() => Changed?.Invoke(this, EventArgs.Empty);
+
+		        internal void Recalculate() {}
+
+		        private protected void Hidden() {}
+
+		        public void Dispose() {}
+
+		        public class Item
+		        {
+		            public decimal Price { get; init; }
+		        }
+
+		        private class Secret
+		        {
+		            public void Reveal() {}
+		        }
+		    }
+
+		    public interface IDiscount
+		    {
+		        decimal Apply(decimal amount);
+
+		        string Code { get; }
+		    }
+
+		    internal class Helper
+		    {
+		        public void Help() {}
+		    }
+
+		    public record Point(int X, int Y);
+
+		    public enum Color { Red, Green }
+
+		    public delegate void CartChanged(Cart cart);
+		}
+	`)
+
+	findings, err := csharp.NewFinder().Find(context.Background(), "Cart.cs", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"event:Cart.Changed",
+		"field:Cart.MaxItems",
+		"method:Cart.Cart",
+		"method:Cart.Dispose",
+		"method:Cart.Filter",
+		"method:Cart.OnChanged",
+		"method:IDiscount.Apply",
+		"property:Cart.Item.Price",
+		"property:Cart.Name",
+		"property:Cart.Total",
+		"property:IDiscount.Code",
+		"type:CartChanged",
+		"type:Cart.Item",
+		"type:Color",
+		"type:IDiscount",
+		"type:Point",
+	}, findings)
+}
+
+func TestFinder_Find_fileScopedNamespace(t *testing.T) {
+	code := heredoc.Doc(`
+		namespace Shop;
+
+		public static class Prices {
+		    public static decimal Round(decimal price) => Math.Round(price, 2);
+
+		    private static string verbatim = @"
+		        public void NotAMethod() {
+		    ";
+		}
+	`)
+
+	findings, err := csharp.NewFinder().Find(context.Background(), "Prices.cs", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"method:Prices.Round",
+		"type:Prices",
+	}, findings)
+}
@@ -0,0 +1,62 @@
+package csharp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the XML documentation comment of the
+// declaration identified by the input. The prompt lists the parameters of
+// methods and delegates, so that each of them is documented using a <param>
+// element.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+
+	var elements string
+	src := lines.Split(input.Code)
+	if line, ok := findDeclaration(src, input.Identifier); ok {
+		switch kind {
+		case "method", "type":
+			for _, p := range parameters(src, line) {
+				elements += fmt.Sprintf("\n<param name=%q><description of %s></param>", p, p)
+			}
+			if kind == "method" && returnsValue(src[line]) {
+				elements += "\n<returns><description of the return value></returns>"
+			}
+		case "property":
+			elements = "\n<value><description of the value></value>"
+		}
+	}
+
+	return heredoc.Docf(`
+		Write an XML documentation comment for the C# %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a method that adds two numbers, you must not describe it as a "method that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the documentation of .NET:
+		---
+		<summary><short description></summary>%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (///). Refer to other types and members using <see cref="..."/> and to parameters using <paramref name="..."/>.
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		elements,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,7 @@
+package csharp
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("cs", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,168 @@
+package csharp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of C# source files.
+var FileExtensions = []string{".cs"}
+
+// tags are the top-level tags of XML documentation comments that JotBot
+// formats, in the order in which they are written.
+var tags = []string{"summary", "typeparam", "param", "returns", "value", "exception", "remarks"}
+
+var elementRE = regexp.MustCompile(`(?s)<(` + strings.Join(tags, "|") + `)\b([^>]*)>(.*?)</(?:` + strings.Join(tags, "|") + `)>`)
+
+var nameRE = regexp.MustCompile(`\bname\s*=\s*"([^"]*)"`)
+
+// Service documents public types and members of C# code using XML
+// documentation comments ("/// <summary>").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for C# code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of C# source files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented public declarations in
+// code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the XML documentation comment of the declaration
+// identified by identifier, replacing its existing documentation comment. The
+// comment is placed above the attributes of the declaration.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		var params []string
+		if strings.HasPrefix(identifier, "method:") || strings.HasPrefix(identifier, "type:") {
+			params = parameters(src, line)
+		}
+		comment = formatDoc(doc, lines.Indent(src[line]), params)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, line), attributeStart(src, line), comment)), nil
+}
+
+type element struct {
+	tag   string
+	attrs string
+	text  string
+}
+
+// formatDoc formats a generated comment as an XML documentation comment. A
+// comment without tags is used as the summary. The summary and remarks are
+// written as blocks, all other elements on their own (wrapped) lines. <param>
+// elements are ordered like the parameters in the signature.
+func formatDoc(doc, indent string, params []string) []string {
+	doc = normalize(doc)
+
+	var elements []element
+	for _, m := range elementRE.FindAllStringSubmatch(doc, -1) {
+		elements = append(elements, element{
+			tag:   m[1],
+			attrs: strings.TrimSpace(m[2]),
+			text:  internal.RemoveColumns(strings.TrimSpace(m[3])),
+		})
+	}
+	if len(elements) == 0 {
+		elements = []element{{tag: "summary", text: internal.RemoveColumns(doc)}}
+	}
+
+	slices.SortStableFunc(elements, func(a, b element) int {
+		return elementOrder(a, params) - elementOrder(b, params)
+	})
+
+	var out []string
+	for _, e := range elements {
+		open := "<" + e.tag + ">"
+		if e.attrs != "" {
+			open = "<" + e.tag + " " + e.attrs + ">"
+		}
+		close := "</" + e.tag + ">"
+
+		switch e.tag {
+		case "summary", "remarks":
+			out = append(out, indent+"/// "+open)
+			out = append(out, lines.Comment(e.text, indent, "/// ", "", 80)...)
+			out = append(out, indent+"/// "+close)
+		default:
+			out = append(out, lines.Comment(open+e.text+close, indent, "/// ", "", 80)...)
+		}
+	}
+
+	return out
+}
+
+// elementOrder returns the sort key of an element of an XML documentation
+// comment: the order of its tag in [tags], and for parameters additionally
+// their order in the signature.
+func elementOrder(e element, params []string) int {
+	order := slices.Index(tags, e.tag) * (len(params) + 1)
+	if e.tag == "param" {
+		if m := nameRE.FindStringSubmatch(e.attrs); m != nil {
+			if i := slices.Index(params, m[1]); i >= 0 {
+				return order + i
+			}
+		}
+		return order + len(params)
+	}
+	return order
+}
+
+// normalize removes comment markers from a generated XML documentation
+// comment and trims its lines.
+func normalize(doc string) string {
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		docLines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "///"))
+	}
+	return strings.TrimSpace(strings.Join(docLines, "\n"))
+}
@@ -0,0 +1,91 @@
+package csharp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/csharp"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		public class Cart
+		{
+		    /// <summary>Outdated.</summary>
+		    [HttpGet]
+		    [Authorize(Roles = "Admin")]
+		    public decimal Total(decimal discount, IDictionary<string, decimal> taxes = null)
+		    {
+		        return 0;
+		    }
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		<summary>
+		Returns the total price of the items in the cart, after subtracting the discount and adding the taxes.
+		</summary>
+		<returns>The total price.</returns>
+		<param name="taxes">The taxes by country code.</param>
+		<param name="discount">The discount that is subtracted from the total.</param>
+	`)
+
+	patched, err := csharp.New().Patch(context.Background(), "method:Cart.Total", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		public class Cart
+		{
+		    /// <summary>
+		    /// Returns the total price of the items in the cart, after subtracting the
+		    /// discount and adding the taxes.
+		    /// </summary>
+		    /// <param name="discount">The discount that is subtracted from the
+		    /// total.</param>
+		    /// <param name="taxes">The taxes by country code.</param>
+		    /// <returns>The total price.</returns>
+		    [HttpGet]
+		    [Authorize(Roles = "Admin")]
+		    public decimal Total(decimal discount, IDictionary<string, decimal> taxes = null)
+		    {
+		        return 0;
+		    }
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_plainText(t *testing.T) {
+	code := heredoc.Doc(`
+		namespace Shop
+		{
+		    public record Point(int X, int Y);
+		}
+	`)
+
+	patched, err := csharp.New().Patch(context.Background(), "type:Point", "/// A point in 2D space.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		namespace Shop
+		{
+		    /// <summary>
+		    /// A point in 2D space.
+		    /// </summary>
+		    public record Point(int X, int Y);
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
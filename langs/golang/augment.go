@@ -0,0 +1,96 @@
+package golang
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/dave/dst"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/nodes"
+)
+
+// Augment configures whether a [*Service] completes the existing
+// documentation of symbols instead of replacing it. The prompts of documented
+// symbols ask the model to add what the documentation misses, such as
+// explanations of parameters or return values, and patches keep the existing
+// documentation at the beginning of the comment. Symbols without
+// documentation are documented as usual.
+func Augment(augment bool) Option {
+	return func(s *Service) {
+		s.augment = augment
+	}
+}
+
+// AugmentPrompt returns the prompt that asks for the completion of doc, the
+// existing documentation of the symbol identified by the input.
+func AugmentPrompt(input generate.PromptInput, doc string) string {
+	target := Target(input.Identifier)
+	return withStructTags(withTypeParams(heredoc.Docf(`
+		Complete the existing GoDoc comment of %s. Do not include any external links, source code, or (code) examples.
+
+		This is the existing comment:
+		---
+		%s
+		---
+
+		Keep the existing comment exactly as it is, and add only what it misses, such as explanations of parameters, return values, errors or side effects. If the comment is already complete, output it unchanged.
+
+		You must enclose references to other types within brackets ([]).
+
+		Output only the unquoted comment, including the existing comment, without comment markers.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		target,
+		doc,
+		input.File,
+		input.Code,
+	), input.Identifier, input.Code), input.Identifier, input.Code)
+}
+
+// documentation returns the existing documentation of the declaration
+// identified by identifier in code, without comment markers.
+func documentation(identifier string, code []byte) string {
+	file, err := nodes.Parse(code)
+	if err != nil {
+		return ""
+	}
+	decs, err := docDecorations(file, identifier)
+	if err != nil || decs == nil {
+		return ""
+	}
+	return existingDoc(decs.Start)
+}
+
+// existingDoc returns the documentation in decs without comment markers. The
+// "Deprecated:" paragraph and directives are not part of it.
+func existingDoc(decs dst.Decorations) string {
+	_, attached := detachComments(decs)
+	lines, _, _ := splitDoc(attached)
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimPrefix(line, "//"), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// augmentDoc returns the documentation that completes existing with the
+// generated documentation, and whether it differs from existing. The model is
+// asked to repeat the existing documentation, but if the generated
+// documentation does not begin with it, it is prepended.
+func augmentDoc(existing, generated string) (string, bool) {
+	if existing == "" {
+		return generated, generated != ""
+	}
+
+	e, g := strings.Fields(existing), strings.Fields(generated)
+	if len(g) <= len(e) {
+		return existing, false
+	}
+	if strings.Join(g[:len(e)], " ") == strings.Join(e, " ") {
+		return generated, true
+	}
+	return existing + "\n\n" + generated, true
+}
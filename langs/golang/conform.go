@@ -0,0 +1,88 @@
+package golang
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// genericSubjectRE matches subjects that models use instead of the name of
+	// the documented symbol, e.g. "This function" or "The method".
+	genericSubjectRE = regexp.MustCompile(`^(?:This|The)\s+(?:function|method|type|struct|interface|variable|constant|value)\b\s*`)
+
+	// verbRE matches a capitalized verb in third person, e.g. "Returns".
+	verbRE = regexp.MustCompile(`^[A-Z][a-z]+s\b`)
+
+	// notVerbs are words that verbRE matches, but that are not verbs.
+	notVerbs = map[string]bool{"This": true, "Thus": true, "Its": true, "As": true}
+)
+
+// Conform rewrites doc so that it begins with the name of the symbol identified
+// by identifier, as Go doc comments do. Doc comments that begin with a generic
+// subject like "This function", with the name in backticks or brackets, or with
+// a verb like "Returns" are rewritten. Doc comments of types may also begin
+// with an article, e.g. "A Foo is ...". Conform returns an error if doc cannot
+// be rewritten. Help texts, and documentation that completes existing
+// documentation (see [Augment]), are returned as they are.
+func (svc *Service) Conform(identifier, doc string) (string, error) {
+	if strings.HasPrefix(identifier, HelpPrefix) || svc.augment {
+		return doc, nil
+	}
+
+	name := simpleIdentifier(identifier)
+	doc = strings.TrimLeft(doc, " \t\n")
+	if beginsWithName(doc, name) {
+		return doc, nil
+	}
+
+	if name, ok := strings.CutPrefix(identifier, "type:"); ok {
+		for _, article := range []string{"A ", "An ", "The "} {
+			if rest, ok := strings.CutPrefix(doc, article); ok && beginsWithName(rest, name) {
+				return doc, nil
+			}
+		}
+	}
+
+	nameRE := regexp.MustCompile(`^(?:The\s+)?[` + "`" + `\[]?(?:\*?[\w.()*]+\.)?` + regexp.QuoteMeta(name) + `(?:\(\))?[` + "`" + `\]]?(?:\s+(?:function|method|type|struct|interface|variable|constant)\b)?`)
+	withName := func(doc string) (string, bool) {
+		loc := nameRE.FindStringIndex(doc)
+		if loc == nil || !endsWord(doc[loc[1]:]) {
+			return "", false
+		}
+		return name + doc[loc[1]:], true
+	}
+
+	if rewritten, ok := withName(doc); ok {
+		return rewritten, nil
+	}
+
+	if loc := genericSubjectRE.FindStringIndex(doc); loc != nil {
+		if rewritten, ok := withName(doc[loc[1]:]); ok {
+			return rewritten, nil
+		}
+		return name + " " + doc[loc[1]:], nil
+	}
+
+	if verb := verbRE.FindString(doc); verb != "" && !notVerbs[verb] {
+		return name + " " + strings.ToLower(verb[:1]) + doc[1:], nil
+	}
+
+	return "", fmt.Errorf("the comment must begin with %q", name+" ")
+}
+
+// beginsWithName reports whether doc begins with the word name, not followed
+// by the parentheses of a call.
+func beginsWithName(doc, name string) bool {
+	rest, ok := strings.CutPrefix(doc, name)
+	return ok && endsWord(rest) && !strings.HasPrefix(rest, "(")
+}
+
+// endsWord reports whether rest, the text after a word, does not continue the
+// word.
+func endsWord(rest string) bool {
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest == "" || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
@@ -0,0 +1,85 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Declarations returns the declaration of the receiver type of the method
+// identified by identifier, including its documentation and the comments of
+// its fields, if code declares the type. Identifiers of functions, types and
+// variables, and of interface methods, have no declarations that they depend
+// on.
+func (svc *Service) Declarations(identifier string, code []byte) ([]string, error) {
+	recv, ok := receiverType(identifier)
+	if !ok {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	source := func(from, to token.Pos) string {
+		return string(code[fset.Position(from).Offset:fset.Position(to).Offset])
+	}
+
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.TypeSpec)
+			if spec.Name.Name != recv {
+				continue
+			}
+			if _, ok := spec.Type.(*ast.InterfaceType); ok {
+				return nil, nil
+			}
+
+			if !decl.Lparen.IsValid() {
+				from := decl.Pos()
+				if decl.Doc != nil {
+					from = decl.Doc.Pos()
+				}
+				return []string{source(from, decl.End())}, nil
+			}
+
+			// Specs of grouped declarations are returned as standalone
+			// declarations, without the indentation of the group.
+			var doc string
+			if spec.Doc != nil {
+				doc = dedent(source(spec.Doc.Pos(), spec.Doc.End())) + "\n"
+			}
+			return []string{doc + "type " + dedent(source(spec.Pos(), spec.End()))}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// receiverType returns the name of the receiver type of the method identified
+// by identifier, e.g. "Foo" for "func:(*Foo).Bar".
+func receiverType(identifier string) (string, bool) {
+	name, ok := strings.CutPrefix(identifier, "func:")
+	if !ok {
+		return "", false
+	}
+	recv, _, ok := strings.Cut(name, ".")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(recv, "(*"), ")"), true
+}
+
+// dedent removes one level of indentation from the lines of code after the
+// first.
+func dedent(code string) string {
+	return strings.ReplaceAll(code, "\n\t", "\n")
+}
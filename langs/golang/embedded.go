@@ -0,0 +1,246 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+)
+
+// interfaceDecl is the declaration of an interface type.
+type interfaceDecl struct {
+	typ  *ast.InterfaceType
+	fset *token.FileSet
+
+	// imports maps the names of the packages imported by the file of the
+	// declaration to their import paths.
+	imports map[string]string
+}
+
+// promotedMethods are the methods that an interface type inherits from one of
+// the interfaces it embeds.
+type promotedMethods struct {
+	// from is the embedded interface, e.g. "io.Reader".
+	from string
+
+	// signatures are the signatures of the methods, e.g.
+	// "Read(p []byte) (n int, err error)".
+	signatures []string
+}
+
+// interfaceDecls returns the interface types that file declares by name.
+func interfaceDecls(fset *token.FileSet, file *ast.File) map[string]interfaceDecl {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	decls := make(map[string]interfaceDecl)
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.TypeSpec)
+			if typ, ok := spec.Type.(*ast.InterfaceType); ok {
+				decls[spec.Name.Name] = interfaceDecl{typ: typ, fset: fset, imports: imports}
+			}
+		}
+	}
+	return decls
+}
+
+// promoted returns the methods that the interface type identified by
+// identifier inherits from the interfaces it embeds, grouped by embedded
+// interface. Embedded interfaces are resolved within the package of the file,
+// which is read from the root of the repository if [Root] is configured, and
+// within the standard library. Interfaces that cannot be resolved, such as
+// those of other modules, are skipped.
+func (svc *Service) promoted(identifier, file string, code []byte) []promotedMethods {
+	kind, name, ok := strings.Cut(identifier, ":")
+	if !ok || kind != "type" {
+		return nil
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	decls := interfaceDecls(fset, node)
+	decl, ok := decls[name]
+	if !ok || !embedsInterfaces(decl.typ) {
+		return nil
+	}
+
+	if svc.root != nil && file != "" {
+		for name, decl := range svc.packageInterfaces(file, node.Name.Name) {
+			if _, ok := decls[name]; !ok {
+				decls[name] = decl
+			}
+		}
+	}
+
+	var promoted []promotedMethods
+	seen := map[*ast.InterfaceType]bool{decl.typ: true}
+	for _, field := range decl.typ.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		from, signatures := embeddedMethods(field.Type, decl, decls, seen)
+		if len(signatures) > 0 {
+			promoted = append(promoted, promotedMethods{from: from, signatures: signatures})
+		}
+	}
+
+	return promoted
+}
+
+// packageInterfaces returns the interface types that are declared by the files
+// of the package pkg in the directory of file.
+func (svc *Service) packageInterfaces(file, pkg string) map[string]interfaceDecl {
+	dir := path.Dir(file)
+	entries, err := fs.ReadDir(svc.root, dir)
+	if err != nil {
+		return nil
+	}
+
+	decls := make(map[string]interfaceDecl)
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".go" {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		code, err := fs.ReadFile(svc.root, path.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		node, err := parser.ParseFile(fset, name, code, parser.SkipObjectResolution)
+		if err != nil || node.Name.Name != pkg {
+			continue
+		}
+
+		for name, decl := range interfaceDecls(fset, node) {
+			decls[name] = decl
+		}
+	}
+
+	return decls
+}
+
+// embeddedMethods returns the name of the interface embedded by expr within
+// the interface decl, and the signatures of its methods, including the methods
+// that it inherits itself. decls are the interface types of the package of
+// decl. Interfaces in seen are skipped, so that each method is returned once.
+func embeddedMethods(expr ast.Expr, decl interfaceDecl, decls map[string]interfaceDecl, seen map[*ast.InterfaceType]bool) (string, []string) {
+	var (
+		name     string
+		embedded interfaceDecl
+		ok       bool
+	)
+
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		name = expr.Name
+		embedded, ok = decls[name]
+		if !ok && name == "error" {
+			return name, []string{"Error() string"}
+		}
+	case *ast.SelectorExpr:
+		pkg, isIdent := expr.X.(*ast.Ident)
+		if !isIdent {
+			return "", nil
+		}
+		name = pkg.Name + "." + expr.Sel.Name
+		var std *stdPackage
+		if std, ok = loadStdPackage(decl.imports[pkg.Name]); ok {
+			embedded, ok = std.interfaces[expr.Sel.Name]
+			decls = std.interfaces
+		}
+	}
+
+	if !ok || seen[embedded.typ] {
+		return name, nil
+	}
+	seen[embedded.typ] = true
+
+	var signatures []string
+	for _, field := range embedded.typ.Methods.List {
+		if len(field.Names) == 0 {
+			_, inherited := embeddedMethods(field.Type, embedded, decls, seen)
+			signatures = append(signatures, inherited...)
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, embedded.fset, field.Type); err != nil {
+			continue
+		}
+		for _, method := range field.Names {
+			signatures = append(signatures, method.Name+strings.TrimPrefix(buf.String(), "func"))
+		}
+	}
+
+	return name, signatures
+}
+
+// embedsInterfaces reports whether an interface type has embedded elements.
+func embedsInterfaces(typ *ast.InterfaceType) bool {
+	for _, field := range typ.Methods.List {
+		if len(field.Names) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withPromotedMethods appends the methods that the interface type of the input
+// inherits from the interfaces it embeds to a prompt, so that the
+// documentation describes the complete method set of the interface.
+func (svc *Service) withPromotedMethods(prompt string, input generate.PromptInput) string {
+	promoted := svc.promoted(input.Identifier, input.File, input.Code)
+	if len(promoted) == 0 {
+		return prompt
+	}
+
+	from := make([]string, len(promoted))
+	var methods []string
+	for i, p := range promoted {
+		from[i] = p.from
+		for _, signature := range p.signatures {
+			methods = append(methods, fmt.Sprintf("%s // from %s", signature, p.from))
+		}
+	}
+
+	return fmt.Sprintf(
+		"%s\n\nNote that %s embeds %s, so its method set also includes the following methods:\n---\n%s\n---",
+		strings.TrimRight(prompt, "\n"),
+		Target(input.Identifier),
+		strings.Join(from, ", "),
+		strings.Join(methods, "\n"),
+	)
+}
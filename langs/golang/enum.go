@@ -0,0 +1,154 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// enumGroup is a parenthesized const declaration that declares the values of
+// an enumeration using iota, such as "const ( A Kind = iota; B; C )".
+type enumGroup struct {
+	// typ is the type of the constants, or empty if they are untyped.
+	typ string
+
+	// names are the names of the constants, without blank identifiers.
+	names []string
+
+	// source is the source code of the declaration.
+	source string
+}
+
+// findEnumGroup returns the iota-based const group in code that declares the
+// constant identified by identifier.
+func findEnumGroup(identifier string, code []byte) (enumGroup, bool) {
+	name, ok := strings.CutPrefix(identifier, "var:")
+	if !ok {
+		return enumGroup{}, false
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return enumGroup{}, false
+	}
+
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.CONST || len(decl.Specs) < 2 || !declares(decl, name) || !usesIota(decl) {
+			continue
+		}
+
+		var group enumGroup
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if group.typ == "" && spec.Type != nil {
+				group.typ = string(code[fset.Position(spec.Type.Pos()).Offset:fset.Position(spec.Type.End()).Offset])
+			}
+			for _, ident := range spec.Names {
+				if ident.Name != "_" {
+					group.names = append(group.names, ident.Name)
+				}
+			}
+		}
+		group.source = string(code[fset.Position(decl.Pos()).Offset:fset.Position(decl.End()).Offset])
+
+		return group, true
+	}
+
+	return enumGroup{}, false
+}
+
+// declares reports whether a const or var declaration declares name.
+func declares(decl *ast.GenDecl, name string) bool {
+	for _, spec := range decl.Specs {
+		if spec, ok := spec.(*ast.ValueSpec); ok {
+			for _, ident := range spec.Names {
+				if ident.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// usesIota reports whether a const declaration uses iota in one of its values.
+func usesIota(decl *ast.GenDecl) bool {
+	var found bool
+	for _, spec := range decl.Specs {
+		for _, value := range spec.(*ast.ValueSpec).Values {
+			ast.Inspect(value, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+					found = true
+				}
+				return !found
+			})
+		}
+	}
+	return found
+}
+
+// IsEnumConstant reports whether the input identifies a constant of a
+// parenthesized const declaration that uses iota to enumerate the values of a
+// type, such as "const ( A Kind = iota; B; C )". These constants are
+// documented using [EnumPrompt].
+func IsEnumConstant(input generate.PromptInput) bool {
+	_, ok := findEnumGroup(input.Identifier, input.Code)
+	return ok
+}
+
+// EnumPrompt returns the prompt for the documentation of a constant of an
+// iota-based enumeration, as reported by [IsEnumConstant]. The prompt passes
+// the whole const group as context and names the shared type and the other
+// values, so that the comments of the constants read as a coherent list.
+func EnumPrompt(input generate.PromptInput) string {
+	group, ok := findEnumGroup(input.Identifier, input.Code)
+	if !ok {
+		return Prompt(input)
+	}
+
+	name := simpleIdentifier(input.Identifier)
+
+	enum := "an enumeration"
+	if group.typ != "" {
+		enum = fmt.Sprintf("the enumeration of [%s]", strings.TrimPrefix(group.typ, "*"))
+	}
+
+	return heredoc.Docf(`
+		Write a comment for the constant %q in idiomatic GoDoc format. Do not include any external links, source code, or (code) examples.
+
+		%s is one of the values of %s, which consists of %s. The values are declared as follows:
+		---
+		%s
+		---
+
+		Describe what %s stands for and how it differs from the other values, so that the comments of all values read as a coherent list. You must enclose references to the type and to the other values within brackets ([]).
+
+		You must begin the comment exactly with "%s ", and maintain the writing style consistent with Go library documentation.
+
+		Output only the unquoted comment, without comment markers.
+
+		Keep the comment short, usually a single sentence.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		name,
+		name,
+		enum,
+		strings.Join(group.names, ", "),
+		group.source,
+		name,
+		name,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,296 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/nodes"
+	"golang.org/x/exp/slices"
+)
+
+// ExampleName returns the name of the example function of the symbol
+// identified by identifier, following the naming convention of "go test":
+// ExampleFoo for the function or type Foo, and ExampleFoo_Bar for the method
+// Bar of the type Foo. Variables, constants and unexported symbols have no
+// example functions.
+func ExampleName(identifier string) (string, bool) {
+	kind, name, ok := strings.Cut(identifier, ":")
+	if !ok || (kind != "func" && kind != "type") || !nodes.IsExportedIdentifier(identifier) {
+		return "", false
+	}
+
+	if recv, method, ok := strings.Cut(name, "."); ok {
+		recv = strings.TrimSuffix(strings.TrimPrefix(recv, "(*"), ")")
+		if !token.IsExported(recv) || !token.IsExported(method) {
+			return "", false
+		}
+		return "Example" + recv + "_" + method, true
+	}
+
+	return "Example" + name, true
+}
+
+// ExampleNames returns the names of the example functions that are declared in
+// code.
+func ExampleNames(code []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	var names []string
+	for _, fn := range file.Decls {
+		if fn, ok := fn.(*ast.FuncDecl); ok && fn.Recv == nil && isTestFunction(fn.Name.Name, "Example") {
+			names = append(names, fn.Name.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// ExampleLanguage returns the language that generates example functions
+// instead of documentation, using [ExamplePrompt]. The code in prompts is
+// minified by svc, which also looks up the declarations of receiver types.
+func ExampleLanguage(svc *Service) generate.Language {
+	return exampleLanguage{svc}
+}
+
+type exampleLanguage struct {
+	svc *Service
+}
+
+func (lang exampleLanguage) Prompt(input generate.PromptInput) string {
+	return ExamplePrompt(input)
+}
+
+func (lang exampleLanguage) Minify(code []byte) ([]byte, error) {
+	return lang.svc.Minify(code)
+}
+
+func (lang exampleLanguage) MinifyTarget(identifier string, code []byte) ([]byte, error) {
+	return lang.svc.MinifyTarget(identifier, code)
+}
+
+func (lang exampleLanguage) Declarations(identifier string, code []byte) ([]string, error) {
+	return lang.svc.Declarations(identifier, code)
+}
+
+// AddExamples adds example functions to the code of a test file of the external
+// test package of pkg, and returns the formatted code of the test file. If code
+// is empty, a new test file is created. Each example is Go code that consists
+// of import declarations and functions, as requested by [ExamplePrompt].
+// Package clauses and Markdown code fences around the code are removed. The
+// package under test is imported from importPath if an example refers to it.
+func AddExamples(code []byte, pkg, importPath string, examples ...string) ([]byte, error) {
+	if len(bytes.TrimSpace(code)) == 0 {
+		code = []byte(fmt.Sprintf("package %s_test\n", pkg))
+	}
+
+	file, err := nodes.Parse(code)
+	if err != nil {
+		return nil, fmt.Errorf("parse test file: %w", err)
+	}
+	if file.Name.Name != pkg+"_test" {
+		return nil, fmt.Errorf("test file belongs to package %q instead of %q", file.Name.Name, pkg+"_test")
+	}
+
+	for _, example := range examples {
+		ex, err := nodes.Parse(fmt.Sprintf("package %s_test\n\n%s\n", pkg, trimExample(example)))
+		if err != nil {
+			return nil, fmt.Errorf("parse example: %w", err)
+		}
+
+		imports := ex.Imports
+		if refersTo(ex, pkg) && !importsPackage(ex, importPath) {
+			imports = append(imports, &dst.ImportSpec{Path: &dst.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)}})
+		}
+		for _, spec := range imports {
+			addImport(file, spec)
+		}
+
+		for _, decl := range ex.Decls {
+			if fn, ok := decl.(*dst.FuncDecl); ok {
+				fn.Decs.Before = dst.EmptyLine
+				file.Decls = append(file.Decls, fn)
+			}
+		}
+	}
+
+	return nodes.Format(file)
+}
+
+// trimExample removes Markdown code fences and the package clause from the
+// code of a generated example.
+func trimExample(example string) string {
+	lines := strings.Split(strings.TrimSpace(example), "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "package ") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// refersTo reports whether the code of file refers to the package with the
+// given name.
+func refersTo(file *dst.File, pkg string) bool {
+	var found bool
+	dst.Inspect(file, func(node dst.Node) bool {
+		if sel, ok := node.(*dst.SelectorExpr); ok {
+			if ident, ok := sel.X.(*dst.Ident); ok && ident.Name == pkg {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func importsPackage(file *dst.File, path string) bool {
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// addImport adds an import to the first import declaration of file, unless
+// file already imports the package.
+func addImport(file *dst.File, spec *dst.ImportSpec) {
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil || importsPackage(file, path) {
+		return
+	}
+
+	imp := &dst.ImportSpec{Path: &dst.BasicLit{Kind: token.STRING, Value: spec.Path.Value}}
+	if spec.Name != nil {
+		imp.Name = dst.NewIdent(spec.Name.Name)
+	}
+	file.Imports = append(file.Imports, imp)
+
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*dst.GenDecl); ok && decl.Tok == token.IMPORT {
+			decl.Specs = append(decl.Specs, imp)
+			decl.Lparen = len(decl.Specs) > 1
+			groupImports(decl)
+			return
+		}
+	}
+
+	decl := &dst.GenDecl{Tok: token.IMPORT, Specs: []dst.Spec{imp}}
+	decl.Decs.After = dst.EmptyLine
+	file.Decls = append([]dst.Decl{decl}, file.Decls...)
+}
+
+// groupImports sorts the imports of decl like goimports: the packages of the
+// standard library come first, followed by the other packages.
+func groupImports(decl *dst.GenDecl) {
+	path := func(spec dst.Spec) string {
+		return spec.(*dst.ImportSpec).Path.Value
+	}
+	std := func(spec dst.Spec) bool {
+		first, _, _ := strings.Cut(strings.Trim(path(spec), `"`), "/")
+		return !strings.Contains(first, ".")
+	}
+
+	slices.SortStableFunc(decl.Specs, func(a, b dst.Spec) int {
+		if std(a) != std(b) {
+			if std(a) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(path(a), path(b))
+	})
+
+	for i, spec := range decl.Specs {
+		decs := spec.Decorations()
+		decs.Before, decs.After = dst.NewLine, dst.NewLine
+		if i > 0 && std(decl.Specs[i-1]) && !std(spec) {
+			decs.Before = dst.EmptyLine
+		}
+	}
+}
+
+// ImportPath returns the import path of the Go package in dir.
+func ImportPath(ctx context.Context, dir string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "list", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// VetFiles runs "go vet" for the Go package in dir as if the given files, keyed
+// by their paths, were written to disk, without writing them. Besides type
+// checking the package and its tests, "go vet" checks that example functions
+// refer to existing symbols and have valid signatures. The returned error
+// contains the output of "go vet".
+func VetFiles(ctx context.Context, dir string, files map[string][]byte) error {
+	tmp, err := os.MkdirTemp("", "jotbot-vet-*")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	overlay := struct{ Replace map[string]string }{Replace: make(map[string]string)}
+	var i int
+	for path, code := range files {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("get absolute path of %s: %w", path, err)
+		}
+
+		i++
+		replacement := filepath.Join(tmp, fmt.Sprintf("%d_%s", i, filepath.Base(path)))
+		if err := os.WriteFile(replacement, code, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", replacement, err)
+		}
+		overlay.Replace[abs] = replacement
+	}
+
+	b, err := json.Marshal(overlay)
+	if err != nil {
+		return fmt.Errorf("marshal overlay: %w", err)
+	}
+	overlayFile := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayFile, b, 0644); err != nil {
+		return fmt.Errorf("write overlay: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "vet", "-overlay="+overlayFile, ".")
+	cmd.Dir = dir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("go vet: %s", strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("go vet: %w", err)
+	}
+
+	return nil
+}
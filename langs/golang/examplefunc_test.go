@@ -0,0 +1,148 @@
+package golang_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/golang"
+)
+
+func TestExampleName(t *testing.T) {
+	tests := map[string]string{
+		"func:Foo":        "ExampleFoo",
+		"type:Foo":        "ExampleFoo",
+		"func:Foo.Bar":    "ExampleFoo_Bar",
+		"func:(*Foo).Bar": "ExampleFoo_Bar",
+		"var:Foo":         "",
+		"func:foo":        "",
+		"func:(*foo).Bar": "",
+	}
+
+	for identifier, want := range tests {
+		name, ok := golang.ExampleName(identifier)
+		if ok != (want != "") || name != want {
+			t.Errorf("ExampleName(%q) should return (%q, %v); got (%q, %v)", identifier, want, want != "", name, ok)
+		}
+	}
+}
+
+func TestAddExamples(t *testing.T) {
+	existing := heredoc.Doc(`
+		package foo_test
+
+		import "fmt"
+
+		func ExampleFoo() {
+			fmt.Println("foo")
+			// Output: foo
+		}
+	`)
+
+	generated := heredoc.Doc(`
+		` + "```go" + `
+		import (
+			"fmt"
+			"strings"
+		)
+
+		func ExampleBar() {
+			fmt.Println(strings.ToUpper(foo.Bar()))
+		}
+		` + "```" + `
+	`)
+
+	code, err := golang.AddExamples([]byte(existing), "foo", "example.com/foo", generated)
+	if err != nil {
+		t.Fatalf("AddExamples() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		package foo_test
+
+		import (
+			"fmt"
+			"strings"
+
+			"example.com/foo"
+		)
+
+		func ExampleFoo() {
+			fmt.Println("foo")
+			// Output: foo
+		}
+
+		func ExampleBar() {
+			fmt.Println(strings.ToUpper(foo.Bar()))
+		}
+	`)
+
+	if string(code) != want {
+		t.Errorf("AddExamples() returned invalid code:\n\n%s", cmp.Diff(want, string(code)))
+	}
+
+	names, err := golang.ExampleNames(code)
+	if err != nil {
+		t.Fatalf("ExampleNames() failed: %v", err)
+	}
+	if !cmp.Equal([]string{"ExampleFoo", "ExampleBar"}, names) {
+		t.Errorf("ExampleNames() should return the names of the examples; got %v", names)
+	}
+
+	if _, err := golang.AddExamples([]byte("package foo\n"), "foo", "example.com/foo", generated); err == nil {
+		t.Errorf("AddExamples() should fail for a test file of another package")
+	}
+}
+
+func TestVetFiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/foo\n\ngo 1.20\n")
+	writeFile(t, filepath.Join(dir, "foo.go"), "package foo\n\nfunc Foo() string { return \"foo\" }\n")
+
+	importPath, err := golang.ImportPath(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ImportPath() failed: %v", err)
+	}
+	if importPath != "example.com/foo" {
+		t.Fatalf("ImportPath() should return %q; got %q", "example.com/foo", importPath)
+	}
+
+	testFile := filepath.Join(dir, "foo_example_test.go")
+
+	valid, err := golang.AddExamples(nil, "foo", importPath, "import \"fmt\"\n\nfunc ExampleFoo() {\n\tfmt.Println(foo.Foo())\n}")
+	if err != nil {
+		t.Fatalf("AddExamples() failed: %v", err)
+	}
+	if err := golang.VetFiles(context.Background(), dir, map[string][]byte{testFile: valid}); err != nil {
+		t.Errorf("VetFiles() should succeed for a valid example; got %v", err)
+	}
+
+	invalid, err := golang.AddExamples(nil, "foo", importPath, "func ExampleBar() {\n\tfoo.Bar()\n}")
+	if err != nil {
+		t.Fatalf("AddExamples() failed: %v", err)
+	}
+	err = golang.VetFiles(context.Background(), dir, map[string][]byte{testFile: invalid})
+	if err == nil || !strings.Contains(err.Error(), "Bar") {
+		t.Errorf("VetFiles() should fail for an example that refers to an unknown symbol; got %v", err)
+	}
+
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Errorf("VetFiles() should not write the test file")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
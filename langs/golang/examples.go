@@ -0,0 +1,119 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+)
+
+// MinExampleWords is the minimum number of words of a doc comment for the
+// documented declaration to be used as a few-shot example.
+var MinExampleWords = 8
+
+// Examples returns the exported functions, methods and types in code whose doc
+// comments have at least [MinExampleWords] words. The code of each example is
+// the doc comment and the declaration of the symbol without the function body.
+// Test files are skipped.
+func (svc *Service) Examples(file string, code []byte) ([]generate.Example, error) {
+	if strings.HasSuffix(file, "_test.go") {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, file, code, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	var examples []generate.Example
+	add := func(identifier string, doc *ast.CommentGroup, decl ast.Node) error {
+		if !wellDocumented(doc) {
+			return nil
+		}
+
+		var buf bytes.Buffer
+		for _, line := range strings.Split(strings.TrimSpace(doc.Text()), "\n") {
+			buf.WriteString(strings.TrimSpace("// "+line) + "\n")
+		}
+		if err := printer.Fprint(&buf, fset, decl); err != nil {
+			return fmt.Errorf("print %s: %w", identifier, err)
+		}
+
+		examples = append(examples, generate.Example{Identifier: identifier, Code: buf.String()})
+		return nil
+	}
+
+	for _, decl := range node.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !decl.Name.IsExported() {
+				continue
+			}
+			identifier := "func:" + decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				recv := decl.Recv.List[0].Type
+				name := receiverName(recv)
+				if !ast.IsExported(name) {
+					continue
+				}
+				if _, ok := recv.(*ast.StarExpr); ok {
+					name = "(*" + name + ")"
+				}
+				identifier = fmt.Sprintf("func:%s.%s", name, decl.Name.Name)
+			}
+
+			signature := *decl
+			signature.Doc, signature.Body = nil, nil
+			if err := add(identifier, decl.Doc, &signature); err != nil {
+				return nil, err
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if !spec.Name.IsExported() {
+					continue
+				}
+
+				doc := spec.Doc
+				if doc == nil && len(decl.Specs) == 1 {
+					doc = decl.Doc
+				}
+
+				typ := *spec
+				typ.Doc, typ.Comment = nil, nil
+				if err := add("type:"+spec.Name.Name, doc, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&typ}}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return examples, nil
+}
+
+func wellDocumented(doc *ast.CommentGroup) bool {
+	return doc != nil && len(strings.Fields(doc.Text())) >= MinExampleWords
+}
+
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
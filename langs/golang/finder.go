@@ -1,10 +1,13 @@
 package golang
 
 import (
+	"context"
 	"fmt"
 	"go/parser"
 	"go/token"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
@@ -14,14 +17,22 @@ import (
 
 // Finder locates identifiers in Go source code, taking into account options for
 // including test functions and documented entities. It analyzes the provided
-// code to produce a sorted list of exported names. The search can be customized
-// through options to either include or exclude test functions and documented
-// identifiers. When examining interface types, it also identifies and includes
-// their exported methods. Finder returns a slice of strings representing the
-// found identifiers and any errors encountered during the analysis process.
+// code to produce a sorted list of exported names, or of all names if
+// [IncludeUnexported] is enabled. The search can be customized through options
+// to either include or exclude test functions and documented identifiers.
+// When examining interface types, it also identifies and includes their
+// exported methods. Finder returns a slice of strings representing the found
+// identifiers and any errors encountered during the analysis process.
 type Finder struct {
 	findTests         bool
+	findBenchmarks    bool
+	findFuzz          bool
+	findExamples      bool
+	testsInAnyFile    bool
 	includeDocumented bool
+	includeUnexported bool
+	findHelp          bool
+	documentedFunc    func(file string) bool
 }
 
 // FinderOption configures the behavior of a [*Finder] by setting its internal
@@ -41,6 +52,40 @@ func FindTests(find bool) FinderOption {
 	}
 }
 
+// FindBenchmarks configures whether a Finder includes benchmark functions
+// (BenchmarkXxx) in its findings. Benchmarks are excluded by default.
+func FindBenchmarks(find bool) FinderOption {
+	return func(f *Finder) {
+		f.findBenchmarks = find
+	}
+}
+
+// FindFuzz configures whether a Finder includes fuzz targets (FuzzXxx) in its
+// findings. Fuzz targets are excluded by default.
+func FindFuzz(find bool) FinderOption {
+	return func(f *Finder) {
+		f.findFuzz = find
+	}
+}
+
+// FindExamples configures whether a Finder includes example functions
+// (ExampleXxx) in its findings. Examples are excluded by default.
+func FindExamples(find bool) FinderOption {
+	return func(f *Finder) {
+		f.findExamples = find
+	}
+}
+
+// TestsInAnyFile configures whether a Finder treats functions such as TestXxx
+// or BenchmarkXxx as tests in files that are not "_test.go" files. By default,
+// such functions are only considered tests within "_test.go" files, so exported
+// helpers like TestServer in regular files are documented.
+func TestsInAnyFile(any bool) FinderOption {
+	return func(f *Finder) {
+		f.testsInAnyFile = any
+	}
+}
+
 // IncludeDocumented configures a Finder to consider documented entities during
 // the search. When set to true, entities with associated documentation will be
 // included in the findings; otherwise, they will be excluded. This option is
@@ -51,8 +96,31 @@ func IncludeDocumented(include bool) FinderOption {
 	}
 }
 
+// IncludeDocumentedFunc configures a Finder to decide for each file whether to
+// consider documented entities, e.g. to override existing documentation only
+// in some directories. include is called with the file that is passed to
+// [*Finder.Find] and takes precedence over [IncludeDocumented].
+func IncludeDocumentedFunc(include func(file string) bool) FinderOption {
+	return func(f *Finder) {
+		f.documentedFunc = include
+	}
+}
+
+// IncludeUnexported configures whether a Finder includes unexported functions,
+// methods, types, variables and constants in its findings, e.g. to document the
+// internals of a package. Blank identifiers and init functions are never
+// included. Unexported identifiers are excluded by default.
+func IncludeUnexported(include bool) FinderOption {
+	return func(f *Finder) {
+		f.includeUnexported = include
+	}
+}
+
 // NewFinder constructs a new Finder with optional configurations provided by
-// FinderOptions. It returns a pointer to the initialized Finder.
+// FinderOptions. It returns a pointer to the initialized Finder. Note that
+// benchmarks, fuzz targets and examples are excluded like test functions
+// unless [FindBenchmarks], [FindFuzz] or [FindExamples] are enabled; earlier
+// versions only excluded test functions.
 func NewFinder(opts ...FinderOption) *Finder {
 	var f Finder
 	for _, opt := range opts {
@@ -67,9 +135,21 @@ func NewFinder(opts ...FinderOption) *Finder {
 // another issue occurs. Identifiers from function declarations, type
 // specifications, and value specifications are included unless they are
 // filtered out by the Finder's settings, such as excluding test functions or
-// documented identifiers.
-func (f *Finder) Find(code []byte) ([]string, error) {
+// documented identifiers. Test functions are only skipped if file is a
+// "_test.go" file, unless [TestsInAnyFile] is enabled. If file is empty, test
+// functions are detected by their names alone.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	includeDocumented := f.includeDocumented
+	if f.documentedFunc != nil {
+		includeDocumented = f.documentedFunc(file)
+	}
+
 	var findings []string
+	isTestFile := file == "" || f.testsInAnyFile || strings.HasSuffix(file, "_test.go")
 
 	fset := token.NewFileSet()
 	node, err := decorator.ParseFile(fset, "", code, parser.ParseComments|parser.SkipObjectResolution)
@@ -81,19 +161,22 @@ func (f *Finder) Find(code []byte) ([]string, error) {
 
 		switch node := node.(type) {
 		case *dst.FuncDecl:
-			if !f.findTests && isTestFunction(node) {
+			if isTestFile && f.skipTestFunction(node) {
 				break
 			}
 
-			if !f.includeDocumented && nodes.HasDoc(node.Decs.NodeDecs.Start) {
+			if !includeDocumented && nodes.HasDoc(node.Decs.NodeDecs.Start) {
 				break
 			}
 
-			if identifier, exported := nodes.Identifier(node); exported {
+			if identifier, exported := nodes.Identifier(node); f.include(identifier, exported) {
 				findings = append(findings, identifier)
 			}
 		case *dst.GenDecl:
-			if !f.includeDocumented && nodes.HasDoc(node.Decs.NodeDecs.Start) {
+			// The doc comment of a grouped const or var declaration documents
+			// all of its specs, but the specs of a grouped type declaration are
+			// documented individually.
+			if !includeDocumented && node.Tok != token.TYPE && nodes.HasDoc(node.Decs.NodeDecs.Start) {
 				break
 			}
 
@@ -104,18 +187,18 @@ func (f *Finder) Find(code []byte) ([]string, error) {
 			for _, spec := range node.Specs {
 				switch spec := spec.(type) {
 				case *dst.TypeSpec:
-					if f.includeDocumented || !nodes.HasDoc(spec.Decs.NodeDecs.Start) {
-						if identifier, exported := nodes.Identifier(spec); exported {
+					if includeDocumented || !typeDocumented(spec, node) {
+						if identifier, exported := nodes.Identifier(spec); f.include(identifier, exported) {
 							findings = append(findings, identifier)
 						}
 					}
 
 					if isInterface(spec) {
-						findings = append(findings, f.findInterfaceMethods(spec)...)
+						findings = append(findings, f.findInterfaceMethods(spec, includeDocumented)...)
 					}
 				case *dst.ValueSpec:
-					if f.includeDocumented || !nodes.HasDoc(spec.Decs.NodeDecs.Start) {
-						if identifier, exported := nodes.Identifier(spec); exported {
+					if includeDocumented || !nodes.HasDoc(spec.Decs.NodeDecs.Start) {
+						if identifier, exported := nodes.Identifier(spec); f.include(identifier, exported) {
 							findings = append(findings, identifier)
 						}
 					}
@@ -124,12 +207,16 @@ func (f *Finder) Find(code []byte) ([]string, error) {
 		}
 	}
 
+	if f.findHelp {
+		findings = append(findings, findHelpTargets(node)...)
+	}
+
 	slices.Sort(findings)
 
 	return findings, nil
 }
 
-func (f *Finder) findInterfaceMethods(spec *dst.TypeSpec) []string {
+func (f *Finder) findInterfaceMethods(spec *dst.TypeSpec, includeDocumented bool) []string {
 	var findings []string
 
 	ifaceName := spec.Name.Name
@@ -139,7 +226,7 @@ func (f *Finder) findInterfaceMethods(spec *dst.TypeSpec) []string {
 		}
 		name := method.Names[0].Name
 		ident := fmt.Sprintf("func:%s.%s", ifaceName, name)
-		if nodes.IsExportedIdentifier(ident) && (f.includeDocumented || !nodes.HasDoc(method.Decs.Start)) {
+		if f.include(ident, nodes.IsExportedIdentifier(ident)) && (includeDocumented || !nodes.HasDoc(method.Decs.Start)) {
 			findings = append(findings, ident)
 		}
 	}
@@ -147,11 +234,64 @@ func (f *Finder) findInterfaceMethods(spec *dst.TypeSpec) []string {
 	return findings
 }
 
+// include reports whether identifier should be part of the findings, given
+// whether it is exported.
+func (f *Finder) include(identifier string, exported bool) bool {
+	if exported {
+		return true
+	}
+	if !f.includeUnexported {
+		return false
+	}
+	name := nodes.StripIdentifierPrefix(identifier)
+	return name != "" && name != "_" && identifier != "func:init"
+}
+
+// typeDocumented reports whether a type spec has a doc comment. Like go/doc,
+// it considers the doc comment of the type declaration only if the spec is the
+// only spec of the declaration.
+func typeDocumented(spec *dst.TypeSpec, decl *dst.GenDecl) bool {
+	if nodes.HasDoc(spec.Decs.NodeDecs.Start) {
+		return true
+	}
+	return len(decl.Specs) == 1 && nodes.HasDoc(decl.Decs.NodeDecs.Start)
+}
+
 func isInterface(spec *dst.TypeSpec) bool {
 	_, ok := spec.Type.(*dst.InterfaceType)
 	return ok
 }
 
-func isTestFunction(node *dst.FuncDecl) bool {
-	return strings.HasPrefix(node.Name.Name, "Test")
+func (f *Finder) skipTestFunction(node *dst.FuncDecl) bool {
+	if node.Recv != nil {
+		return false
+	}
+
+	name := node.Name.Name
+	switch {
+	case isTestFunction(name, "Test"):
+		return !f.findTests
+	case isTestFunction(name, "Benchmark"):
+		return !f.findBenchmarks
+	case isTestFunction(name, "Fuzz"):
+		return !f.findFuzz
+	case isTestFunction(name, "Example"):
+		return !f.findExamples
+	}
+
+	return false
+}
+
+// isTestFunction reports whether name is the name of a test function with the
+// given prefix, using the same rules as "go test": the prefix must not be
+// followed by a lowercase letter.
+func isTestFunction(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
 }
@@ -1,9 +1,12 @@
 package golang_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/analyze"
 	"github.com/modernice/jotbot/internal/tests"
 	"github.com/modernice/jotbot/langs/golang"
 )
@@ -33,7 +36,7 @@ func TestFinder_Find(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -68,7 +71,7 @@ func TestFinder_Find_onlyUncommented(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -100,7 +103,7 @@ func TestFinder_Find_pointerReceiver(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -139,7 +142,7 @@ func TestFinder_Find_generics(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -168,7 +171,7 @@ func TestFinder_Find_excludesTestsByDefault(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -190,7 +193,7 @@ func TestFinder_Find_variableList(t *testing.T) {
 
 	f := golang.NewFinder()
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -198,6 +201,34 @@ func TestFinder_Find_variableList(t *testing.T) {
 	tests.ExpectIdentifiers(t, []string{"var:Foo", "var:Bar"}, findings)
 }
 
+func TestFinder_Find_typeList(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Types of the package.
+		type (
+			Foo struct{}
+
+			// Bar is a bar.
+			Bar struct{}
+		)
+
+		// Values of the package.
+		const (
+			Baz = "baz"
+		)
+	`)
+
+	f := golang.NewFinder()
+
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"type:Foo"}, findings)
+}
+
 func TestFindTests(t *testing.T) {
 	code := heredoc.Doc(`
 		package foo
@@ -213,7 +244,7 @@ func TestFindTests(t *testing.T) {
 
 	f := golang.NewFinder(golang.FindTests(true))
 
-	findings, err := f.Find([]byte(code))
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -224,3 +255,314 @@ func TestFindTests(t *testing.T) {
 		"func:Foobar",
 	}, findings)
 }
+
+func TestFinder_Find_excludesBenchmarksFuzzAndExamplesByDefault(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "testing"
+
+		func BenchmarkFoo(b *testing.B) {}
+
+		func FuzzFoo(f *testing.F) {}
+
+		func ExampleFoo() {}
+
+		func Example() {}
+
+		func Examples() {}
+	`)
+
+	f := golang.NewFinder()
+
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:Examples",
+	}, findings)
+}
+
+func TestFindBenchmarks(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "testing"
+
+		func TestFoo(t *testing.T) {}
+
+		func BenchmarkFoo(b *testing.B) {}
+
+		func FuzzFoo(f *testing.F) {}
+
+		func ExampleFoo() {}
+	`)
+
+	f := golang.NewFinder(golang.FindBenchmarks(true))
+
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:BenchmarkFoo"}, findings)
+}
+
+func TestFindFuzz(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "testing"
+
+		func BenchmarkFoo(b *testing.B) {}
+
+		func FuzzFoo(f *testing.F) {}
+	`)
+
+	f := golang.NewFinder(golang.FindFuzz(true))
+
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:FuzzFoo"}, findings)
+}
+
+func TestFindExamples(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		func FuzzFoo() {}
+
+		func ExampleFoo() {}
+
+		func Example() {}
+	`)
+
+	f := golang.NewFinder(golang.FindExamples(true))
+
+	findings, err := f.Find(context.Background(), "foo_test.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:ExampleFoo", "func:Example"}, findings)
+}
+
+func TestFinder_Find_testsOnlyInTestFiles(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "testing"
+
+		func TestServer() {}
+
+		func TestFoo(t *testing.T) {}
+	`)
+
+	f := golang.NewFinder()
+
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:TestServer", "func:TestFoo"}, findings)
+
+	findings, err = f.Find(context.Background(), "foo_test.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, nil, findings)
+}
+
+func TestTestsInAnyFile(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		func TestServer() {}
+
+		func Foo() {}
+	`)
+
+	f := golang.NewFinder(golang.TestsInAnyFile(true))
+
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:Foo"}, findings)
+}
+
+func TestIncludeDocumentedFunc(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo is documented.
+		func Foo() {}
+
+		func Bar() {}
+	`)
+
+	f := golang.NewFinder(golang.IncludeDocumented(true), golang.IncludeDocumentedFunc(func(file string) bool {
+		return file == "internal/foo.go"
+	}))
+
+	findings, err := f.Find(context.Background(), "internal/foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	tests.ExpectIdentifiers(t, []string{"func:Bar", "func:Foo"}, findings)
+
+	findings, err = f.Find(context.Background(), "api/foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	tests.ExpectIdentifiers(t, []string{"func:Bar"}, findings)
+}
+
+func TestIncludeUnexported(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		func init() {}
+
+		func foo() {}
+
+		func Foo() {}
+
+		type bar struct{}
+
+		func (bar) baz() {}
+
+		type qux interface {
+			quux()
+		}
+
+		var _ = foo
+
+		const limit = 3
+	`)
+
+	findings, err := golang.NewFinder().Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	tests.ExpectIdentifiers(t, []string{"func:Foo"}, findings)
+
+	findings, err = golang.NewFinder(golang.IncludeUnexported(true)).Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	tests.ExpectIdentifiers(t, []string{
+		"func:Foo",
+		"func:bar.baz",
+		"func:foo",
+		"func:qux.quux",
+		"type:bar",
+		"type:qux",
+		"var:limit",
+	}, findings)
+}
+
+func TestFinder_Symbols(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo is a foo.
+		// It foos.
+		func Foo() {}
+
+		func Bar() {}
+
+		// Grouped values.
+		var (
+			X = "x"
+			Y = "y"
+		)
+
+		type Baz interface {
+			// Qux quxes.
+			Qux()
+		}
+
+		func unexported() {}
+	`)
+
+	symbols, err := golang.NewFinder(golang.IncludeDocumented(true)).Symbols(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Symbols() failed: %v", err)
+	}
+
+	want := []analyze.Symbol{
+		{Identifier: "func:Bar"},
+		{Identifier: "func:Baz.Qux", Documented: true, Doc: "Qux quxes."},
+		{Identifier: "func:Foo", Documented: true, Doc: "Foo is a foo.\nIt foos."},
+		{Identifier: "type:Baz"},
+		{Identifier: "var:X", Documented: true, Doc: "Grouped values."},
+		{Identifier: "var:Y", Documented: true, Doc: "Grouped values."},
+	}
+
+	if diff := cmp.Diff(want, symbols); diff != "" {
+		t.Fatalf("unexpected symbols (-want +got):\n%s", diff)
+	}
+}
+
+func TestFindHelp(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// CLI is the command-line interface.
+		type CLI struct {
+			Serve struct {
+				Port int    ` + "`" + `name:"port" default:"8080"` + "`" + `
+				Host string ` + "`" + `name:"host" help:"Host to listen on"` + "`" + `
+			} ` + "`" + `cmd:""` + "`" + `
+
+			Verbose bool ` + "`" + `short:"v" help:""` + "`" + `
+			Hidden  bool ` + "`" + `name:"hidden" hidden:""` + "`" + `
+		}
+
+		func commands() {
+			cmd := &cobra.Command{Use: "serve [flags]"}
+			cmd.Flags().StringP("root", "r", ".", "")
+			cmd.Flags().IntVar(&port, "port", 0, "Port to listen on")
+
+			_ = &cli.Command{
+				Name:  "lint",
+				Usage: "Lint the code",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "fix"},
+				},
+			}
+		}
+	`)
+
+	f := golang.NewFinder(golang.FindHelp(true))
+
+	findings, err := f.Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"help:CLI.Serve",
+		"help:CLI.Serve.Port",
+		"help:CLI.Verbose",
+		"help:command(serve)",
+		"help:flag(fix)",
+		"help:flag(root)",
+	}, findings)
+
+	findings, err = golang.NewFinder().Find(context.Background(), "foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, nil, findings)
+}
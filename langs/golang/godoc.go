@@ -0,0 +1,168 @@
+package golang
+
+import (
+	"go/doc/comment"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal"
+)
+
+var (
+	// markdownListItemRE matches the items of Markdown lists, which models
+	// write without indentation, e.g. "- Foo" or "1. Foo".
+	markdownListItemRE = regexp.MustCompile(`^\s*(?:[-*+•]|[0-9]+[.)])\s+\S`)
+
+	// markdownHeadingRE matches Markdown headings, e.g. "## Foo".
+	markdownHeadingRE = regexp.MustCompile(`^#{1,6}\s+(\S.*)$`)
+)
+
+// parseGodoc parses a generated comment as a Go doc comment, after converting
+// the Markdown syntax that models tend to use: fenced code blocks become code
+// blocks, list items are indented, and headings of any level become "# Heading"
+// lines. The code of fenced code blocks is not parsed, so that lists after a
+// code block do not become part of it.
+func parseGodoc(doc string) *comment.Doc {
+	var parser comment.Parser
+	out := &comment.Doc{}
+
+	var text, code []string
+	var fenced bool
+	flush := func() {
+		// The parser removes the indentation that all lines have in common,
+		// which would turn a text that consists of a list into a paragraph.
+		// An unindented paragraph in front of the text prevents that.
+		parsed := parser.Parse("-\n\n" + godocText(strings.Join(text, "\n")))
+		out.Content = append(out.Content, parsed.Content[1:]...)
+		out.Links = append(out.Links, parsed.Links...)
+		text = nil
+	}
+
+	for _, line := range strings.Split(doc, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if fenced {
+				code = append(code, line)
+			} else {
+				text = append(text, line)
+			}
+			continue
+		}
+
+		if fenced = !fenced; fenced {
+			flush()
+			continue
+		}
+		if len(code) > 0 {
+			out.Content = append(out.Content, &comment.Code{Text: strings.Join(code, "\n") + "\n"})
+		}
+		code = nil
+	}
+	text = append(text, code...)
+	flush()
+
+	return out
+}
+
+// godocText converts the Markdown lists and headings in text into the syntax
+// of Go doc comments.
+func godocText(text string) string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case markdownHeadingRE.MatchString(trimmed):
+			out = append(out, "", "# "+markdownHeadingRE.FindStringSubmatch(trimmed)[1], "")
+		case markdownListItemRE.MatchString(line):
+			out = append(out, "  "+trimmed)
+		default:
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// printDoc prints a parsed Go doc comment without comment markers. Unlike
+// [comment.Printer.Comment], printDoc wraps the text of paragraphs and list
+// items at the given width. Code blocks and headings are printed as they are.
+func printDoc(doc *comment.Doc, width int) []string {
+	var lines []string
+	for i, block := range doc.Content {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		switch block := block.(type) {
+		case *comment.Paragraph:
+			lines = append(lines, internal.Columns(paragraphText(block), width)...)
+		case *comment.List:
+			// Indented lines that directly follow a code block would become
+			// part of it, so such lists are printed without indentation.
+			var afterCode bool
+			if i > 0 {
+				_, afterCode = doc.Content[i-1].(*comment.Code)
+			}
+			for j, item := range block.Items {
+				if j > 0 && block.BlankBetween() {
+					lines = append(lines, "")
+				}
+
+				marker := "  - "
+				if item.Number != "" {
+					marker = " " + item.Number + ". "
+				}
+				if afterCode {
+					marker = strings.TrimLeft(marker, " ")
+				}
+
+				for k, content := range item.Content {
+					para, ok := content.(*comment.Paragraph)
+					if !ok {
+						continue
+					}
+					if k > 0 {
+						lines = append(lines, "")
+					}
+					for l, line := range internal.Columns(paragraphText(para), width-len(marker)) {
+						if k == 0 && l == 0 {
+							lines = append(lines, marker+line)
+							continue
+						}
+						if afterCode {
+							lines = append(lines, line)
+							continue
+						}
+						lines = append(lines, strings.Repeat(" ", len(marker))+line)
+					}
+				}
+			}
+		default:
+			lines = append(lines, printBlocks(&comment.Doc{Content: []comment.Block{block}})...)
+		}
+	}
+
+	if len(doc.Links) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, printBlocks(&comment.Doc{Links: doc.Links})...)
+	}
+
+	return lines
+}
+
+// canonicalDoc parses and prints the lines of a doc comment without comment
+// markers, like gofmt does. Because the parser keeps the line breaks of
+// paragraphs, the printed lines differ only where gofmt would change them,
+// e.g. where a wrapped line would be interpreted as a heading.
+func canonicalDoc(lines []string) []string {
+	var parser comment.Parser
+	return printBlocks(parser.Parse(strings.Join(lines, "\n")))
+}
+
+// paragraphText returns the text of a paragraph on a single line.
+func paragraphText(para *comment.Paragraph) string {
+	return strings.Join(printBlocks(&comment.Doc{Content: []comment.Block{para}}), " ")
+}
+
+func printBlocks(doc *comment.Doc) []string {
+	var printer comment.Printer
+	return strings.Split(strings.TrimRight(string(printer.Comment(doc)), "\n"), "\n")
+}
@@ -0,0 +1,338 @@
+package golang
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/dave/dst"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+)
+
+// HelpPrefix is the prefix of the identifiers of help strings of command-line
+// commands and flags, which are found if [FindHelp] is enabled. The
+// identifiers have one of the following forms:
+//   - "help:Type.Field" for fields of kong command structs, e.g.
+//     "help:CLI.Serve.Port"
+//   - "help:command(name)" for cobra and urfave/cli commands
+//   - "help:flag(name)" for cobra and urfave/cli flags
+const HelpPrefix = "help:"
+
+// kongTags are the struct tags that mark a field as a command, argument or
+// flag of a kong CLI.
+var kongTags = []string{"cmd", "arg", "name", "short", "env", "default", "enum", "placeholder", "required", "negatable", "help"}
+
+// cobraFlagRE matches the names of the methods of pflag.FlagSet that define
+// flags, e.g. "StringVarP".
+var cobraFlagRE = regexp.MustCompile(`^[A-Z]\w*?(Var)?(P)?$`)
+
+// FindHelp configures a Finder to also find the commands and flags of
+// command-line interfaces that have no help string. Supported are the command
+// structs of kong (fields without a "help" tag), cobra commands without a
+// "Short" description and flags with an empty usage string, and urfave/cli
+// commands and flags without "Usage". See [HelpPrefix] for the identifiers of
+// the help strings.
+func FindHelp(find bool) FinderOption {
+	return func(f *Finder) {
+		f.findHelp = find
+	}
+}
+
+// helpTarget is a help string of a command or flag in the syntax tree.
+type helpTarget struct {
+	identifier string
+	help       string
+
+	// set replaces the help string in the syntax tree.
+	set func(help string)
+}
+
+// helpTargets returns the help strings of the commands and flags that are
+// declared in file.
+func helpTargets(file *dst.File) []helpTarget {
+	var targets []helpTarget
+
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*dst.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			if spec, ok := spec.(*dst.TypeSpec); ok {
+				if st, ok := spec.Type.(*dst.StructType); ok {
+					targets = append(targets, kongTargets(spec.Name.Name, st)...)
+				}
+			}
+		}
+	}
+
+	dst.Inspect(file, func(node dst.Node) bool {
+		switch node := node.(type) {
+		case *dst.CompositeLit:
+			if t, ok := literalTarget(node); ok {
+				targets = append(targets, t)
+			}
+		case *dst.CallExpr:
+			if t, ok := cobraFlagTarget(node); ok {
+				targets = append(targets, t)
+			}
+		}
+		return true
+	})
+
+	return targets
+}
+
+// kongTargets returns the help strings of the fields of a kong command
+// struct, including the fields of nested anonymous structs.
+func kongTargets(path string, st *dst.StructType) []helpTarget {
+	var targets []helpTarget
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			continue
+		}
+
+		if field.Tag != nil {
+			tag := reflect.StructTag(unquote(field.Tag.Value))
+			if isKongField(tag) {
+				help, _ := tag.Lookup("help")
+				field := field
+				for _, name := range field.Names {
+					targets = append(targets, helpTarget{
+						identifier: HelpPrefix + path + "." + name.Name,
+						help:       help,
+						set: func(help string) {
+							field.Tag.Value = "`" + setTag(unquote(field.Tag.Value), "help", help) + "`"
+						},
+					})
+				}
+			}
+		}
+
+		if nested, ok := field.Type.(*dst.StructType); ok {
+			targets = append(targets, kongTargets(path+"."+field.Names[0].Name, nested)...)
+		}
+	}
+	return targets
+}
+
+func isKongField(tag reflect.StructTag) bool {
+	if _, ok := tag.Lookup("embed"); ok {
+		return false
+	}
+	if _, ok := tag.Lookup("hidden"); ok {
+		return false
+	}
+	for _, key := range kongTags {
+		if _, ok := tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setTag sets the value of the given key in a struct tag, appending the key
+// if the tag does not contain it.
+func setTag(tag, key, value string) string {
+	quoted := key + ":" + strconv.Quote(value)
+	re := regexp.MustCompile(`(?:^|\s)(` + regexp.QuoteMeta(key) + `:"(?:[^"\\]|\\.)*")`)
+	if loc := re.FindStringSubmatchIndex(tag); loc != nil {
+		return tag[:loc[2]] + quoted + tag[loc[3]:]
+	}
+	if tag == "" {
+		return quoted
+	}
+	return tag + " " + quoted
+}
+
+// literalTarget returns the help string of a cobra.Command, cli.Command or
+// cli.*Flag composite literal.
+func literalTarget(lit *dst.CompositeLit) (helpTarget, bool) {
+	sel, ok := lit.Type.(*dst.SelectorExpr)
+	if !ok {
+		return helpTarget{}, false
+	}
+
+	fields := make(map[string]*dst.KeyValueExpr)
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*dst.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*dst.Ident); ok {
+				fields[key.Name] = kv
+			}
+		}
+	}
+
+	var kind, name, helpKey string
+	switch {
+	case sel.Sel.Name == "Command" && fields["Use"] != nil:
+		kind, helpKey = "command", "Short"
+		name, _, _ = strings.Cut(stringValue(fields["Use"].Value), " ")
+	case sel.Sel.Name == "Command" && fields["Name"] != nil:
+		kind, helpKey = "command", "Usage"
+		name = stringValue(fields["Name"].Value)
+	case strings.HasSuffix(sel.Sel.Name, "Flag") && fields["Name"] != nil:
+		kind, helpKey = "flag", "Usage"
+		name = stringValue(fields["Name"].Value)
+	}
+	if kind == "" || name == "" {
+		return helpTarget{}, false
+	}
+
+	var help string
+	if kv := fields[helpKey]; kv != nil {
+		lit, ok := kv.Value.(*dst.BasicLit)
+		if !ok {
+			// The help string is not a literal, e.g. a constant.
+			return helpTarget{}, false
+		}
+		help = stringValue(lit)
+	}
+
+	return helpTarget{
+		identifier: fmt.Sprintf("%s%s(%s)", HelpPrefix, kind, name),
+		help:       help,
+		set: func(help string) {
+			value := &dst.BasicLit{Kind: token.STRING, Value: strconv.Quote(help)}
+			if kv := fields[helpKey]; kv != nil {
+				kv.Value = value
+				return
+			}
+			kv := &dst.KeyValueExpr{Key: dst.NewIdent(helpKey), Value: value}
+			kv.Decs.Before, kv.Decs.After = dst.NewLine, dst.NewLine
+			lit.Elts = append(lit.Elts, kv)
+		},
+	}, true
+}
+
+// cobraFlagTarget returns the usage string of a flag that is defined on the
+// flag set of a cobra command, e.g. cmd.Flags().StringP("name", "n", "", "").
+func cobraFlagTarget(call *dst.CallExpr) (helpTarget, bool) {
+	sel, ok := call.Fun.(*dst.SelectorExpr)
+	if !ok {
+		return helpTarget{}, false
+	}
+
+	recv, ok := sel.X.(*dst.CallExpr)
+	if !ok {
+		return helpTarget{}, false
+	}
+	if recvSel, ok := recv.Fun.(*dst.SelectorExpr); !ok || (recvSel.Sel.Name != "Flags" && recvSel.Sel.Name != "PersistentFlags") {
+		return helpTarget{}, false
+	}
+
+	m := cobraFlagRE.FindStringSubmatch(sel.Sel.Name)
+	if m == nil {
+		return helpTarget{}, false
+	}
+
+	nameArg := 0
+	if m[1] != "" {
+		nameArg = 1
+	}
+	if len(call.Args) < nameArg+3 {
+		return helpTarget{}, false
+	}
+
+	name := stringValue(call.Args[nameArg])
+	usage, ok := call.Args[len(call.Args)-1].(*dst.BasicLit)
+	if name == "" || !ok {
+		return helpTarget{}, false
+	}
+
+	return helpTarget{
+		identifier: fmt.Sprintf("%sflag(%s)", HelpPrefix, name),
+		help:       stringValue(usage),
+		set: func(help string) {
+			usage.Value = strconv.Quote(help)
+		},
+	}, true
+}
+
+func stringValue(expr dst.Expr) string {
+	lit, ok := expr.(*dst.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	return unquote(lit.Value)
+}
+
+func unquote(s string) string {
+	if v, err := strconv.Unquote(s); err == nil {
+		return v
+	}
+	return s
+}
+
+// findHelpTargets returns the identifiers of the help strings in file that
+// are missing.
+func findHelpTargets(file *dst.File) []string {
+	var found []string
+	for _, t := range helpTargets(file) {
+		if strings.TrimSpace(t.help) == "" {
+			found = append(found, t.identifier)
+		}
+	}
+	return found
+}
+
+// patchHelp sets the help string identified by identifier to the first
+// paragraph of doc.
+func patchHelp(file *dst.File, identifier, doc string) error {
+	for _, t := range helpTargets(file) {
+		if t.identifier == identifier {
+			t.set(formatHelp(doc))
+			return nil
+		}
+	}
+	return fmt.Errorf("help string %q not found", identifier)
+}
+
+// formatHelp formats a generated help string as a single line without a
+// trailing period, following the conventions of command-line help.
+func formatHelp(doc string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(doc), "\n\n")
+	help := internal.RemoveColumns(strings.Trim(strings.TrimSpace(paragraph), `"`))
+	// Kong help strings are part of raw struct tags.
+	help = strings.ReplaceAll(help, "`", "'")
+	if strings.Count(help, ".") == 1 {
+		help = strings.TrimSuffix(help, ".")
+	}
+	return help
+}
+
+// HelpPrompt returns the prompt that asks for the help string of the command
+// or flag identified by the input. See [HelpPrefix].
+func HelpPrompt(input generate.PromptInput) string {
+	target := strings.TrimPrefix(input.Identifier, HelpPrefix)
+
+	kind := "command-line flag or argument"
+	switch {
+	case strings.HasPrefix(target, "command("):
+		kind, target = "command-line command", strings.TrimSuffix(strings.TrimPrefix(target, "command("), ")")
+	case strings.HasPrefix(target, "flag("):
+		kind, target = "command-line flag", strings.TrimSuffix(strings.TrimPrefix(target, "flag("), ")")
+	}
+
+	return heredoc.Docf(`
+		Write the help text for the %s %q, which is shown in the output of "--help". If the Go code already documents it, for example in a doc comment, keep the help text consistent with the documentation.
+
+		The help text must be a single short line that starts with a capital letter and does not end with a period, e.g. "Root directory of the repository".
+
+		Output only the unquoted help text.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		kind,
+		target,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,276 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modernice/jotbot/generate"
+)
+
+// docLinkRE matches doc links such as "[Foo]", "[*Foo]", "[Foo.Bar]",
+// "[io.Reader]" and "[encoding/json.Decoder]" that are not link definitions
+// ("[Foo]: https://...") or Markdown links.
+var docLinkRE = regexp.MustCompile(`\[(\*?)((?:[\w.-]+/)*[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)\]([^:(]|$)`)
+
+// versionRE matches the major version suffix of a module path, e.g. "v2".
+var versionRE = regexp.MustCompile(`^v[0-9]+$`)
+
+// Links returns the doc links in doc that can be validated, e.g. "[Foo]",
+// "[Foo.Bar]" or "[json.Decoder]". Links to packages of the standard library
+// by their import path, such as "[io.Reader]" or "[encoding/json.Decoder]",
+// are validated against the standard library and only returned if the symbol
+// does not exist. Links to predeclared identifiers, such as "[error]", and to
+// other packages by their full import path are omitted.
+func (svc *Service) Links(doc string) []generate.Link {
+	var links []generate.Link
+	for _, m := range docLinkRE.FindAllStringSubmatch(doc, -1) {
+		target := m[2]
+		if r, _ := utf8.DecodeRuneInString(target); !unicode.IsUpper(r) {
+			pkg, symbol, ok := splitDocLink(target)
+			if !ok {
+				continue
+			}
+			if std, ok := loadStdPackage(pkg); ok {
+				if std.names[symbol] {
+					continue
+				}
+			} else if strings.Contains(pkg, "/") {
+				continue
+			}
+		}
+		links = append(links, generate.Link{
+			Text:   "[" + m[1] + target + "]",
+			Target: target,
+			Plain:  m[1] + target,
+		})
+	}
+	return links
+}
+
+// RewriteLink returns the text of link with its target replaced by target.
+func (svc *Service) RewriteLink(link generate.Link, target string) string {
+	if strings.HasPrefix(link.Text, "[*") {
+		return "[*" + target + "]"
+	}
+	return "[" + target + "]"
+}
+
+// splitDocLink splits the target of a doc link into the import path or name of
+// the package and the symbol, e.g. "encoding/json" and "Decoder" for
+// "encoding/json.Decoder". Targets without a symbol, such as predeclared
+// identifiers, are not split.
+func splitDocLink(target string) (pkg, symbol string, ok bool) {
+	slash := strings.LastIndex(target, "/")
+	dot := strings.Index(target[slash+1:], ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	dot += slash + 1
+	return target[:dot], target[dot+1:], true
+}
+
+// Names returns the names of the top-level declarations in code, and of the
+// methods and fields of its types in the form "Type.Name", which doc links
+// can refer to. For each package imported by code, Names also returns the
+// exported names of the package, qualified by the name under which it is
+// imported, e.g. "json.Decoder". The symbols of packages that are not part of
+// the standard library cannot be listed, so they are returned as "pkg.*".
+func (svc *Service) Names(file string, code []byte) ([]string, error) {
+	node, err := parser.ParseFile(token.NewFileSet(), file, code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	names := declNames(node)
+
+	for _, spec := range node.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		std, isStd := loadStdPackage(importPath)
+
+		var name string
+		switch {
+		case spec.Name != nil:
+			name = spec.Name.Name
+		case isStd:
+			name = std.name
+		default:
+			name = importName(importPath)
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+
+		if !isStd {
+			names = append(names, name+".*")
+			continue
+		}
+		for symbol := range std.names {
+			names = append(names, name+"."+symbol)
+		}
+	}
+
+	return names, nil
+}
+
+// importName returns the name of the package with the given import path,
+// assuming that the name of a package is the last element of its path,
+// ignoring major version suffixes.
+func importName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && versionRE.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+	return name
+}
+
+func declNames(node *ast.File) []string {
+	var names []string
+	for _, decl := range node.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				names = append(names, receiverName(decl.Recv.List[0].Type)+"."+decl.Name.Name)
+				continue
+			}
+			names = append(names, decl.Name.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+					names = append(names, memberNames(spec)...)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func memberNames(spec *ast.TypeSpec) []string {
+	var fields *ast.FieldList
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		fields = typ.Fields
+	case *ast.InterfaceType:
+		fields = typ.Methods
+	default:
+		return nil
+	}
+
+	var names []string
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names = append(names, spec.Name.Name+"."+name.Name)
+		}
+		if len(field.Names) == 0 {
+			// Embedded fields are named after their type.
+			if name := receiverName(field.Type); name != "" {
+				names = append(names, spec.Name.Name+"."+name)
+			}
+		}
+	}
+	return names
+}
+
+// stdPackage is a package of the standard library.
+type stdPackage struct {
+	name string
+
+	// names are the exported names of the package, as returned by declNames.
+	names map[string]bool
+
+	// interfaces are the interface types of the package, which are used to
+	// resolve the methods of embedded interfaces.
+	interfaces map[string]interfaceDecl
+}
+
+var stdPackages struct {
+	sync.Mutex
+	cache map[string]*stdPackage
+}
+
+// loadStdPackage loads the package of the standard library with the given
+// import path from GOROOT. It reports false if there is no such package.
+// Packages are loaded once and cached.
+func loadStdPackage(importPath string) (*stdPackage, bool) {
+	stdPackages.Lock()
+	defer stdPackages.Unlock()
+
+	if pkg, ok := stdPackages.cache[importPath]; ok {
+		return pkg, pkg != nil
+	}
+	if stdPackages.cache == nil {
+		stdPackages.cache = make(map[string]*stdPackage)
+	}
+
+	pkg := parseStdPackage(importPath)
+	stdPackages.cache[importPath] = pkg
+
+	return pkg, pkg != nil
+}
+
+func parseStdPackage(importPath string) *stdPackage {
+	first, _, _ := strings.Cut(importPath, "/")
+	if build.Default.GOROOT == "" || strings.Contains(first, ".") || path.Clean(importPath) != importPath {
+		return nil
+	}
+
+	bpkg, err := build.Default.ImportDir(filepath.Join(build.Default.GOROOT, "src", filepath.FromSlash(importPath)), 0)
+	if err != nil {
+		return nil
+	}
+
+	pkg := &stdPackage{
+		name:       bpkg.Name,
+		names:      make(map[string]bool),
+		interfaces: make(map[string]interfaceDecl),
+	}
+	fset := token.NewFileSet()
+	for _, file := range bpkg.GoFiles {
+		node, err := parser.ParseFile(fset, filepath.Join(bpkg.Dir, file), nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, name := range declNames(node) {
+			if exported(name) {
+				pkg.names[name] = true
+			}
+		}
+		for name, decl := range interfaceDecls(fset, node) {
+			pkg.interfaces[name] = decl
+		}
+	}
+
+	return pkg
+}
+
+// exported reports whether all elements of a name such as "Type.Method" are
+// exported.
+func exported(name string) bool {
+	for _, elem := range strings.Split(name, ".") {
+		if !token.IsExported(elem) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Root configures the file system of the repository, which a [*Service] uses
+// to resolve the Go module of a package from the nearest go.mod file in its
+// directory or a parent directory. This makes import paths correct in
+// repositories with several modules, e.g. workspaces with a go.work file.
+// File paths are relative to the root of fsys. Without a root, the import path
+// of a package is guessed from its directory.
+func Root(fsys fs.FS) Option {
+	return func(s *Service) {
+		s.root = fsys
+	}
+}
+
+// importPath returns the import path of the package in dir, which is relative
+// to the root of the repository, using the go.mod file of its module.
+func (svc *Service) importPath(dir string) (string, bool) {
+	if svc.root == nil {
+		return "", false
+	}
+
+	dir = path.Clean(dir)
+	for modDir := dir; ; modDir = path.Dir(modDir) {
+		if module, ok := svc.modulePath(modDir); ok {
+			if module == "" {
+				return "", false
+			}
+			if modDir == dir {
+				return module, true
+			}
+			return path.Join(module, strings.TrimPrefix(dir, modDir+"/")), true
+		}
+		if modDir == "." {
+			return "", false
+		}
+	}
+}
+
+// modulePath returns the module path declared by the go.mod file in dir, and
+// whether dir contains a go.mod file. The module path is empty if the go.mod
+// file does not declare a module. The results are cached.
+func (svc *Service) modulePath(dir string) (string, bool) {
+	svc.modulesMux.Lock()
+	defer svc.modulesMux.Unlock()
+
+	if mod, ok := svc.modules[dir]; ok {
+		return mod.path, mod.found
+	}
+
+	var mod module
+	if data, err := fs.ReadFile(svc.root, path.Join(dir, "go.mod")); err == nil {
+		mod = module{path: parseModulePath(data), found: true}
+	}
+
+	if svc.modules == nil {
+		svc.modules = make(map[string]module)
+	}
+	svc.modules[dir] = mod
+
+	return mod.path, mod.found
+}
+
+type module struct {
+	path  string
+	found bool
+}
+
+// parseModulePath returns the module path of the "module" directive of a
+// go.mod file.
+func parseModulePath(gomod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted
+		}
+		return fields[1]
+	}
+	return ""
+}
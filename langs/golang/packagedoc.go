@@ -0,0 +1,18 @@
+package golang
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// PackageDoc returns the package comment of code, without comment markers, or
+// an empty string if code has no package comment.
+func (svc *Service) PackageDoc(code []byte) (string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("parse code: %w", err)
+	}
+	return strings.TrimSpace(file.Doc.Text()), nil
+}
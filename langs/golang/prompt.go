@@ -2,6 +2,9 @@ package golang
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
@@ -14,11 +17,15 @@ import (
 // on how to write descriptive comments without including technical details such
 // as external links or source code examples. The output is designed to guide
 // the user in documenting their code effectively while maintaining consistency
-// with Go library documentation standards.
+// with Go library documentation standards. For generic functions and types, and
+// methods of generic types, the prompt also states the type parameters and the
+// declarations of their constraints. For type aliases, the prompt asks to
+// document the alias as such instead of as a new type. For struct types, the
+// prompt lists the serialization tags of the fields, such as json or db tags.
 func Prompt(input generate.PromptInput) string {
 	target := Target(input.Identifier)
 	simple := simpleIdentifier(input.Identifier)
-	return heredoc.Docf(`
+	prompt := withTypeParams(heredoc.Docf(`
 		Write a comment for %s in idiomatic GoDoc format. Do not include any external links, source code, or (code) examples.
 
 		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two integers, you must not describe it as a "function that adds two integers." Instead, you must describe it as "adds two integers.".
@@ -43,6 +50,152 @@ func Prompt(input generate.PromptInput) string {
 		simple,
 		input.File,
 		input.Code,
+	), input.Identifier, input.Code)
+	prompt = withTypeAlias(prompt, input.Identifier, input.Code)
+	return withStructTags(prompt, input.Identifier, input.Code)
+}
+
+// isTypeAlias reports whether code declares the type identified by identifier
+// as a type alias, such as "type Foo = Bar".
+func isTypeAlias(identifier string, code []byte) bool {
+	name, ok := strings.CutPrefix(identifier, "type:")
+	if !ok {
+		return false
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return false
+	}
+
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				if spec := spec.(*ast.TypeSpec); spec.Name.Name == name {
+					return spec.Assign.IsValid()
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// withTypeAlias appends a note to a prompt for the documentation of a type
+// alias, so that the alias is not described as the definition of a new type.
+func withTypeAlias(prompt, identifier string, code []byte) string {
+	if !isTypeAlias(identifier, code) {
+		return prompt
+	}
+
+	name := simpleIdentifier(identifier)
+
+	return fmt.Sprintf(
+		"%s\n\nNote that %s is a type alias, not the definition of a new type: %s and the aliased type are identical and have the same methods. Describe it as an alias, e.g. \"%s is an alias for ...\", and explain why it exists if the code makes it clear.",
+		strings.TrimRight(prompt, "\n"),
+		Target(identifier),
+		name,
+		name,
+	)
+}
+
+// TestPrompt returns the prompt for the documentation of a test function,
+// benchmark or fuzz target, as reported by [IsTestFunction]. Instead of what
+// the function does, the prompt asks for the behavior that the function
+// verifies, measures or checks.
+func TestPrompt(input generate.PromptInput) string {
+	name := simpleIdentifier(input.Identifier)
+
+	var kind, describe string
+	switch {
+	case isTestFunction(name, "Benchmark"):
+		kind = "benchmark"
+		describe = fmt.Sprintf(`Describe what %s measures, not how it is implemented. For example, if "BenchmarkAdd" benchmarks a function that adds two integers, you must describe it as "measures the performance of [Add] for small integers.".`, name)
+	case isTestFunction(name, "Fuzz"):
+		kind = "fuzz target"
+		describe = fmt.Sprintf(`Describe which property %s checks for arbitrary inputs, not how it is implemented. For example, if "FuzzParse" fuzzes a parser, you must describe it as "checks that [Parse] never panics and that parsed values survive a round trip through [Value.String].".`, name)
+	default:
+		kind = "test"
+		describe = fmt.Sprintf(`Describe what behavior %s verifies, not how it is implemented. For example, if "TestAdd" tests a function that adds two integers, you must describe it as "verifies that [Add] returns the sum of two integers.".`, name)
+	}
+
+	return heredoc.Docf(`
+		Write a comment for the %s %q in idiomatic GoDoc format. Do not include any external links, source code, or (code) examples.
+
+		%s
+
+		You must enclose references to other types and functions within brackets ([]).
+
+		You must begin the comment exactly with "%s ", and maintain the writing style consistent with Go library documentation.
+
+		Output only the unquoted comment, without comment markers.
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		kind,
+		name,
+		describe,
+		name,
+		input.File,
+		input.Code,
+	)
+}
+
+// IsTestFunction reports whether the function identified by the input is a
+// test function, benchmark or fuzz target, i.e. a TestXxx, BenchmarkXxx or
+// FuzzXxx function without a receiver in a "_test.go" file. If the file of the
+// input is empty, functions are detected by their names alone.
+func IsTestFunction(input generate.PromptInput) bool {
+	name, ok := strings.CutPrefix(input.Identifier, "func:")
+	if !ok || strings.Contains(name, ".") {
+		return false
+	}
+	if input.File != "" && !strings.HasSuffix(input.File, "_test.go") {
+		return false
+	}
+	return isTestFunction(name, "Test") || isTestFunction(name, "Benchmark") || isTestFunction(name, "Fuzz")
+}
+
+// ExamplePrompt returns the prompt that asks for a runnable example function of
+// the function, method or type identified by the input, named as returned by
+// [ExampleName]. The example is requested as the import declaration and the
+// function of a file of the external test package, without a package clause.
+func ExamplePrompt(input generate.PromptInput) string {
+	target := Target(input.Identifier)
+	name, _ := ExampleName(input.Identifier)
+
+	pkg := "main"
+	if file, err := parser.ParseFile(token.NewFileSet(), "", input.Code, parser.PackageClauseOnly); err == nil {
+		pkg = file.Name.Name
+	}
+
+	return heredoc.Docf(`
+		Write a runnable example function for %s of the Go package %q, as it would appear in the documentation of the package.
+
+		Name the function %s. It must take no arguments and return nothing. The example is declared in the external test package %q, so you must refer to the exported identifiers of the package as "%s.Name", and you cannot use its unexported identifiers.
+
+		Keep the example short and focused on the typical usage of %s. Only use the standard library and the package itself. If the example prints deterministic output, end the function with an "// Output:" comment that lists the exact output.
+
+		Output only Go code: the import declaration of the used packages, followed by the example function. Do not include a package clause, explanations, or Markdown code fences.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		target,
+		pkg,
+		name,
+		pkg+"_test",
+		pkg,
+		target,
+		input.File,
+		input.Code,
 	)
 }
 
@@ -52,6 +205,10 @@ func Prompt(input generate.PromptInput) string {
 // not match any of the expected formats, it returns the identifier as-is
 // enclosed in quotes.
 func Target(identifier string) string {
+	if help, ok := strings.CutPrefix(identifier, HelpPrefix); ok {
+		return fmt.Sprintf("help text of %q", help)
+	}
+
 	parts := strings.Split(identifier, ":")
 	if len(parts) != 2 {
 		return identifier
@@ -0,0 +1,7 @@
+package golang
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("go", func() jotbot.Language { return Must() })
+}
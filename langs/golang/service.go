@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"go/parser"
 	"go/token"
+	"io/fs"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
@@ -16,6 +19,7 @@ import (
 	"github.com/modernice/jotbot/services/openai"
 	"github.com/modernice/jotbot/tools/reset"
 	"github.com/tiktoken-go/tokenizer"
+	"golang.org/x/exp/slices"
 )
 
 var (
@@ -54,11 +58,20 @@ var (
 // code.
 type Service struct {
 	model         string
+	encoding      string
+	contextWindow int
 	maxTokens     int
 	clearComments bool
+	focus         bool
+	augment       bool
+	commentStyle  Style
+	keepSimilar   float64
 	codec         tokenizer.Codec
 	finder        *Finder
 	minifySteps   []nodes.MinifyOptions
+	root          fs.FS
+	modulesMux    sync.Mutex
+	modules       map[string]module
 }
 
 // Option configures a Service by setting various internal fields such as model,
@@ -85,6 +98,23 @@ func Model(m string) Option {
 	}
 }
 
+// Encoding configures the tokenizer encoding (e.g. "cl100k_base") that is used
+// to count the tokens of minified code, instead of the encoding of the model.
+func Encoding(enc string) Option {
+	return func(s *Service) {
+		s.encoding = enc
+	}
+}
+
+// ContextWindow configures the size of the context window of the model in
+// tokens, which limits the size of minified code. By default, the context
+// window of the model is looked up using [openai.MaxTokensForModel].
+func ContextWindow(tokens int) Option {
+	return func(s *Service) {
+		s.contextWindow = tokens
+	}
+}
+
 // Minify applies a series of transformations to Go source code represented as a
 // byte slice to reduce its size, potentially making it more suitable for
 // processing within token-based limitations. It returns the minified source
@@ -106,6 +136,17 @@ func ClearComments(clear bool) Option {
 	}
 }
 
+// Focus configures whether a [*Service] removes the declarations that are
+// unrelated to the documented symbol from files that exceed the context window
+// even after minification. The symbol, the declarations of the types that it
+// refers to directly, and the imports of the file are kept. This is the last
+// minification step and only affects [*Service.MinifyTarget].
+func Focus(focus bool) Option {
+	return func(s *Service) {
+		s.focus = focus
+	}
+}
+
 // Must creates a new Service with the provided options, panicking if an error
 // occurs during its creation. It ensures that a Service is returned without the
 // need to handle errors directly, simplifying initialization in cases where
@@ -129,17 +170,24 @@ func New(opts ...Option) (*Service, error) {
 		opt(&svc)
 	}
 
+	if svc.focus {
+		svc.minifySteps = append(slices.Clip(svc.minifySteps), nodes.MinifyFocus)
+	}
+
 	if svc.model == "" {
 		svc.model = openai.DefaultModel
 	}
 
-	codec, err := internal.OpenAITokenizer(svc.model)
+	codec, _, err := internal.Tokenizer(svc.model, svc.encoding)
 	if err != nil {
 		return nil, fmt.Errorf("create tokenizer: %w", err)
 	}
 	svc.codec = codec
 
-	svc.maxTokens = openai.MaxTokensForModel(string(svc.model))
+	svc.maxTokens = svc.contextWindow
+	if svc.maxTokens <= 0 {
+		svc.maxTokens = openai.MaxTokensForModel(string(svc.model))
+	}
 
 	if svc.finder == nil {
 		svc.finder = NewFinder()
@@ -160,8 +208,8 @@ func (svc *Service) Extensions() []string {
 // defers the actual searching to the associated Finder type within the Service.
 // If no identifiers are found or an error occurs, it may return an empty list
 // and the corresponding error.
-func (svc *Service) Find(code []byte) ([]string, error) {
-	return svc.finder.Find(code)
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
 }
 
 // Minify reduces the size of the given Go source code while aiming to preserve
@@ -173,6 +221,24 @@ func (svc *Service) Find(code []byte) ([]string, error) {
 // it returns an error indicating why minification failed, such as if the
 // resulting code still exceeds the maximum allowed token count.
 func (svc *Service) Minify(code []byte) ([]byte, error) {
+	return svc.minify(code, "")
+}
+
+// MinifyTarget minifies code like [*Service.Minify], but keeps the body and the
+// comments of the declaration identified by identifier, so that the prompt
+// contains the full code of the symbol that is documented. If the code does not
+// fit into the context window this way, the declaration is minified, too,
+// unless the service augments existing documentation. See [Augment].
+func (svc *Service) MinifyTarget(identifier string, code []byte) ([]byte, error) {
+	minified, err := svc.minify(code, identifier)
+	if err == nil || svc.augment {
+		// Augmenting requires the existing documentation in the prompt.
+		return minified, err
+	}
+	return svc.minify(code, "")
+}
+
+func (svc *Service) minify(code []byte, preserve string) ([]byte, error) {
 	if len(code) == 0 {
 		return code, nil
 	}
@@ -202,6 +268,7 @@ func (svc *Service) Minify(code []byte) ([]byte, error) {
 			return formatted, nil
 		}
 
+		step.Preserve = preserve
 		node = nodes.Minify(node, step)
 
 		minified, err := nodes.Format(node)
@@ -223,11 +290,23 @@ func (svc *Service) Minify(code []byte) ([]byte, error) {
 }
 
 // Prompt prepares the input code by potentially clearing comments and then
-// passes the modified input to the underlying Prompt function. If the
+// passes the modified input to the underlying Prompt function, or to
+// [TestPrompt] for test functions, benchmarks and fuzz targets, or to
+// [EnumPrompt] for the constants of iota-based enumerations. Documented
+// symbols are prompted using [AugmentPrompt] if [Augment] is enabled. Prompts
+// for interface types list the methods promoted from embedded interfaces. If the
 // clearComments option is enabled in the Service, it removes all comments from
 // the input code before generating a prompt. It returns the generated output as
 // a string.
 func (svc *Service) Prompt(input generate.PromptInput) string {
+	if strings.HasPrefix(input.Identifier, HelpPrefix) {
+		// Existing doc comments help to keep help strings consistent.
+		return HelpPrompt(input)
+	}
+	var doc string
+	if svc.augment {
+		doc = documentation(input.Identifier, input.Code)
+	}
 	if svc.clearComments {
 		if node, err := nodes.Parse(input.Code); err == nil {
 			reset.Comments(node)
@@ -236,7 +315,22 @@ func (svc *Service) Prompt(input generate.PromptInput) string {
 			}
 		}
 	}
-	return Prompt(input)
+	if doc != "" {
+		return svc.withPromotedMethods(AugmentPrompt(input, doc), input)
+	}
+	if IsTestFunction(input) {
+		return TestPrompt(input)
+	}
+	if IsEnumConstant(input) {
+		return EnumPrompt(input)
+	}
+	return svc.withPromotedMethods(Prompt(input), input)
+}
+
+// Target returns the description of the identifier that is used in prompts.
+// See [Target].
+func (svc *Service) Target(identifier string) string {
+	return Target(identifier)
 }
 
 // Patch applies a documentation string to the declaration identified by the
@@ -245,64 +339,216 @@ func (svc *Service) Prompt(input generate.PromptInput) string {
 // structure and formatting. If the identifier does not exist within the source
 // code, Patch returns an error indicating that the node was not found. On
 // successful application of the documentation string, Patch returns the updated
-// source code as a byte slice along with a nil error. If an error occurs during
-// parsing or formatting of the source code, Patch will return the error
-// encountered.
+// source code as a byte slice along with a nil error. If the declaration is
+// already documented with the exact same comment, Patch returns the code
+// unchanged, so that applying the same documentation twice is a no-op. The
+// same applies to similar documentation if [KeepSimilar] is configured. If an
+// error occurs during parsing or formatting of the source code, Patch will
+// return the error encountered.
 func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
 	fset := token.NewFileSet()
 	file, err := decorator.ParseFile(fset, "", code, parser.ParseComments|parser.SkipObjectResolution)
 	if err != nil {
 		return nil, fmt.Errorf("parse code: %w", err)
 	}
-	return svc.patch(file, identifier, doc)
+	if strings.HasPrefix(identifier, HelpPrefix) {
+		if err := patchHelp(file, identifier, doc); err != nil {
+			return nil, err
+		}
+		return nodes.Format(file)
+	}
+
+	return svc.patch(file, identifier, doc, code)
+}
+
+func (svc *Service) patch(file *dst.File, identifier, doc string, code []byte) ([]byte, error) {
+	decs, err := docDecorations(file, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if decs != nil {
+		if svc.augment {
+			augmented, changed := augmentDoc(existingDoc(decs.Start), doc)
+			if !changed {
+				return code, nil
+			}
+			doc = augmented
+		}
+		if doc != "" && hasDoc(decs.Start, doc, svc.commentStyle) {
+			return code, nil
+		}
+		if svc.keepSimilar > 0 && !svc.augment && doc != "" {
+			if existing := existingDoc(decs.Start); existing != "" && similarity(existing, doc) >= svc.keepSimilar {
+				return code, nil
+			}
+		}
+		updateDoc(&decs.Start, doc, svc.commentStyle)
+		decs.After = dst.EmptyLine
+	}
+
+	return nodes.Format(file)
 }
 
-func (svc *Service) patch(file *dst.File, identifier, doc string) ([]byte, error) {
+// docDecorations returns the decorations of the node that holds the doc
+// comment of the declaration identified by identifier.
+func docDecorations(file *dst.File, identifier string) (*dst.NodeDecs, error) {
 	spec, decl, ok := nodes.Find(identifier, file)
 	if !ok {
 		return nil, fmt.Errorf("node %q not found", identifier)
 	}
 
-	target := nodes.CommentTarget(spec, decl)
-
-	switch target := target.(type) {
+	switch target := nodes.CommentTarget(spec, decl).(type) {
 	case *dst.FuncDecl:
-		updateDoc(&target.Decs.Start, doc)
-		target.Decs.After = dst.EmptyLine
+		return &target.Decs.NodeDecs, nil
 	case *dst.GenDecl:
-		updateDoc(&target.Decs.Start, doc)
-		target.Decs.After = dst.EmptyLine
+		return &target.Decs.NodeDecs, nil
 	case *dst.TypeSpec:
-		updateDoc(&target.Decs.Start, doc)
-		target.Decs.After = dst.EmptyLine
+		return &target.Decs.NodeDecs, nil
 	case *dst.ValueSpec:
-		updateDoc(&target.Decs.Start, doc)
-		target.Decs.After = dst.EmptyLine
+		return &target.Decs.NodeDecs, nil
 	case *dst.Field:
-		updateDoc(&target.Decs.Start, doc)
-		target.Decs.After = dst.EmptyLine
+		return &target.Decs.NodeDecs, nil
 	}
-
-	return nodes.Format(file)
+	return nil, nil
 }
 
+// formatDoc formats a generated comment as a Go doc comment. The comment is
+// parsed as a Go doc comment after converting the Markdown syntax that models
+// tend to use, so that lists, headings and code blocks survive, and the text
+// of paragraphs and list items is wrapped at 77 columns. The wrapped comment
+// is then parsed and printed once more, so that it is in the canonical format
+// of gofmt, which would otherwise reformat it.
 func formatDoc(doc string) string {
-	doc = normalizeGeneratedComment(doc)
-
-	lines := internal.Columns(doc, 77)
-	lines = slice.Map(lines, func(s string) string {
-		return "// " + s
+	printed := printDoc(parseGodoc(normalizeGeneratedComment(doc)), 77)
+	lines := slice.Map(canonicalDoc(printed), func(s string) string {
+		switch {
+		case s == "":
+			return "//"
+		case strings.HasPrefix(s, "\t"):
+			return "//" + s
+		default:
+			return "// " + s
+		}
 	})
 	return strings.Join(lines, "\n")
 }
 
+// normalizeGeneratedComment removes the comment markers from a generated
+// comment whose lines all start with "//".
 func normalizeGeneratedComment(doc string) string {
-	return internal.RemoveColumns(strings.ReplaceAll(doc, "// ", ""))
+	lines := strings.Split(strings.TrimSpace(doc), "\n")
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "//") {
+			return doc
+		}
+	}
+	for i, line := range lines {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "//")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
 }
 
-func updateDoc(decs *dst.Decorations, doc string) {
-	decs.Clear()
+// hasDoc reports whether decs consists of exactly the comment that formatDoc
+// would produce for doc in the given style, ignoring the lines that updateDoc
+// preserves.
+func hasDoc(decs dst.Decorations, doc string, style Style) bool {
+	_, attached := detachComments(decs)
+	lines, _, _ := splitDoc(attached)
+	formatted := formatDoc(doc)
+	_, block := blockComment(strings.Split(formatted, "\n"))
+	return strings.Join(lines, "\n") == formatted && isBlockComment(attached) == (block && style == Block)
+}
+
+// updateDoc replaces the comment in decs with the formatted doc. A trailing
+// "Deprecated:" paragraph and directives such as "//go:noinline" or
+// "//nolint:errcheck" are not part of the documentation that is generated, so
+// they are kept below the new comment. Comments that are separated from the
+// declaration by an empty line, like a "//go:generate" line above a type, are
+// not part of the documentation at all and are kept as they are. In the
+// [Block] style, the "Deprecated:" paragraph becomes part of the block
+// comment.
+func updateDoc(decs *dst.Decorations, doc string, style Style) {
+	detached, attached := detachComments(*decs)
+	_, deprecated, directives := splitDoc(attached)
+
+	var lines []string
 	if doc != "" {
-		decs.Append(formatDoc(doc))
+		lines = strings.Split(formatDoc(doc), "\n")
+	}
+	if len(deprecated) > 0 {
+		if doc != "" {
+			lines = append(lines, "//")
+		}
+		lines = append(lines, deprecated...)
+	}
+
+	decs.Clear()
+	decs.Append(detached...)
+	block, isBlock := blockComment(lines)
+	if isBlock = isBlock && style == Block && len(lines) > 0; isBlock {
+		decs.Append(block, "\n")
+	} else {
+		decs.Append(lines...)
+	}
+	if len(directives) > 0 {
+		if !isBlock && len(decs.All()) > len(detached) {
+			decs.Append("//")
+		}
+		decs.Append(directives...)
+	}
+}
+
+var directiveRE = regexp.MustCompile(`^//(?:[a-z0-9]+:\S|nolint\b|\s*\+build\s)`)
+
+// detachComments splits decs at the last empty line into the comments that are
+// detached from the declaration and the comment that documents it. The
+// detached comments include the empty line. A "\n" decoration that follows a
+// block comment ends the line of the comment, and is an empty line otherwise.
+func detachComments(decs dst.Decorations) (detached, attached []string) {
+	all := decs.All()
+	for i := len(all) - 1; i > 0; i-- {
+		if all[i] == "\n" && !strings.HasPrefix(all[i-1], "/*") {
+			return all[:i+1], all[i+1:]
+		}
+	}
+	return nil, all
+}
+
+// splitDoc splits the comment lines in decs into the documentation, the
+// "Deprecated:" paragraph, and the directive lines. Block comments are
+// converted into line comments first. Empty comment lines at the end of the
+// documentation are dropped.
+func splitDoc(decs []string) (doc, deprecated, directives []string) {
+	var inDeprecated bool
+	for _, dec := range lineComments(decs) {
+		if strings.TrimSpace(dec) == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(dec, "\n"), "\n") {
+			text := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+			switch {
+			case directiveRE.MatchString(line):
+				directives = append(directives, line)
+				inDeprecated = false
+			case strings.HasPrefix(line, "//") && text == "":
+				if !inDeprecated {
+					doc = append(doc, line)
+				}
+				inDeprecated = false
+			case strings.HasPrefix(line, "//") && strings.HasPrefix(text, "Deprecated:"):
+				deprecated = append(deprecated, line)
+				inDeprecated = true
+			case inDeprecated:
+				deprecated = append(deprecated, line)
+			default:
+				doc = append(doc, line)
+			}
+		}
+	}
+	for len(doc) > 0 && strings.TrimSpace(strings.TrimPrefix(doc[len(doc)-1], "//")) == "" {
+		doc = doc[:len(doc)-1]
 	}
+	return doc, deprecated, directives
 }
@@ -2,7 +2,10 @@ package golang_test
 
 import (
 	"context"
+	"go/format"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/google/go-cmp/cmp"
@@ -14,7 +17,15 @@ import (
 
 var _ interface {
 	generate.Language
+	generate.Linker
+	generate.LinkRewriter
+	generate.Declarer
+	generate.Usager
+	generate.TargetMinifier
+	generate.PackageDocumenter
+	generate.Conformer
 	patch.Language
+	patch.Verifier
 	jotbot.Language
 } = (*golang.Service)(nil)
 
@@ -133,6 +144,105 @@ func TestService_Patch_groupDeclaration(t *testing.T) {
 	}
 }
 
+func TestService_Patch_groupTypeDeclaration(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Types of the package.
+		type (
+			Foo struct {
+				Foo string
+			}
+			Bar struct{}
+		)
+
+		type (
+			Baz int
+		)
+	`)
+
+	svc := golang.Must()
+
+	patched := []byte(code)
+	for _, p := range []struct{ identifier, doc string }{
+		{"type:Foo", "Foo is a foo."},
+		{"type:Bar", "Bar is a bar."},
+		{"type:Baz", "Baz is a baz."},
+	} {
+		var err error
+		if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+			t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+		}
+	}
+
+	expect := heredoc.Doc(`
+		package foo
+
+		// Types of the package.
+		type (
+			// Foo is a foo.
+			Foo struct {
+				Foo string
+			}
+
+			// Bar is a bar.
+			Bar struct{}
+		)
+
+		type (
+			// Baz is a baz.
+			Baz int
+		)
+	`)
+
+	if string(patched) != expect {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_typeAlias(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type Foo = Bar
+
+		type (
+			Bar struct{}
+			Baz = map[string]Bar
+		)
+	`)
+
+	svc := golang.Must()
+
+	patched := []byte(code)
+	for _, p := range []struct{ identifier, doc string }{
+		{"type:Foo", "Foo is an alias for [Bar]."},
+		{"type:Baz", "Baz is an alias for a map of [Bar] values."},
+	} {
+		var err error
+		if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+			t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+		}
+	}
+
+	expect := heredoc.Doc(`
+		package foo
+
+		// Foo is an alias for [Bar].
+		type Foo = Bar
+
+		type (
+			Bar struct{}
+			// Baz is an alias for a map of [Bar] values.
+			Baz = map[string]Bar
+		)
+	`)
+
+	if string(patched) != expect {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+}
+
 func TestService_Patch_interfaceMethods(t *testing.T) {
 	code := heredoc.Doc(`
 		package foo
@@ -170,3 +280,1098 @@ func TestService_Patch_interfaceMethods(t *testing.T) {
 		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
 	}
 }
+
+func TestService_Patch_blockComments(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		func Foo() {}
+
+		// Bar is deprecated.
+		//
+		// Deprecated: Use Foo instead.
+		//go:noinline
+		func Bar() {}
+	`)
+
+	svc := golang.Must(golang.CommentStyle(golang.Block))
+
+	patched := []byte(code)
+	for _, p := range []struct{ identifier, doc string }{
+		{"func:Foo", "Foo does foo.\n\nIt is the successor of [Bar]."},
+		{"func:Bar", "Bar does bar."},
+	} {
+		var err error
+		if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+			t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+		}
+	}
+
+	expect := heredoc.Doc(`
+		package foo
+
+		/*
+		Foo does foo.
+
+		It is the successor of [Bar].
+		*/
+		func Foo() {}
+
+		/*
+		Bar does bar.
+
+		Deprecated: Use Foo instead.
+		*/
+		//go:noinline
+		func Bar() {}
+	`)
+
+	if string(patched) != expect {
+		t.Fatalf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+
+	formatted, err := format.Source(patched)
+	if err != nil {
+		t.Fatalf("format patched code: %v", err)
+	}
+	if string(formatted) != expect {
+		t.Errorf("gofmt changes the patched code:\n\n%s", cmp.Diff(expect, string(formatted)))
+	}
+
+	again, err := svc.Patch(context.Background(), "func:Bar", "Bar does bar.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	if string(again) != expect {
+		t.Errorf("Patch() should not change the code when applied twice:\n\n%s", cmp.Diff(expect, string(again)))
+	}
+
+	lines, err := golang.Must().Patch(context.Background(), "func:Foo", "Foo does foo.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	if !strings.Contains(string(lines), "// Foo does foo.\nfunc Foo() {}") {
+		t.Errorf("Patch() should replace the block comment with a line comment in the Line style\n\n%s", lines)
+	}
+}
+
+func TestService_Patch_idempotent(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		func Foo() {}
+	`)
+
+	svc := golang.Must()
+
+	patched, err := svc.Patch(context.Background(), "func:Foo", "Foo is a foo.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	repatched, err := svc.Patch(context.Background(), "func:Foo", "Foo is a foo.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if string(repatched) != string(patched) {
+		t.Errorf("patching the same doc twice should not change the code:\n\n%s", cmp.Diff(string(patched), string(repatched)))
+	}
+}
+
+func TestKeepSimilar(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Sum returns the sum of a and b.
+		func Sum(a, b int) int { return a + b }
+	`)
+
+	svc := golang.Must(golang.KeepSimilar(0.9))
+
+	tests := map[string]bool{
+		"Sum returns the sum of a and b.":                     false,
+		"sum returns the sum of a and b":                      false,
+		"Sum returns the sum of [a] and b.":                   true,
+		"Sum adds two integers and returns their total.":      true,
+		"Sum returns the sum of a and b, which may overflow.": true,
+	}
+
+	for doc, changed := range tests {
+		patched, err := svc.Patch(context.Background(), "func:Sum", doc, []byte(code))
+		if err != nil {
+			t.Fatalf("Patch(%q) failed: %v", doc, err)
+		}
+		if got := string(patched) != code; got != changed {
+			t.Errorf("Patch(%q) should change the code: %v\n\n%s", doc, changed, patched)
+		}
+	}
+}
+
+func TestService_Patch_preservesDeprecatedAndDirectives(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo does something.
+		//
+		// Deprecated: Use Bar instead.
+		//
+		//go:noinline
+		//nolint:errcheck
+		func Foo() {}
+
+		//go:generate stringer -type=Baz
+		type Baz int
+	`)
+
+	svc := golang.Must()
+
+	patched, err := svc.Patch(context.Background(), "func:Foo", "Foo is a foo.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	patched, err = svc.Patch(context.Background(), "type:Baz", "Baz is a baz.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	expect := heredoc.Doc(`
+		package foo
+
+		// Foo is a foo.
+		//
+		// Deprecated: Use Bar instead.
+		//
+		//go:noinline
+		//nolint:errcheck
+		func Foo() {}
+
+		// Baz is a baz.
+		//
+		//go:generate stringer -type=Baz
+		type Baz int
+	`)
+
+	if string(patched) != expect {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+
+	repatched, err := svc.Patch(context.Background(), "func:Foo", "Foo is a foo.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	if string(repatched) != string(patched) {
+		t.Errorf("patching the same doc twice should not change the code:\n\n%s", cmp.Diff(string(patched), string(repatched)))
+	}
+}
+
+func TestService_Patch_buildConstraintsAndGenerate(t *testing.T) {
+	code := heredoc.Doc(`
+		//go:build linux
+		// +build linux
+
+		package foo
+
+		//go:generate stringer -type=Foo
+
+		type Foo int
+
+		//go:generate echo bar
+
+		// Bar is outdated.
+		func Bar() {}
+
+		//go:generate echo baz
+		type Baz int
+	`)
+
+	svc := golang.Must()
+
+	patched := []byte(code)
+	for i := 0; i < 2; i++ {
+		for _, p := range []struct{ identifier, doc string }{
+			{"type:Foo", "Foo is a foo."},
+			{"func:Bar", "Bar is a bar."},
+			{"type:Baz", "Baz is a baz."},
+		} {
+			var err error
+			if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+				t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+			}
+		}
+	}
+
+	expect := heredoc.Doc(`
+		//go:build linux
+		// +build linux
+
+		package foo
+
+		//go:generate stringer -type=Foo
+
+		// Foo is a foo.
+		type Foo int
+
+		//go:generate echo bar
+
+		// Bar is a bar.
+		func Bar() {}
+
+		// Baz is a baz.
+		//
+		//go:generate echo baz
+		type Baz int
+	`)
+
+	if string(patched) != expect {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+}
+
+func TestService_Links(t *testing.T) {
+	doc := "Foo returns a [Bar] or [*Baz.Qux] and reads from an [io.Reader], [io.Foo], [json.Decoder], [encoding/json.Decoder], [encoding/json.Foo] or [example.com/foo.Foo]. It returns an [error]."
+
+	want := []generate.Link{
+		{Text: "[Bar]", Target: "Bar", Plain: "Bar"},
+		{Text: "[*Baz.Qux]", Target: "Baz.Qux", Plain: "*Baz.Qux"},
+		{Text: "[io.Foo]", Target: "io.Foo", Plain: "io.Foo"},
+		{Text: "[json.Decoder]", Target: "json.Decoder", Plain: "json.Decoder"},
+		{Text: "[encoding/json.Foo]", Target: "encoding/json.Foo", Plain: "encoding/json.Foo"},
+	}
+
+	if diff := cmp.Diff(want, golang.Must().Links(doc)); diff != "" {
+		t.Errorf("Links() returned wrong links:\n%s", diff)
+	}
+}
+
+func TestService_Names(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import (
+			"encoding/json"
+			stdio "io"
+			_ "embed"
+
+			"github.com/dave/dst"
+			"example.com/foo/v2"
+		)
+
+		type Foo struct {
+			Bar string
+		}
+
+		func (*Foo) Baz() {}
+	`)
+
+	names, err := golang.Must().Names("foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Names() failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, name := range names {
+		got[name] = true
+	}
+
+	for _, name := range []string{"Foo", "Foo.Bar", "Foo.Baz", "json.Decoder", "json.Decoder.Decode", "stdio.Reader", "dst.*", "foo.*"} {
+		if !got[name] {
+			t.Errorf("Names() should return %q", name)
+		}
+	}
+	for _, name := range []string{"io.Reader", "json.decodeState", "embed.FS"} {
+		if got[name] {
+			t.Errorf("Names() should not return %q", name)
+		}
+	}
+}
+
+func TestService_Patch_godocFormatting(t *testing.T) {
+	doc := heredoc.Doc(`
+		Foo does a thing with a [Bar] and returns a [*Baz]. This sentence is long enough to be wrapped at the column limit.
+
+		It supports:
+		- a thing that is described in a list item that is long enough to be wrapped
+		- another thing
+
+		## Usage
+		` + "```go" + `
+		x := Foo()
+		// Output: 1
+		` + "```" + `
+		1. first
+		2. second
+	`)
+
+	svc := golang.Must()
+
+	patched, err := svc.Patch(context.Background(), "func:Foo", doc, []byte("package foo\n\nfunc Foo() {}\n"))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	expect := heredoc.Doc(`
+		package foo
+
+		// Foo does a thing with a [Bar] and returns a [*Baz]. This sentence is long
+		// enough to be wrapped at the column limit.
+		//
+		// It supports:
+		//
+		//   - a thing that is described in a list item that is long enough to be
+		//     wrapped
+		//   - another thing
+		//
+		// # Usage
+		//
+		//	x := Foo()
+		//	// Output: 1
+		//
+		// 1. first
+		// 2. second
+		func Foo() {}
+	`)
+
+	if string(patched) != expect {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(expect, string(patched)), string(patched))
+	}
+
+	formatted, err := format.Source(patched)
+	if err != nil {
+		t.Fatalf("format patched code: %v", err)
+	}
+	if string(formatted) != string(patched) {
+		t.Errorf("gofmt should not change the patched code:\n\n%s", cmp.Diff(string(patched), string(formatted)))
+	}
+
+	repatched, err := svc.Patch(context.Background(), "func:Foo", doc, patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	if string(repatched) != string(patched) {
+		t.Errorf("patching the same doc twice should not change the code:\n\n%s", cmp.Diff(string(patched), string(repatched)))
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import (
+			"fmt"
+		)
+
+		func Foo(args ...any) {
+			fmt.Println(args...)
+		}
+	`)
+
+	tests := map[string]struct {
+		identifier string
+		patched    string
+		valid      bool
+	}{
+		"comments": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\n// Package comment.\n\nimport (\n\t\"fmt\"\n)\n\n// Foo prints args.\nfunc Foo(args ...any) {\n\t// Print the args.\n\tfmt.Println(args...)\n}\n",
+			valid:      true,
+		},
+		"formatting": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\nimport (\n\t\"fmt\"\n)\n\nfunc Foo(args ...any) { fmt.Println(args...) }\n",
+			valid:      true,
+		},
+		"renamed function": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\nimport (\n\t\"fmt\"\n)\n\nfunc Bar(args ...any) {\n\tfmt.Println(args...)\n}\n",
+		},
+		"ungrouped import": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\nimport \"fmt\"\n\nfunc Foo(args ...any) {\n\tfmt.Println(args...)\n}\n",
+		},
+		"non-variadic call": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\nimport (\n\t\"fmt\"\n)\n\nfunc Foo(args ...any) {\n\tfmt.Println(args)\n}\n",
+		},
+		"invalid code": {
+			identifier: "func:Foo",
+			patched:    "package foo\n\nfunc Foo(",
+		},
+		"help text": {
+			identifier: golang.HelpPrefix + "flag(verbose)",
+			patched:    "package foo\n\nimport (\n\t\"fmt\"\n)\n\nfunc Foo(args ...any) {\n\tfmt.Println(\"verbose\")\n}\n",
+			valid:      true,
+		},
+	}
+
+	svc := golang.Must()
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := svc.Verify(tt.identifier, []byte(code), []byte(tt.patched))
+			if tt.valid && err != nil {
+				t.Errorf("Verify() should succeed; got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Verify() should fail")
+			}
+		})
+	}
+}
+
+func TestService_cgo(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		/*
+		#include <stdlib.h>
+
+		// add adds a and b.
+		static int add(int a, int b) { return a + b; }
+		*/
+		import "C"
+
+		func Add(a, b int) int {
+			return int(C.add(C.int(a), C.int(b)))
+		}
+	`)
+	preamble := "/*\n#include <stdlib.h>\n\n// add adds a and b.\nstatic int add(int a, int b) { return a + b; }\n*/\nimport \"C\""
+
+	svc := golang.Must(golang.ClearComments(true))
+
+	patched, err := svc.Patch(context.Background(), "func:Add", "Add adds a and b.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+	if !strings.Contains(string(patched), preamble) {
+		t.Errorf("Patch() should keep the cgo preamble\n\n%s", patched)
+	}
+
+	prompt := svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "func:Add"}})
+	if !strings.Contains(prompt, preamble) {
+		t.Errorf("Prompt() should keep the cgo preamble when clearing comments\n\n%s", prompt)
+	}
+
+	changed := strings.Replace(code, "#include <stdlib.h>", "#include <stdio.h>", 1)
+	if err := svc.Verify("func:Add", []byte(code), []byte(changed)); err == nil {
+		t.Errorf("Verify() should fail if the cgo preamble was changed")
+	}
+}
+
+func TestService_Prompt_testFunctions(t *testing.T) {
+	tests := []struct {
+		file       string
+		identifier string
+		want       string
+	}{
+		{file: "foo_test.go", identifier: "func:TestFoo", want: `Write a comment for the test "TestFoo"`},
+		{file: "foo_test.go", identifier: "func:BenchmarkFoo", want: `Write a comment for the benchmark "BenchmarkFoo"`},
+		{file: "foo_test.go", identifier: "func:FuzzFoo", want: `Write a comment for the fuzz target "FuzzFoo"`},
+		{file: "", identifier: "func:TestFoo", want: `Write a comment for the test "TestFoo"`},
+		{file: "foo.go", identifier: "func:TestServer", want: `Write a comment for function "TestServer()"`},
+		{file: "foo_test.go", identifier: "func:Testify", want: `Write a comment for function "Testify()"`},
+		{file: "foo_test.go", identifier: "func:(*suite).TestFoo", want: `Write a comment for function "(*suite).TestFoo()"`},
+	}
+
+	svc := golang.Must()
+	for _, tt := range tests {
+		prompt := svc.Prompt(generate.PromptInput{
+			File:  tt.file,
+			Input: generate.Input{Code: []byte("package foo\n"), Language: "go", Identifier: tt.identifier},
+		})
+		if !strings.HasPrefix(prompt, tt.want) {
+			t.Errorf("Prompt(%q, %q) should begin with %q\n\n%s", tt.file, tt.identifier, tt.want, prompt)
+		}
+	}
+}
+
+func TestService_Prompt_enum(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type Kind int
+
+		const (
+			Func Kind = iota
+			Type
+			_
+			Var
+		)
+
+		const (
+			A = 1
+			B = 2
+		)
+	`)
+
+	svc := golang.Must()
+
+	prompt := svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "var:Type"}})
+	for _, want := range []string{
+		`Write a comment for the constant "Type"`,
+		"Type is one of the values of the enumeration of [Kind], which consists of Func, Type, Var.",
+		"---\nconst (\n\tFunc Kind = iota\n\tType\n\t_\n\tVar\n)\n---",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt() should contain %q\n\n%s", want, prompt)
+		}
+	}
+
+	prompt = svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "var:A"}})
+	if want := `Write a comment for variable "A"`; !strings.HasPrefix(prompt, want) {
+		t.Errorf("Prompt() should begin with %q for constants without iota\n\n%s", want, prompt)
+	}
+}
+
+func TestService_MinifyTarget(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo returns the sum of the squares of a and b.
+		func Foo(a, b int) int {
+			return square(a) + square(b)
+		}
+
+		// square returns the square of n.
+		func square(n int) int {
+			return n * n
+		}
+	`)
+
+	svc := golang.Must(golang.ContextWindow(40))
+
+	minified, err := svc.Minify([]byte(code))
+	if err != nil {
+		t.Fatalf("Minify() failed: %v", err)
+	}
+	if strings.Contains(string(minified), "return n * n") {
+		t.Fatalf("Minify() should minify the body of square\n\n%s", minified)
+	}
+
+	minified, err = svc.MinifyTarget("func:square", []byte(code))
+	if err != nil {
+		t.Fatalf("MinifyTarget() failed: %v", err)
+	}
+	want := "// square returns the square of n.\nfunc square(n int) int {\n\treturn n * n\n}"
+	if !strings.Contains(string(minified), want) {
+		t.Fatalf("MinifyTarget() should preserve the declaration of square\n\n%s", minified)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type Foo struct{ Bar, Baz, Qux string }
+
+		func (f Foo) Bar() string { return f.Bar }
+
+		func Baz(a, b, c, d, e, f, g, h string) string { return a + b + c + d + e + f + g + h }
+
+		func Qux(a, b, c, d, e, f, g, h string) string { return a + b + c + d + e + f + g + h }
+	`)
+
+	if _, err := golang.Must(golang.ContextWindow(30)).MinifyTarget("func:Foo.Bar", []byte(code)); err == nil {
+		t.Fatalf("MinifyTarget() should fail without focus")
+	}
+
+	minified, err := golang.Must(golang.ContextWindow(30), golang.Focus(true)).MinifyTarget("func:Foo.Bar", []byte(code))
+	if err != nil {
+		t.Fatalf("MinifyTarget() failed: %v", err)
+	}
+
+	want := "package foo\n\ntype Foo struct{ Bar, Baz, Qux string }\n\nfunc (f Foo) Bar() string { return f.Bar }\n"
+	if string(minified) != want {
+		t.Fatalf("MinifyTarget() returned wrong code:\n%s", cmp.Diff(want, string(minified)))
+	}
+}
+
+func TestPrompt_typeParams(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "golang.org/x/exp/constraints"
+
+		type (
+			// Number is a number.
+			Number interface {
+				~int | ~float64
+			}
+		)
+
+		func Sum[N Number](numbers ...N) N
+
+		func Max[T constraints.Ordered](a, b T) T
+
+		type Set[K comparable, V any] map[K]V
+
+		func (s Set[K, V]) Add(k K, v V)
+
+		func Foo()
+	`)
+
+	tests := map[string]string{
+		"func:Sum":     "Note that function \"Sum()\" is generic and has the type parameters [N Number]. If a constraint restricts the types that callers can use, explain it in the comment. The constraints are declared as follows:\n---\ntype Number interface {\n\t~int | ~float64\n}\n---",
+		"func:Max":     "Note that function \"Max()\" is generic and has the type parameters [T constraints.Ordered]. If a constraint restricts the types that callers can use, explain it in the comment.",
+		"type:Set":     "Note that type \"Set\" is generic and has the type parameters [K comparable, V any].",
+		"func:Set.Add": "Note that the receiver type of function \"Set.Add()\" is generic and has the type parameters [K comparable, V any].",
+		"func:Foo":     "",
+	}
+
+	for identifier, want := range tests {
+		prompt := golang.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: identifier}})
+		if want == "" {
+			if strings.Contains(prompt, "is generic") {
+				t.Errorf("Prompt(%q) should not mention type parameters\n\n%s", identifier, prompt)
+			}
+			continue
+		}
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt(%q) should contain %q\n\n%s", identifier, want, prompt)
+		}
+	}
+}
+
+func TestPrompt_typeAlias(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type Foo = Bar
+
+		type Bar struct{}
+	`)
+
+	prompt := golang.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "type:Foo"}})
+	if want := "Note that type \"Foo\" is a type alias"; !strings.Contains(prompt, want) {
+		t.Errorf("Prompt() should contain %q\n\n%s", want, prompt)
+	}
+
+	prompt = golang.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "type:Bar"}})
+	if strings.Contains(prompt, "type alias") {
+		t.Errorf("Prompt() should not label type definitions as aliases\n\n%s", prompt)
+	}
+}
+
+func TestPrompt_structTags(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type User struct {
+			ID        int    ` + "`json:\"id\" db:\"user_id\"`" + `
+			Name      string ` + "`json:\"name,omitempty\" validate:\"required\"`" + `
+			Password  string ` + "`json:\"-\"`" + `
+			Timestamps ` + "`yaml:\",inline\"`" + `
+			internal  bool
+		}
+
+		type Timestamps struct{}
+	`)
+
+	prompt := golang.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "type:User"}})
+
+	want := "The fields of type \"User\" have struct tags that define how they are serialized:\n---\n" +
+		"ID json:\"id\" db:\"user_id\"\n" +
+		"Name json:\"name,omitempty\"\n" +
+		"Password json:\"-\"\n" +
+		"Timestamps yaml:\",inline\"\n" +
+		"---"
+	if !strings.Contains(prompt, want) {
+		t.Errorf("Prompt() should contain %q\n\n%s", want, prompt)
+	}
+
+	prompt = golang.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "type:Timestamps"}})
+	if strings.Contains(prompt, "struct tags") {
+		t.Errorf("Prompt() should not mention struct tags for structs without tags\n\n%s", prompt)
+	}
+}
+
+func TestService_Prompt_promotedMethods(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		import "io"
+
+		type ReadCloser interface {
+			io.Reader
+			Closer
+			Name() string
+		}
+
+		type Failure interface {
+			error
+			Temporary() bool
+		}
+
+		type Plain interface {
+			Name() string
+		}
+	`)
+
+	fsys := fstest.MapFS{
+		"foo/foo.go":    &fstest.MapFile{Data: []byte(code)},
+		"foo/closer.go": &fstest.MapFile{Data: []byte("package foo\n\ntype Closer interface {\n\tClose(force bool) error\n}\n")},
+	}
+
+	svc := golang.Must(golang.Root(fsys))
+
+	tests := map[string][]string{
+		"type:ReadCloser": {
+			"Note that type \"ReadCloser\" embeds io.Reader, Closer, so its method set also includes the following methods:",
+			"Read(p []byte) (n int, err error) // from io.Reader",
+			"Close(force bool) error // from Closer",
+		},
+		"type:Failure": {"Error() string // from error"},
+		"type:Plain":   nil,
+	}
+
+	for identifier, want := range tests {
+		prompt := svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: identifier}, File: "foo/foo.go"})
+		if want == nil && strings.Contains(prompt, "method set") {
+			t.Errorf("Prompt(%q) should not list promoted methods\n\n%s", identifier, prompt)
+		}
+		for _, want := range want {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("Prompt(%q) should contain %q\n\n%s", identifier, want, prompt)
+			}
+		}
+	}
+}
+
+func TestAugment(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo adds a and b.
+		//
+		// Deprecated: Use Bar instead.
+		func Foo(a, b int) int { return a + b }
+
+		func Bar(a, b int) int { return a + b }
+	`)
+
+	svc := golang.Must(golang.Augment(true), golang.ClearComments(true))
+
+	prompt := svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "func:Foo"}})
+	if !strings.Contains(prompt, "This is the existing comment:\n---\nFoo adds a and b.\n---") {
+		t.Errorf("prompt should contain the existing comment\n\n%s", prompt)
+	}
+	prompt = svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "go", Identifier: "func:Bar"}})
+	if strings.Contains(prompt, "existing comment") {
+		t.Errorf("prompt of an undocumented symbol should not ask for completion\n\n%s", prompt)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "completed",
+			doc:  "Foo adds a and b. It returns the sum of a and b.",
+			want: "// Foo adds a and b. It returns the sum of a and b.\n//\n// Deprecated: Use Bar instead.\nfunc Foo",
+		},
+		{
+			name: "rewritten",
+			doc:  "Foo returns the sum of a and b.",
+			want: "// Foo adds a and b.\n//\n// Foo returns the sum of a and b.\n//\n// Deprecated: Use Bar instead.\nfunc Foo",
+		},
+		{name: "unchanged", doc: "Foo  adds a\nand b.", want: code},
+		{name: "shortened", doc: "Foo sums up.", want: code},
+	}
+
+	for _, tt := range tests {
+		patched, err := svc.Patch(context.Background(), "func:Foo", tt.doc, []byte(code))
+		if err != nil {
+			t.Fatalf("%s: Patch() failed: %v", tt.name, err)
+		}
+		if !strings.Contains(string(patched), tt.want) {
+			t.Errorf("%s: Patch() returned invalid code:\n\n%s", tt.name, patched)
+		}
+	}
+}
+
+func TestService_Declarations(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Foo is a foo.
+		type Foo struct {
+			// Bar is a bar.
+			Bar string
+		}
+
+		type (
+			// Baz is a baz.
+			Baz struct {
+				Qux int
+			}
+
+			Quux interface {
+				Quux()
+			}
+		)
+
+		func (*Foo) Foo() {}
+
+		func (Baz) Baz() {}
+	`)
+
+	tests := map[string][]string{
+		"func:(*Foo).Foo": {"// Foo is a foo.\ntype Foo struct {\n\t// Bar is a bar.\n\tBar string\n}"},
+		"func:Baz.Baz":    {"// Baz is a baz.\ntype Baz struct {\n\tQux int\n}"},
+		"func:Quux.Quux":  nil,
+		"func:(*Bar).Foo": nil,
+		"type:Foo":        nil,
+	}
+
+	svc := golang.Must()
+	for identifier, want := range tests {
+		decls, err := svc.Declarations(identifier, []byte(code))
+		if err != nil {
+			t.Fatalf("Declarations(%q) failed: %v", identifier, err)
+		}
+		if !cmp.Equal(want, decls) {
+			t.Errorf("Declarations(%q) returned wrong declarations:\n%s", identifier, cmp.Diff(want, decls))
+		}
+	}
+}
+
+func TestService_Usages(t *testing.T) {
+	input := generate.PromptInput{
+		File: "foo/foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() {}\n\ntype Bar struct{}\n\nfunc (*Bar) Baz() {}\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		identifier string
+		file       string
+		code       string
+		want       []string
+	}{
+		{
+			name:       "same package",
+			identifier: "func:Foo",
+			file:       "foo/bar.go",
+			code:       "package foo\n\nfunc bar() {\n\tFoo()\n}\n\nfunc baz() {}\n",
+			want:       []string{"func bar() {\n\tFoo()\n}"},
+		},
+		{
+			name:       "other package",
+			identifier: "func:Foo",
+			file:       "cmd/main.go",
+			code:       "package main\n\nimport f \"example.com/mod/foo\"\n\nfunc main() {\n\tf.Foo()\n\tFoo()\n}\n",
+			want:       []string{"func main() {\n\tf.Foo()\n\tFoo()\n}"},
+		},
+		{
+			name:       "other package with the same name",
+			identifier: "func:Foo",
+			file:       "bar/bar.go",
+			code:       "package bar\n\nimport foo \"example.com/mod/other\"\n\nfunc bar() {\n\tfoo.Foo()\n}\n",
+		},
+		{
+			name:       "method",
+			identifier: "func:(*Bar).Baz",
+			file:       "foo/foo_test.go",
+			code:       "package foo_test\n\nimport \"example.com/mod/foo\"\n\nfunc TestBaz(t *testing.T) {\n\tvar b foo.Bar\n\tb.Baz()\n}\n",
+			want:       []string{"func TestBaz(t *testing.T) {\n\tvar b foo.Bar\n\tb.Baz()\n}"},
+		},
+		{
+			name:       "long function",
+			identifier: "func:Foo",
+			file:       "foo/bar.go",
+			code:       "package foo\n\nfunc bar() {\n" + strings.Repeat("\tx()\n", 10) + "\tFoo()\n" + strings.Repeat("\tx()\n", 10) + "}\n",
+			want:       []string{strings.Repeat("\tx()\n", 3) + "\tFoo()\n" + strings.TrimSuffix(strings.Repeat("\tx()\n", 3), "\n")},
+		},
+	}
+
+	svc := golang.Must()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := input
+			input.Identifier = tt.identifier
+
+			snippets, err := svc.Usages(input, tt.file, []byte(tt.code))
+			if err != nil {
+				t.Fatalf("Usages() failed: %v", err)
+			}
+			if !cmp.Equal(tt.want, snippets) {
+				t.Errorf("Usages() returned wrong snippets:\n%s", cmp.Diff(tt.want, snippets))
+			}
+		})
+	}
+}
+
+func TestRoot(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.work":        &fstest.MapFile{Data: []byte("go 1.21\n\nuse (\n\t./a\n\t./b\n)\n")},
+		"a/go.mod":       &fstest.MapFile{Data: []byte("module example.com/a // the a module\n\ngo 1.21\n")},
+		"b/go.mod":       &fstest.MapFile{Data: []byte("module \"example.com/b\"\n\ngo 1.21\n")},
+		"a/foo/foo.go":   &fstest.MapFile{Data: []byte("package foo\n\nfunc Foo() {}\n")},
+		"b/cmd/main.go":  &fstest.MapFile{Data: []byte("package main\n\nimport \"example.com/a/foo\"\n\nfunc main() {\n\tfoo.Foo()\n}\n")},
+		"b/cmd/other.go": &fstest.MapFile{Data: []byte("package main\n\nimport \"example.com/b/a/foo\"\n\nfunc other() {\n\tfoo.Foo()\n}\n")},
+	}
+
+	input := generate.PromptInput{
+		File:  "a/foo/foo.go",
+		Input: generate.Input{Code: fsys["a/foo/foo.go"].Data, Language: "go", Identifier: "func:Foo"},
+	}
+
+	svc := golang.Must(golang.Root(fsys))
+
+	tests := map[string][]string{
+		"b/cmd/main.go":  {"func main() {\n\tfoo.Foo()\n}"},
+		"b/cmd/other.go": nil,
+	}
+
+	for file, want := range tests {
+		snippets, err := svc.Usages(input, file, fsys[file].Data)
+		if err != nil {
+			t.Fatalf("Usages() failed: %v", err)
+		}
+		if !cmp.Equal(want, snippets) {
+			t.Errorf("Usages() returned wrong snippets for %s:\n%s", file, cmp.Diff(want, snippets))
+		}
+	}
+}
+
+func TestService_Examples(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		// Client sends requests to the API and decodes the responses.
+		type Client struct {
+			url string
+		}
+
+		// Get sends a GET request to the given path and decodes the response into v.
+		func (c *Client) Get(path string, v any) error {
+			return nil
+		}
+
+		// Close closes the client.
+		func (c *Client) Close() error { return nil }
+
+		// helper is unexported, even though its documentation is long enough.
+		func helper() {}
+	`)
+
+	examples, err := golang.Must().Examples("foo.go", []byte(code))
+	if err != nil {
+		t.Fatalf("Examples() failed: %v", err)
+	}
+
+	want := []generate.Example{
+		{
+			Identifier: "type:Client",
+			Code:       "// Client sends requests to the API and decodes the responses.\ntype Client struct {\n\turl string\n}",
+		},
+		{
+			Identifier: "func:(*Client).Get",
+			Code:       "// Get sends a GET request to the given path and decodes the response into v.\nfunc (c *Client) Get(path string, v any) error",
+		},
+	}
+
+	if !cmp.Equal(want, examples) {
+		t.Fatalf("Examples() returned wrong examples\n%s", cmp.Diff(want, examples))
+	}
+}
+
+func TestService_Patch_help(t *testing.T) {
+	code := heredoc.Doc(`
+		package foo
+
+		type CLI struct {
+			Root string ` + "`" + `name:"root" default:"."` + "`" + `
+		}
+
+		func commands() {
+			cmd := &cobra.Command{
+				Use: "serve",
+			}
+			cmd.Flags().StringP("host", "H", "", "")
+		}
+	`)
+
+	svc := golang.Must()
+
+	patched, err := svc.Patch(context.Background(), "help:CLI.Root", "Root directory of the repository.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "help:command(serve)", "Serve the `docs`.\n\nThis documentation was generated by JotBot.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "help:flag(host)", "\"Host to listen on\"", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		package foo
+
+		type CLI struct {
+			Root string ` + "`" + `name:"root" default:"." help:"Root directory of the repository"` + "`" + `
+		}
+
+		func commands() {
+			cmd := &cobra.Command{
+				Use:   "serve",
+				Short: "Serve the 'docs'",
+			}
+			cmd.Flags().StringP("host", "H", "", "Host to listen on")
+		}
+	`)
+
+	if got := string(patched); got != want {
+		t.Fatalf("Patch() returned unexpected code:\n\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestService_Conform(t *testing.T) {
+	tests := []struct {
+		identifier string
+		doc        string
+		want       string
+	}{
+		{identifier: "func:Foo", doc: "Foo returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "Foo's result is foo.", want: "Foo's result is foo."},
+		{identifier: "func:Foo", doc: "Returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "This function returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "This function `Foo` returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "`Foo` returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "Foo() returns foo.", want: "Foo returns foo."},
+		{identifier: "func:Foo", doc: "The Foo function returns foo.", want: "Foo returns foo."},
+		{identifier: "func:(*Foo).Bar", doc: "[*Foo.Bar] returns bar.", want: "Bar returns bar."},
+		{identifier: "func:(*Foo).Bar", doc: "(*Foo).Bar returns bar.", want: "Bar returns bar."},
+		{identifier: "type:Foo", doc: "A Foo is a foo.", want: "A Foo is a foo."},
+		{identifier: "type:Foo", doc: "Represents a foo.", want: "Foo represents a foo."},
+		{identifier: "func:Foo", doc: "Foobar returns foo."},
+		{identifier: "func:Foo", doc: "This is foo."},
+		{identifier: "func:Foo", doc: "Given a bar, returns foo."},
+	}
+
+	svc := golang.Must()
+	for _, tt := range tests {
+		got, err := svc.Conform(tt.identifier, tt.doc)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Conform(%q, %q) should fail; got %q", tt.identifier, tt.doc, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Conform(%q, %q) failed: %v", tt.identifier, tt.doc, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Conform(%q, %q) = %q; want %q", tt.identifier, tt.doc, got, tt.want)
+		}
+	}
+}
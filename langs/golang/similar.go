@@ -0,0 +1,64 @@
+package golang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeepSimilar configures a [*Service] to keep the existing documentation of a
+// symbol if the generated documentation is essentially identical, so that
+// overriding documentation does not produce diffs that only reword or rewrap
+// comments. threshold is the minimum similarity between 0 and 1 at which the
+// existing documentation is kept, where 1 means that both consist of the same
+// words. The similarity is computed from the longest common subsequence of the
+// words of both comments, ignoring case and punctuation. A threshold of 0
+// disables the comparison, which is the default.
+func KeepSimilar(threshold float64) Option {
+	return func(s *Service) {
+		s.keepSimilar = threshold
+	}
+}
+
+// similarity returns the similarity of the texts a and b between 0 and 1, as
+// twice the length of the longest common subsequence of their words divided by
+// the total number of words.
+func similarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa)+len(wb) == 0 {
+		return 1
+	}
+
+	// lcs[j] is the length of the longest common subsequence of the words of
+	// a that were compared so far and the first j words of b.
+	lcs := make([]int, len(wb)+1)
+	for _, w := range wa {
+		var prev int
+		for j := range wb {
+			cur := lcs[j+1]
+			switch {
+			case w == wb[j]:
+				lcs[j+1] = prev + 1
+			case lcs[j] > lcs[j+1]:
+				lcs[j+1] = lcs[j]
+			}
+			prev = cur
+		}
+	}
+
+	return 2 * float64(lcs[len(wb)]) / float64(len(wa)+len(wb))
+}
+
+// words returns the lowercased words of text without surrounding punctuation.
+func words(text string) []string {
+	fields := strings.Fields(strings.ToLower(text))
+	out := fields[:0]
+	for _, field := range fields {
+		field = strings.TrimFunc(field, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '[' && r != ']'
+		})
+		if field != "" {
+			out = append(out, field)
+		}
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// serializationTags are the keys of struct tags that define how a field is
+// encoded, e.g. its JSON key or database column.
+var serializationTags = []string{"json", "yaml", "xml", "toml", "db", "bson", "msgpack", "mapstructure", "form"}
+
+// structTags returns the fields of the struct type identified by identifier
+// that have serialization tags, together with these tags, e.g.
+// `ID json:"id" db:"user_id"`. Tags with other keys are omitted.
+func structTags(identifier string, code []byte) []string {
+	name, ok := strings.CutPrefix(identifier, "type:")
+	if !ok {
+		return nil
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var st *ast.StructType
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				if spec := spec.(*ast.TypeSpec); spec.Name.Name == name {
+					st, _ = spec.Type.(*ast.StructType)
+				}
+			}
+		}
+	}
+	if st == nil {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+
+		tag := reflect.StructTag(unquote(field.Tag.Value))
+		var tags []string
+		for _, key := range serializationTags {
+			if value, ok := tag.Lookup(key); ok {
+				tags = append(tags, key+":"+strconv.Quote(value))
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+
+		names := make([]string, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = name.Name
+		}
+		if len(names) == 0 {
+			// Embedded fields are named after their type.
+			names = append(names, receiverName(field.Type))
+		}
+
+		fields = append(fields, strings.Join(names, ", ")+" "+strings.Join(tags, " "))
+	}
+
+	return fields
+}
+
+// withStructTags appends the serialization tags of the fields of the struct
+// type of the input to a prompt, so that the documentation can mention the
+// names under which the fields are encoded.
+func withStructTags(prompt, identifier string, code []byte) string {
+	fields := structTags(identifier, code)
+	if len(fields) == 0 {
+		return prompt
+	}
+
+	return fmt.Sprintf(
+		"%s\n\nThe fields of %s have struct tags that define how they are serialized:\n---\n%s\n---\nWhere relevant, mention the serialized names, such as JSON keys or database columns, as they appear in the tags instead of the names of the Go fields.",
+		strings.TrimRight(prompt, "\n"),
+		Target(identifier),
+		strings.Join(fields, "\n"),
+	)
+}
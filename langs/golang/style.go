@@ -0,0 +1,85 @@
+package golang
+
+import "strings"
+
+// Style is the style of the comments that a [*Service] writes.
+type Style int
+
+const (
+	// Line writes documentation as "//" line comments, which is the style of
+	// the standard library. This is the default.
+	Line Style = iota
+
+	// Block writes documentation as a "/* ... */" block comment, with the
+	// comment markers on lines of their own.
+	Block
+)
+
+// CommentStyle configures the style of the comments that a [*Service] writes
+// when it patches documentation into code. Directives such as "//go:noinline"
+// are always kept as line comments. Documentation that contains "*/" cannot be
+// written as a block comment and is written as line comments instead.
+func CommentStyle(style Style) Option {
+	return func(s *Service) {
+		s.commentStyle = style
+	}
+}
+
+// blockComment converts the line comments returned by formatDoc into a block
+// comment in the canonical format of gofmt. It reports false if the comment
+// cannot be written as a block comment.
+func blockComment(lines []string) (string, bool) {
+	text := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, "//")
+		if strings.HasPrefix(line, " ") {
+			line = line[1:]
+		}
+		if strings.Contains(line, "*/") {
+			return "", false
+		}
+		text[i] = line
+	}
+	return "/*\n" + strings.Join(text, "\n") + "\n*/", true
+}
+
+// lineComments converts the block comments in decs into line comments, so
+// that existing documentation is processed alike in both styles.
+func lineComments(decs []string) []string {
+	var lines []string
+	for _, dec := range decs {
+		if !strings.HasPrefix(dec, "/*") {
+			lines = append(lines, dec)
+			continue
+		}
+
+		text := strings.TrimSuffix(strings.TrimPrefix(dec, "/*"), "*/")
+		text = strings.Trim(text, "\n")
+		if !strings.Contains(text, "\n") {
+			text = strings.TrimSpace(text)
+		}
+
+		for _, line := range strings.Split(text, "\n") {
+			switch {
+			case strings.TrimSpace(line) == "":
+				lines = append(lines, "//")
+			case strings.HasPrefix(line, "\t"):
+				lines = append(lines, "//"+line)
+			default:
+				lines = append(lines, "// "+line)
+			}
+		}
+	}
+	return lines
+}
+
+// isBlockComment reports whether the documentation in decs is written as a
+// block comment.
+func isBlockComment(decs []string) bool {
+	for _, dec := range decs {
+		if strings.HasPrefix(dec, "/*") {
+			return true
+		}
+	}
+	return false
+}
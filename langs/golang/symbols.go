@@ -0,0 +1,81 @@
+package golang
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/modernice/jotbot/analyze"
+	"github.com/modernice/jotbot/internal/nodes"
+	"golang.org/x/exp/slices"
+)
+
+// Symbols returns the exported symbols in code, documented or not, for use by
+// an [analyze.Analyzer]. The symbols are the identifiers that Find reports
+// when documented identifiers are included, using the same rules for test
+// functions. The documentation options of the Finder are ignored.
+func (f *Finder) Symbols(ctx context.Context, file string, code []byte) ([]analyze.Symbol, error) {
+	all, undocumented := *f, *f
+	all.includeDocumented, all.documentedFunc, all.findHelp = true, nil, false
+	undocumented.includeDocumented, undocumented.documentedFunc, undocumented.findHelp = false, nil, false
+
+	identifiers, err := all.Find(ctx, file, code)
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := undocumented.Find(ctx, file, code)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := nodes.Parse(code)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]analyze.Symbol, len(identifiers))
+	for i, identifier := range identifiers {
+		symbols[i].Identifier = identifier
+		if _, found := slices.BinarySearch(missing, identifier); found {
+			continue
+		}
+		symbols[i].Documented = true
+
+		spec, outer, ok := nodes.Find(identifier, root)
+		if !ok {
+			continue
+		}
+		doc := docText(nodes.CommentTarget(spec, outer))
+		if typeSpec, ok := spec.(*dst.TypeSpec); doc == "" && (!ok || typeDocumented(typeSpec, outer.(*dst.GenDecl))) {
+			// The doc comment of a grouped const or var declaration documents
+			// all its specs, but types are documented individually.
+			doc = docText(outer)
+		}
+		symbols[i].Doc = doc
+	}
+
+	return symbols, nil
+}
+
+// docText returns the text of the doc comment of node without the comment
+// markers.
+func docText(node dst.Node) string {
+	var lines []string
+	for _, c := range node.Decorations().Start.All() {
+		c = strings.TrimSpace(c)
+		switch {
+		case strings.HasPrefix(c, "//"):
+			lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(c, "//"), " "))
+		case strings.HasPrefix(c, "/*"):
+			lines = append(lines, strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(c, "/*"), "*/")))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Symbols returns the exported symbols in code, documented or not. See
+// [*Finder.Symbols].
+func (svc *Service) Symbols(ctx context.Context, file string, code []byte) ([]analyze.Symbol, error) {
+	return svc.finder.Symbols(ctx, file, code)
+}
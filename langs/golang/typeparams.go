@@ -0,0 +1,130 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// typeParams returns the type parameter list of the generic function or type
+// identified by identifier, e.g. "[K comparable, V any]", and the declarations
+// of the constraints that code declares. Methods have the type parameters of
+// their receiver type. If the symbol is not generic, typeParams returns an
+// empty list.
+func typeParams(identifier string, code []byte) (params string, constraints []string) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err != nil {
+		return "", nil
+	}
+
+	source := func(node ast.Node) string {
+		return string(code[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset])
+	}
+
+	list := typeParamList(identifier, file)
+	if list == nil || len(list.List) == 0 {
+		return "", nil
+	}
+
+	types := make(map[string]*ast.TypeSpec)
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				types[spec.Name.Name] = spec
+			}
+		}
+	}
+
+	fields := make([]string, len(list.List))
+	seen := make(map[string]bool)
+	for i, field := range list.List {
+		names := make([]string, len(field.Names))
+		for j, name := range field.Names {
+			names[j] = name.Name
+		}
+		fields[i] = strings.Join(names, ", ") + " " + source(field.Type)
+
+		ast.Inspect(field.Type, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.SelectorExpr:
+				return false
+			case *ast.Ident:
+				spec, ok := types[node.Name]
+				if !ok || seen[node.Name] {
+					break
+				}
+				if _, ok := spec.Type.(*ast.InterfaceType); ok {
+					seen[node.Name] = true
+					constraints = append(constraints, "type "+dedent(source(spec)))
+				}
+			}
+			return true
+		})
+	}
+
+	return "[" + strings.Join(fields, ", ") + "]", constraints
+}
+
+// typeParamList returns the type parameters of the function or type
+// identified by identifier, or of the receiver type of the method identified
+// by identifier.
+func typeParamList(identifier string, file *ast.File) *ast.FieldList {
+	kind, name, ok := strings.Cut(identifier, ":")
+	if !ok {
+		return nil
+	}
+
+	if recv, ok := receiverType(identifier); ok {
+		kind, name = "type", recv
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if kind == "func" && decl.Recv == nil && decl.Name.Name == name {
+				return decl.Type.TypeParams
+			}
+		case *ast.GenDecl:
+			if kind != "type" || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				if spec := spec.(*ast.TypeSpec); spec.Name.Name == name {
+					return spec.TypeParams
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// withTypeParams appends the type parameters of the generic function or type
+// of the input to a prompt, so that the documentation explains their
+// constraints.
+func withTypeParams(prompt, identifier string, code []byte) string {
+	params, constraints := typeParams(identifier, code)
+	if params == "" {
+		return prompt
+	}
+
+	generic := "Note that " + Target(identifier) + " is generic"
+	if _, ok := receiverType(identifier); ok {
+		generic = "Note that the receiver type of " + Target(identifier) + " is generic"
+	}
+
+	prompt = fmt.Sprintf(
+		"%s\n\n%s and has the type parameters %s. If a constraint restricts the types that callers can use, explain it in the comment.",
+		strings.TrimRight(prompt, "\n"),
+		generic,
+		params,
+	)
+	if len(constraints) > 0 {
+		prompt = fmt.Sprintf("%s The constraints are declared as follows:\n---\n%s\n---", prompt, strings.Join(constraints, "\n\n"))
+	}
+	return prompt
+}
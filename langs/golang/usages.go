@@ -0,0 +1,152 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+)
+
+// MaxUsageLines is the maximum number of lines of a function that calls the
+// documented symbol for the whole function to be used as a usage snippet.
+// The snippets of longer functions consist of the lines around the call.
+const MaxUsageLines = 15
+
+// Usages returns the functions in code that call the function or method of
+// the input, one snippet per function. Calls are recognized by name, without
+// type information: a function is called by its name within its package, and
+// as "pkg.Name" by packages that import it, and a method is called as
+// "x.Name" by its own package and by packages that import it. Calls of methods
+// with the same name on other types cannot be told apart. Importing packages
+// are recognized by the import path of the package, which requires [Root] in
+// repositories with several modules.
+func (svc *Service) Usages(input generate.PromptInput, file string, code []byte) ([]string, error) {
+	kind, name, ok := strings.Cut(input.Identifier, ":")
+	if !ok || kind != "func" {
+		return nil, nil
+	}
+	_, method, isMethod := strings.Cut(name, ".")
+	if isMethod {
+		name = method
+	}
+	if !bytes.Contains(code, []byte(name)) {
+		return nil, nil
+	}
+
+	declPkg, err := parser.ParseFile(token.NewFileSet(), "", input.Code, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("parse package clause of %s: %w", input.File, err)
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, file, code, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	samePkg := path.Dir(file) == path.Dir(input.File) && node.Name.Name == declPkg.Name.Name
+	importPath, _ := svc.importPath(path.Dir(input.File))
+	qualifiers := importNames(node, importPath, path.Dir(input.File), declPkg.Name.Name)
+	if !samePkg && (len(qualifiers) == 0 || !token.IsExported(name)) {
+		return nil, nil
+	}
+
+	calls := func(expr ast.Expr) bool {
+		switch fn := expr.(type) {
+		case *ast.IndexExpr:
+			expr = fn.X
+		case *ast.IndexListExpr:
+			expr = fn.X
+		}
+		switch fn := expr.(type) {
+		case *ast.Ident:
+			return samePkg && !isMethod && fn.Name == name
+		case *ast.SelectorExpr:
+			if fn.Sel.Name != name {
+				return false
+			}
+			if isMethod {
+				return true
+			}
+			x, ok := fn.X.(*ast.Ident)
+			return ok && qualifiers[x.Name]
+		}
+		return false
+	}
+
+	lines := bytes.Split(code, []byte("\n"))
+
+	var snippets []string
+	for _, decl := range node.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		var call *ast.CallExpr
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if c, ok := n.(*ast.CallExpr); ok && call == nil && calls(c.Fun) {
+				call = c
+			}
+			return call == nil
+		})
+		if call == nil {
+			continue
+		}
+
+		from, to := fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+		if to-from+1 > MaxUsageLines {
+			line := fset.Position(call.Pos()).Line
+			if line-MaxUsageLines/4 > from {
+				from = line - MaxUsageLines/4
+			}
+			if line+MaxUsageLines/4 < to {
+				to = line + MaxUsageLines/4
+			}
+		}
+		snippets = append(snippets, string(bytes.Join(lines[from-1:to], []byte("\n"))))
+	}
+
+	return snippets, nil
+}
+
+// importNames returns the names under which node imports the package in the
+// directory dir, which is named pkg. If the import path of the package is
+// known, the package is recognized by its import path. Otherwise, it is
+// recognized by the suffix of its import path, or by its name if it is in the
+// root directory.
+func importNames(node *ast.File, importPath, dir, pkg string) map[string]bool {
+	names := make(map[string]bool)
+	for _, spec := range node.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case importPath != "":
+			if p != importPath {
+				continue
+			}
+		case dir == ".":
+			if importName(p) != pkg {
+				continue
+			}
+		case p != dir && !strings.HasSuffix(p, "/"+dir):
+			continue
+		}
+
+		name := pkg
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = true
+	}
+	return names
+}
@@ -0,0 +1,160 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	commentsType     = reflect.TypeOf([]*ast.CommentGroup(nil))
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+	nodeType         = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// Verify returns an error if patched, the code patched with the documentation
+// of the given identifier, is not valid Go code or differs from code in
+// anything other than comments and formatting. Both files are parsed and
+// their syntax trees are compared, ignoring comments and the exact positions
+// of nodes, except for cgo preambles, which must not change. Help texts are
+// string literals, so patches of help texts are not verified.
+func (svc *Service) Verify(identifier string, code, patched []byte) error {
+	if strings.HasPrefix(identifier, HelpPrefix) {
+		return nil
+	}
+
+	before, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("parse original code: %w", err)
+	}
+
+	fset := token.NewFileSet()
+	after, err := parser.ParseFile(fset, "", patched, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return fmt.Errorf("patched code is invalid: %w", err)
+	}
+
+	var c astComparer
+	if !c.equal(reflect.ValueOf(before), reflect.ValueOf(after)) {
+		if c.at.IsValid() {
+			return fmt.Errorf("patch changed more than comments (line %d of the patched code)", fset.Position(c.at).Line)
+		}
+		return fmt.Errorf("patch changed more than comments")
+	}
+
+	want, got := cgoPreambles(before), cgoPreambles(after)
+	for i, p := range got {
+		if p.text != want[i].text {
+			return fmt.Errorf("patch changed the cgo preamble (line %d of the patched code)", fset.Position(p.pos).Line)
+		}
+	}
+
+	return nil
+}
+
+type cgoPreamble struct {
+	pos  token.Pos
+	text string
+}
+
+// cgoPreambles returns the cgo preambles of the `import "C"` declarations of
+// file, in order. The preamble of an import spec is its own comment, or the
+// comment of its declaration if it is the only spec of the declaration.
+func cgoPreambles(file *ast.File) []cgoPreamble {
+	var preambles []cgoPreamble
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ImportSpec)
+			if spec.Path.Value != `"C"` {
+				continue
+			}
+
+			doc := spec.Doc
+			if doc == nil && len(decl.Specs) == 1 {
+				doc = decl.Doc
+			}
+
+			p := cgoPreamble{pos: spec.Pos()}
+			if doc != nil {
+				p.pos = doc.Pos()
+				for _, c := range doc.List {
+					p.text += c.Text + "\n"
+				}
+			}
+			preambles = append(preambles, p)
+		}
+	}
+	return preambles
+}
+
+// astComparer compares syntax trees, ignoring comments and the positions of
+// nodes. Only the validity of positions is compared, because it is meaningful
+// for some nodes, e.g. the position of the parentheses of a grouped
+// declaration or of the ellipsis of a variadic call.
+type astComparer struct {
+	// at is the position of the innermost node of the second tree that
+	// differs from the first tree.
+	at token.Pos
+}
+
+func (c *astComparer) equal(a, b reflect.Value) bool {
+	if c.compare(a, b) {
+		return true
+	}
+	if c.at == token.NoPos && b.Type().Implements(nodeType) && (b.Kind() != reflect.Pointer && b.Kind() != reflect.Interface || !b.IsNil()) {
+		c.at = b.Interface().(ast.Node).Pos()
+	}
+	return false
+}
+
+func (c *astComparer) compare(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	if a.Type() == posType {
+		return a.Interface().(token.Pos).IsValid() == b.Interface().(token.Pos).IsValid()
+	}
+
+	switch a.Type() {
+	case commentGroupType, commentsType, objectType, scopeType:
+		return true
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return c.equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !c.equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !c.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
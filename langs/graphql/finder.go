@@ -0,0 +1,302 @@
+package graphql
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// The expressions are matched against lines whose strings and comments were
+// blanked out by stripLine. An optional description on the same line, such as
+// `"The ID." id: ID!`, is captured by the first group.
+var (
+	typeRE     = regexp.MustCompile(`^\s*((?:"[^"]*"\s*)+)?(extend\s+)?(type|interface|input|enum|union|scalar)\s+([_A-Za-z]\w*)`)
+	fieldRE    = regexp.MustCompile(`^\s*((?:"[^"]*"\s*)+)?([_A-Za-z]\w*)\s*[(:]`)
+	argumentRE = regexp.MustCompile(`^\s*((?:"[^"]*"\s*)+)?([_A-Za-z]\w*)\s*:`)
+)
+
+// Finder searches GraphQL schemas for types, fields and arguments that have no
+// description.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the types ("type:Name"), fields
+// ("field:Type.name") and arguments ("arg:Type.field.name") in code that have
+// no description. Fields of type extensions are identified by the extended
+// type, while the extensions themselves cannot have a description.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+
+	// name is the byte offset of the name of the declaration in its line.
+	// If it is greater than the indentation of the line, the declaration is
+	// preceded by a description on the same line.
+	name   int
+	inline bool
+
+	// shared reports whether an argument shares its line with the field or
+	// with other arguments, so that it must be moved to its own line before
+	// it can be documented.
+	shared bool
+
+	// field is the line of the field of an argument, and typ the line of the
+	// type of a field or argument.
+	field int
+	typ   int
+}
+
+// declarations returns the type definitions, fields and arguments of a schema.
+func declarations(src []string) []declaration {
+	var (
+		decls     []declaration
+		owner     string
+		ownerLine int
+		hasFields bool
+		field     string
+		fieldLine int
+		braces    int
+		parens    int
+		block     bool
+	)
+	for i, line := range src {
+		var code string
+		code, block = stripLine(line, block)
+
+		switch {
+		case braces == 0:
+			if m := typeRE.FindStringSubmatchIndex(code); m != nil {
+				name := code[m[8]:m[9]]
+				owner, ownerLine, field = name, i, ""
+				kind := code[m[6]:m[7]]
+				hasFields = kind == "type" || kind == "interface" || kind == "input"
+				if m[4] < 0 {
+					decls = append(decls, declaration{identifier: "type:" + name, line: i, name: m[6], inline: m[2] >= 0})
+				}
+			} else if !continuesType(code) {
+				owner = ""
+			}
+
+		case braces == 1 && parens == 0 && owner != "" && hasFields:
+			m := fieldRE.FindStringSubmatchIndex(code)
+			if m == nil {
+				break
+			}
+			field, fieldLine = code[m[4]:m[5]], i
+			decls = append(decls, declaration{
+				identifier: "field:" + owner + "." + field,
+				line:       i,
+				name:       m[4],
+				inline:     m[2] >= 0,
+				typ:        ownerLine,
+			})
+			if strings.TrimSpace(code[m[5]:m[1]]) == "(" {
+				for _, a := range arguments(code[m[1]:]) {
+					decls = append(decls, declaration{
+						identifier: "arg:" + owner + "." + field + "." + a.name,
+						line:       i,
+						inline:     a.documented,
+						shared:     true,
+						field:      fieldLine,
+						typ:        ownerLine,
+					})
+				}
+			}
+
+		case braces == 1 && parens > 0 && field != "":
+			args := arguments(code)
+			for _, a := range args {
+				d := declaration{
+					identifier: "arg:" + owner + "." + field + "." + a.name,
+					line:       i,
+					inline:     a.documented,
+					shared:     len(args) > 1,
+					field:      fieldLine,
+					typ:        ownerLine,
+				}
+				if m := argumentRE.FindStringSubmatchIndex(code); m != nil && !d.shared {
+					d.name = m[4]
+				}
+				decls = append(decls, d)
+			}
+		}
+
+		braces += strings.Count(code, "{") - strings.Count(code, "}")
+		parens += strings.Count(code, "(") - strings.Count(code, ")")
+		if braces < 0 {
+			braces = 0
+		}
+		if parens < 0 {
+			parens = 0
+		}
+		if braces == 0 && parens == 0 {
+			field = ""
+		}
+	}
+
+	return decls
+}
+
+// continuesType reports whether a line at the top level of a schema continues
+// the preceding type definition, e.g. with its implemented interfaces or the
+// opening brace of its fields.
+func continuesType(code string) bool {
+	trimmed := strings.TrimSpace(code)
+	return trimmed == "" ||
+		strings.HasPrefix(trimmed, "{") ||
+		strings.HasPrefix(trimmed, "&") ||
+		strings.HasPrefix(trimmed, "@") ||
+		strings.HasPrefix(trimmed, "implements") ||
+		strings.HasPrefix(trimmed, `"`)
+}
+
+type argument struct {
+	name       string
+	documented bool
+}
+
+// arguments returns the arguments in the given part of an argument list, up
+// to the parenthesis that closes the list. Arguments are documented if they
+// are preceded by a description within the same part.
+func arguments(code string) []argument {
+	var args []argument
+	for _, piece := range splitArguments(code) {
+		if m := argumentRE.FindStringSubmatch(piece); m != nil {
+			args = append(args, argument{name: m[2], documented: m[1] != ""})
+		}
+	}
+	return args
+}
+
+// splitArguments splits the given part of an argument list at the commas that
+// separate the arguments, up to the parenthesis that closes the list.
+func splitArguments(code string) []string {
+	var (
+		pieces []string
+		depth  int
+		start  int
+	)
+	for i := 0; i < len(code); i++ {
+		switch code[i] {
+		case '(', '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ')':
+			if depth == 0 {
+				return append(pieces, code[start:i])
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				pieces = append(pieces, code[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(pieces, code[start:])
+}
+
+// stripLine blanks out the contents of strings and comments in a line, so that
+// the braces and parentheses within them are not counted. The returned line
+// has the same length as the original line, and the quotes of strings are
+// kept. block reports whether the line starts within a block string, and the
+// returned bool whether it ends within one.
+func stripLine(line string, block bool) (string, bool) {
+	out := []byte(line)
+	str := false
+	for i := 0; i < len(out); i++ {
+		switch {
+		case block:
+			if strings.HasPrefix(line[i:], `"""`) && (i == 0 || line[i-1] != '\\') {
+				block = false
+				i += 2
+				continue
+			}
+			out[i] = ' '
+		case str:
+			switch line[i] {
+			case '\\':
+				out[i] = ' '
+				if i+1 < len(out) {
+					i++
+					out[i] = ' '
+				}
+			case '"':
+				str = false
+			default:
+				out[i] = ' '
+			}
+		case strings.HasPrefix(line[i:], `"""`):
+			block = true
+			i += 2
+		case line[i] == '"':
+			str = true
+		case line[i] == '#':
+			for ; i < len(out); i++ {
+				out[i] = ' '
+			}
+		}
+	}
+	return string(out), block
+}
+
+func findDeclaration(src []string, identifier string) (declaration, bool) {
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			return d, true
+		}
+	}
+	return declaration{}, false
+}
+
+// documented reports whether the declaration has a description, either on the
+// lines above or on the same line.
+func documented(src []string, d declaration) bool {
+	return d.inline || !d.shared && docStart(src, d.line) < d.line
+}
+
+// docStart returns the index of the first line of the description that
+// directly precedes the given line, or line if there is none.
+func docStart(src []string, line int) int {
+	if line == 0 {
+		return line
+	}
+
+	prev := strings.TrimSpace(src[line-1])
+	switch {
+	case strings.HasSuffix(prev, `"""`):
+		if len(prev) >= 6 && strings.HasPrefix(prev, `"""`) {
+			return line - 1
+		}
+		for i := line - 2; i >= 0; i-- {
+			if strings.HasPrefix(strings.TrimSpace(src[i]), `"""`) {
+				return i
+			}
+		}
+	case len(prev) >= 2 && prev[0] == '"' && strings.HasSuffix(prev, `"`):
+		return line - 1
+	}
+
+	return line
+}
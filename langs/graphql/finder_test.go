@@ -0,0 +1,75 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/graphql"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		"""
+		A user of the application.
+		"""
+		type User implements Node {
+		  "The ID of the user."
+		  id: ID!
+		  name: String # "{"
+		  friends(first: Int = 10, "The cursor." after: String): [User!]!
+		  posts(
+		    """
+		    The maximum number of posts (default 10).
+		    """
+		    first: Int
+		    orderBy: PostOrder = {field: CREATED_AT, direction: DESC}
+		  ): [Post!]!
+		}
+
+		enum Role {
+		  ADMIN
+		  MEMBER
+		}
+
+		input PostOrder {
+		  """The field to order by."""
+		  field: PostField!
+		  direction: Direction
+		}
+
+		scalar DateTime
+
+		union SearchResult = User | Post
+
+		extend type Query {
+		  me: User
+		}
+
+		query Viewer {
+		  me {
+		    id
+		  }
+		}
+	`)
+
+	findings, err := graphql.NewFinder().Find(context.Background(), "schema.graphql", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"arg:User.friends.first",
+		"arg:User.posts.orderBy",
+		"field:PostOrder.direction",
+		"field:Query.me",
+		"field:User.friends",
+		"field:User.name",
+		"field:User.posts",
+		"type:DateTime",
+		"type:PostOrder",
+		"type:Role",
+		"type:SearchResult",
+	}, findings)
+}
@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// Prompt returns the prompt that asks for the description of the type, field
+// or argument identified by the input.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+	if kind == "arg" {
+		kind = "argument"
+	}
+
+	return heredoc.Docf(`
+		Write a description for the GraphQL %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s represents for the clients of the API, but not how it is implemented. For example, if %s is a field that returns the users of a team, you must not describe it as a "field that returns the users of a team." Instead, you must describe it as "The users of the team.".
+
+		Refer to other types, fields and arguments by enclosing them in backticks.
+
+		Output only the unquoted description, do not include the description quotes (""" or ").
+
+		Keep the description as short as possible while still being descriptive.
+
+		Here is the schema for reference:
+		---
+		# %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		input.File,
+		input.Code,
+	)
+}
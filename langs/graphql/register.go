@@ -0,0 +1,7 @@
+package graphql
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("graphql", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,196 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of GraphQL schema files.
+var FileExtensions = []string{".graphql", ".gql"}
+
+// Service documents types, fields and arguments of GraphQL schemas using
+// descriptions ("""...""").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for GraphQL schemas.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of GraphQL schema files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented types, fields and arguments
+// in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the description of the declaration identified by
+// identifier, replacing its existing description. Arguments that share a line
+// with their field or with other arguments are first moved to their own lines,
+// so that each argument can have a description.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	d, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	if d.shared {
+		src = expandArguments(src, d.field, d.typ)
+		if d, ok = findDeclaration(src, identifier); !ok || d.shared {
+			return nil, fmt.Errorf("cannot move %q to its own line", identifier)
+		}
+	}
+
+	indent := lines.Indent(src[d.line])
+	if d.inline {
+		src[d.line] = indent + src[d.line][d.name:]
+	}
+
+	var description []string
+	if doc = normalize(doc); doc != "" {
+		description = formatDescription(doc, indent)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, d.line), d.line, description)), nil
+}
+
+// expandArguments moves each argument of the field at the given line to its
+// own line. The arguments are indented by one level more than the field, as
+// determined by the indentation of the field within its type.
+func expandArguments(src []string, field, typ int) []string {
+	code, block := stripLine(src[field], false)
+	open := strings.Index(code, "(")
+	if open < 0 {
+		return src
+	}
+
+	indent := lines.Indent(src[field])
+	step := strings.TrimPrefix(indent, lines.Indent(src[typ]))
+	if step == "" {
+		step = "  "
+	}
+
+	var (
+		pieces []string
+		piece  strings.Builder
+		depth  int
+	)
+	flush := func() {
+		if p := strings.TrimSpace(piece.String()); p != "" {
+			pieces = append(pieces, p)
+		}
+		piece.Reset()
+	}
+
+	for i := field; i < len(src); i++ {
+		start := 0
+		if i == field {
+			start = open + 1
+		} else {
+			code, block = stripLine(src[i], block)
+		}
+
+		for j := start; j < len(code); j++ {
+			switch code[j] {
+			case '(', '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+			case ')':
+				if depth == 0 {
+					flush()
+					out := []string{strings.TrimRight(src[field][:open+1], " \t")}
+					for _, p := range pieces {
+						for _, l := range strings.Split(p, "\n") {
+							out = append(out, indent+step+strings.TrimSpace(l))
+						}
+					}
+					out = append(out, indent+src[i][j:])
+					return lines.Replace(src, field, i+1, out)
+				}
+				depth--
+			case ',':
+				if depth == 0 {
+					flush()
+					continue
+				}
+			}
+			piece.WriteByte(src[i][j])
+		}
+
+		// Block strings keep their lines, all other lines end the argument.
+		if block {
+			piece.WriteByte('\n')
+		} else {
+			flush()
+		}
+	}
+
+	return src
+}
+
+// formatDescription formats doc as a description. Short descriptions are
+// written on a single line, longer ones are wrapped within a block string.
+func formatDescription(doc, indent string) []string {
+	doc = strings.ReplaceAll(doc, `"""`, `\"""`)
+	if !strings.Contains(doc, "\n") && len(indent)+len(doc)+len(`""""""`) <= 80 {
+		return []string{indent + `"""` + doc + `"""`}
+	}
+
+	out := append([]string{indent + `"""`}, lines.Comment(doc, indent, "", "", 80)...)
+	return append(out, indent+`"""`)
+}
+
+// normalize removes quotes and comment markers from a generated description
+// and joins the lines of its paragraphs.
+func normalize(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if strings.HasPrefix(doc, `"""`) && strings.HasSuffix(doc, `"""`) && len(doc) >= 6 {
+		doc = doc[3 : len(doc)-3]
+	} else if strings.HasPrefix(doc, `"`) && strings.HasSuffix(doc, `"`) && len(doc) >= 2 {
+		doc = doc[1 : len(doc)-1]
+	}
+
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		docLines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "#"))
+	}
+
+	return internal.RemoveColumns(strings.TrimSpace(strings.Join(docLines, "\n")))
+}
@@ -0,0 +1,58 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/graphql"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		type User {
+		  "Outdated."
+		  id: ID!
+		  friends(first: Int = 10, "The cursor." after: String): [User!]!
+		}
+	`)
+
+	patches := []struct {
+		identifier string
+		doc        string
+	}{
+		{"type:User", "A user of the application, who can be a member of any number of teams and is identified by a unique ID."},
+		{"field:User.id", `"""The ID of the user."""`},
+		{"arg:User.friends.first", "The maximum number of\nfriends."},
+	}
+
+	svc := graphql.New()
+	patched := []byte(code)
+	for _, p := range patches {
+		var err error
+		if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+			t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+		}
+	}
+
+	want := heredoc.Doc(`
+		"""
+		A user of the application, who can be a member of any number of teams and is
+		identified by a unique ID.
+		"""
+		type User {
+		  """The ID of the user."""
+		  id: ID!
+		  friends(
+		    """The maximum number of friends."""
+		    first: Int = 10
+		    "The cursor." after: String
+		  ): [User!]!
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
@@ -0,0 +1,357 @@
+package groovy
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+const modifiers = `(?:(?:public|protected|private|static|final|abstract|synchronized|sealed|non-sealed|default|@\w+(?:\([^)]*\))?)\s+)*`
+
+var (
+	classRE     = regexp.MustCompile(`^\s*(` + modifiers + `)(?:class|interface|trait|enum|@interface|record)\s+(\w+)`)
+	methodRE    = regexp.MustCompile(`^\s*(` + modifiers + `)(def\s+)?(?:([\w.]+(?:<[\w<>?,.\s]*>)?(?:\[\])*)\s+)?(\w+)\s*\(`)
+	taskRE      = regexp.MustCompile(`^\s*task(?:\s+|\s*\(\s*)['"]?([\w-]+)`)
+	registerRE  = regexp.MustCompile(`^\s*tasks\.(?:register|create)\s*\(\s*['"]([\w-]+)['"]`)
+	privateRE   = regexp.MustCompile(`\b(?:private|protected)\b`)
+	identRE     = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+	annotatedRE = regexp.MustCompile(`^\s*@\w+`)
+)
+
+var keywords = []string{"if", "for", "while", "switch", "catch", "synchronized", "return", "new", "throw", "else", "assert"}
+
+// Finder searches Groovy source code and Gradle build scripts for public
+// classes, methods and custom task definitions that have no Groovydoc
+// comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public classes ("class:Name"),
+// methods ("method:Class.name"), top-level script methods ("func:name") and, in
+// Gradle build scripts, task definitions ("task:name") in code that have no
+// Groovydoc comment. Nested classes are identified by their path, e.g.
+// "class:Outer.Inner".
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src, isGradle(file)) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+func isGradle(file string) bool {
+	return path.Ext(file) == ".gradle"
+}
+
+type declaration struct {
+	identifier string
+	line       int
+}
+
+type class struct {
+	path   string
+	depth  int
+	public bool
+}
+
+// declarations returns the public declarations in src. Task definitions are
+// only returned if gradle is true.
+func declarations(src []string, gradle bool) []declaration {
+	var (
+		decls   []declaration
+		classes []class
+		depth   int
+		state   lineState
+	)
+	for i, line := range src {
+		code := state.strip(line)
+
+		for len(classes) > 0 && classes[len(classes)-1].depth > depth {
+			classes = classes[:len(classes)-1]
+		}
+
+		var owner *class
+		if len(classes) > 0 && classes[len(classes)-1].depth == depth {
+			owner = &classes[len(classes)-1]
+		}
+
+		if owner != nil || depth == 0 {
+			if d, c, ok := parseDeclaration(src, i, code, owner, gradle); ok {
+				if d != "" {
+					decls = append(decls, declaration{identifier: d, line: i})
+				}
+				if c != nil {
+					c.depth = depth + 1
+					classes = append(classes, *c)
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+	}
+
+	return decls
+}
+
+// parseDeclaration parses the declaration in the given line of code, which is
+// either at the top level of the file (owner is nil) or in the body of the
+// owner class. It returns the identifier of the declaration, which is empty if
+// the declaration is not public, and the class that the declaration opens, if
+// any.
+func parseDeclaration(src []string, line int, code string, owner *class, gradle bool) (string, *class, bool) {
+	public := owner == nil || owner.public
+
+	if m := classRE.FindStringSubmatch(code); m != nil {
+		c := class{path: m[2], public: public && !privateRE.MatchString(m[1])}
+		if owner != nil {
+			c.path = owner.path + "." + m[2]
+		}
+		if !c.public {
+			return "", &c, true
+		}
+		return "class:" + c.path, &c, true
+	}
+
+	if owner == nil && gradle {
+		if m := taskRE.FindStringSubmatch(src[line]); m != nil {
+			return "task:" + m[1], nil, true
+		}
+		if m := registerRE.FindStringSubmatch(src[line]); m != nil {
+			return "task:" + m[1], nil, true
+		}
+	}
+
+	m := methodRE.FindStringSubmatch(code)
+	if m == nil || slices.Contains(keywords, m[4]) || slices.Contains(keywords, m[3]) {
+		return "", nil, false
+	}
+
+	name := m[4]
+	isConstructor := owner != nil && m[3] == "" && m[2] == "" && name == lastSegment(owner.path)
+	if m[1] == "" && m[2] == "" && m[3] == "" && !isConstructor {
+		// Method calls, such as "println(x)", have neither modifiers nor types.
+		return "", nil, false
+	}
+
+	if owner == nil {
+		// Top-level statements such as "println foo(bar)" look like method
+		// declarations, so top-level methods must have a body.
+		if !hasBody(src, line) {
+			return "", nil, false
+		}
+		if !public || privateRE.MatchString(m[1]) {
+			return "", nil, false
+		}
+		return "func:" + name, nil, true
+	}
+
+	if !public || privateRE.MatchString(m[1]) {
+		return "", nil, false
+	}
+
+	return "method:" + owner.path + "." + name, nil, true
+}
+
+// returnsValue reports whether the method that is declared in the given line
+// returns a value. Methods that are declared using "def" are assumed to return
+// a value.
+func returnsValue(line string) bool {
+	m := methodRE.FindStringSubmatch(line)
+	return m != nil && (m[2] != "" || (m[3] != "" && m[3] != "void"))
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// signature returns the code of the declaration that begins at the given
+// line up to the end of its parameter list, which may span multiple lines, and
+// the index of the line that ends the parameter list.
+func signature(src []string, line int) (string, int) {
+	var (
+		parts  []string
+		parens int
+		state  lineState
+	)
+	for i := line; i < len(src); i++ {
+		code := state.strip(src[i])
+		parts = append(parts, code)
+		parens += strings.Count(code, "(") - strings.Count(code, ")")
+		if parens <= 0 {
+			return strings.Join(parts, "\n"), i
+		}
+	}
+	return strings.Join(parts, "\n"), len(src) - 1
+}
+
+// hasBody reports whether the method that is declared at the given line has
+// a body.
+func hasBody(src []string, line int) bool {
+	sig, end := signature(src, line)
+	if strings.Contains(sig[strings.LastIndex(sig, ")")+1:], "{") {
+		return true
+	}
+	return end+1 < len(src) && strings.HasPrefix(strings.TrimSpace(src[end+1]), "{")
+}
+
+// parameters returns the names of the parameters of the method that is
+// declared at the given line.
+func parameters(src []string, line int) []string {
+	sig, _ := signature(src, line)
+	start := strings.Index(sig, "(")
+	if start < 0 {
+		return nil
+	}
+
+	var (
+		params []string
+		depth  int
+		item   strings.Builder
+	)
+	flush := func() {
+		decl, _, _ := strings.Cut(item.String(), "=")
+		if names := identRE.FindAllString(decl, -1); len(names) > 0 {
+			params = append(params, names[len(names)-1])
+		}
+		item.Reset()
+	}
+	for _, r := range sig[start+1:] {
+		switch r {
+		case '(', '<', '[':
+			depth++
+		case ')', '>', ']':
+			if depth == 0 && r == ')' {
+				flush()
+				return params
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		item.WriteRune(r)
+	}
+	flush()
+
+	return params
+}
+
+// lineState is the state of a multiline construct, such as a block comment
+// or a triple-quoted string, at the end of a line.
+type lineState struct {
+	comment bool
+	quote   string
+}
+
+// strip removes comments and string literals from a line of code, so that
+// the braces within them are not counted.
+func (s *lineState) strip(line string) string {
+	var (
+		out strings.Builder
+		esc bool
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case s.comment:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.comment = false
+				i++
+			}
+		case s.quote != "":
+			switch {
+			case esc:
+				esc = false
+			case line[i] == '\\':
+				esc = true
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return out.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comment = true
+			i++
+		case strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], "'''"):
+			s.quote = line[i : i+3]
+			i += 2
+		case line[i] == '"' || line[i] == '\'':
+			s.quote = line[i : i+1]
+		default:
+			out.WriteByte(line[i])
+		}
+	}
+	if len(s.quote) == 1 {
+		// Only triple-quoted strings can span multiple lines.
+		s.quote = ""
+	}
+	return out.String()
+}
+
+// findDeclaration returns the line of the declaration identified by
+// identifier. Task definitions are only searched for if identifier identifies
+// a task.
+func findDeclaration(src []string, identifier string) (int, bool) {
+	for _, d := range declarations(src, strings.HasPrefix(identifier, "task:")) {
+		if d.identifier == identifier {
+			return d.line, true
+		}
+	}
+	return 0, false
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a Groovydoc comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < annotationStart(src, line)
+}
+
+// annotationStart returns the index of the first line of the annotations that
+// directly precede the given line, or line if there are none.
+func annotationStart(src []string, line int) int {
+	return lines.BlockStart(src, line, annotatedRE.MatchString)
+}
+
+// docStart returns the index of the first line of the Groovydoc comment that
+// directly precedes the declaration at the given line and its annotations. If
+// there is none, the index of the first annotation is returned.
+func docStart(src []string, line int) int {
+	start := annotationStart(src, line)
+	if start == 0 || !strings.HasSuffix(strings.TrimSpace(src[start-1]), "*/") {
+		return start
+	}
+
+	for i := start - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(src[i])
+		if strings.HasPrefix(trimmed, "/**") {
+			return i
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			return start
+		}
+	}
+
+	return start
+}
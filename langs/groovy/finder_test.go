@@ -0,0 +1,135 @@
+package groovy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/groovy"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		package shop
+
+		/** A shopping cart. */
+		class Cart {
+		    List<Item> items = []
+
+		    Cart(List<Item> items) {
+		        this.items = items
+		    }
+
+		    /** Adds an item to the cart. */
+		    @CompileStatic
+		    void add(Item item) {
+		        if (item.price > 0) {
+		            items << item
+		        }
+		    }
+
+		    @Override
+		    String toString() { "Cart(${items.size()})" }
+
+		    BigDecimal total(
+		        BigDecimal discount = 0,
+		    ) {
+		        def sum = { a, b -> a + b }
+		        items*.price.inject(0, sum) - discount
+		    }
+
+		    private void recalculate() {}
+
+		    static class Item {
+		        def price
+		        def rename(String name) { "{" }
+		    }
+
+		    private static class Secret {
+		        void hidden() {}
+		    }
+		}
+
+		interface Discount {
+		    BigDecimal apply(BigDecimal amount)
+		}
+
+		def text = """
+		    void notAMethod() {
+		"""
+
+		def checkout(Cart cart) {
+		    println total(cart)
+		}
+
+		println checkout(new Cart([]))
+	`)
+
+	findings, err := groovy.NewFinder().Find(context.Background(), "Cart.groovy", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"class:Cart.Item",
+		"class:Discount",
+		"func:checkout",
+		"method:Cart.Cart",
+		"method:Cart.Item.rename",
+		"method:Cart.toString",
+		"method:Cart.total",
+		"method:Discount.apply",
+	}, findings)
+}
+
+func TestFinder_Find_gradle(t *testing.T) {
+	code := heredoc.Doc(`
+		plugins {
+		    id 'java'
+		}
+
+		dependencies {
+		    implementation project(':core')
+		}
+
+		task hello {
+		    doLast { println 'Hello' }
+		}
+
+		/** Copies the docs into the build directory. */
+		task copyDocs(type: Copy) {
+		    from 'docs'
+		}
+
+		tasks.register('greet', GreetingTask) {
+		    greeting = 'Hi'
+		}
+
+		tasks.named('test') {
+		    useJUnitPlatform()
+		}
+
+		class GreetingTask extends DefaultTask {
+		    @Input
+		    String greeting = 'Hello'
+
+		    @TaskAction
+		    def greet() {
+		        println greeting
+		    }
+		}
+	`)
+
+	findings, err := groovy.NewFinder().Find(context.Background(), "build.gradle", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"class:GreetingTask",
+		"method:GreetingTask.greet",
+		"task:greet",
+		"task:hello",
+	}, findings)
+}
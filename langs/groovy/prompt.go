@@ -0,0 +1,66 @@
+package groovy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the Groovydoc comment of the
+// declaration identified by the input. The prompt lists the parameters of
+// methods, so that each of them is documented using a @param tag. Gradle task
+// definitions are described by what the task does when it is executed.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+
+	var tags string
+	switch kind {
+	case "func":
+		kind = "function"
+		fallthrough
+	case "method":
+		src := lines.Split(input.Code)
+		if line, ok := findDeclaration(src, input.Identifier); ok {
+			for _, p := range parameters(src, line) {
+				tags += fmt.Sprintf("\n@param %s <description of %s>", p, p)
+			}
+			if returnsValue(src[line]) {
+				tags += "\n@return <description of the return value>"
+			}
+		}
+	case "task":
+		kind = "Gradle task"
+	}
+
+	return heredoc.Docf(`
+		Write a Groovydoc comment for the Groovy %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a method that adds two numbers, you must not describe it as a "method that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with Groovydoc and Javadoc conventions:
+		---
+		<short description>
+		%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (/** or */).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		tags,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,7 @@
+package groovy
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("groovy", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,154 @@
+package groovy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of Groovy source files and Gradle
+// build scripts.
+var FileExtensions = []string{".groovy", ".gradle"}
+
+// Service documents public classes, methods and Gradle task definitions of
+// Groovy code using Groovydoc comments.
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Groovy code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Groovy source files and Gradle
+// build scripts.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented public declarations in
+// code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the Groovydoc comment of the declaration identified by
+// identifier, replacing its existing Groovydoc comment. The comment is placed
+// above the annotations of the declaration.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		var params []string
+		if strings.HasPrefix(identifier, "method:") || strings.HasPrefix(identifier, "func:") {
+			params = parameters(src, line)
+		}
+		comment = formatDoc(doc, lines.Indent(src[line]), params)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, line), annotationStart(src, line), comment)), nil
+}
+
+// formatDoc formats a generated comment as a Groovydoc comment. The
+// description is wrapped, and each tag is written on its own line. @param tags
+// are ordered like the parameters in the signature.
+func formatDoc(doc, indent string, params []string) []string {
+	var (
+		description []string
+		tags        []string
+	)
+	for _, l := range strings.Split(normalize(doc), "\n") {
+		switch {
+		case strings.HasPrefix(l, "@"):
+			tags = append(tags, l)
+		case len(tags) > 0 && l != "":
+			tags[len(tags)-1] += " " + l
+		case len(tags) == 0:
+			description = append(description, l)
+		}
+	}
+
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return tagOrder(a, params) - tagOrder(b, params)
+	})
+
+	text := internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n")))
+	if len(tags) == 0 && !strings.Contains(text, "\n") && len(indent)+len(text)+len("/**  */") <= 80 {
+		return []string{indent + "/** " + text + " */"}
+	}
+
+	out := append([]string{indent + "/**"}, lines.Comment(text, indent, " * ", "", 80)...)
+	if len(tags) > 0 {
+		out = append(out, indent+" *")
+	}
+	for _, tag := range tags {
+		out = append(out, lines.Comment(tag, indent, " *   ", " * ", 80)...)
+	}
+
+	return append(out, indent+" */")
+}
+
+// tagOrder returns the sort key of a Groovydoc tag: parameters in the order of
+// the signature first, then all other tags.
+func tagOrder(tag string, params []string) int {
+	fields := strings.Fields(tag)
+	if fields[0] != "@param" {
+		return len(params) + 1
+	}
+	if len(fields) > 1 {
+		if i := slices.Index(params, fields[1]); i >= 0 {
+			return i
+		}
+	}
+	return len(params)
+}
+
+// normalize removes comment markers from a generated Groovydoc comment and
+// trims its lines.
+func normalize(doc string) string {
+	doc = strings.TrimSpace(doc)
+	doc = strings.TrimPrefix(doc, "/**")
+	doc = strings.TrimSuffix(doc, "*/")
+
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		docLines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*"))
+	}
+
+	return strings.Join(docLines, "\n")
+}
@@ -0,0 +1,79 @@
+package groovy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/groovy"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		class Cart {
+		    /** Outdated. */
+		    @CompileStatic
+		    BigDecimal total(BigDecimal discount = 0, Map<String, BigDecimal> taxes = [:]) {
+		        0
+		    }
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Returns the total price of the items in the cart.
+
+		@return The total price.
+		@param taxes The taxes by country code.
+		@param discount The discount that is subtracted from the total.
+	`)
+
+	patched, err := groovy.New().Patch(context.Background(), "method:Cart.total", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		class Cart {
+		    /**
+		     * Returns the total price of the items in the cart.
+		     *
+		     * @param discount The discount that is subtracted from the total.
+		     * @param taxes The taxes by country code.
+		     * @return The total price.
+		     */
+		    @CompileStatic
+		    BigDecimal total(BigDecimal discount = 0, Map<String, BigDecimal> taxes = [:]) {
+		        0
+		    }
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_task(t *testing.T) {
+	code := heredoc.Doc(`
+		tasks.register('greet', GreetingTask) {
+		    greeting = 'Hi'
+		}
+	`)
+
+	patched, err := groovy.New().Patch(context.Background(), "task:greet", "Prints a greeting.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		/** Prints a greeting. */
+		tasks.register('greet', GreetingTask) {
+		    greeting = 'Hi'
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
@@ -0,0 +1,227 @@
+package haskell
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	moduleRE    = regexp.MustCompile(`^module\s+[\w.']+`)
+	whereRE     = regexp.MustCompile(`\bwhere\b`)
+	typeDeclRE  = regexp.MustCompile(`^(?:data|newtype|type)\s+([A-Z][\w']*)`)
+	signatureRE = regexp.MustCompile(`^([a-z_][\w']*(?:\s*,\s*[a-z_][\w']*)*)\s*::`)
+	equationRE  = regexp.MustCompile(`^([a-z_][\w']*)(?:\s|=|$)`)
+)
+
+// keywords are the reserved words that may begin a top-level line but never
+// name a function.
+var keywords = map[string]bool{
+	"module": true, "import": true, "where": true, "instance": true,
+	"class": true, "data": true, "newtype": true, "type": true,
+	"deriving": true, "infix": true, "infixl": true, "infixr": true,
+	"foreign": true, "default": true, "pattern": true, "let": true,
+	"if": true, "then": true, "else": true, "case": true, "of": true, "do": true,
+}
+
+// Finder searches Haskell source code for exported top-level functions and
+// data types that have no Haddock comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the exported top-level functions
+// ("func:name") and data types ("type:Name") in code that have no Haddock
+// comment. If the module has an export list, only the listed names are
+// considered exported; otherwise all top-level declarations are.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+}
+
+// declarations returns the exported top-level declarations of a module. The
+// line of a function is the line of its type signature, or of its first
+// equation if it has no signature.
+func declarations(src []string) []declaration {
+	exports, hasExports := exportList(src)
+	exported := func(name string) bool {
+		return !hasExports || exports[name]
+	}
+
+	var (
+		decls        []declaration
+		seen         = make(map[string]bool)
+		blockComment bool
+	)
+	for i, line := range src {
+		if blockComment {
+			blockComment = !strings.Contains(line, "-}")
+			continue
+		}
+		if strings.HasPrefix(line, "{-") {
+			blockComment = !strings.Contains(line, "-}")
+			continue
+		}
+
+		if m := typeDeclRE.FindStringSubmatch(line); m != nil {
+			if exported(m[1]) && !seen["type:"+m[1]] {
+				seen["type:"+m[1]] = true
+				decls = append(decls, declaration{identifier: "type:" + m[1], line: i})
+			}
+			continue
+		}
+
+		if m := signatureRE.FindStringSubmatch(line); m != nil {
+			names := strings.Split(m[1], ",")
+			for j, name := range names {
+				name = strings.TrimSpace(name)
+				id := "func:" + name
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				// A signature that declares multiple functions is documented
+				// once, using the first function.
+				if j == 0 && exported(name) {
+					decls = append(decls, declaration{identifier: id, line: i})
+				}
+			}
+			continue
+		}
+
+		if m := equationRE.FindStringSubmatch(line); m != nil && !keywords[m[1]] {
+			id := "func:" + m[1]
+			if !seen[id] && exported(m[1]) {
+				decls = append(decls, declaration{identifier: id, line: i})
+			}
+			seen[id] = true
+		}
+	}
+
+	return decls
+}
+
+// exportList returns the names in the export list of the module header. It
+// returns false if the module has no export list.
+func exportList(src []string) (map[string]bool, bool) {
+	var header strings.Builder
+	var inModule bool
+	for _, line := range src {
+		if !inModule {
+			if !moduleRE.MatchString(line) {
+				continue
+			}
+			inModule = true
+		}
+
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		header.WriteString(line)
+		header.WriteString(" ")
+
+		if whereRE.MatchString(line) {
+			break
+		}
+	}
+
+	h := header.String()
+	open := strings.Index(h, "(")
+	where := strings.LastIndex(h, "where")
+	if open < 0 || (where >= 0 && open > where) {
+		return nil, false
+	}
+
+	exports := make(map[string]bool)
+	var (
+		depth int
+		item  strings.Builder
+	)
+	flush := func() {
+		name := strings.TrimSpace(item.String())
+		name = strings.TrimPrefix(name, "type ")
+		name = strings.TrimPrefix(name, "pattern ")
+		name = strings.TrimSpace(name)
+		if name != "" && !strings.HasPrefix(name, "module ") {
+			exports[name] = true
+		}
+		item.Reset()
+	}
+
+	for _, r := range h[open+1:] {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth == 0 {
+				flush()
+				return exports, true
+			}
+			depth--
+		case r == ',' && depth == 0:
+			flush()
+		case depth == 0:
+			item.WriteRune(r)
+		}
+	}
+
+	flush()
+	return exports, true
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a Haddock comment ("-- |" or "{-|").
+func documented(src []string, line int) bool {
+	return haddockStart(src, line) < line
+}
+
+// haddockStart returns the index of the first line of the Haddock comment
+// that directly precedes the given line, or line if there is none.
+func haddockStart(src []string, line int) int {
+	if line == 0 {
+		return line
+	}
+
+	if strings.HasSuffix(strings.TrimSpace(src[line-1]), "-}") {
+		for i := line - 1; i >= 0; i-- {
+			if strings.HasPrefix(src[i], "{-") {
+				if strings.HasPrefix(src[i], "{-|") {
+					return i
+				}
+				return line
+			}
+		}
+		return line
+	}
+
+	start := lines.BlockStart(src, line, func(l string) bool {
+		return strings.HasPrefix(l, "--")
+	})
+	for i := start; i < line; i++ {
+		if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(src[i], "--")), "|") {
+			return i
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,75 @@
+package haskell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/haskell"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		module Foo
+		  ( Shape (..)
+		  , Point
+		  , area
+		  , origin
+		  , documented
+		  ) where
+
+		import Data.List (sortOn)
+
+		data Shape = Circle Double | Square Double
+
+		newtype Point = Point (Double, Double)
+
+		type Internal = Int
+
+		area :: Shape -> Double
+		area (Circle r) = pi * r * r
+		area (Square s) = s * s
+
+		origin = Point (0, 0)
+
+		-- | Already documented.
+		documented :: Int
+		documented = 42
+
+		helper :: Int -> Int
+		helper = (+ 1)
+	`)
+
+	findings, err := haskell.NewFinder().Find(context.Background(), "Foo.hs", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"type:Shape",
+		"type:Point",
+		"func:area",
+		"func:origin",
+	}, findings)
+}
+
+func TestFinder_Find_noExportList(t *testing.T) {
+	code := heredoc.Doc(`
+		module Main where
+
+		{-| Runs the program. -}
+		main :: IO ()
+		main = greet "world"
+
+		greet :: String -> IO ()
+		greet name = putStrLn ("Hello, " ++ name)
+	`)
+
+	findings, err := haskell.NewFinder().Find(context.Background(), "Main.hs", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:greet"}, findings)
+}
@@ -0,0 +1,67 @@
+package haskell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// Prompt returns the prompt that asks for the Haddock comment of the function
+// or data type identified by the input.
+func Prompt(input generate.PromptInput) string {
+	target := Target(input.Identifier)
+	simple := simpleIdentifier(input.Identifier)
+	return heredoc.Docf(`
+		Write a comment for %s in Haddock format. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two integers, you must not describe it as a "function that adds two integers." Instead, you must describe it as "Adds two integers.".
+
+		You must enclose references to other functions and types within single quotes. For example, if %q returns a Foo, you must describe it as "Returns a 'Foo'.".
+
+		You should maintain the writing style consistent with the documentation of Haskell libraries on Hackage.
+
+		Output only the unquoted comment, do not include comment markers (-- | or {-|).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		target,
+		target,
+		simple,
+		simple,
+		input.File,
+		input.Code,
+	)
+}
+
+// Target returns a description of the function or data type that is
+// identified by identifier, such as `function "foo"` or `data type "Foo"`. If
+// the identifier has an unknown format, it is returned as-is.
+func Target(identifier string) string {
+	parts := strings.Split(identifier, ":")
+	if len(parts) != 2 {
+		return identifier
+	}
+
+	switch parts[0] {
+	case "func":
+		return fmt.Sprintf("function %q", parts[1])
+	case "type":
+		return fmt.Sprintf("data type %q", parts[1])
+	default:
+		return identifier
+	}
+}
+
+func simpleIdentifier(identifier string) string {
+	if _, name, ok := strings.Cut(identifier, ":"); ok {
+		return name
+	}
+	return identifier
+}
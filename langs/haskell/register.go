@@ -0,0 +1,7 @@
+package haskell
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("hs", func() jotbot.Language { return New() })
+}
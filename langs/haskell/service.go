@@ -0,0 +1,105 @@
+package haskell
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"github.com/modernice/jotbot/internal/slice"
+)
+
+// FileExtensions are the file extensions of Haskell source files.
+var FileExtensions = []string{".hs"}
+
+// Service documents exported top-level functions and data types of Haskell
+// modules using Haddock comments.
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Haskell code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Haskell source files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented exported functions and data
+// types in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the Haddock comment of the declaration identified by
+// identifier, replacing its existing Haddock comment. The comment is placed
+// above the type signature of functions.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	var (
+		line  int
+		found bool
+	)
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			line, found = d.line, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = NormalizeGeneratedComment(doc); doc != "" {
+		comment = lines.Comment(doc, "", "-- ", "-- | ", 80)
+	}
+
+	return lines.Join(lines.Replace(src, haddockStart(src, line), line, comment)), nil
+}
+
+// NormalizeGeneratedComment removes comment markers from a generated Haddock
+// comment and joins the lines of its paragraphs.
+func NormalizeGeneratedComment(doc string) string {
+	doc = strings.TrimSpace(doc)
+	doc = strings.TrimPrefix(doc, "{-|")
+	doc = strings.TrimSuffix(doc, "-}")
+
+	docLines := slice.Map(strings.Split(doc, "\n"), func(l string) string {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "--")
+		l = strings.TrimPrefix(strings.TrimSpace(l), "|")
+		return strings.TrimSpace(l)
+	})
+
+	return internal.RemoveColumns(strings.TrimSpace(strings.Join(docLines, "\n")))
+}
@@ -0,0 +1,60 @@
+package haskell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/haskell"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		module Foo where
+
+		data Shape = Circle Double | Square Double
+
+		-- | Outdated.
+		area :: Shape -> Double
+		area (Circle r) = pi * r * r
+		area (Square s) = s * s
+	`)
+
+	svc := haskell.New()
+
+	patched, err := svc.Patch(context.Background(), "type:Shape", "A geometric shape.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "func:area", "Computes the area of a 'Shape'.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		module Foo where
+
+		-- | A geometric shape.
+		data Shape = Circle Double | Square Double
+
+		-- | Computes the area of a 'Shape'.
+		area :: Shape -> Double
+		area (Circle r) = pi * r * r
+		area (Square s) = s * s
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+
+	again, err := svc.Patch(context.Background(), "func:area", "Computes the area of a 'Shape'.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if string(again) != string(patched) {
+		t.Errorf("patching the same comment twice should not change the code:\n\n%s", cmp.Diff(string(patched), string(again)))
+	}
+}
@@ -0,0 +1,230 @@
+package hcl
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	blockRE     = regexp.MustCompile(`^\s*(variable|output|module)\s+"([^"]+)"\s*\{`)
+	attributeRE = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)\s*=`)
+	heredocRE   = regexp.MustCompile(`<<-?([A-Za-z_]\w*)\s*$`)
+)
+
+// Finder searches Terraform configurations for input variables, output values
+// and module calls that have no description.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the variable ("variable:name") and
+// output ("output:name") blocks in code that have no description attribute,
+// and of the module blocks ("module:name") that have no comment. Terraform
+// does not accept a description in module blocks, so module calls are
+// documented using a comment above the block instead.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, b := range blocks(src) {
+		if !documented(src, b) {
+			findings = append(findings, b.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type block struct {
+	identifier string
+
+	// line is the index of the line that opens the block, and end the index
+	// of the line that closes it.
+	line int
+	end  int
+
+	// attributes are the attributes that are set directly in the block, i.e.
+	// not in nested blocks, in the order of the code.
+	attributes []attribute
+}
+
+type attribute struct {
+	name string
+
+	// line is the index of the first line of the attribute, and end the index
+	// of the last line of its value.
+	line int
+	end  int
+}
+
+func (b block) kind() string {
+	kind, _, _ := strings.Cut(b.identifier, ":")
+	return kind
+}
+
+func (b block) attribute(name string) (attribute, bool) {
+	for _, attr := range b.attributes {
+		if attr.name == name {
+			return attr, true
+		}
+	}
+	return attribute{}, false
+}
+
+// blocks returns the variable, output and module blocks at the top level of
+// the configuration.
+func blocks(src []string) []block {
+	var (
+		out     []block
+		current *block
+		pending = -1
+		depth   int
+		heredoc string
+		comment bool
+	)
+
+	// endAttribute ends the pending attribute of the current block at the
+	// line with the given index, if the value of the attribute is complete.
+	endAttribute := func(i int) {
+		if current != nil && pending >= 0 && depth == 1 && heredoc == "" {
+			current.attributes[pending].end = i
+			pending = -1
+		}
+	}
+
+	for i, line := range src {
+		if heredoc != "" {
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+				endAttribute(i)
+			}
+			continue
+		}
+
+		var code string
+		code, comment = stripLine(line, comment)
+
+		if depth == 0 {
+			// The labels of the block are matched in the original line,
+			// because stripLine removes the contents of strings.
+			if m := blockRE.FindStringSubmatch(line); m != nil && strings.HasPrefix(strings.TrimSpace(code), m[1]) {
+				out = append(out, block{identifier: m[1] + ":" + m[2], line: i, end: i})
+				current = &out[len(out)-1]
+			}
+		} else if depth == 1 && current != nil {
+			if m := attributeRE.FindStringSubmatch(code); m != nil {
+				current.attributes = append(current.attributes, attribute{name: m[1], line: i, end: i})
+				pending = len(current.attributes) - 1
+			}
+		}
+
+		if m := heredocRE.FindStringSubmatch(code); m != nil {
+			heredoc = m[1]
+		}
+
+		if depth += nesting(code); depth < 0 {
+			depth = 0
+		}
+		endAttribute(i)
+
+		if depth == 0 && current != nil {
+			current.end = i
+			current, pending = nil, -1
+		}
+	}
+
+	return out
+}
+
+// documented reports whether the block has a description attribute or, for
+// module blocks, a comment.
+func documented(src []string, b block) bool {
+	if b.kind() == "module" {
+		return docStart(src, b.line) < b.line
+	}
+	_, ok := b.attribute("description")
+	return ok
+}
+
+// docStart returns the index of the first line of the comment that directly
+// precedes the given line, or line if there is none.
+func docStart(src []string, line int) int {
+	return lines.BlockStart(src, line, isComment)
+}
+
+func isComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//")
+}
+
+// nesting returns the number of braces, brackets and parentheses that are
+// opened in the given code minus the number of those that are closed.
+func nesting(code string) int {
+	return strings.Count(code, "{") + strings.Count(code, "[") + strings.Count(code, "(") -
+		strings.Count(code, "}") - strings.Count(code, "]") - strings.Count(code, ")")
+}
+
+// stripLine removes comments and the contents of string literals from a line
+// of code, so that the braces within them are not counted. comment reports
+// whether the line starts within a block comment, and the returned bool
+// whether the line ends within one.
+func stripLine(line string, comment bool) (string, bool) {
+	var (
+		out    strings.Builder
+		quoted bool
+		esc    bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if comment {
+			if strings.HasPrefix(line[i:], "*/") {
+				comment = false
+				i++
+			}
+			continue
+		}
+		if quoted {
+			switch {
+			case esc:
+				esc = false
+			case c == '\\':
+				esc = true
+			case c == '"':
+				quoted = false
+				out.WriteByte(c)
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			quoted = true
+			out.WriteByte(c)
+		case c == '#' || strings.HasPrefix(line[i:], "//"):
+			return out.String(), false
+		case strings.HasPrefix(line[i:], "/*"):
+			comment = true
+			i++
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), comment
+}
+
+func findBlock(src []string, identifier string) (block, bool) {
+	for _, b := range blocks(src) {
+		if b.identifier == identifier {
+			return b, true
+		}
+	}
+	return block{}, false
+}
@@ -0,0 +1,79 @@
+package hcl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/hcl"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		variable "region" {
+		  type        = string
+		  description = "The region to deploy to."
+		}
+
+		variable "instance_count" {
+		  type    = number
+		  default = 1
+		}
+
+		variable "tags" {}
+
+		variable "settings" {
+		  type = object({
+		    description = string
+		  })
+		}
+
+		variable "policy" {
+		  description = <<-EOT
+		    The IAM policy document. Interpolations such as ${var.region}
+		    variable "not_a_block" {
+		  EOT
+		}
+
+		/*
+		variable "commented" {}
+		*/
+
+		output "bucket_arn" {
+		  value = aws_s3_bucket.this.arn # "{"
+		}
+
+		output "bucket_name" {
+		  description = "The name of the bucket."
+		  value       = aws_s3_bucket.this.id
+		}
+
+		# Creates the network of the environment.
+		module "network" {
+		  source = "./modules/network"
+		}
+
+		module "database" {
+		  source      = "./modules/database"
+		  description = "Not a description, but an input of the module."
+		}
+
+		resource "aws_s3_bucket" "this" {
+		  bucket = "${var.region}-bucket"
+		}
+	`)
+
+	findings, err := hcl.NewFinder().Find(context.Background(), "main.tf", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"module:database",
+		"output:bucket_arn",
+		"variable:instance_count",
+		"variable:settings",
+		"variable:tags",
+	}, findings)
+}
@@ -0,0 +1,48 @@
+package hcl
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// Prompt returns the prompt that asks for the description of the block
+// identified by the input.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+
+	var target, subject string
+	switch kind {
+	case "variable":
+		target = "input variable"
+		subject = "Describe the value that the variable configures"
+	case "output":
+		target = "output value"
+		subject = "Describe the value that the output exposes"
+	default:
+		target = "module call"
+		subject = "Describe the infrastructure that the module call provisions"
+	}
+
+	return heredoc.Docf(`
+		Write a description for the Terraform %s %q. Do not include any external links, source code, or (code) examples.
+
+		%s, but not how it is implemented. Write one or two plain sentences that start with a capital letter and end with a period, as in the descriptions of the variables and outputs of the official Terraform modules. Refer to other variables, outputs and resources by their name.
+
+		Output only the unquoted description, do not include the attribute name, quotes or comment markers.
+
+		Keep the description as short as possible while still being descriptive.
+
+		Here is the configuration for reference:
+		---
+		# %s
+		%s
+	`,
+		target,
+		name,
+		subject,
+		input.File,
+		input.Code,
+	)
+}
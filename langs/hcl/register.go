@@ -0,0 +1,7 @@
+package hcl
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("tf", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,220 @@
+package hcl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of Terraform configuration files.
+var FileExtensions = []string{".tf"}
+
+// Service documents input variables, output values and module calls of
+// Terraform configurations. Variables and outputs are documented using their
+// description attribute, module calls using a comment ("#").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// blocks.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Terraform configurations.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Terraform configuration files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented blocks in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the description of the block identified by identifier,
+// replacing its existing description. Variables and outputs get a description
+// attribute, which is aligned with the surrounding attributes like "terraform
+// fmt" does. Module calls get a comment above the block.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	b, ok := findBlock(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	doc = NormalizeDescription(doc)
+
+	if b.kind() == "module" {
+		var comment []string
+		if doc != "" {
+			comment = lines.Comment(doc, lines.Indent(src[b.line]), "# ", "", 80)
+		}
+		return lines.Join(lines.Replace(src, docStart(src, b.line), b.line, comment)), nil
+	}
+
+	if b.line == b.end {
+		src = expandBlock(src, b)
+		if b, ok = findBlock(src, identifier); !ok {
+			return nil, fmt.Errorf("%q not found in code", identifier)
+		}
+	}
+
+	var attr []string
+	if doc != "" {
+		attr = []string{bodyIndent(src, b) + "description = " + quote(doc)}
+	}
+
+	if existing, ok := b.attribute("description"); ok {
+		src = lines.Replace(src, existing.line, existing.end+1, attr)
+	} else {
+		at := b.line + 1
+		if typ, ok := b.attribute("type"); ok && len(b.attributes) > 0 && b.attributes[0] == typ {
+			at = typ.end + 1
+		}
+		src = lines.Replace(src, at, at, attr)
+	}
+
+	if b, ok = findBlock(src, identifier); ok {
+		align(src, b)
+	}
+
+	return lines.Join(src), nil
+}
+
+// expandBlock rewrites a block that is written on a single line, such as
+// `variable "name" {}`, so that its body is written on its own lines.
+func expandBlock(src []string, b block) []string {
+	line := strings.TrimRight(src[b.line], " \t\r")
+	open, end := strings.Index(line, "{"), strings.LastIndex(line, "}")
+	if open < 0 || end < open {
+		return src
+	}
+
+	indent := lines.Indent(line)
+	expanded := []string{strings.TrimRight(line[:open+1], " ")}
+	if body := strings.TrimSpace(line[open+1 : end]); body != "" {
+		expanded = append(expanded, indent+"  "+body)
+	}
+	expanded = append(expanded, indent+"}")
+
+	return lines.Replace(src, b.line, b.line+1, expanded)
+}
+
+// bodyIndent returns the indentation of the attributes of a block. Blocks
+// without attributes are indented by two spaces, like "terraform fmt" does.
+func bodyIndent(src []string, b block) string {
+	if len(b.attributes) > 0 {
+		return lines.Indent(src[b.attributes[0].line])
+	}
+	for i := b.line + 1; i < b.end; i++ {
+		if strings.TrimSpace(src[i]) != "" {
+			return lines.Indent(src[i])
+		}
+	}
+	return lines.Indent(src[b.line]) + "  "
+}
+
+// align aligns the equals signs of the single-line attributes that directly
+// surround the description attribute of a block.
+func align(src []string, b block) {
+	i := -1
+	for j, attr := range b.attributes {
+		if attr.name == "description" {
+			i = j
+		}
+	}
+	if i < 0 {
+		return
+	}
+
+	single := func(j int) bool {
+		return b.attributes[j].line == b.attributes[j].end
+	}
+	adjacent := func(j int) bool {
+		return b.attributes[j].line == b.attributes[j-1].end+1
+	}
+
+	first, last := i, i
+	for first > 0 && single(first-1) && adjacent(first) {
+		first--
+	}
+	for last < len(b.attributes)-1 && single(last+1) && adjacent(last+1) {
+		last++
+	}
+
+	// A multi-line value ends the group, but its first line is aligned, too.
+	if last < len(b.attributes)-1 && adjacent(last+1) {
+		last++
+	}
+
+	width := 0
+	for _, attr := range b.attributes[first : last+1] {
+		if len(attr.name) > width {
+			width = len(attr.name)
+		}
+	}
+
+	for _, attr := range b.attributes[first : last+1] {
+		line := src[attr.line]
+		_, value, _ := strings.Cut(line, "=")
+		src[attr.line] = lines.Indent(line) + attr.name + strings.Repeat(" ", width-len(attr.name)) + " = " + strings.TrimLeft(value, " \t")
+	}
+}
+
+// quote returns s as an HCL string literal. Template sequences ("${" and
+// "%{") are escaped, so that the description is used literally.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	return strconv.Quote(s)
+}
+
+// NormalizeDescription removes comment markers, quotes and an attribute name
+// from a generated description and joins its lines.
+func NormalizeDescription(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if name, value, ok := strings.Cut(doc, "="); ok && strings.TrimSpace(name) == "description" {
+		doc = strings.TrimSpace(value)
+	}
+	if unquoted, err := strconv.Unquote(doc); err == nil {
+		doc = unquoted
+	}
+
+	docLines := strings.Split(doc, "\n")
+	for i, l := range docLines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "#")
+		l = strings.TrimPrefix(l, "//")
+		docLines[i] = l
+	}
+
+	return strings.Join(strings.Fields(strings.Join(docLines, " ")), " ")
+}
@@ -0,0 +1,88 @@
+package hcl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/hcl"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		variable "instance_count" {
+		  type    = number
+		  default = 1
+		}
+
+		variable "tags" {}
+
+		variable "policy" {
+		  description = <<-EOT
+		    Outdated.
+		  EOT
+		  type = string
+		}
+
+		output "bucket_arn" {
+		  value = aws_s3_bucket.this.arn
+		}
+
+		# Outdated.
+		module "network" {
+		  source = "./modules/network"
+		}
+	`)
+
+	patches := []struct {
+		identifier string
+		doc        string
+	}{
+		{"variable:instance_count", "The number of instances\nto launch."},
+		{"variable:tags", `description = "Tags that are added to all ${var.env} resources."`},
+		{"variable:policy", "The IAM policy document."},
+		{"output:bucket_arn", "The ARN of the bucket."},
+		{"module:network", "# Creates the network of the environment."},
+	}
+
+	svc := hcl.New()
+	patched := []byte(code)
+	for _, p := range patches {
+		var err error
+		if patched, err = svc.Patch(context.Background(), p.identifier, p.doc, patched); err != nil {
+			t.Fatalf("Patch(%q) failed: %v", p.identifier, err)
+		}
+	}
+
+	want := heredoc.Doc(`
+		variable "instance_count" {
+		  type        = number
+		  description = "The number of instances to launch."
+		  default     = 1
+		}
+
+		variable "tags" {
+		  description = "Tags that are added to all $${var.env} resources."
+		}
+
+		variable "policy" {
+		  description = "The IAM policy document."
+		  type        = string
+		}
+
+		output "bucket_arn" {
+		  description = "The ARN of the bucket."
+		  value       = aws_s3_bucket.this.arn
+		}
+
+		# Creates the network of the environment.
+		module "network" {
+		  source = "./modules/network"
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
@@ -0,0 +1,62 @@
+package ipynb
+
+import (
+	"context"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// Finder searches the code cells of Jupyter notebooks for Python functions,
+// classes and methods that have no docstring.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public functions and classes in
+// the code cells of the notebook that have no docstring. Functions and classes
+// are identified as "func:name" and "class:Name", and methods of top-level
+// classes as "func:Class.method". If a definition appears in multiple cells,
+// only its first occurrence is considered, because the notebook is patched at
+// that location.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	cells, err := codeCells(code)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var findings []string
+	for _, c := range cells {
+		src := lines.Split([]byte(c.source))
+		for _, def := range definitions(src) {
+			if seen[def.identifier] {
+				continue
+			}
+			seen[def.identifier] = true
+
+			if _, _, ok := docstring(src, def); !ok {
+				findings = append(findings, def.identifier)
+			}
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+// findDefinition returns the index of the first code cell that contains the
+// definition identified by identifier, together with the definition.
+func findDefinition(cells []cell, identifier string) (int, definition, bool) {
+	for i, c := range cells {
+		for _, def := range definitions(lines.Split([]byte(c.source))) {
+			if def.identifier == identifier {
+				return i, def, true
+			}
+		}
+	}
+	return 0, definition{}, false
+}
@@ -0,0 +1,78 @@
+package ipynb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/ipynb"
+)
+
+var notebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": [
+    "def documented_in_markdown():\n",
+    "    pass"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": [
+      "3 < 4\n"
+     ]
+    }
+   ],
+   "source": [
+    "def add(a,\n",
+    "        b):\n",
+    "    return a + b\n",
+    "\n",
+    "class Calculator:\n",
+    "    \"\"\"A calculator.\"\"\"\n",
+    "\n",
+    "    def multiply(self, a, b):\n",
+    "        return a * b\n",
+    "\n",
+    "    def _helper(self):\n",
+    "        pass\n",
+    "\n",
+    "print(add(1, 2), \"<\", 4)"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": null,
+   "metadata": {},
+   "outputs": [],
+   "source": "def subtract(a, b):\n    \"\"\"Subtract b from a.\"\"\"\n    return a - b\n\ndef add(a, b):\n    return a + b\n"
+  }
+ ],
+ "metadata": {
+  "kernelspec": {
+   "display_name": "Python 3",
+   "language": "python",
+   "name": "python3"
+  }
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestFinder_Find(t *testing.T) {
+	findings, err := ipynb.NewFinder().Find(context.Background(), "notebook.ipynb", []byte(notebook))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:Calculator.multiply", "func:add"}, findings)
+}
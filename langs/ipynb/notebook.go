@@ -0,0 +1,212 @@
+package ipynb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// cell is a code cell of a notebook.
+type cell struct {
+	// source is the source code of the cell.
+	source string
+
+	// start and end are the byte offsets of the JSON value of the "source"
+	// field of the cell within the notebook.
+	start, end int
+
+	// raw is the JSON value of the "source" field.
+	raw []byte
+}
+
+// codeCells returns the code cells of a notebook. Only the locations of the
+// sources are recorded, so that the notebook can be patched without
+// re-encoding, which keeps outputs, metadata and formatting intact.
+func codeCells(notebook []byte) ([]cell, error) {
+	start, end, ok, err := field(notebook, 0, "cells")
+	if err != nil {
+		return nil, fmt.Errorf("parse notebook: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("parse notebook: missing %q field", "cells")
+	}
+
+	elems, err := elements(notebook, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("parse notebook cells: %w", err)
+	}
+
+	var cells []cell
+	for _, e := range elems {
+		var meta struct {
+			CellType string `json:"cell_type"`
+		}
+		if err := json.Unmarshal(notebook[e[0]:e[1]], &meta); err != nil {
+			return nil, fmt.Errorf("parse notebook cell: %w", err)
+		}
+		if meta.CellType != "code" {
+			continue
+		}
+
+		start, end, ok, err := field(notebook, e[0], "source")
+		if err != nil {
+			return nil, fmt.Errorf("parse notebook cell: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		raw := notebook[start:end]
+		source, err := decodeSource(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse source of notebook cell: %w", err)
+		}
+
+		cells = append(cells, cell{source: source, start: start, end: end, raw: raw})
+	}
+
+	return cells, nil
+}
+
+// field returns the byte offsets of the value of the given field of the JSON
+// object that begins at offset.
+func field(data []byte, offset int, name string) (start, end int, ok bool, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if tok != json.Delim('{') {
+		return 0, 0, false, fmt.Errorf("expected object at offset %d", offset)
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return 0, 0, false, err
+		}
+
+		if key == name {
+			end := offset + int(dec.InputOffset())
+			return end - len(value), end, true, nil
+		}
+	}
+
+	return 0, 0, false, nil
+}
+
+// elements returns the byte offsets of the elements of the JSON array in
+// data[start:end].
+func elements(data []byte, start, end int) ([][2]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[start:end]))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected array at offset %d", start)
+	}
+
+	var elems [][2]int
+	for dec.More() {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		end := start + int(dec.InputOffset())
+		elems = append(elems, [2]int{end - len(value), end})
+	}
+
+	return elems, nil
+}
+
+// decodeSource decodes the source of a cell, which is either a string or a
+// list of lines.
+func decodeSource(raw []byte) (string, error) {
+	var source string
+	if err := json.Unmarshal(raw, &source); err == nil {
+		return source, nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return "", err
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+// encodeSource encodes source in the format of the original JSON value of the
+// source, so that a patched notebook differs from the original only in the
+// changed lines.
+func encodeSource(source string, original []byte) ([]byte, error) {
+	if len(original) > 0 && original[0] == '"' {
+		return marshal(source)
+	}
+
+	var lines []string
+	for len(source) > 0 {
+		i := strings.IndexByte(source, '\n')
+		if i < 0 {
+			lines = append(lines, source)
+			break
+		}
+		lines = append(lines, source[:i+1])
+		source = source[i+1:]
+	}
+
+	if len(lines) == 0 {
+		return []byte("[]"), nil
+	}
+
+	// Notebooks are usually written with one line per element, indented by
+	// one space relative to the "source" field (as written by nbformat).
+	elemIndent, closeIndent := " ", ""
+	if i := bytes.IndexByte(original, '\n'); i >= 0 {
+		rest := original[i+1:]
+		elemIndent = string(rest[:len(rest)-len(bytes.TrimLeft(rest, " \t"))])
+		if j := bytes.LastIndexByte(original, '\n'); j >= 0 {
+			closeIndent = string(bytes.TrimRight(original[j+1:], "]"))
+		}
+	} else if len(original) > 0 {
+		return marshal(lines)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, line := range lines {
+		b, err := marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(elemIndent)
+		buf.Write(b)
+		if i < len(lines)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(closeIndent)
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// marshal encodes v as JSON without escaping HTML characters, like Jupyter.
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
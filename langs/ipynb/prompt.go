@@ -0,0 +1,52 @@
+package ipynb
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// Prompt returns the prompt that asks for the docstring of the Python function,
+// class or method identified by the input. The code of the input is expected
+// to be the minified notebook, which contains only the code cells.
+func Prompt(input generate.PromptInput) string {
+	kind, name := "function", simpleIdentifier(input.Identifier)
+	switch {
+	case strings.HasPrefix(input.Identifier, "class:"):
+		kind = "class"
+	case strings.Contains(name, "."):
+		kind = "method"
+	}
+
+	return heredoc.Docf(`
+		Write a docstring for the Python %s %q, which is defined in a code cell of a Jupyter notebook. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two numbers, you must not describe it as a "function that adds two numbers." Instead, you must describe it as "Add two numbers.".
+
+		Follow the conventions of PEP 257: begin with a one-line summary in the imperative mood that ends with a period, optionally followed by a blank line and a more elaborate description.
+
+		Output only the docstring text, do not include the surrounding quotes (""").
+
+		Keep the docstring as short as possible while still being descriptive.
+
+		Here are the code cells of the notebook for reference:
+		---
+		# %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		input.File,
+		input.Code,
+	)
+}
+
+func simpleIdentifier(identifier string) string {
+	if _, name, ok := strings.Cut(identifier, ":"); ok {
+		return name
+	}
+	return identifier
+}
@@ -0,0 +1,166 @@
+package ipynb
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+var (
+	defRE       = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`)
+	docstringRE = regexp.MustCompile(`^(?i:[rub]{0,2})("""|''')`)
+)
+
+// definition is a function or class definition in the source of a cell.
+type definition struct {
+	identifier string
+
+	// line is the line of the "def" or "class" keyword.
+	line int
+
+	// body is the line after the signature of the definition.
+	body int
+}
+
+// definitions returns the public top-level functions and classes and the
+// public methods of top-level classes in the given Python source.
+func definitions(src []string) []definition {
+	var (
+		defs  []definition
+		class string
+	)
+	for i := 0; i < len(src); i++ {
+		m := defRE.FindStringSubmatch(src[i])
+		if m == nil {
+			if strings.TrimSpace(src[i]) != "" && lines.Indent(src[i]) == "" && !strings.HasPrefix(src[i], "#") && !strings.HasPrefix(src[i], "@") {
+				class = ""
+			}
+			continue
+		}
+
+		indent, kind, name := m[1], m[2], m[3]
+		body := signatureEnd(src, i) + 1
+
+		var identifier string
+		switch {
+		case indent == "" && kind == "class":
+			class = name
+			identifier = "class:" + name
+		case indent == "":
+			class = ""
+			identifier = "func:" + name
+		case class != "" && kind == "def" && isMethodIndent(src, i, indent):
+			identifier = "func:" + class + "." + name
+		}
+
+		if identifier != "" && !strings.HasPrefix(name, "_") {
+			defs = append(defs, definition{identifier: identifier, line: i, body: body})
+		}
+	}
+	return defs
+}
+
+// isMethodIndent reports whether the definition at line i with the given
+// indent is declared directly within the enclosing top-level class.
+func isMethodIndent(src []string, i int, indent string) bool {
+	for j := i - 1; j >= 0; j-- {
+		if strings.TrimSpace(src[j]) == "" {
+			continue
+		}
+		if m := defRE.FindStringSubmatch(src[j]); m != nil && m[1] == "" && m[2] == "class" {
+			return true
+		}
+		if len(lines.Indent(src[j])) < len(indent) && lines.Indent(src[j]) != "" {
+			return false
+		}
+	}
+	return false
+}
+
+// signatureEnd returns the line that ends the signature of the definition at
+// line i, which is the first line that ends with a colon outside of brackets.
+func signatureEnd(src []string, i int) int {
+	var depth int
+	for j := i; j < len(src); j++ {
+		code, _, _ := strings.Cut(src[j], "#")
+		for _, c := range code {
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			}
+		}
+		if depth <= 0 && strings.HasSuffix(strings.TrimSpace(code), ":") {
+			return j
+		}
+	}
+	return i
+}
+
+// docstring returns the range of lines of the docstring of the definition, or
+// false if the definition has no docstring.
+func docstring(src []string, def definition) (start, end int, ok bool) {
+	start = def.body
+	for start < len(src) && strings.TrimSpace(src[start]) == "" {
+		start++
+	}
+	if start >= len(src) {
+		return 0, 0, false
+	}
+
+	first := strings.TrimSpace(src[start])
+	m := docstringRE.FindStringSubmatch(first)
+	if m == nil {
+		return 0, 0, false
+	}
+	quote := m[1]
+
+	rest := first[len(m[0]):]
+	if strings.Contains(rest, quote) {
+		return start, start + 1, true
+	}
+	for end = start + 1; end < len(src); end++ {
+		if strings.Contains(src[end], quote) {
+			return start, end + 1, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// bodyIndent returns the indentation of the body of the definition.
+func bodyIndent(src []string, def definition) string {
+	for i := def.body; i < len(src); i++ {
+		if strings.TrimSpace(src[i]) != "" {
+			if indent := lines.Indent(src[i]); len(indent) > len(lines.Indent(src[def.line])) {
+				return indent
+			}
+			break
+		}
+	}
+	return lines.Indent(src[def.line]) + "    "
+}
+
+// formatDocstring formats doc as a PEP 257 docstring with the given indent.
+func formatDocstring(doc, indent string) []string {
+	docLines := lines.Comment(doc, indent, "", "", 80-len(`"""`))
+	if len(docLines) == 1 {
+		return []string{indent + `"""` + strings.TrimSpace(docLines[0]) + `"""`}
+	}
+	docLines[0] = indent + `"""` + strings.TrimSpace(docLines[0])
+	return append(docLines, indent+`"""`)
+}
+
+// normalizeDocstring removes quotes from a generated docstring and joins the
+// lines of its paragraphs.
+func normalizeDocstring(doc string) string {
+	doc = strings.TrimSpace(doc)
+	for _, quote := range []string{`"""`, `'''`} {
+		doc = strings.TrimPrefix(doc, quote)
+		doc = strings.TrimSuffix(doc, quote)
+	}
+	return internal.RemoveColumns(strings.TrimSpace(doc))
+}
@@ -0,0 +1,7 @@
+package ipynb
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("ipynb", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,117 @@
+package ipynb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of Jupyter notebooks.
+var FileExtensions = []string{".ipynb"}
+
+// Service documents the Python functions and classes that are defined in the
+// code cells of Jupyter notebooks. Patches only modify the sources of code
+// cells, so outputs, metadata and the formatting of the notebook are kept.
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// definitions.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Jupyter notebooks.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Jupyter notebooks.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the definitions in the code cells of the
+// notebook that have no docstring.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Minify extracts the code cells from the notebook, so that outputs and
+// metadata are not sent as part of the prompt. Cells are separated by
+// "# %%" markers.
+func (svc *Service) Minify(code []byte) ([]byte, error) {
+	cells, err := codeCells(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for i, c := range cells {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		fmt.Fprintf(&buf, "# %%%% [cell %d]\n", i+1)
+		buf.WriteString(c.source)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the docstring of the definition identified by
+// identifier, replacing its existing docstring. Only the source of the code
+// cell that contains the definition is rewritten.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	cells, err := codeCells(code)
+	if err != nil {
+		return nil, err
+	}
+
+	i, def, ok := findDefinition(cells, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in notebook", identifier)
+	}
+	c := cells[i]
+
+	src := lines.Split([]byte(c.source))
+	start, end, ok := docstring(src, def)
+	if !ok {
+		start, end = def.body, def.body
+	}
+
+	var repl []string
+	if doc = normalizeDocstring(doc); doc != "" {
+		repl = formatDocstring(doc, bodyIndent(src, def))
+	}
+
+	source, err := encodeSource(string(lines.Join(lines.Replace(src, start, end, repl))), c.raw)
+	if err != nil {
+		return nil, fmt.Errorf("encode source of notebook cell: %w", err)
+	}
+
+	out := make([]byte, 0, len(code)-len(c.raw)+len(source))
+	out = append(out, code[:c.start]...)
+	out = append(out, source...)
+	return append(out, code[c.end:]...), nil
+}
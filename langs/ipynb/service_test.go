@@ -0,0 +1,73 @@
+package ipynb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/ipynb"
+)
+
+func TestService_Patch(t *testing.T) {
+	svc := ipynb.New()
+
+	patched, err := svc.Patch(context.Background(), "func:add", "Add two numbers.", []byte(notebook))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "func:Calculator.multiply", "Multiply two numbers.\n\nThe numbers may be of any numeric type.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := strings.Replace(notebook, `    "        b):\n",
+    "    return a + b\n",`, `    "        b):\n",
+    "    \"\"\"Add two numbers.\"\"\"\n",
+    "    return a + b\n",`, 1)
+	want = strings.Replace(want, `    "    def multiply(self, a, b):\n",
+    "        return a * b\n",`, `    "    def multiply(self, a, b):\n",
+    "        \"\"\"Multiply two numbers.\n",
+    "\n",
+    "        The numbers may be of any numeric type.\n",
+    "        \"\"\"\n",
+    "        return a * b\n",`, 1)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid notebook:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_stringSource(t *testing.T) {
+	code := `{"cells": [{"cell_type": "code", "outputs": [], "source": "def add(a, b):\n    return a + b"}]}`
+
+	patched, err := ipynb.New().Patch(context.Background(), "func:add", `"""Add two numbers."""`, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := `{"cells": [{"cell_type": "code", "outputs": [], "source": "def add(a, b):\n    \"\"\"Add two numbers.\"\"\"\n    return a + b"}]}`
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid notebook:\n\n%s", cmp.Diff(want, string(patched)))
+	}
+}
+
+func TestService_Minify(t *testing.T) {
+	minified, err := ipynb.New().Minify([]byte(notebook))
+	if err != nil {
+		t.Fatalf("Minify() failed: %v", err)
+	}
+
+	got := string(minified)
+	if !strings.HasPrefix(got, "# %% [cell 1]\ndef add(a,\n") {
+		t.Errorf("Minify() should begin with the first code cell; got\n\n%s", got)
+	}
+	if !strings.Contains(got, "\n\n# %% [cell 2]\ndef subtract(a, b):\n") {
+		t.Errorf("Minify() should separate code cells; got\n\n%s", got)
+	}
+	if strings.Contains(got, "3 < 4") || strings.Contains(got, "documented_in_markdown") {
+		t.Errorf("Minify() should only contain the sources of code cells; got\n\n%s", got)
+	}
+}
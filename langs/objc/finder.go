@@ -0,0 +1,426 @@
+package objc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	interfaceRE      = regexp.MustCompile(`^@interface\s+(\w+)\s*(\(\s*(\w*)\s*\))?`)
+	protocolRE       = regexp.MustCompile(`^@protocol\s+(\w+)`)
+	implementationRE = regexp.MustCompile(`^@implementation\s+(\w+)\s*(?:\(\s*(\w*)\s*\))?`)
+	methodRE         = regexp.MustCompile(`^[-+]\s*\(`)
+	trailingMacroRE  = regexp.MustCompile(`\s+[A-Z][A-Z0-9_]*(?:\(.*\))?\s*$`)
+	identifierRE     = regexp.MustCompile(`[A-Za-z_]\w*`)
+)
+
+// Finder searches Objective-C source code for interfaces, protocols,
+// properties and methods that have no doc comment.
+type Finder struct {
+	headers fs.FS
+}
+
+// FinderOption configures a [*Finder].
+type FinderOption func(*Finder)
+
+// Headers configures the Finder to place documentation in header files. When
+// an implementation file ("Foo.m") is searched, the methods that are also
+// declared in its header file ("Foo.h") are skipped, because their
+// documentation belongs in the header. Header files are read from fsys, using
+// the file paths that are passed to [*Finder.Find].
+func Headers(fsys fs.FS) FinderOption {
+	return func(f *Finder) {
+		f.headers = fsys
+	}
+}
+
+// NewFinder returns a Finder.
+func NewFinder(opts ...FinderOption) *Finder {
+	var f Finder
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return &f
+}
+
+// Find returns the sorted identifiers of the interfaces ("interface:Name" or
+// "interface:Name(Category)"), protocols ("protocol:Name"), properties
+// ("property:Owner.name") and methods ("method:-[Owner selector:]") in code that
+// have no doc comment. Class extensions are private and therefore skipped.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	declaredInHeader, err := f.headerDeclarations(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if d.implementation && declaredInHeader[d.identifier] {
+			continue
+		}
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+// headerDeclarations returns the identifiers of the declarations in the header
+// file of the given implementation file. It returns nil if header placement is
+// disabled, file is not an implementation file, or it has no header file.
+func (f *Finder) headerDeclarations(file string) (map[string]bool, error) {
+	if f.headers == nil || path.Ext(file) != ".m" {
+		return nil, nil
+	}
+
+	header := strings.TrimSuffix(file, ".m") + ".h"
+	code, err := fs.ReadFile(f.headers, header)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read header file %s: %w", header, err)
+	}
+
+	out := make(map[string]bool)
+	for _, d := range declarations(lines.Split(code)) {
+		out[d.identifier] = true
+	}
+
+	return out, nil
+}
+
+type declaration struct {
+	identifier     string
+	line           int
+	implementation bool
+}
+
+type container struct {
+	owner          string
+	implementation bool
+	private        bool
+}
+
+// declarations returns the declarations of the interfaces, protocols and
+// implementations in src. The identifiers of methods within implementations
+// match the identifiers of their declarations in interfaces.
+func declarations(src []string) []declaration {
+	var (
+		decls   []declaration
+		current *container
+		depth   int
+		comment bool
+	)
+	for i := 0; i < len(src); i++ {
+		var code string
+		code, comment = stripLine(src[i], comment)
+		trimmed := strings.TrimSpace(code)
+
+		if current == nil {
+			if c, d, ok := parseContainer(trimmed); ok {
+				current = &c
+				if d != "" {
+					decls = append(decls, declaration{identifier: d, line: i})
+				}
+				depth = strings.Count(code, "{") - strings.Count(code, "}")
+			}
+			continue
+		}
+
+		if depth == 0 {
+			switch {
+			case strings.HasPrefix(trimmed, "@end"):
+				current = nil
+				continue
+			case current.private:
+			case methodRE.MatchString(trimmed):
+				sig, end := signature(src, i)
+				if sel, _ := selector(sig); sel != "" {
+					decls = append(decls, declaration{
+						identifier:     fmt.Sprintf("method:%c[%s %s]", sig[0], current.owner, sel),
+						line:           i,
+						implementation: current.implementation,
+					})
+				}
+				// Count the braces of the signature lines that follow the first.
+				for j := i + 1; j <= end; j++ {
+					var c string
+					c, comment = stripLine(src[j], comment)
+					depth += strings.Count(c, "{") - strings.Count(c, "}")
+				}
+				depth += strings.Count(code, "{") - strings.Count(code, "}")
+				i = end
+				continue
+			case strings.HasPrefix(trimmed, "@property") && !current.implementation:
+				if name := propertyName(trimmed); name != "" {
+					decls = append(decls, declaration{
+						identifier: "property:" + current.owner + "." + name,
+						line:       i,
+					})
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+	}
+
+	return decls
+}
+
+// parseContainer parses the "@interface", "@protocol" or "@implementation"
+// directive in the given line of code. It returns the identifier of the
+// container, or an empty identifier for implementations.
+func parseContainer(code string) (container, string, bool) {
+	if m := interfaceRE.FindStringSubmatch(code); m != nil {
+		owner := m[1]
+		if m[2] != "" {
+			if m[3] == "" {
+				// Class extensions declare private API.
+				return container{owner: owner, private: true}, "", true
+			}
+			owner += "(" + m[3] + ")"
+		}
+		return container{owner: owner}, "interface:" + owner, true
+	}
+
+	if m := protocolRE.FindStringSubmatch(code); m != nil {
+		// "@protocol Foo;" and "@protocol Foo, Bar;" are forward declarations.
+		if strings.HasSuffix(code, ";") {
+			return container{}, "", false
+		}
+		return container{owner: m[1]}, "protocol:" + m[1], true
+	}
+
+	if m := implementationRE.FindStringSubmatch(code); m != nil {
+		owner := m[1]
+		if m[2] != "" {
+			owner += "(" + m[2] + ")"
+		}
+		return container{owner: owner, implementation: true}, "", true
+	}
+
+	return container{}, "", false
+}
+
+// signature returns the method signature that begins at the given line,
+// which may span multiple lines, and the index of its last line.
+func signature(src []string, line int) (string, int) {
+	var parts []string
+	for i := line; i < len(src); i++ {
+		code, _ := stripLine(src[i], false)
+		if j := strings.IndexAny(code, ";{"); j >= 0 {
+			return strings.TrimSpace(strings.Join(append(parts, code[:j]), " ")), i
+		}
+		parts = append(parts, strings.TrimSpace(code))
+	}
+	return strings.TrimSpace(strings.Join(parts, " ")), len(src) - 1
+}
+
+// selector returns the selector of a method signature, e.g. "insert:atIndex:"
+// for "- (void)insert:(id)object atIndex:(NSUInteger)index", and the names of
+// its parameters.
+func selector(sig string) (string, []string) {
+	s := strings.TrimSpace(sig[1:])
+	s = skipParens(s)
+
+	var (
+		parts  []string
+		params []string
+	)
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		name := identifierRE.FindString(s)
+		if !strings.HasPrefix(s, name) {
+			name = ""
+		}
+		rest := strings.TrimLeftFunc(s[len(name):], unicode.IsSpace)
+		if !strings.HasPrefix(rest, ":") {
+			if len(parts) == 0 {
+				return name, nil
+			}
+			return strings.Join(parts, ""), params
+		}
+		parts = append(parts, name+":")
+
+		s = skipParens(strings.TrimLeftFunc(rest[1:], unicode.IsSpace))
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		param := identifierRE.FindString(s)
+		if param == "" || !strings.HasPrefix(s, param) {
+			return strings.Join(parts, ""), params
+		}
+		params = append(params, param)
+		s = s[len(param):]
+	}
+}
+
+// returnType returns the return type of a method signature, or "id" if the
+// signature does not declare one.
+func returnType(sig string) string {
+	s := strings.TrimLeftFunc(sig[1:], unicode.IsSpace)
+	if !strings.HasPrefix(s, "(") {
+		return "id"
+	}
+	rest := skipParens(s)
+	return strings.TrimSpace(s[1 : len(s)-len(rest)-1])
+}
+
+// skipParens removes the balanced parentheses at the beginning of s, e.g. the
+// return type or a parameter type of a method.
+func skipParens(s string) string {
+	if !strings.HasPrefix(s, "(") {
+		return s
+	}
+	var depth int
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+// propertyName returns the name of the property that is declared by the
+// given "@property" line.
+func propertyName(code string) string {
+	code = strings.TrimSpace(strings.TrimPrefix(code, "@property"))
+	code = skipParens(code)
+	code, _, _ = strings.Cut(code, ";")
+
+	// Block properties: "void (^handler)(BOOL finished)"
+	if _, block, ok := strings.Cut(code, "(^"); ok {
+		return identifierRE.FindString(block)
+	}
+
+	for {
+		stripped := trailingMacroRE.ReplaceAllString(code, "")
+		if stripped == code {
+			break
+		}
+		code = stripped
+	}
+
+	names := identifierRE.FindAllString(code, -1)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[len(names)-1]
+}
+
+// stripLine removes comments and string and character literals from a line of
+// code. comment reports whether the line begins within a block comment; the
+// returned bool reports whether the line ends within one.
+func stripLine(line string, comment bool) (string, bool) {
+	var (
+		out   strings.Builder
+		quote rune
+		esc   bool
+	)
+	for i := 0; i < len(line); i++ {
+		r := rune(line[i])
+		if comment {
+			if strings.HasPrefix(line[i:], "*/") {
+				comment = false
+				i++
+			}
+			continue
+		}
+		if quote != 0 {
+			switch {
+			case esc:
+				esc = false
+			case r == '\\':
+				esc = true
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '"' || r == '\'':
+			quote = r
+		case strings.HasPrefix(line[i:], "//"):
+			return out.String(), false
+		case strings.HasPrefix(line[i:], "/*"):
+			comment = true
+			i++
+		default:
+			out.WriteByte(line[i])
+		}
+	}
+	return out.String(), comment
+}
+
+func findDeclaration(src []string, identifier string) (int, bool) {
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			return d.line, true
+		}
+	}
+	return 0, false
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a doc comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < line
+}
+
+// docStart returns the index of the first line of the doc comment that
+// directly precedes the given line, or line if there is none. Doc comments
+// are either block comments that begin with "/**" or "/*!", or consecutive
+// line comments that begin with "///".
+func docStart(src []string, line int) int {
+	if line == 0 {
+		return line
+	}
+
+	prev := strings.TrimSpace(src[line-1])
+	if isLineDoc(prev) {
+		return lines.BlockStart(src, line, func(l string) bool {
+			return isLineDoc(strings.TrimSpace(l))
+		})
+	}
+
+	if !strings.HasSuffix(prev, "*/") {
+		return line
+	}
+	for i := line - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(src[i])
+		if strings.HasPrefix(trimmed, "/**") || strings.HasPrefix(trimmed, "/*!") {
+			return i
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			return line
+		}
+	}
+
+	return line
+}
+
+func isLineDoc(line string) bool {
+	return strings.HasPrefix(line, "///") && !strings.HasPrefix(line, "////")
+}
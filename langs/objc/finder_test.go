@@ -0,0 +1,123 @@
+package objc_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/objc"
+)
+
+var header = heredoc.Doc(`
+	#import <Foundation/Foundation.h>
+
+	@class Item;
+	@protocol Observer;
+
+	/** Observes changes of a list. */
+	@protocol ListObserver <NSObject>
+	- (void)listDidChange:(List *)list;
+	@optional
+	- (void)list:(List *)list didInsertItem:(Item *)item atIndex:(NSUInteger)index;
+	@end
+
+	@interface List : NSObject {
+	    NSMutableArray *_items;
+	}
+
+	/// The number of items in the list.
+	@property (nonatomic, readonly) NSUInteger count;
+	@property (nonatomic, copy, nullable) NSString *name NS_SWIFT_NAME(title);
+	@property (nonatomic, copy) void (^onChange)(List *list);
+
+	+ (instancetype)list;
+	- (void)insert:(Item *)item
+	       atIndex:(NSUInteger)index;
+	/*
+	 * Not a doc comment.
+	 */
+	- (void)removeAll;
+	- (void)sortUsingComparator:(NSComparisonResult (^)(Item *a, Item *b))comparator NS_SWIFT_NAME(sort(by:));
+	@end
+
+	@interface List (Filtering)
+	- (List *)filteredListUsingPredicate:(NSPredicate *)predicate;
+	@end
+`)
+
+var implementation = heredoc.Doc(`
+	#import "List.h"
+
+	@interface List ()
+	@property (nonatomic) BOOL dirty;
+	- (void)markDirty;
+	@end
+
+	@implementation List
+
+	+ (instancetype)list {
+	    return [[self alloc] init];
+	}
+
+	- (void)insert:(Item *)item atIndex:(NSUInteger)index {
+	    if (index > self.count) {
+	        return;
+	    }
+	    [_items insertObject:item atIndex:index];
+	}
+
+	- (void)markDirty {
+	    self.dirty = YES; // "{"
+	}
+
+	@end
+`)
+
+func TestFinder_Find(t *testing.T) {
+	findings, err := objc.NewFinder().Find(context.Background(), "List.h", []byte(header))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"interface:List",
+		"interface:List(Filtering)",
+		"method:+[List list]",
+		"method:-[List insert:atIndex:]",
+		"method:-[List removeAll]",
+		"method:-[List sortUsingComparator:]",
+		"method:-[List(Filtering) filteredListUsingPredicate:]",
+		"method:-[ListObserver list:didInsertItem:atIndex:]",
+		"method:-[ListObserver listDidChange:]",
+		"property:List.name",
+		"property:List.onChange",
+	}, findings)
+}
+
+func TestFinder_Find_implementation(t *testing.T) {
+	findings, err := objc.NewFinder().Find(context.Background(), "List.m", []byte(implementation))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"method:+[List list]",
+		"method:-[List insert:atIndex:]",
+		"method:-[List markDirty]",
+	}, findings)
+}
+
+func TestHeaders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/List.h": &fstest.MapFile{Data: []byte(header)},
+	}
+
+	findings, err := objc.NewFinder(objc.Headers(fsys)).Find(context.Background(), "src/List.m", []byte(implementation))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"method:-[List markDirty]"}, findings)
+}
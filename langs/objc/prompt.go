@@ -0,0 +1,61 @@
+package objc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the doc comment of the declaration
+// identified by the input. The prompt lists the parameters of methods, so that
+// each of them is documented using a @param tag.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+
+	var tags string
+	if kind == "method" {
+		src := lines.Split(input.Code)
+		if line, ok := findDeclaration(src, input.Identifier); ok {
+			sig, _ := signature(src, line)
+			_, params := selector(sig)
+			for _, p := range params {
+				tags += fmt.Sprintf("\n@param %s <description of %s>", p, p)
+			}
+			if returnType(sig) != "void" && returnType(sig) != "IBAction" {
+				tags += "\n@return <description of the return value>"
+			}
+		}
+	}
+
+	return heredoc.Docf(`
+		Write a HeaderDoc/Doxygen comment for the Objective-C %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a method that adds two numbers, you must not describe it as a "method that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the documentation of Apple's frameworks:
+		---
+		<short description>
+		%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (/** or */).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		tags,
+		input.File,
+		input.Code,
+	)
+}
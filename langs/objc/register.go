@@ -0,0 +1,7 @@
+package objc
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("objc", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,155 @@
+package objc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of Objective-C header and
+// implementation files.
+var FileExtensions = []string{".h", ".m"}
+
+// Service documents interfaces, protocols, properties and methods of
+// Objective-C code using HeaderDoc/Doxygen comments ("/** ... */").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Objective-C code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Objective-C header and
+// implementation files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented declarations in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the doc comment of the declaration identified by
+// identifier, replacing its existing doc comment.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		var params []string
+		if strings.HasPrefix(identifier, "method:") {
+			sig, _ := signature(src, line)
+			_, params = selector(sig)
+		}
+		comment = formatDoc(doc, lines.Indent(src[line]), params)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, line), line, comment)), nil
+}
+
+// formatDoc formats a generated comment as a Doxygen comment. The description
+// is wrapped, and each tag is written on its own line. @param tags are ordered
+// like the parameters of the method.
+func formatDoc(doc, indent string, params []string) []string {
+	var (
+		description []string
+		tags        []string
+	)
+	for _, l := range strings.Split(normalize(doc), "\n") {
+		switch {
+		case strings.HasPrefix(l, "@"):
+			tags = append(tags, l)
+		case len(tags) > 0 && l != "":
+			tags[len(tags)-1] += " " + l
+		case len(tags) == 0:
+			description = append(description, l)
+		}
+	}
+
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return tagOrder(a, params) - tagOrder(b, params)
+	})
+
+	text := internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n")))
+	if len(tags) == 0 && !strings.Contains(text, "\n") && len(indent)+len(text)+len("/**  */") <= 80 {
+		return []string{indent + "/** " + text + " */"}
+	}
+
+	out := append([]string{indent + "/**"}, lines.Comment(text, indent, " * ", "", 80)...)
+	if len(tags) > 0 {
+		out = append(out, indent+" *")
+	}
+	for _, tag := range tags {
+		out = append(out, lines.Comment(tag, indent, " *   ", " * ", 80)...)
+	}
+
+	return append(out, indent+" */")
+}
+
+// tagOrder returns the sort key of a Doxygen tag: parameters in the order of
+// the signature first, then all other tags.
+func tagOrder(tag string, params []string) int {
+	fields := strings.Fields(tag)
+	if fields[0] != "@param" {
+		return len(params) + 1
+	}
+	if len(fields) > 1 {
+		if i := slices.Index(params, fields[1]); i >= 0 {
+			return i
+		}
+	}
+	return len(params)
+}
+
+// normalize removes comment markers from a generated doc comment and trims
+// its lines.
+func normalize(doc string) string {
+	doc = strings.TrimSpace(doc)
+	doc = strings.TrimPrefix(doc, "/**")
+	doc = strings.TrimPrefix(doc, "/*!")
+	doc = strings.TrimSuffix(doc, "*/")
+
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		l = strings.TrimPrefix(strings.TrimSpace(l), "///")
+		docLines[i] = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+	}
+
+	return strings.Join(docLines, "\n")
+}
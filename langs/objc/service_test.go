@@ -0,0 +1,77 @@
+package objc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/objc"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		@interface List : NSObject
+		    /// Outdated.
+		    - (void)insert:(Item *)item
+		           atIndex:(NSUInteger)index;
+		@end
+	`)
+
+	doc := heredoc.Doc(`
+		Inserts an item into the list at the given index. Items at and after the index are moved back by one.
+
+		@param index The index at which to insert the item.
+		@param item The item to insert.
+	`)
+
+	patched, err := objc.New().Patch(context.Background(), "method:-[List insert:atIndex:]", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		@interface List : NSObject
+		    /**
+		     * Inserts an item into the list at the given index. Items at and after the
+		     * index are moved back by one.
+		     *
+		     * @param item The item to insert.
+		     * @param index The index at which to insert the item.
+		     */
+		    - (void)insert:(Item *)item
+		           atIndex:(NSUInteger)index;
+		@end
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_singleLine(t *testing.T) {
+	code := heredoc.Doc(`
+		@interface List : NSObject
+		/**
+		 * Outdated.
+		 */
+		@property (nonatomic, readonly) NSUInteger count;
+		@end
+	`)
+
+	patched, err := objc.New().Patch(context.Background(), "property:List.count", "The number of items in the list.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		@interface List : NSObject
+		/** The number of items in the list. */
+		@property (nonatomic, readonly) NSUInteger count;
+		@end
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
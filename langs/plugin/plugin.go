@@ -0,0 +1,196 @@
+// Package plugin adds languages to JotBot that are implemented by external
+// programs, so that languages can be added without changing JotBot itself.
+//
+// A plugin is an executable that is run once per operation. The operation is
+// passed as the last argument of the command ("find", "prompt", "patch" or
+// "minify"). The plugin reads a JSON-encoded [Request] from stdin and writes a
+// JSON-encoded [Response] to stdout:
+//
+//   - find: reads "file" and "code", and responds with the "identifiers" of the
+//     undocumented symbols in the code
+//   - prompt: reads "file", "code" and "identifier", and responds with the
+//     "prompt" that asks for the documentation of the symbol
+//   - patch: reads "code", "identifier" and "doc", and responds with the
+//     "code" in which the documentation of the symbol is replaced by doc
+//   - minify: reads "code", and responds with the "code" that is sent to the
+//     model as part of the prompt. Plugins that do not minify code respond
+//     with an empty object.
+//
+// A plugin reports a failure by writing a response with an "error", or by
+// exiting with a non-zero status. Anything that the plugin writes to stderr is
+// included in the error.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/slog"
+)
+
+// Operations of the plugin protocol.
+const (
+	Find   = "find"
+	Prompt = "prompt"
+	Patch  = "patch"
+	Minify = "minify"
+)
+
+// Request is the input of a plugin, read from stdin.
+type Request struct {
+	File       string `json:"file,omitempty"`
+	Code       string `json:"code"`
+	Identifier string `json:"identifier,omitempty"`
+	Doc        string `json:"doc,omitempty"`
+}
+
+// Response is the output of a plugin, written to stdout.
+type Response struct {
+	Identifiers []string `json:"identifiers,omitempty"`
+	Prompt      string   `json:"prompt,omitempty"`
+	Code        string   `json:"code,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Service is a language that is implemented by a plugin.
+type Service struct {
+	command    string
+	args       []string
+	dir        string
+	extensions []string
+	log        *slog.Logger
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Args configures the arguments that are passed to the plugin before the
+// operation.
+func Args(args ...string) Option {
+	return func(s *Service) {
+		s.args = append(s.args, args...)
+	}
+}
+
+// Dir configures the working directory of the plugin. By default, the plugin
+// runs in the working directory of JotBot.
+func Dir(dir string) Option {
+	return func(s *Service) {
+		s.dir = dir
+	}
+}
+
+// WithLogger configures the logger that reports plugin failures which cannot be
+// returned as errors.
+func WithLogger(log *slog.Logger) Option {
+	return func(s *Service) {
+		s.log = log
+	}
+}
+
+// New returns a Service that runs the given command for the files with the
+// given extensions.
+func New(command string, extensions []string, opts ...Option) *Service {
+	svc := Service{command: command, extensions: extensions}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions that the plugin handles.
+func (svc *Service) Extensions() []string {
+	return svc.extensions
+}
+
+// Find runs the "find" operation of the plugin and returns the identifiers of
+// the undocumented symbols in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	resp, err := svc.run(ctx, Find, Request{File: file, Code: string(code)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Identifiers, nil
+}
+
+// Prompt runs the "prompt" operation of the plugin. If the plugin fails, the
+// failure is logged and a generic prompt is returned instead.
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	resp, err := svc.run(context.Background(), Prompt, Request{
+		File:       input.File,
+		Code:       string(input.Code),
+		Identifier: input.Identifier,
+	})
+	if err == nil && resp.Prompt != "" {
+		return resp.Prompt
+	}
+	if err == nil {
+		err = errors.New("empty prompt")
+	}
+	svc.log.Warn(fmt.Sprintf("[%s] Plugin failed to create prompt for %s: %v", svc.command, input.Identifier, err))
+	return genericPrompt(input)
+}
+
+// Patch runs the "patch" operation of the plugin and returns the patched code.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	resp, err := svc.run(ctx, Patch, Request{Code: string(code), Identifier: identifier, Doc: doc})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code == "" {
+		return nil, fmt.Errorf("%s %s: plugin returned no code", svc.command, Patch)
+	}
+	return []byte(resp.Code), nil
+}
+
+// Minify runs the "minify" operation of the plugin. The code is returned
+// unchanged if the plugin responds without code.
+func (svc *Service) Minify(code []byte) ([]byte, error) {
+	resp, err := svc.run(context.Background(), Minify, Request{Code: string(code)})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code == "" {
+		return code, nil
+	}
+	return []byte(resp.Code), nil
+}
+
+func (svc *Service) run(ctx context.Context, op string, req Request) (Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal %s request: %w", op, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, svc.command, append(append([]string(nil), svc.args...), op)...)
+	cmd.Dir = svc.dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return Response{}, fmt.Errorf("%s %s: %w\n%s", svc.command, op, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return Response{}, fmt.Errorf("%s %s: unmarshal response: %w\n%s", svc.command, op, err, stdout.Bytes())
+	}
+
+	if resp.Error != "" {
+		return Response{}, fmt.Errorf("%s %s: %s", svc.command, op, resp.Error)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,129 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/plugin"
+)
+
+// TestHelperPlugin is not a test, but the plugin that is run by the other
+// tests. It implements a language whose symbols are lines that start with
+// "def ", documented by lines that start with "# ".
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("JOTBOT_HELPER_PLUGIN") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	var req plugin.Request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var resp plugin.Response
+	if msg := os.Getenv("JOTBOT_HELPER_PLUGIN_ERROR"); msg != "" {
+		resp.Error = msg
+		json.NewEncoder(os.Stdout).Encode(resp)
+		return
+	}
+
+	lines := strings.Split(req.Code, "\n")
+	switch op := os.Args[len(os.Args)-1]; op {
+	case plugin.Find:
+		for i, l := range lines {
+			if strings.HasPrefix(l, "def ") && (i == 0 || !strings.HasPrefix(lines[i-1], "# ")) {
+				resp.Identifiers = append(resp.Identifiers, "func:"+strings.TrimPrefix(l, "def "))
+			}
+		}
+	case plugin.Prompt:
+		resp.Prompt = "Document " + req.Identifier + " in " + req.File
+	case plugin.Patch:
+		for i, l := range lines {
+			if l == "def "+strings.TrimPrefix(req.Identifier, "func:") {
+				lines = append(lines[:i], append([]string{"# " + req.Doc}, lines[i:]...)...)
+				break
+			}
+		}
+		resp.Code = strings.Join(lines, "\n")
+	case plugin.Minify:
+	default:
+		resp.Error = "unknown operation " + op
+	}
+
+	json.NewEncoder(os.Stdout).Encode(resp)
+}
+
+func newPlugin(t *testing.T) *plugin.Service {
+	t.Setenv("JOTBOT_HELPER_PLUGIN", "1")
+	return plugin.New(os.Args[0], []string{".def"}, plugin.Args("-test.run=^TestHelperPlugin$", "--"))
+}
+
+const code = "# Documented.\ndef foo\ndef bar\n"
+
+func TestService_Find(t *testing.T) {
+	svc := newPlugin(t)
+
+	findings, err := svc.Find(context.Background(), "foo.def", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:bar"}, findings)
+}
+
+func TestService_Prompt(t *testing.T) {
+	svc := newPlugin(t)
+
+	prompt := svc.Prompt(generate.PromptInput{
+		Input: generate.Input{Code: []byte(code), Identifier: "func:bar"},
+		File:  "foo.def",
+	})
+
+	if want := "Document func:bar in foo.def"; prompt != want {
+		t.Errorf("Prompt() should return the prompt of the plugin\n\nwant:\n%s\n\ngot:\n%s", want, prompt)
+	}
+}
+
+func TestService_Patch(t *testing.T) {
+	svc := newPlugin(t)
+
+	patched, err := svc.Patch(context.Background(), "func:bar", "Bar.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if want := "# Documented.\ndef foo\n# Bar.\ndef bar\n"; string(patched) != want {
+		t.Errorf("Patch() returned wrong code\n\nwant:\n%s\n\ngot:\n%s", want, patched)
+	}
+}
+
+func TestService_Minify(t *testing.T) {
+	svc := newPlugin(t)
+
+	minified, err := svc.Minify([]byte(code))
+	if err != nil {
+		t.Fatalf("Minify() failed: %v", err)
+	}
+
+	if string(minified) != code {
+		t.Errorf("Minify() should return the code unchanged if the plugin does not minify\n\nwant:\n%s\n\ngot:\n%s", code, minified)
+	}
+}
+
+func TestService_error(t *testing.T) {
+	svc := newPlugin(t)
+	t.Setenv("JOTBOT_HELPER_PLUGIN_ERROR", "invalid code")
+
+	_, err := svc.Find(context.Background(), "foo.def", []byte(code))
+	if err == nil || !strings.Contains(err.Error(), "invalid code") {
+		t.Fatalf("Find() should fail with the error of the plugin; got %v", err)
+	}
+}
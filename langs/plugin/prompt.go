@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// genericPrompt returns a language-agnostic prompt for the given input. It is
+// used if a plugin fails to create a prompt.
+func genericPrompt(input generate.PromptInput) string {
+	return heredoc.Docf(`
+		Write the documentation for %q in the file %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is.
+
+		Output only the unquoted documentation, do not include comment markers.
+
+		Keep the documentation as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		%s
+	`,
+		input.Identifier,
+		input.File,
+		input.Identifier,
+		input.Code,
+	)
+}
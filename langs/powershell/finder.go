@@ -0,0 +1,296 @@
+package powershell
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	functionRE = regexp.MustCompile(`(?i)^\s*(?:function|filter)\s+([\w-]+(?::[\w-]+)?)`)
+	helpRE     = regexp.MustCompile(`(?im)^\s*#?\s*\.(?:SYNOPSIS|DESCRIPTION)\b`)
+	variableRE = regexp.MustCompile(`\$([\w]+)`)
+)
+
+// Finder searches PowerShell scripts and modules for functions and cmdlets
+// (advanced functions) that have no comment-based help.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers ("func:Verb-Noun") of the functions in
+// code that have no comment-based help, neither before the function nor at the
+// beginning of its body.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, fn := range functions(src) {
+		if !documented(src, fn.line) {
+			findings = append(findings, "func:"+fn.name)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type function struct {
+	name string
+	line int
+}
+
+// functions returns the functions that are declared in the given lines,
+// skipping block comments and here-strings.
+func functions(src []string) []function {
+	var (
+		fns  []function
+		skip string
+	)
+	for i, line := range src {
+		trimmed := strings.TrimSpace(line)
+
+		if skip != "" {
+			if strings.HasPrefix(trimmed, skip) || (skip == "#>" && strings.Contains(line, "#>")) {
+				skip = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "<#") && !strings.Contains(trimmed, "#>"):
+			skip = "#>"
+			continue
+		case strings.HasSuffix(trimmed, `@"`):
+			skip = `"@`
+			continue
+		case strings.HasSuffix(trimmed, `@'`):
+			skip = `'@`
+			continue
+		}
+
+		if m := functionRE.FindStringSubmatch(line); m != nil {
+			fns = append(fns, function{name: m[1], line: i})
+		}
+	}
+	return fns
+}
+
+func findFunction(src []string, name string) (int, bool) {
+	for _, fn := range functions(src) {
+		if strings.EqualFold(fn.name, name) {
+			return fn.line, true
+		}
+	}
+	return 0, false
+}
+
+// documented reports whether the function declared at the given line has
+// comment-based help, either directly before the function or at the
+// beginning of its body.
+func documented(src []string, line int) bool {
+	if helpStart(src, line) < line {
+		return true
+	}
+
+	body, ok := functionBody(src, line)
+	if !ok {
+		return false
+	}
+
+	return helpRE.MatchString(leadingComment(body))
+}
+
+// functionBody returns the code of the function declared at the given line,
+// starting after its opening brace.
+func functionBody(src []string, line int) (string, bool) {
+	code := strings.Join(src[line:], "\n")
+	loc := functionRE.FindStringIndex(code)
+	if loc == nil {
+		return "", false
+	}
+	code = code[loc[1]:]
+
+	// Skip parameters that are declared in parentheses.
+	if rest := strings.TrimSpace(code); strings.HasPrefix(rest, "(") {
+		if end := closing(rest); end > 0 {
+			code = rest[end+1:]
+		}
+	}
+
+	_, body, ok := strings.Cut(code, "{")
+	return body, ok
+}
+
+// leadingComment returns the block comment or the line comments at the
+// beginning of code, without comment markers. Each line of the comment is
+// trimmed.
+func leadingComment(code string) string {
+	code = strings.TrimSpace(code)
+
+	if strings.HasPrefix(code, "<#") {
+		comment, _, _ := strings.Cut(code[2:], "#>")
+		return trimLines(comment)
+	}
+
+	var comment []string
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment = append(comment, strings.TrimPrefix(line, "#"))
+	}
+	return trimLines(strings.Join(comment, "\n"))
+}
+
+func trimLines(s string) string {
+	ls := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range ls {
+		ls[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(ls, "\n")
+}
+
+// closing returns the index of the bracket that closes the bracket at the
+// beginning of code, or -1 if it is not closed.
+func closing(code string) int {
+	var depth int
+	for i, r := range code {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// helpStart returns the index of the first line of the comment-based help that
+// directly precedes the given line, or line if there is none.
+func helpStart(src []string, line int) int {
+	if line == 0 {
+		return line
+	}
+
+	start := line
+	if strings.HasSuffix(strings.TrimSpace(src[line-1]), "#>") {
+		for i := line - 1; i >= 0; i-- {
+			if strings.HasPrefix(strings.TrimSpace(src[i]), "<#") {
+				start = i
+				break
+			}
+		}
+	} else {
+		start = lines.BlockStart(src, line, func(l string) bool {
+			return strings.HasPrefix(strings.TrimSpace(l), "#")
+		})
+	}
+
+	for i := start; i < line; i++ {
+		if helpRE.MatchString(strings.TrimPrefix(strings.TrimSpace(src[i]), "<#")) {
+			return start
+		}
+	}
+
+	return line
+}
+
+// parameters returns the names of the parameters of the function declared at
+// the given line, which are declared either in parentheses after the name of
+// the function or in a param() block at the beginning of its body.
+func parameters(src []string, line int) []string {
+	code := strings.Join(src[line:], "\n")
+	loc := functionRE.FindStringIndex(code)
+	if loc == nil {
+		return nil
+	}
+	code = strings.TrimSpace(code[loc[1]:])
+
+	if !strings.HasPrefix(code, "(") {
+		body, ok := functionBody(src, line)
+		if !ok {
+			return nil
+		}
+		code = skipPreamble(body)
+		if !strings.HasPrefix(strings.ToLower(code), "param") {
+			return nil
+		}
+		code = strings.TrimSpace(code[len("param"):])
+		if !strings.HasPrefix(code, "(") {
+			return nil
+		}
+	}
+
+	end := closing(code)
+	if end < 0 {
+		return nil
+	}
+
+	// The name of a parameter is the first variable at the top level of each
+	// comma-separated declaration, which excludes variables in attributes
+	// and default values.
+	var (
+		params []string
+		depth  int
+		next   = true
+	)
+	for i := 1; i < end; i++ {
+		switch code[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				next = true
+			}
+		case '$':
+			if depth == 0 && next {
+				if m := variableRE.FindStringSubmatch(code[i:]); m != nil {
+					params = append(params, m[1])
+					next = false
+				}
+			}
+		}
+	}
+
+	return params
+}
+
+// skipPreamble skips the comments and attributes, such as [CmdletBinding()],
+// at the beginning of the body of a function.
+func skipPreamble(code string) string {
+	for {
+		code = strings.TrimSpace(code)
+		switch {
+		case strings.HasPrefix(code, "<#"):
+			_, rest, ok := strings.Cut(code, "#>")
+			if !ok {
+				return ""
+			}
+			code = rest
+		case strings.HasPrefix(code, "#"):
+			_, rest, _ := strings.Cut(code, "\n")
+			code = rest
+		case strings.HasPrefix(code, "["):
+			end := closing(code)
+			if end < 0 {
+				return ""
+			}
+			code = code[end+1:]
+		default:
+			return code
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package powershell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/powershell"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		function Get-Greeting {
+		    [CmdletBinding()]
+		    param(
+		        [Parameter(Mandatory)]
+		        [string]$Name
+		    )
+		    "Hello, $Name"
+		}
+
+		<#
+		.SYNOPSIS
+		Adds two numbers.
+		#>
+		function Add-Numbers($A, $B) {
+		    $A + $B
+		}
+
+		function Remove-Item2 {
+		    <#
+		    .SYNOPSIS
+		    Removes an item.
+		    #>
+		    param($Path)
+		}
+
+		filter Select-Even {
+		    if ($_ % 2 -eq 0) { $_ }
+		}
+	`)
+
+	findings, err := powershell.NewFinder().Find(context.Background(), "foo.psm1", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:Get-Greeting", "func:Select-Even"}, findings)
+}
@@ -0,0 +1,65 @@
+package powershell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the comment-based help of the
+// function identified by the input. The prompt lists the parameters of the
+// function, so that each of them is documented in a .PARAMETER section.
+func Prompt(input generate.PromptInput) string {
+	name := simpleIdentifier(input.Identifier)
+
+	var params string
+	src := lines.Split(input.Code)
+	if line, ok := findFunction(src, name); ok {
+		for _, p := range parameters(src, line) {
+			params += fmt.Sprintf("\n\n.PARAMETER %s\n<description of $%s>", p, p)
+		}
+	}
+
+	return heredoc.Docf(`
+		Write the comment-based help for the PowerShell function %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two integers, you must not describe it as a "function that adds two integers." Instead, you must describe it as "Adds two integers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the help of built-in PowerShell cmdlets:
+		---
+		.SYNOPSIS
+		<one sentence that summarizes %s>
+
+		.DESCRIPTION
+		<short description of %s>%s
+		---
+
+		Output only the unquoted help, do not include comment markers (<# or #>).
+
+		Keep the help as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		name,
+		name,
+		name,
+		name,
+		name,
+		params,
+		input.File,
+		input.Code,
+	)
+}
+
+func simpleIdentifier(identifier string) string {
+	if _, name, ok := strings.Cut(identifier, ":"); ok {
+		return name
+	}
+	return identifier
+}
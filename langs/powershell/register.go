@@ -0,0 +1,7 @@
+package powershell
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("ps", func() jotbot.Language { return New() })
+}
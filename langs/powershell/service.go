@@ -0,0 +1,141 @@
+package powershell
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of PowerShell scripts and modules.
+var FileExtensions = []string{".ps1", ".psm1"}
+
+var keywordRE = regexp.MustCompile(`^\.([A-Za-z]+)(?:\s+(.*))?$`)
+
+// Service documents the functions and cmdlets of PowerShell scripts and
+// modules using comment-based help.
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// functions.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for PowerShell code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of PowerShell scripts and modules.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the functions in code that have no
+// comment-based help.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the comment-based help block of the function identified
+// by identifier, replacing the help block that precedes the function.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findFunction(src, simpleIdentifier(identifier))
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var help []string
+	if sections := parseHelp(doc); len(sections) > 0 {
+		help = formatHelp(sections, lines.Indent(src[line]))
+	}
+
+	return lines.Join(lines.Replace(src, helpStart(src, line), line, help)), nil
+}
+
+type section struct {
+	keyword  string
+	argument string
+	text     string
+}
+
+// parseHelp parses generated comment-based help into its sections. Text
+// without a section keyword is used as the synopsis.
+func parseHelp(doc string) []section {
+	doc = strings.TrimSpace(doc)
+	doc = strings.TrimPrefix(doc, "<#")
+	doc = strings.TrimSuffix(doc, "#>")
+
+	var (
+		sections []section
+		text     []string
+	)
+	flush := func() {
+		t := internal.RemoveColumns(strings.TrimSpace(strings.Join(text, "\n")))
+		text = text[:0]
+		if len(sections) == 0 {
+			if t == "" {
+				return
+			}
+			sections = append(sections, section{keyword: "SYNOPSIS"})
+		}
+		sections[len(sections)-1].text = t
+	}
+
+	for _, l := range strings.Split(doc, "\n") {
+		l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "#"))
+		if m := keywordRE.FindStringSubmatch(l); m != nil {
+			flush()
+			sections = append(sections, section{keyword: strings.ToUpper(m[1]), argument: m[2]})
+			continue
+		}
+		text = append(text, l)
+	}
+	flush()
+
+	return sections
+}
+
+func formatHelp(sections []section, indent string) []string {
+	out := []string{indent + "<#"}
+	for i, s := range sections {
+		if i > 0 {
+			out = append(out, "")
+		}
+		keyword := "." + s.keyword
+		if s.argument != "" {
+			keyword += " " + s.argument
+		}
+		out = append(out, indent+keyword)
+		if s.text != "" {
+			out = append(out, lines.Comment(s.text, indent, "", "", 80)...)
+		}
+	}
+	return append(out, indent+"#>")
+}
@@ -0,0 +1,97 @@
+package powershell_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/langs/powershell"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		function Get-Greeting {
+		    param([string]$Name = $env:USERNAME, [switch]$Loud)
+		    "Hello, $Name"
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		.SYNOPSIS
+		Returns a greeting.
+
+		.PARAMETER Name
+		The name of the person to greet.
+
+		.PARAMETER Loud
+		Greets loudly.
+	`)
+
+	svc := powershell.New()
+
+	patched, err := svc.Patch(context.Background(), "func:Get-Greeting", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		<#
+		.SYNOPSIS
+		Returns a greeting.
+
+		.PARAMETER Name
+		The name of the person to greet.
+
+		.PARAMETER Loud
+		Greets loudly.
+		#>
+		function Get-Greeting {
+		    param([string]$Name = $env:USERNAME, [switch]$Loud)
+		    "Hello, $Name"
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+
+	again, err := svc.Patch(context.Background(), "func:Get-Greeting", doc, patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if string(again) != string(patched) {
+		t.Errorf("patching the same help twice should not change the code:\n\n%s", cmp.Diff(string(patched), string(again)))
+	}
+}
+
+func TestPrompt_parameters(t *testing.T) {
+	code := heredoc.Doc(`
+		function Get-Greeting {
+		    [CmdletBinding()]
+		    param(
+		        [Parameter(Mandatory)]
+		        [string]$Name,
+		        [int]$Times = $DefaultTimes
+		    )
+		}
+	`)
+
+	prompt := powershell.Prompt(generate.PromptInput{Input: generate.Input{
+		Identifier: "func:Get-Greeting",
+		Code:       []byte(code),
+	}})
+
+	for _, want := range []string{".PARAMETER Name", ".PARAMETER Times"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q:\n%s", want, prompt)
+		}
+	}
+
+	if strings.Contains(prompt, ".PARAMETER DefaultTimes") {
+		t.Errorf("prompt should not contain variables of default values:\n%s", prompt)
+	}
+}
@@ -0,0 +1,123 @@
+package r
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var functionRE = regexp.MustCompile("^([A-Za-z][\\w.]*|`[^`]+`)\\s*(?:<-|=)\\s*(?:function\\s*|\\\\)\\(")
+
+// Finder searches R code for exported functions that have no roxygen2
+// comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers ("func:name") of the exported top-level
+// functions in code that have no roxygen2 comment. Following the default
+// export pattern of R packages, functions whose name begins with a dot are
+// considered internal.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, fn := range functions(src) {
+		if strings.HasPrefix(fn.name, ".") || documented(src, fn.line) {
+			continue
+		}
+		findings = append(findings, "func:"+fn.name)
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type function struct {
+	name string
+	line int
+}
+
+func functions(src []string) []function {
+	var fns []function
+	for i, line := range src {
+		if m := functionRE.FindStringSubmatch(line); m != nil {
+			fns = append(fns, function{name: strings.Trim(m[1], "`"), line: i})
+		}
+	}
+	return fns
+}
+
+func findFunction(src []string, name string) (int, bool) {
+	for _, fn := range functions(src) {
+		if fn.name == name {
+			return fn.line, true
+		}
+	}
+	return 0, false
+}
+
+func isRoxygen(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#'")
+}
+
+func documented(src []string, line int) bool {
+	return lines.BlockStart(src, line, isRoxygen) < line
+}
+
+// parameters returns the names of the parameters in the signature of the
+// function declared at the given line.
+func parameters(src []string, line int) []string {
+	code := strings.Join(src[line:], "\n")
+	loc := functionRE.FindStringIndex(code)
+	if loc == nil {
+		return nil
+	}
+
+	var (
+		params []string
+		param  strings.Builder
+		depth  int
+		quote  rune
+	)
+	flush := func() {
+		name, _, _ := strings.Cut(param.String(), "=")
+		if name = strings.Trim(strings.TrimSpace(name), "`"); name != "" {
+			params = append(params, name)
+		}
+		param.Reset()
+	}
+
+	for _, c := range code[loc[1]:] {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				flush()
+				return params
+			}
+			depth--
+		case c == ',' && depth == 0:
+			flush()
+			continue
+		}
+		if depth == 0 && quote == 0 {
+			param.WriteRune(c)
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,35 @@
+package r_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/r"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		add <- function(x, y = 1) {
+		  x + y
+		}
+
+		#' Subtracts two numbers.
+		#' @export
+		subtract <- function(x, y) x - y
+
+		.internal <- function() NULL
+
+		square = \(x) x^2
+
+		result <- add(1, 2)
+	`)
+
+	findings, err := r.NewFinder().Find(context.Background(), "R/math.R", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"func:add", "func:square"}, findings)
+}
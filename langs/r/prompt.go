@@ -0,0 +1,63 @@
+package r
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the roxygen2 comment of the function
+// identified by the input. The prompt lists the parameters of the function, so
+// that each of them is documented using an @param tag.
+func Prompt(input generate.PromptInput) string {
+	name := simpleIdentifier(input.Identifier)
+
+	var params string
+	src := lines.Split(input.Code)
+	if line, ok := findFunction(src, name); ok {
+		for _, p := range parameters(src, line) {
+			params += fmt.Sprintf("\n@param %s <description of %s>", p, p)
+		}
+	}
+
+	return heredoc.Docf(`
+		Write a roxygen2 comment for the R function %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two numbers, you must not describe it as a "function that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the documentation of packages on CRAN:
+		---
+		<title in sentence case>
+
+		<short description>
+		%s
+		@return <description of the return value>
+		---
+
+		Output only the unquoted comment, do not include comment markers (#').
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		name,
+		name,
+		name,
+		params,
+		input.File,
+		input.Code,
+	)
+}
+
+func simpleIdentifier(identifier string) string {
+	if _, name, ok := strings.Cut(identifier, ":"); ok {
+		return name
+	}
+	return identifier
+}
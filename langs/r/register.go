@@ -0,0 +1,7 @@
+package r
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("r", func() jotbot.Language { return New() })
+}
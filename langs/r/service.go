@@ -0,0 +1,132 @@
+package r
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of R source files.
+var FileExtensions = []string{".R", ".r"}
+
+// Service documents the exported functions of R packages using roxygen2
+// comments.
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// functions.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for R code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of R source files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the exported functions in code that have no
+// roxygen2 comment.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the roxygen2 comment of the function identified by
+// identifier, replacing its existing roxygen2 comment. Parameters of the
+// function that are not documented by doc get an @param stub, so that the
+// documentation passes R CMD check.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findFunction(src, simpleIdentifier(identifier))
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		comment = formatDoc(doc, parameters(src, line))
+	}
+
+	return lines.Join(lines.Replace(src, lines.BlockStart(src, line, isRoxygen), line, comment)), nil
+}
+
+// formatDoc formats a generated comment as roxygen2 comment lines. The
+// description is wrapped, and each tag is written on its own line.
+func formatDoc(doc string, params []string) []string {
+	var (
+		description []string
+		tags        []string
+	)
+	for _, l := range strings.Split(doc, "\n") {
+		l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "#'"))
+		switch {
+		case strings.HasPrefix(l, "@"):
+			tags = append(tags, l)
+		case len(tags) > 0 && l != "":
+			tags[len(tags)-1] += " " + l
+		case len(tags) == 0:
+			description = append(description, l)
+		}
+	}
+
+	// @param tags are ordered like the parameters in the signature.
+	paramTags := make(map[string]string)
+	var other []string
+	for _, tag := range tags {
+		fields := strings.Fields(tag)
+		if len(fields) > 1 && fields[0] == "@param" && slices.Contains(params, fields[1]) {
+			paramTags[fields[1]] = tag
+			continue
+		}
+		other = append(other, tag)
+	}
+
+	tags = tags[:0]
+	for _, p := range params {
+		tag, ok := paramTags[p]
+		if !ok {
+			tag = fmt.Sprintf("@param %s TODO: Describe `%s`.", p, p)
+		}
+		tags = append(tags, tag)
+	}
+	tags = append(tags, other...)
+
+	out := lines.Comment(internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n"))), "", "#' ", "", 80)
+	if len(tags) > 0 {
+		out = append(out, "#'")
+	}
+	for _, tag := range tags {
+		out = append(out, lines.Comment(tag, "", "#'   ", "#' ", 80)...)
+	}
+
+	return out
+}
@@ -0,0 +1,49 @@
+package r_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/r"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		add <- function(x, y = c(1, 2), ...) {
+		  x + y
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Add numbers
+
+		Adds two numbers.
+
+		@return The sum of the numbers.
+		@param y The second number.
+	`)
+
+	patched, err := r.New().Patch(context.Background(), "func:add", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := "" +
+		"#' Add numbers\n" +
+		"#'\n" +
+		"#' Adds two numbers.\n" +
+		"#'\n" +
+		"#' @param x TODO: Describe `x`.\n" +
+		"#' @param y The second number.\n" +
+		"#' @param ... TODO: Describe `...`.\n" +
+		"#' @return The sum of the numbers.\n" +
+		"add <- function(x, y = c(1, 2), ...) {\n" +
+		"  x + y\n" +
+		"}\n"
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
@@ -0,0 +1,292 @@
+package scala
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	declarationRE = regexp.MustCompile("^(\\s*)((?:(?:private|protected)(?:\\[[\\w.]*\\])?\\s+|(?:final|sealed|abstract|implicit|override|lazy|case|inline|open|transparent)\\s+)*)(class|trait|object|def|val|var|given)\\s+([\\w$]+|`[^`]+`)")
+	privateRE     = regexp.MustCompile(`\b(?:private|protected)\b`)
+	parameterRE   = regexp.MustCompile("([\\w$]+|`[^`]+`)\\s*:")
+)
+
+// Finder searches Scala source code for public classes, traits, objects and
+// methods that have no Scaladoc comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public classes ("class:Name"),
+// traits ("trait:Name"), objects ("object:Name"), top-level functions
+// ("func:name") and methods ("method:Owner.name") in code that have no
+// Scaladoc comment. Members of nested templates are identified by their path,
+// e.g. "method:Outer.Inner.name". Overriding methods inherit the documentation
+// of the overridden method, so they are not reported.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	kind       string
+	line       int
+}
+
+type scope struct {
+	indent   int
+	path     string
+	template bool
+	public   bool
+}
+
+// declarations returns the public declarations in the given lines. Members
+// are associated with their enclosing template by indentation, which works
+// for both brace syntax and the indentation syntax of Scala 3. Overloaded
+// methods are returned once, using the first overload.
+func declarations(src []string) []declaration {
+	var (
+		decls  []declaration
+		scopes []scope
+		seen   = make(map[string]bool)
+	)
+	for _, i := range codeLines(src) {
+		m := declarationRE.FindStringSubmatch(src[i])
+		if m == nil {
+			continue
+		}
+
+		indent, modifiers, keyword, name := len(m[1]), m[2], m[3], strings.Trim(m[4], "`")
+		for len(scopes) > 0 && scopes[len(scopes)-1].indent >= indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		parent := scope{template: true, public: true}
+		if len(scopes) > 0 {
+			parent = scopes[len(scopes)-1]
+		}
+
+		public := parent.template && parent.public && !privateRE.MatchString(modifiers)
+		path := name
+		if parent.path != "" {
+			path = parent.path + "." + name
+		}
+
+		template := keyword == "class" || keyword == "trait" || keyword == "object"
+		scopes = append(scopes, scope{indent: indent, path: path, template: template, public: public})
+
+		if !public {
+			continue
+		}
+
+		var kind string
+		switch {
+		case template:
+			kind = keyword
+		case keyword == "def" && strings.Contains(modifiers, "override"):
+			continue
+		case keyword == "def" && parent.path == "":
+			kind = "func"
+		case keyword == "def":
+			kind = "method"
+		default:
+			continue
+		}
+
+		id := kind + ":" + path
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		decls = append(decls, declaration{identifier: id, kind: keyword, line: i})
+	}
+
+	return decls
+}
+
+// codeLines returns the indices of the lines that are not part of a block
+// comment or a multi-line string.
+func codeLines(src []string) []int {
+	var (
+		out  []int
+		skip string
+	)
+	for i, line := range src {
+		if skip != "" {
+			if strings.Contains(line, skip) {
+				skip = ""
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "/*") && !strings.Contains(trimmed, "*/"):
+			skip = "*/"
+			continue
+		case strings.Count(line, `"""`)%2 == 1:
+			out = append(out, i)
+			skip = `"""`
+			continue
+		}
+
+		out = append(out, i)
+	}
+	return out
+}
+
+func findDeclaration(src []string, identifier string) (declaration, bool) {
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			return d, true
+		}
+	}
+	return declaration{}, false
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a Scaladoc comment.
+func documented(src []string, line int) bool {
+	return scaladocStart(src, line) < annotationStart(src, line)
+}
+
+// annotationStart returns the index of the first line of the annotations that
+// directly precede the given line, or line if there are none.
+func annotationStart(src []string, line int) int {
+	return lines.BlockStart(src, line, func(l string) bool {
+		return strings.HasPrefix(strings.TrimSpace(l), "@")
+	})
+}
+
+// scaladocStart returns the index of the first line of the Scaladoc comment
+// that directly precedes the declaration at the given line and its
+// annotations. If there is none, the index of the first annotation is
+// returned.
+func scaladocStart(src []string, line int) int {
+	start := annotationStart(src, line)
+	if start == 0 || !strings.HasSuffix(strings.TrimSpace(src[start-1]), "*/") {
+		return start
+	}
+
+	for i := start - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(src[i])
+		if strings.HasPrefix(trimmed, "/**") {
+			return i
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			return start
+		}
+	}
+
+	return start
+}
+
+// parameters returns the names of the parameters of the declaration at the
+// given line, from all of its parameter lists. The parameters of classes are
+// their constructor parameters.
+func parameters(src []string, line int) []string {
+	code := strings.Join(src[line:], "\n")
+	loc := declarationRE.FindStringIndex(code)
+	if loc == nil {
+		return nil
+	}
+	code = code[loc[1]:]
+
+	var params []string
+	for {
+		code = strings.TrimSpace(code)
+		if strings.HasPrefix(code, "[") {
+			end := closing(code)
+			if end < 0 {
+				return params
+			}
+			code = code[end+1:]
+			continue
+		}
+		if !strings.HasPrefix(code, "(") {
+			return params
+		}
+
+		end := closing(code)
+		if end < 0 {
+			return params
+		}
+		params = append(params, parameterList(code[1:end])...)
+		code = code[end+1:]
+	}
+}
+
+// parameterList returns the names of the parameters in a parameter list. Each
+// parameter is the first name that is followed by a colon in a top-level
+// comma-separated item, which skips modifiers, annotations and anonymous
+// context parameters.
+func parameterList(list string) []string {
+	var (
+		params []string
+		depth  int
+		item   strings.Builder
+	)
+	flush := func() {
+		s := strings.TrimSpace(item.String())
+		item.Reset()
+		if m := parameterRE.FindStringSubmatch(s); m != nil {
+			params = append(params, strings.Trim(m[1], "`"))
+		}
+	}
+
+	for _, r := range list {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		if depth == 0 {
+			item.WriteRune(r)
+		}
+	}
+	flush()
+
+	return params
+}
+
+// closing returns the index of the bracket that closes the bracket at the
+// beginning of code, or -1 if it is not closed.
+func closing(code string) int {
+	var depth int
+	for i, r := range code {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
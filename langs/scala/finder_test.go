@@ -0,0 +1,71 @@
+package scala_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/scala"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		package shapes
+
+		/** A point in the plane. */
+		final case class Point(x: Double, y: Double)
+
+		sealed trait Shape {
+		  def area: Double
+
+		  /** The perimeter of the shape. */
+		  def perimeter: Double
+
+		  override def toString: String = "Shape"
+
+		  private def secret(): Int = 42
+		}
+
+		object Shape {
+		  @deprecated("use circle", "1.0")
+		  def apply(radius: Double): Shape = circle(radius)
+
+		  def circle(radius: Double): Shape = {
+		    def helper(x: Double): Double = x * x
+		    Circle(radius)
+		  }
+
+		  def circle(radius: Double, center: Point): Shape = Circle(radius)
+
+		  private[shapes] class Internal {
+		    def hidden: Int = 1
+		  }
+
+		  class Registry:
+		    def register(shape: Shape): Unit = ()
+		}
+
+		val doc = """
+		  def notAMethod = 1
+		"""
+
+		def area(shape: Shape): Double = shape.area
+	`)
+
+	findings, err := scala.NewFinder().Find(context.Background(), "Shapes.scala", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"class:Shape.Registry",
+		"func:area",
+		"method:Shape.Registry.register",
+		"method:Shape.apply",
+		"method:Shape.circle",
+		"method:Shape.area",
+		"object:Shape",
+		"trait:Shape",
+	}, findings)
+}
@@ -0,0 +1,65 @@
+package scala
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the Scaladoc comment of the
+// declaration identified by the input. The prompt lists the parameters of
+// methods and the constructor parameters of classes, such as the fields of case
+// classes, so that each of them is documented using a @param tag.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+	switch kind {
+	case "func":
+		kind = "function"
+	case "class", "trait", "object", "method":
+	default:
+		kind = "declaration"
+	}
+
+	var params string
+	src := lines.Split(input.Code)
+	if d, ok := findDeclaration(src, input.Identifier); ok {
+		for _, p := range parameters(src, d.line) {
+			params += fmt.Sprintf("\n@param %s <description of %s>", p, p)
+		}
+		if d.kind == "def" {
+			params += "\n@return <description of the return value>"
+		}
+	}
+
+	return heredoc.Docf(`
+		Write a Scaladoc comment for the Scala %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a method that adds two numbers, you must not describe it as a "method that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the Scaladoc of the Scala standard library:
+		---
+		<short description>
+		%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (/** or */).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		params,
+		input.File,
+		input.Code,
+	)
+}
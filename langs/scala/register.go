@@ -0,0 +1,7 @@
+package scala
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("scala", func() jotbot.Language { return New() })
+}
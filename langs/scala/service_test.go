@@ -0,0 +1,102 @@
+package scala_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/scala"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		object Geometry {
+		  /** Outdated. */
+		  @inline
+		  def distance(from: Point, to: Point)(implicit metric: Metric): Double =
+		    metric(from, to)
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Computes the distance between two points.
+
+		@return The distance between the points.
+		@param to The end point.
+		@param metric The metric that measures the distance.
+		@param from The start point.
+	`)
+
+	patched, err := scala.New().Patch(context.Background(), "method:Geometry.distance", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		object Geometry {
+		  /** Computes the distance between two points.
+		    *
+		    * @param from The start point.
+		    * @param to The end point.
+		    * @param metric The metric that measures the distance.
+		    * @return The distance between the points.
+		    */
+		  @inline
+		  def distance(from: Point, to: Point)(implicit metric: Metric): Double =
+		    metric(from, to)
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_caseClass(t *testing.T) {
+	code := heredoc.Doc(`
+		case class User(
+		    id: Long,
+		    @deprecated("use email", "2.0") name: String,
+		    email: String = "",
+		)
+	`)
+
+	doc := "A registered user.\n\n@param id The unique ID of the user.\n@param name The name of the user.\n@param email The email address of the user."
+
+	patched, err := scala.New().Patch(context.Background(), "class:User", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		/** A registered user.
+		  *
+		  * @param id The unique ID of the user.
+		  * @param name The name of the user.
+		  * @param email The email address of the user.
+		  */
+		case class User(
+		    id: Long,
+		    @deprecated("use email", "2.0") name: String,
+		    email: String = "",
+		)
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_short(t *testing.T) {
+	code := "trait Shape\n"
+
+	patched, err := scala.New().Patch(context.Background(), "trait:Shape", "/** A geometric shape. */", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	if want := "/** A geometric shape. */\ntrait Shape\n"; string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s", cmp.Diff(want, string(patched)))
+	}
+}
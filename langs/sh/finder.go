@@ -0,0 +1,210 @@
+package sh
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	functionRE = regexp.MustCompile(`^\s*function\s+([A-Za-z_][\w:.-]*)\s*(?:\(\s*\))?\s*(?:[{(]|$)`)
+	posixRE    = regexp.MustCompile(`^\s*([A-Za-z_][\w:.-]*)\s*\(\s*\)\s*(?:[{(]|$)`)
+	heredocRE  = regexp.MustCompile(`(?:^|[^<])<<-?\s*['"]?([A-Za-z_]\w*)['"]?`)
+	argumentRE = regexp.MustCompile(`\$(?:\{#?)?([1-9])\b`)
+	namedRE    = regexp.MustCompile(`^\s*(?:local\s+|declare\s+(?:-\w+\s+)*|readonly\s+)?([A-Za-z_]\w*)=["']?\$\{?([1-9])\b`)
+)
+
+// Finder searches shell scripts for functions that have no comment header.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the functions ("func:name") in code
+// that have no comment header.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) && !slices.Contains(findings, d.identifier) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+}
+
+// declarations returns the function definitions of a script. Lines within
+// here-documents are skipped.
+func declarations(src []string) []declaration {
+	var (
+		decls   []declaration
+		heredoc string
+	)
+	for i, line := range src {
+		if heredoc != "" {
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+			}
+			continue
+		}
+
+		if name, ok := parseFunction(line); ok {
+			decls = append(decls, declaration{identifier: "func:" + name, line: i})
+		}
+
+		if m := heredocRE.FindStringSubmatch(stripComment(line)); m != nil {
+			heredoc = m[1]
+		}
+	}
+	return decls
+}
+
+func parseFunction(line string) (string, bool) {
+	if m := functionRE.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	if m := posixRE.FindStringSubmatch(line); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// stripComment removes a trailing comment from a line. A "#" only starts a
+// comment at the beginning of a word, and not within quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\\':
+			i++
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// findDeclaration returns the line of the function with the given identifier.
+// If a function is defined more than once, an undocumented definition is
+// preferred.
+func findDeclaration(src []string, identifier string) (int, bool) {
+	line, found := 0, false
+	for _, d := range declarations(src) {
+		if d.identifier != identifier {
+			continue
+		}
+		if !documented(src, d.line) {
+			return d.line, true
+		}
+		if !found {
+			line, found = d.line, true
+		}
+	}
+	return line, found
+}
+
+// documented reports whether the function at the given line is preceded by a
+// comment header. Directives, such as "# shellcheck disable=SC2034", are not
+// part of the header.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < directiveStart(src, line)
+}
+
+// directiveStart returns the index of the first line of the directives that
+// directly precede the given line, or line if there are none.
+func directiveStart(src []string, line int) int {
+	return lines.BlockStart(src, line, isDirective)
+}
+
+// docStart returns the index of the first line of the comment header that
+// directly precedes the directives of the given line, or the index of the
+// first directive if there is no comment header.
+func docStart(src []string, line int) int {
+	return lines.BlockStart(src, directiveStart(src, line), isComment)
+}
+
+func isComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#!") && !isDirective(line)
+}
+
+func isDirective(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "#") && strings.HasPrefix(strings.TrimSpace(trimmed[1:]), "shellcheck ")
+}
+
+// arguments returns the positional arguments that are used in the body of the
+// function at the given line, in the form "$1" or "$1 (name)" if the argument
+// is assigned to a variable, e.g. using `local name="$1"`.
+func arguments(src []string, line int) []string {
+	names := make(map[int]string)
+	var used []int
+	for _, l := range body(src, line) {
+		code := stripComment(l)
+		for _, m := range argumentRE.FindAllStringSubmatch(code, -1) {
+			n, _ := strconv.Atoi(m[1])
+			if !slices.Contains(used, n) {
+				used = append(used, n)
+			}
+		}
+		if m := namedRE.FindStringSubmatch(code); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			if _, ok := names[n]; !ok {
+				names[n] = m[1]
+			}
+		}
+	}
+	slices.Sort(used)
+
+	out := make([]string, len(used))
+	for i, n := range used {
+		out[i] = "$" + strconv.Itoa(n)
+		if name, ok := names[n]; ok {
+			out[i] += " (" + name + ")"
+		}
+	}
+	return out
+}
+
+// body returns the lines of the function at the given line, up to the closing
+// brace at the indentation of the definition, or up to the next function.
+func body(src []string, line int) []string {
+	if strings.Contains(stripComment(src[line]), "}") {
+		return src[line : line+1]
+	}
+
+	indent := lines.Indent(src[line])
+	for i := line + 1; i < len(src); i++ {
+		trimmed := strings.TrimSpace(src[i])
+		if (trimmed == "}" || trimmed == ")") && lines.Indent(src[i]) == indent {
+			return src[line : i+1]
+		}
+		if _, ok := parseFunction(src[i]); ok && lines.Indent(src[i]) == indent {
+			return src[line:i]
+		}
+	}
+	return src[line:]
+}
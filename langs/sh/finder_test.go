@@ -0,0 +1,52 @@
+package sh_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/sh"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		#!/usr/bin/env bash
+		set -euo pipefail
+
+		# Prints the usage of the script.
+		usage() {
+		  cat <<EOF
+		Usage: backup.sh <dir>
+		fake() {
+		EOF
+		}
+
+		log::info() {
+		  echo "[info] $*" >&2
+		}
+
+		# shellcheck disable=SC2034
+		function backup {
+		  local dir="$1"
+		}
+
+		function cleanup() { rm -rf "$tmp"; }
+
+		main () {
+		  usage # not_a_function() {
+		}
+	`)
+
+	findings, err := sh.NewFinder().Find(context.Background(), "backup.sh", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:backup",
+		"func:cleanup",
+		"func:log::info",
+		"func:main",
+	}, findings)
+}
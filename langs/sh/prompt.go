@@ -0,0 +1,67 @@
+package sh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the comment header of the function
+// identified by the input. The prompt lists the positional arguments that the
+// function uses, so that each of them is described in the "Arguments:"
+// section.
+func Prompt(input generate.PromptInput) string {
+	_, name, _ := strings.Cut(input.Identifier, ":")
+
+	args := "  None"
+	src := lines.Split(input.Code)
+	if line, ok := findDeclaration(src, input.Identifier); ok {
+		if used := arguments(src, line); len(used) > 0 {
+			args = ""
+			for i, a := range used {
+				if i > 0 {
+					args += "\n"
+				}
+				args += fmt.Sprintf("  %s <description of the argument>", a)
+			}
+		}
+	}
+
+	return heredoc.Docf(`
+		Write a comment header for the shell function %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that backs up a directory, you must not describe it as a "function that backs up a directory." Instead, you must describe it as "Backs up a directory.".
+
+		You must use exactly the following format, as described by the Google Shell Style Guide:
+		---
+		<short description>
+		Globals:
+		  <global variables that are used or modified, omit the section if there are none>
+		Arguments:
+		%s
+		Outputs:
+		  <what is written to stdout or stderr, omit the section if nothing is written>
+		Returns:
+		  <the exit status, omit the section if it is not meaningful>
+		---
+
+		Output only the unquoted comment, do not include comment markers (#).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		# %s
+		%s
+	`,
+		name,
+		name,
+		name,
+		args,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,7 @@
+package sh
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("sh", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,129 @@
+package sh
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of shell scripts.
+var FileExtensions = []string{".sh", ".bash"}
+
+// sectionRE matches the headings of the sections of a function header, as
+// described by the Google Shell Style Guide.
+var sectionRE = regexp.MustCompile(`^(Globals|Arguments|Outputs|Returns):\s*(.*)$`)
+
+// Service documents functions of shell scripts using comment headers ("#").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// functions.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for shell scripts.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of shell scripts.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented functions in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the comment header of the function identified by
+// identifier, replacing its existing header. The header is placed above the
+// shellcheck directives of the function.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		comment = formatDoc(doc, lines.Indent(src[line]))
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, line), directiveStart(src, line), comment)), nil
+}
+
+// formatDoc formats a generated comment as a function header. The description
+// is wrapped, and the entries of the sections ("Arguments:", "Outputs:", ...)
+// are indented below their headings.
+func formatDoc(doc, indent string) []string {
+	var (
+		description []string
+		sections    []string
+	)
+	for _, l := range strings.Split(normalize(doc), "\n") {
+		switch m := sectionRE.FindStringSubmatch(l); {
+		case m != nil:
+			sections = append(sections, m[1]+":")
+			if m[2] != "" {
+				sections = append(sections, "  "+m[2])
+			}
+		case len(sections) > 0 && l != "":
+			sections = append(sections, "  "+l)
+		case len(sections) == 0:
+			description = append(description, l)
+		}
+	}
+
+	text := internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n")))
+	out := lines.Comment(text, indent, "# ", "", 80)
+	for _, s := range sections {
+		if strings.HasPrefix(s, "  ") {
+			out = append(out, lines.Comment(strings.TrimSpace(s), indent, "#     ", "#   ", 80)...)
+			continue
+		}
+		out = append(out, indent+"# "+s)
+	}
+
+	return out
+}
+
+// normalize removes comment markers from a generated comment and trims its
+// lines. Entries of sections may be prefixed with a dash, which is removed.
+func normalize(doc string) string {
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimSpace(strings.TrimLeft(l, "#"))
+		l = strings.TrimSpace(strings.TrimPrefix(l, "- "))
+		docLines[i] = l
+	}
+	return strings.Join(docLines, "\n")
+}
@@ -0,0 +1,77 @@
+package sh_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/langs/sh"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		#!/usr/bin/env bash
+
+		# Outdated.
+		# shellcheck disable=SC2034
+		backup() {
+		  local dir="$1"
+		  tar -czf "$2" "$dir"
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Backs up a directory into a compressed archive.
+		Arguments:
+		  $1 (dir) - The directory to back up, which must exist and be readable by the current user.
+		  $2 The path of the archive.
+		Returns:
+		  0 on success, non-zero on error.
+	`)
+
+	patched, err := sh.New().Patch(context.Background(), "func:backup", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		#!/usr/bin/env bash
+
+		# Backs up a directory into a compressed archive.
+		# Arguments:
+		#   $1 (dir) - The directory to back up, which must exist and be readable by
+		#     the current user.
+		#   $2 The path of the archive.
+		# Returns:
+		#   0 on success, non-zero on error.
+		# shellcheck disable=SC2034
+		backup() {
+		  local dir="$1"
+		  tar -czf "$2" "$dir"
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	code := heredoc.Doc(`
+		backup() {
+		  local dir="$1"
+		  tar -czf "$2" "$dir"
+		}
+	`)
+
+	prompt := sh.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Identifier: "func:backup"}, File: "backup.sh"})
+
+	for _, want := range []string{"$1 (dir) <description of the argument>", "$2 <description of the argument>"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt should contain %q\n\n%s", want, prompt)
+		}
+	}
+}
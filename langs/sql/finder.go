@@ -0,0 +1,342 @@
+package sql
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// partExpr matches a part of a name, which is either an unquoted or a
+	// quoted identifier ("name", `name` or [name]).
+	partExpr = `"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[A-Za-z_][\w$]*`
+
+	// nameExpr matches a possibly qualified name, e.g. public.users.
+	nameExpr = `(?:` + partExpr + `)(?:\s*\.\s*(?:` + partExpr + `))*`
+)
+
+// The expressions are matched against lines whose strings and comments were
+// blanked out by stripLine.
+var (
+	tableRE     = regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:(?:global|local)\s+)?(?:temp\s+|temporary\s+|unlogged\s+)?table\s+(?:if\s+not\s+exists\s+)?(` + nameExpr + `)`)
+	routineRE   = regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:definer\s*=\s*\S+\s+)?(function|procedure)\s+(?:if\s+not\s+exists\s+)?(` + nameExpr + `)`)
+	addColumnRE = regexp.MustCompile(`(?i)^\s*alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(` + nameExpr + `)\s+add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?(` + partExpr + `)\s`)
+	columnRE    = regexp.MustCompile(`^\s*(` + partExpr + `)\s+\S`)
+	commentOnRE = regexp.MustCompile(`(?i)^\s*comment\s+on\s+(table|column|function|procedure)\s+(` + nameExpr + `)`)
+	delimiterRE = regexp.MustCompile(`(?i)^\s*delimiter\s+(\S+)`)
+
+	// inlineRE matches the inline comment of a MySQL column or table, e.g.
+	// COMMENT 'The ID.' or COMMENT='Users'.
+	inlineRE = regexp.MustCompile(`(?i)\bcomment\s*=?\s*'`)
+)
+
+// constraints are the keywords that start the items of a table definition
+// that are not columns.
+var constraints = []string{
+	"constraint", "primary", "foreign", "unique", "check", "exclude", "index",
+	"key", "like", "period", "fulltext", "spatial",
+}
+
+// Finder searches SQL files for tables, columns, functions and procedures that
+// have no comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the tables ("table:name"), columns
+// ("column:table.name"), functions ("func:name") and procedures ("proc:name")
+// in code that have no comment. Columns are found in CREATE TABLE statements
+// if they start a line, and in ALTER TABLE … ADD COLUMN statements.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+	comments := commentStatements(src)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d, comments) && !slices.Contains(findings, d.identifier) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	kind       string
+
+	// target is the name of the declaration as written in the code, as used by
+	// COMMENT ON statements, e.g. "public.users" or `"users".id`.
+	target string
+
+	// line is the first line of the declaration, and end the last line of the
+	// statement that declares it.
+	line int
+	end  int
+
+	// inline reports whether the declaration is followed by a comment on the
+	// same line.
+	inline bool
+}
+
+// declarations returns the tables, columns, functions and procedures that are
+// declared in src.
+func declarations(src []string) []declaration {
+	var (
+		decls      []declaration
+		state      string
+		depth      int
+		statement  bool
+		table      string
+		expectItem bool
+		delimiter  = ";"
+	)
+
+	for i, line := range src {
+		code, comment, next := stripLine(line, state)
+		state = next
+
+		if !statement {
+			if m := delimiterRE.FindStringSubmatch(code); m != nil {
+				delimiter = m[1]
+				continue
+			}
+		}
+
+		switch {
+		case !statement && depth == 0:
+			if m := tableRE.FindStringSubmatch(code); m != nil {
+				table = m[1]
+				decls = append(decls, declaration{identifier: "table:" + unquote(table), kind: "table", target: table, line: i, end: -1})
+			} else if m := routineRE.FindStringSubmatch(code); m != nil {
+				kind := "func"
+				if strings.EqualFold(m[1], "procedure") {
+					kind = "proc"
+				}
+				decls = append(decls, declaration{identifier: kind + ":" + unquote(m[2]), kind: kind, target: m[2], line: i, end: -1})
+			} else if m := addColumnRE.FindStringSubmatch(code); m != nil && !isConstraint(m[2]) {
+				target := m[1] + "." + m[2]
+				decls = append(decls, declaration{identifier: "column:" + unquote(target), kind: "column", target: target, line: i, end: -1, inline: comment})
+			}
+
+		case table != "" && depth == 1 && expectItem:
+			if m := columnRE.FindStringSubmatch(code); m != nil && !isConstraint(m[1]) {
+				target := table + "." + m[1]
+				decls = append(decls, declaration{identifier: "column:" + unquote(target), kind: "column", target: target, line: i, end: -1, inline: comment})
+			}
+		}
+
+		for j := 0; j < len(code); j++ {
+			switch c := code[j]; {
+			case depth == 0 && strings.HasPrefix(code[j:], delimiter):
+				for k := range decls {
+					if decls[k].end < 0 {
+						decls[k].end = i
+					}
+				}
+				statement, table = false, ""
+				j += len(delimiter) - 1
+			case c == '(':
+				depth++
+				expectItem = depth == 1
+			case c == ')':
+				if depth > 0 {
+					depth--
+				}
+				expectItem = false
+			case c == ',' && depth == 1:
+				expectItem = true
+			case c != ' ' && c != '\t' && c != '\r':
+				statement = true
+				if depth == 1 {
+					expectItem = false
+				}
+			}
+		}
+	}
+
+	for i := range decls {
+		if decls[i].end < 0 {
+			decls[i].end = len(src) - 1
+		}
+	}
+
+	return decls
+}
+
+// stripLine blanks out the strings and comments of a line, but keeps the
+// quotes of strings. The state is the delimiter of the string or comment that
+// continues from the previous line: "*/" for block comments, "'" for strings
+// and "$$" or "$tag$" for dollar-quoted bodies of functions. stripLine also
+// reports whether the line contains a comment.
+func stripLine(line, state string) (string, bool, string) {
+	out := []byte(line)
+	var comment bool
+	for i := 0; i < len(out); i++ {
+		switch {
+		case state == "'":
+			switch {
+			case strings.HasPrefix(line[i:], "''") || line[i] == '\\':
+				blank(out, i, i+2)
+				i++
+			case line[i] == '\'':
+				state = ""
+			default:
+				out[i] = ' '
+			}
+		case state != "":
+			if strings.HasPrefix(line[i:], state) {
+				blank(out, i, i+len(state))
+				i += len(state) - 1
+				state = ""
+				continue
+			}
+			out[i] = ' '
+		case strings.HasPrefix(line[i:], "--"):
+			comment = true
+			blank(out, i, len(out))
+			i = len(out)
+		case strings.HasPrefix(line[i:], "/*"):
+			comment = true
+			state = "*/"
+			blank(out, i, i+2)
+			i++
+		case line[i] == '\'':
+			state = "'"
+		case line[i] == '$':
+			if tag := dollarTagRE.FindString(line[i:]); tag != "" {
+				state = tag
+				blank(out, i, i+len(tag))
+				i += len(tag) - 1
+			}
+		}
+	}
+	return string(out), comment, state
+}
+
+var dollarTagRE = regexp.MustCompile(`^\$(?:[A-Za-z_]\w*)?\$`)
+
+func blank(b []byte, from, to int) {
+	for i := from; i < to && i < len(b); i++ {
+		b[i] = ' '
+	}
+}
+
+// commentStatements returns the COMMENT ON statements of src, keyed by their
+// kind ("table", "column", "function" or "procedure") and the lowercased name
+// of their target, with the line of each statement.
+func commentStatements(src []string) map[string]int {
+	comments := make(map[string]int)
+	var state string
+	for i, line := range src {
+		var code string
+		code, _, state = stripLine(line, state)
+		if m := commentOnRE.FindStringSubmatch(code); m != nil {
+			comments[commentKey(m[1], m[2])] = i
+		}
+	}
+	return comments
+}
+
+func commentKey(kind, target string) string {
+	return strings.ToLower(kind) + ":" + strings.ToLower(unquote(target))
+}
+
+// commentStatement returns the line of the COMMENT ON statement of the
+// declaration. The names of tables and columns match if one of them is
+// qualified by a schema that the other one omits.
+func commentStatement(d declaration, comments map[string]int) (int, bool) {
+	kinds := map[string]string{"table": "table", "column": "column", "func": "function", "proc": "procedure"}
+	key := commentKey(kinds[d.kind], d.target)
+	kind, name, _ := strings.Cut(key, ":")
+	for k, line := range comments {
+		ckind, cname, _ := strings.Cut(k, ":")
+		if ckind == kind && (cname == name || strings.HasSuffix(cname, "."+name) || strings.HasSuffix(name, "."+cname)) {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+// documented reports whether the declaration has a comment: "--" comments on
+// the lines above or on the same line, an inline COMMENT of MySQL, or a
+// COMMENT ON statement anywhere in the file.
+func documented(src []string, d declaration, comments map[string]int) bool {
+	if d.inline || docStart(src, d.line) < d.line {
+		return true
+	}
+	if _, ok := commentStatement(d, comments); ok {
+		return true
+	}
+
+	switch d.kind {
+	case "column":
+		code, _, _ := stripLine(src[d.line], "")
+		return inlineRE.MatchString(code)
+	case "table":
+		for i := d.line + 1; i <= d.end; i++ {
+			code, _, _ := stripLine(src[i], "")
+			if strings.HasPrefix(strings.TrimSpace(code), ")") && inlineRE.MatchString(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// docStart returns the index of the first line of the "--" comment that
+// directly precedes the given line, or line if there is no comment. Directives
+// of migration tools end the comment, so that comments are placed below them.
+func docStart(src []string, line int) int {
+	return lines.BlockStart(src, line, isComment)
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "--") && !isDirective(line)
+}
+
+// isDirective reports whether the line is a directive of a migration tool,
+// such as "-- +goose Up" (goose), "-- +migrate Up" (sql-migrate) or
+// "-- migrate:up" (dbmate).
+func isDirective(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "--") {
+		return false
+	}
+	trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "--"))
+	return strings.HasPrefix(trimmed, "+") || strings.HasPrefix(trimmed, "migrate:")
+}
+
+func isConstraint(name string) bool {
+	return slices.Contains(constraints, strings.ToLower(name))
+}
+
+// unquote removes the quotes and the whitespace from a possibly qualified
+// name, e.g. `"public" . "users"` becomes "public.users".
+func unquote(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		switch c {
+		case '"', '`', '[', ']', ' ', '\t':
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func findDeclaration(src []string, identifier string) (declaration, bool) {
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			return d, true
+		}
+	}
+	return declaration{}, false
+}
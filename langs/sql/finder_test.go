@@ -0,0 +1,63 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/sql"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		-- +goose Up
+		CREATE TABLE IF NOT EXISTS public.users (
+		  id BIGSERIAL PRIMARY KEY,
+		  -- Email address of the user.
+		  email TEXT NOT NULL,
+		  name TEXT DEFAULT 'a, (b', -- Display name.
+		  "createdAt" TIMESTAMPTZ NOT NULL DEFAULT now(),
+		  CONSTRAINT users_email_key UNIQUE (email)
+		);
+
+		COMMENT ON COLUMN users.id IS 'ID; of the user.';
+
+		-- Teams of users.
+		CREATE TABLE teams (id INT);
+
+		ALTER TABLE teams ADD COLUMN owner_id BIGINT;
+
+		CREATE OR REPLACE FUNCTION count_users(team INT) RETURNS INT AS $$
+		  CREATE TABLE not_a_table (id INT);
+		$$ LANGUAGE sql;
+
+		-- +goose StatementBegin
+		CREATE PROCEDURE archive_users()
+		LANGUAGE plpgsql AS $body$
+		BEGIN
+		  DELETE FROM users;
+		END
+		$body$;
+		-- +goose StatementEnd
+
+		DELIMITER //
+		CREATE TABLE mysql_table (
+		  id INT COMMENT 'The ID.'
+		) COMMENT='MySQL table' //
+		DELIMITER ;
+	`)
+
+	findings, err := sql.NewFinder().Find(context.Background(), "001_users.sql", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"table:public.users",
+		"column:public.users.createdAt",
+		"column:teams.owner_id",
+		"func:count_users",
+		"proc:archive_users",
+	}, findings)
+}
@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+var promptKinds = map[string]string{
+	"table":  "table",
+	"column": "column",
+	"func":   "function",
+	"proc":   "stored procedure",
+}
+
+// Prompt returns the prompt that asks for the comment of the table, column,
+// function or procedure identified by the input.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+
+	return heredoc.Docf(`
+		Write a comment for the SQL %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s stores or does for the application, but not how it is declared. For example, if %s is a column that stores the email address of a user, you must not describe it as a "column that stores the email address of a user." Instead, you must describe it as "Email address of the user.".
+
+		Output only the unquoted comment, do not include comment markers (--) or a COMMENT ON statement.
+
+		Keep the comment as short as possible while still being descriptive, preferably a single sentence.
+
+		Here is the source code for reference:
+		---
+		-- %s
+		%s
+	`,
+		promptKinds[kind],
+		name,
+		name,
+		name,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,7 @@
+package sql
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("sql", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,150 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// FileExtensions are the file extensions of SQL files.
+var FileExtensions = []string{".sql"}
+
+// commentKinds are the object types of COMMENT ON statements by the kinds of
+// declarations.
+var commentKinds = map[string]string{
+	"table":  "TABLE",
+	"column": "COLUMN",
+	"func":   "FUNCTION",
+	"proc":   "PROCEDURE",
+}
+
+// Service documents tables, columns, functions and procedures of SQL files.
+// Tables and columns are documented using COMMENT ON statements, which are
+// inserted after the statement that declares them, and functions and
+// procedures using "--" comments above their declaration.
+type Service struct {
+	finder *Finder
+	inline bool
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// InlineComments configures the Service to document tables and columns using
+// "--" comments above their declaration, too. It is meant for databases that
+// do not support COMMENT ON statements, such as MySQL and SQLite.
+func InlineComments(inline bool) Option {
+	return func(s *Service) {
+		s.inline = inline
+	}
+}
+
+// New returns a Service for SQL files.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of SQL files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented declarations in code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the comment of the declaration identified by identifier.
+// The COMMENT ON statement of a table or column replaces an existing statement
+// for the same object, and is otherwise inserted after the statement that
+// declares the object, following the COMMENT ON statements that are already
+// there.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	d, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	doc = normalize(doc)
+
+	if svc.inline || d.kind == "func" || d.kind == "proc" {
+		var comment []string
+		if doc != "" {
+			comment = lines.Comment(doc, lines.Indent(src[d.line]), "-- ", "", 80)
+		}
+		return lines.Join(lines.Replace(src, docStart(src, d.line), d.line, comment)), nil
+	}
+
+	var statement []string
+	if doc != "" {
+		statement = []string{fmt.Sprintf(
+			"%sCOMMENT ON %s %s IS '%s';",
+			lines.Indent(src[d.end]),
+			commentKinds[d.kind],
+			d.target,
+			strings.ReplaceAll(strings.Join(strings.Fields(doc), " "), "'", "''"),
+		)}
+	}
+
+	if line, ok := commentStatement(d, commentStatements(src)); ok {
+		return lines.Join(lines.Replace(src, line, statementEnd(src, line)+1, statement)), nil
+	}
+
+	insert := d.end + 1
+	for insert < len(src) && commentOnRE.MatchString(src[insert]) {
+		insert = statementEnd(src, insert) + 1
+	}
+
+	return lines.Join(lines.Replace(src, insert, insert, statement)), nil
+}
+
+// statementEnd returns the line of the semicolon that ends the statement that
+// starts at the given line.
+func statementEnd(src []string, line int) int {
+	var state string
+	for i := line; i < len(src); i++ {
+		var code string
+		code, _, state = stripLine(src[i], state)
+		if strings.Contains(code, ";") {
+			return i
+		}
+	}
+	return len(src) - 1
+}
+
+// normalize removes comment markers from a generated comment and trims its
+// lines.
+func normalize(doc string) string {
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimSpace(strings.TrimPrefix(l, "--"))
+		docLines[i] = l
+	}
+	return strings.TrimSpace(strings.Join(docLines, "\n"))
+}
@@ -0,0 +1,108 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/langs/sql"
+)
+
+var migration = heredoc.Doc(`
+	-- +goose Up
+	CREATE TABLE users (
+	  id BIGSERIAL PRIMARY KEY,
+	  email TEXT NOT NULL
+	);
+
+	COMMENT ON COLUMN users.id IS 'ID.';
+
+	CREATE FUNCTION count_users() RETURNS INT AS $$
+	  SELECT count(*) FROM users;
+	$$ LANGUAGE sql;
+`)
+
+func TestService_Patch(t *testing.T) {
+	svc := sql.New()
+
+	patched, err := svc.Patch(context.Background(), "table:users", "Users of the application.", []byte(migration))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "column:users.email", "Email address of the user's account.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "column:users.id", "Unique ID of the user.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "func:count_users", "-- Returns the number of users.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		-- +goose Up
+		CREATE TABLE users (
+		  id BIGSERIAL PRIMARY KEY,
+		  email TEXT NOT NULL
+		);
+		COMMENT ON TABLE users IS 'Users of the application.';
+		COMMENT ON COLUMN users.email IS 'Email address of the user''s account.';
+
+		COMMENT ON COLUMN users.id IS 'Unique ID of the user.';
+
+		-- Returns the number of users.
+		CREATE FUNCTION count_users() RETURNS INT AS $$
+		  SELECT count(*) FROM users;
+		$$ LANGUAGE sql;
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("Patch() returned wrong code\n\nwant:\n%s\n\ngot:\n%s", want, patched)
+	}
+}
+
+func TestInlineComments(t *testing.T) {
+	svc := sql.New(sql.InlineComments(true))
+
+	patched, err := svc.Patch(context.Background(), "column:users.email", "Email address of the user.", []byte(migration))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "table:users", "Users of the application.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		-- +goose Up
+		-- Users of the application.
+		CREATE TABLE users (
+		  id BIGSERIAL PRIMARY KEY,
+		  -- Email address of the user.
+		  email TEXT NOT NULL
+		);
+	`)
+
+	if got := string(patched[:len(want)]); got != want {
+		t.Fatalf("Patch() returned wrong code\n\nwant:\n%s\n\ngot:\n%s", want, patched)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	prompt := sql.Prompt(generate.PromptInput{
+		Input: generate.Input{Code: []byte(migration), Identifier: "proc:archive"},
+		File:  "001_users.sql",
+	})
+
+	if want := `Write a comment for the SQL stored procedure "archive".`; len(prompt) < len(want) || prompt[:len(want)] != want {
+		t.Errorf("Prompt() should ask for the comment of the procedure; got\n\n%s", prompt)
+	}
+}
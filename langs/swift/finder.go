@@ -0,0 +1,452 @@
+package swift
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	attributes = `(?:@\w+(?:\([^)]*\))?\s+)*`
+	modifiers  = `((?:(?:public|open|internal|package|private|fileprivate|static|class|final|override|required|convenience|mutating|nonmutating|lazy|weak|unowned|dynamic|indirect|nonisolated|optional|prefix|postfix|infix|distributed)(?:\([^)]*\))?\s+)*)`
+)
+
+var (
+	typeRE      = regexp.MustCompile(`^\s*` + attributes + modifiers + `(class|struct|enum|protocol|actor|extension)\s+([\w.]+)`)
+	memberRE    = regexp.MustCompile(`^\s*` + attributes + modifiers + `(func|init|subscript|var|let|typealias)\b[?!]?\s*([^\s(<:=]*)`)
+	publicRE    = regexp.MustCompile(`\b(?:public|open)\b`)
+	privateRE   = regexp.MustCompile(`\b(?:private|fileprivate|internal|package)\b(?:\s|$)`)
+	identRE     = regexp.MustCompile("[A-Za-z_`][\\w`]*")
+	attributeRE = regexp.MustCompile(`^\s*@\w+`)
+	docRE       = regexp.MustCompile(`^\s*///`)
+)
+
+// keywords are the keywords that the type regular expression may match as the
+// name of a type, e.g. in "class func".
+var keywords = []string{"func", "var", "let", "subscript", "init", "deinit", "typealias"}
+
+// Finder searches Swift source code for public declarations that have no
+// documentation comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public types and type aliases
+// ("type:Name"), functions ("func:name"), global variables and constants
+// ("var:name"), methods and initializers ("method:Type.name",
+// "method:Type.init") and properties and subscripts ("property:Type.name",
+// "property:Type.subscript") in code that have no documentation comment.
+// Nested types are identified by their path, e.g. "type:Outer.Inner", and the
+// members of extensions by the extended type. Declarations are public if they
+// are declared public or open, or if they are members of a public protocol or
+// a public extension.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return slices.Compact(findings), nil
+}
+
+type declaration struct {
+	identifier string
+	kind       string
+	line       int
+}
+
+// container is a type or extension whose body contains declarations.
+type container struct {
+	path  string
+	depth int
+
+	// visible reports whether the members of the container can be public.
+	visible bool
+
+	// public reports whether the members of the container are public by
+	// default, which is the case for public protocols and extensions.
+	public bool
+
+	// open reports whether the body of the container has been opened.
+	open bool
+}
+
+// declarations returns the public declarations in src.
+func declarations(src []string) []declaration {
+	var (
+		decls      []declaration
+		containers []container
+		depth      int
+		state      lineState
+	)
+	for i, line := range src {
+		code := state.strip(line)
+
+		for len(containers) > 0 {
+			top := containers[len(containers)-1]
+			if !top.open || top.depth <= depth {
+				break
+			}
+			containers = containers[:len(containers)-1]
+		}
+
+		var owner *container
+		if n := len(containers); n > 0 && containers[n-1].open && containers[n-1].depth == depth {
+			owner = &containers[n-1]
+		}
+
+		if owner != nil || depth == 0 {
+			if d, c := parseDeclaration(code, owner); d != nil || c != nil {
+				if d != nil {
+					d.line = i
+					decls = append(decls, *d)
+				}
+				if c != nil {
+					c.depth = depth + 1
+					containers = append(containers, *c)
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		if depth < 0 {
+			depth = 0
+		}
+
+		if n := len(containers); n > 0 && !containers[n-1].open && strings.Contains(code, "{") {
+			containers[n-1].open = true
+		}
+	}
+
+	return decls
+}
+
+// parseDeclaration parses the declaration in the given line of code, which is
+// either at the top level of the file (owner is nil) or in the body of the
+// owner. It returns the declaration if it is public, and the container that
+// the declaration opens, if any.
+func parseDeclaration(code string, owner *container) (*declaration, *container) {
+	if m := typeRE.FindStringSubmatch(code); m != nil && !slices.Contains(keywords, m[3]) {
+		mods, keyword, name := m[1], m[2], m[3]
+		public := isPublic(mods, owner)
+
+		if keyword == "extension" {
+			// Extensions have no documentation of their own. Their members
+			// belong to the extended type.
+			return nil, &container{
+				path:    name,
+				visible: true,
+				public:  publicRE.MatchString(mods),
+			}
+		}
+
+		c := container{
+			path:    name,
+			visible: public,
+			public:  public && keyword == "protocol",
+		}
+		if owner != nil {
+			c.path = owner.path + "." + name
+		}
+		if !public {
+			return nil, &c
+		}
+		return &declaration{identifier: "type:" + c.path, kind: keyword}, &c
+	}
+
+	m := memberRE.FindStringSubmatch(code)
+	if m == nil || !isPublic(m[1], owner) {
+		return nil, nil
+	}
+
+	mods, keyword, name := m[1], m[2], strings.Trim(m[3], "`")
+
+	var kind string
+	switch keyword {
+	case "typealias":
+		kind = "type"
+	case "init":
+		kind, name = "method", "init"
+	case "subscript":
+		kind, name = "property", "subscript"
+	case "func":
+		kind = "func"
+		if owner != nil {
+			kind = "method"
+		}
+	case "var", "let":
+		kind = "var"
+		if owner != nil {
+			kind = "property"
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	if strings.Contains(mods, "override") {
+		// Overriding members inherit the documentation of the overridden member.
+		return nil, nil
+	}
+
+	if owner != nil {
+		name = owner.path + "." + name
+	}
+
+	return &declaration{identifier: kind + ":" + name, kind: keyword}, nil
+}
+
+// isPublic reports whether a declaration with the given modifiers is part of
+// the public API, given its owner (nil for top-level declarations).
+func isPublic(mods string, owner *container) bool {
+	if owner != nil && !owner.visible {
+		return false
+	}
+	if publicRE.MatchString(mods) {
+		return true
+	}
+	return owner != nil && owner.public && !privateRE.MatchString(mods)
+}
+
+// lineState is the state of a multiline construct, such as a block comment or
+// a multiline string literal, at the end of a line.
+type lineState struct {
+	comment int
+	quote   string
+}
+
+// strip removes comments and string literals from a line of code, so that the
+// braces within them are not counted. String interpolations are removed along
+// with their string.
+func (s *lineState) strip(line string) string {
+	var (
+		out strings.Builder
+		esc bool
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case s.comment > 0:
+			// Block comments can be nested in Swift.
+			switch {
+			case strings.HasPrefix(line[i:], "/*"):
+				s.comment++
+				i++
+			case strings.HasPrefix(line[i:], "*/"):
+				s.comment--
+				i++
+			}
+		case s.quote != "":
+			switch {
+			case esc:
+				esc = false
+			case line[i] == '\\' && !strings.HasSuffix(s.quote, "#"):
+				esc = true
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return out.String()
+		case strings.HasPrefix(line[i:], "/*"):
+			s.comment++
+			i++
+		case strings.HasPrefix(line[i:], `#"""`):
+			s.quote = `"""#`
+			i += 3
+		case strings.HasPrefix(line[i:], `"""`):
+			s.quote = `"""`
+			i += 2
+		case strings.HasPrefix(line[i:], `#"`):
+			s.quote = `"#`
+			i++
+		case line[i] == '"':
+			s.quote = `"`
+		default:
+			out.WriteByte(line[i])
+		}
+	}
+	if s.quote == `"` || s.quote == `"#` {
+		// Only multiline strings can span multiple lines.
+		s.quote = ""
+	}
+	return out.String()
+}
+
+// findDeclaration returns the declaration identified by identifier.
+// Overloaded functions share an identifier, so the first overload without a
+// documentation comment is preferred.
+func findDeclaration(src []string, identifier string) (declaration, bool) {
+	var (
+		found declaration
+		ok    bool
+	)
+	for _, d := range declarations(src) {
+		if d.identifier != identifier {
+			continue
+		}
+		if !documented(src, d.line) {
+			return d, true
+		}
+		if !ok {
+			found, ok = d, true
+		}
+	}
+	return found, ok
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a documentation comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < attributeStart(src, line)
+}
+
+// attributeStart returns the index of the first line of the attributes that
+// directly precede the given line, or line if there are none.
+func attributeStart(src []string, line int) int {
+	return lines.BlockStart(src, line, attributeRE.MatchString)
+}
+
+// docStart returns the index of the first line of the documentation comment
+// ("///" or "/** */") that directly precedes the declaration at the given
+// line and its attributes. If there is none, the index of the first attribute
+// is returned.
+func docStart(src []string, line int) int {
+	start := attributeStart(src, line)
+	if doc := lines.BlockStart(src, start, docRE.MatchString); doc < start {
+		return doc
+	}
+
+	if start == 0 || !strings.HasSuffix(strings.TrimSpace(src[start-1]), "*/") {
+		return start
+	}
+	for i := start - 1; i >= 0; i-- {
+		trimmed := strings.TrimSpace(src[i])
+		if strings.HasPrefix(trimmed, "/**") {
+			return i
+		}
+		if strings.HasPrefix(trimmed, "/*") {
+			return start
+		}
+	}
+	return start
+}
+
+// signature returns the signature of the declaration at the given line, up to
+// the opening brace of its body.
+func signature(src []string, line int) string {
+	var (
+		sig   strings.Builder
+		state lineState
+	)
+	for i := line; i < len(src); i++ {
+		code := state.strip(src[i])
+		if before, _, found := strings.Cut(code, "{"); found {
+			sig.WriteString(before)
+			break
+		}
+		sig.WriteString(code + "\n")
+	}
+	return sig.String()
+}
+
+// parameters returns the names of the parameters of the function, initializer
+// or subscript that is declared at the given line. The names are the local
+// parameter names, not the argument labels.
+func parameters(src []string, line int) []string {
+	sig := signature(src, line)
+
+	start := strings.Index(sig, "(")
+	if start < 0 {
+		return nil
+	}
+
+	var (
+		params []string
+		depth  int
+		item   strings.Builder
+	)
+	flush := func() {
+		decl, _, _ := strings.Cut(item.String(), ":")
+		item.Reset()
+		names := identRE.FindAllString(decl, -1)
+		if len(names) == 0 {
+			return
+		}
+		if name := strings.Trim(names[len(names)-1], "`"); name != "_" {
+			params = append(params, name)
+		}
+	}
+	for _, r := range sig[start+1:] {
+		switch r {
+		case '(', '<', '[':
+			depth++
+		case ')', '>', ']':
+			if depth == 0 && r == ')' {
+				flush()
+				return params
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		item.WriteRune(r)
+	}
+	flush()
+
+	return params
+}
+
+// returnsValue reports whether the function or subscript that is declared at
+// the given line returns a value.
+func returnsValue(src []string, line int) bool {
+	_, result, found := strings.Cut(effects(src, line), "->")
+	if !found {
+		return false
+	}
+	result, _, _ = strings.Cut(strings.TrimSpace(result), " where ")
+	result = strings.TrimSpace(result)
+	return result != "Void" && result != "()"
+}
+
+// throws reports whether the function or initializer that is declared at the
+// given line can throw an error.
+func throws(src []string, line int) bool {
+	before, _, _ := strings.Cut(effects(src, line), "->")
+	return strings.Contains(before, "throws")
+}
+
+// effects returns the part of the signature of the declaration at the given
+// line that follows its parameter list, such as "async throws -> Int".
+func effects(src []string, line int) string {
+	sig := signature(src, line)
+	start := strings.Index(sig, "(")
+	if start < 0 {
+		return ""
+	}
+	var depth int
+	for i, r := range sig[start:] {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sig[start+i+1:]
+			}
+		}
+	}
+	return ""
+}
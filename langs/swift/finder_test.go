@@ -0,0 +1,102 @@
+package swift_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/swift"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		import Foundation
+
+		/// A point in the plane.
+		public struct Point {
+		    public var x: Double
+		    public private(set) var y: Double
+		    var internalValue = 0
+
+		    public init(x: Double, y: Double) {
+		        let message = "{"
+		        self.x = x
+		        self.y = y
+		    }
+
+		    public static func distance(from a: Point, to b: Point) -> Double { 0 }
+
+		    public static func distance(from a: Point) -> Double { 0 }
+
+		    private func helper() {}
+		}
+
+		@MainActor
+		public final class Canvas: NSObject
+		{
+		    public class var shared: Canvas { Canvas() }
+
+		    public override func awakeFromNib() {}
+
+		    public subscript(index: Int) -> Point { Point(x: 0, y: 0) }
+
+		    public enum Mode {
+		        case draw
+		    }
+
+		    /* public func commented() {} */
+		}
+
+		public protocol Shape {
+		    var area: Double { get }
+		    func draw(on canvas: Canvas)
+		}
+
+		public extension Point {
+		    func moved(by offset: Double) -> Point { self }
+		    fileprivate func hidden() {}
+		}
+
+		extension Point {
+		    public var length: Double { 0 }
+		    func internalHelper() {}
+		}
+
+		struct Internal {
+		    public func ignored() {}
+		}
+
+		public typealias Handler = (Point) -> Void
+
+		public let origin = Point(x: 0, y: 0)
+
+		public func render(_ shapes: [Shape]) throws {}
+
+		func internalFunction() {}
+	`)
+
+	findings, err := swift.NewFinder().Find(context.Background(), "Point.swift", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:render",
+		"method:Point.distance",
+		"method:Point.init",
+		"method:Point.moved",
+		"method:Shape.draw",
+		"property:Canvas.shared",
+		"property:Canvas.subscript",
+		"property:Point.length",
+		"property:Point.x",
+		"property:Point.y",
+		"property:Shape.area",
+		"type:Canvas",
+		"type:Canvas.Mode",
+		"type:Handler",
+		"type:Shape",
+		"var:origin",
+	}, findings)
+}
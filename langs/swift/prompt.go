@@ -0,0 +1,80 @@
+package swift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal/lines"
+)
+
+// Prompt returns the prompt that asks for the documentation comment of the
+// declaration identified by the input. The prompt lists the parameters of
+// functions, initializers and subscripts, so that each of them is documented
+// in the "Parameters" callout, followed by the "Returns" and "Throws" callouts
+// if they apply.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+	switch kind {
+	case "func":
+		kind = "function"
+	case "var":
+		kind = "variable"
+	case "type", "method", "property":
+	default:
+		kind = "declaration"
+	}
+
+	var callouts string
+	src := lines.Split(input.Code)
+	if d, ok := findDeclaration(src, input.Identifier); ok {
+		switch d.kind {
+		case "func", "init", "subscript":
+			if params := parameters(src, d.line); len(params) > 0 {
+				callouts += "\n- Parameters:"
+				for _, p := range params {
+					callouts += fmt.Sprintf("\n  - %s: <description of %s>", p, p)
+				}
+			}
+			if d.kind != "init" && returnsValue(src, d.line) {
+				callouts += "\n- Returns: <description of the return value>"
+			}
+			if throws(src, d.line) {
+				callouts += "\n- Throws: <description of the errors that are thrown>"
+			}
+		}
+	}
+	if callouts != "" {
+		callouts = "\n" + callouts
+	}
+
+	return heredoc.Docf(`
+		Write a documentation comment for the Swift %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two numbers, you must not describe it as a "function that adds two numbers." Instead, you must describe it as "Adds two numbers.".
+
+		You must use exactly the following format, and keep the writing style consistent with the documentation of the Swift standard library:
+		---
+		<short description>%s
+		---
+
+		Output only the unquoted comment, do not include comment markers (///). Refer to other symbols using double backticks, e.g. %s.
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		callouts,
+		"``Array``",
+		input.File,
+		input.Code,
+	)
+}
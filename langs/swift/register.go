@@ -0,0 +1,7 @@
+package swift
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("swift", func() jotbot.Language { return New() })
+}
@@ -0,0 +1,220 @@
+package swift
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+// FileExtensions are the file extensions of Swift source files.
+var FileExtensions = []string{".swift"}
+
+var (
+	calloutRE    = regexp.MustCompile(`^-\s+([\w` + "`" + `]+):\s*(.*)$`)
+	parametersRE = regexp.MustCompile(`^-\s+Parameters:\s*$`)
+	parameterRE  = regexp.MustCompile(`^-\s+Parameter\s+([\w` + "`" + `]+):\s*(.*)$`)
+)
+
+// Service documents public declarations of Swift code using documentation
+// comments ("///").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Swift code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Swift source files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented public declarations in
+// code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the documentation comment of the declaration identified
+// by identifier, replacing its existing documentation comment. The comment is
+// placed above the attributes of the declaration.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	d, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = strings.TrimSpace(doc); doc != "" {
+		comment = formatDoc(doc, lines.Indent(src[d.line]), parameters(src, d.line))
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, d.line), attributeStart(src, d.line), comment)), nil
+}
+
+type callout struct {
+	name string
+	text string
+}
+
+// formatDoc formats a generated comment as a documentation comment. The
+// description is wrapped, and each callout is written on its own (wrapped)
+// line. Parameters are ordered like in the signature and written as a single
+// "Parameter" callout or, if there are multiple, as a "Parameters" list. They
+// are followed by the "Returns" and "Throws" callouts and all other callouts.
+func formatDoc(doc, indent string, signature []string) []string {
+	var (
+		description []string
+		params      []callout
+		callouts    []callout
+		inParams    bool
+		last        *callout
+	)
+	for _, l := range strings.Split(normalize(doc), "\n") {
+		switch {
+		case parametersRE.MatchString(l):
+			inParams, last = true, nil
+		case parameterRE.MatchString(l):
+			m := parameterRE.FindStringSubmatch(l)
+			params = append(params, callout{name: strings.Trim(m[1], "`"), text: m[2]})
+			inParams, last = false, &params[len(params)-1]
+		case calloutRE.MatchString(l):
+			m := calloutRE.FindStringSubmatch(l)
+			if inParams && !isCallout(m[1]) {
+				params = append(params, callout{name: strings.Trim(m[1], "`"), text: m[2]})
+				last = &params[len(params)-1]
+				break
+			}
+			inParams = false
+			callouts = append(callouts, callout{name: m[1], text: m[2]})
+			last = &callouts[len(callouts)-1]
+		case last != nil && l != "":
+			last.text += " " + l
+		case last == nil && len(params) == 0 && len(callouts) == 0:
+			description = append(description, l)
+		}
+	}
+
+	slices.SortStableFunc(params, func(a, b callout) int {
+		return paramOrder(a.name, signature) - paramOrder(b.name, signature)
+	})
+	slices.SortStableFunc(callouts, func(a, b callout) int {
+		return calloutOrder(a.name) - calloutOrder(b.name)
+	})
+
+	out := lines.Comment(internal.RemoveColumns(strings.TrimSpace(strings.Join(description, "\n"))), indent, "/// ", "", 80)
+	if len(params) > 0 || len(callouts) > 0 {
+		out = append(out, indent+"///")
+	}
+
+	switch len(params) {
+	case 0:
+	case 1:
+		out = append(out, lines.Comment("Parameter "+params[0].name+": "+params[0].text, indent, "///   ", "/// - ", 80)...)
+	default:
+		out = append(out, indent+"/// - Parameters:")
+		for _, p := range params {
+			out = append(out, lines.Comment(p.name+": "+p.text, indent, "///     ", "///   - ", 80)...)
+		}
+	}
+
+	for _, c := range callouts {
+		out = append(out, lines.Comment(c.name+": "+c.text, indent, "///   ", "/// - ", 80)...)
+	}
+
+	return out
+}
+
+// isCallout reports whether name is the name of a callout of the Swift
+// documentation markup, rather than the name of a parameter.
+func isCallout(name string) bool {
+	switch name {
+	case "Returns", "Throws", "Note", "Important", "Warning", "Attention", "Precondition", "Postcondition",
+		"Requires", "Invariant", "Complexity", "SeeAlso", "Remark", "Experiment", "Bug", "ToDo",
+		"Author", "Authors", "Copyright", "Date", "Since", "Version", "Tag":
+		return true
+	}
+	return false
+}
+
+// paramOrder returns the sort key of a parameter: its position in the
+// signature, or the number of parameters if it is not part of the signature.
+func paramOrder(name string, signature []string) int {
+	if i := slices.Index(signature, name); i >= 0 {
+		return i
+	}
+	return len(signature)
+}
+
+// calloutOrder returns the sort key of a callout: "Returns" and "Throws" come
+// first, followed by all other callouts.
+func calloutOrder(name string) int {
+	switch name {
+	case "Returns":
+		return 0
+	case "Throws":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// normalize removes comment markers from a generated documentation comment
+// and trims its lines.
+func normalize(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if strings.HasPrefix(doc, "/**") {
+		doc = strings.TrimSuffix(strings.TrimPrefix(doc, "/**"), "*/")
+	}
+
+	docLines := strings.Split(strings.TrimSpace(doc), "\n")
+	for i, l := range docLines {
+		l = strings.TrimSpace(l)
+		l = strings.TrimPrefix(l, "///")
+		l = strings.TrimPrefix(l, "* ")
+		l = strings.TrimSpace(l)
+		if l == "*" {
+			l = ""
+		}
+		// Callouts may also be written using "*" or "+" as list markers.
+		if strings.HasPrefix(l, "* ") || strings.HasPrefix(l, "+ ") {
+			l = "- " + l[2:]
+		}
+		docLines[i] = l
+	}
+
+	return strings.TrimSpace(strings.Join(docLines, "\n"))
+}
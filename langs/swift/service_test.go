@@ -0,0 +1,94 @@
+package swift_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/swift"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		public struct Geometry {
+		    /// Outdated.
+		    @inlinable
+		    @available(macOS 13, *)
+		    public static func distance(from start: Point, to end: Point, using metric: Metric) throws -> Double {
+		        try metric.measure(start, end)
+		    }
+		}
+	`)
+
+	doc := heredoc.Doc(`
+		Computes the distance between two points.
+
+		- Throws: An error if the metric cannot measure the distance.
+		- Returns: The distance between the points.
+		- Parameters:
+		  - end: The end point.
+		  - metric: The metric that measures the distance between the start and the end point.
+		  - start: The start point.
+	`)
+
+	patched, err := swift.New().Patch(context.Background(), "method:Geometry.distance", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		public struct Geometry {
+		    /// Computes the distance between two points.
+		    ///
+		    /// - Parameters:
+		    ///   - start: The start point.
+		    ///   - end: The end point.
+		    ///   - metric: The metric that measures the distance between the start and
+		    ///     the end point.
+		    /// - Returns: The distance between the points.
+		    /// - Throws: An error if the metric cannot measure the distance.
+		    @inlinable
+		    @available(macOS 13, *)
+		    public static func distance(from start: Point, to end: Point, using metric: Metric) throws -> Double {
+		        try metric.measure(start, end)
+		    }
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
+
+func TestService_Patch_blockComment(t *testing.T) {
+	code := heredoc.Doc(`
+		/**
+		 * Outdated.
+		 */
+		public func greet(_ name: String) -> String {
+		    "Hello, \(name)!"
+		}
+	`)
+
+	doc := "/// Returns a greeting.\n///\n/// - Parameter name: The name of the person to greet.\n/// - Returns: The greeting."
+
+	patched, err := swift.New().Patch(context.Background(), "func:greet", doc, []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		/// Returns a greeting.
+		///
+		/// - Parameter name: The name of the person to greet.
+		/// - Returns: The greeting.
+		public func greet(_ name: String) -> String {
+		    "Hello, \(name)!"
+		}
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
@@ -2,14 +2,10 @@ package ts
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/modernice/jotbot/internal"
-	"github.com/modernice/jotbot/internal/slice"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
@@ -37,6 +33,18 @@ const (
 	// Property represents a TypeScript object property symbol used for identifying
 	// such properties within source code during static analysis.
 	Property = Symbol("prop")
+
+	// Accessor represents a TypeScript get or set accessor. A getter and a
+	// setter of the same name are a single accessor, which is documented at
+	// its first declaration.
+	Accessor = Symbol("accessor")
+
+	// Type represents a TypeScript type alias symbol.
+	Type = Symbol("type")
+
+	// Enum represents a TypeScript enum symbol, including const enums. The
+	// members of an enum are found as its properties.
+	Enum = Symbol("enum")
 )
 
 // Symbol represents a distinct element or token in the TypeScript language that
@@ -61,10 +69,9 @@ type Position struct {
 // Finder provides functionality for locating specific symbols within TypeScript
 // code. It supports customization through options that can specify which
 // symbols to look for, whether to include documented symbols in the search, and
-// an optional logger for logging purposes. Finder offers methods to execute
-// searches that return either a list of found symbol names or the position of a
-// particular symbol. It handles the execution context and potential errors,
-// returning structured results based on the TypeScript code provided.
+// an optional logger for logging purposes. The code is parsed natively, without
+// type-checking, so that code with type errors or missing imports can still be
+// searched.
 type Finder struct {
 	symbols           []Symbol
 	includeDocumented bool
@@ -122,87 +129,43 @@ func NewFinder(opts ...FinderOption) *Finder {
 }
 
 // Find searches for specified symbols in the provided TypeScript code and
-// returns a list of findings. It respects the configured symbols and
-// documentation inclusion settings of the Finder instance. If an error occurs
-// during the search, it is returned along with an empty list. The context
-// parameter allows the search to be canceled or have a deadline.
-func (f *Finder) Find(ctx context.Context, code []byte) ([]string, error) {
-	raw, err := f.executeFind(ctx, code)
-	if err != nil {
-		return nil, err
-	}
-
+// returns their identifiers in the order of the code. It respects the
+// configured symbols and documentation inclusion settings of the Finder
+// instance. Overloaded functions and methods are reported once. If file has a
+// ".vue" extension, code is a Vue single-file component, and the symbols of
+// its <script> blocks are found.
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
 	var found []string
-	if err := json.Unmarshal(raw, &found); err != nil {
-		return nil, fmt.Errorf("unmarshal findings: %w\n%s", err, raw)
+	for _, d := range parse(code, isVueFile(file)) {
+		if len(f.symbols) > 0 && !slices.Contains(f.symbols, d.symbol) {
+			continue
+		}
+		if !f.includeDocumented && d.documented() {
+			continue
+		}
+		if !slices.Contains(found, d.identifier) {
+			found = append(found, d.identifier)
+		}
 	}
-
 	return found, nil
 }
 
-func (f *Finder) executeFind(ctx context.Context, code []byte) ([]byte, error) {
-	args := []string{"find", "--json"}
-
-	if len(f.symbols) > 0 {
-		symbols := internal.JoinStrings(slice.Map(f.symbols, unquote[Symbol]), ",")
-		args = append(args, "-s", string(symbols))
-	}
-
-	if f.includeDocumented {
-		args = append(args, "--documented")
-	}
-
-	args = append(args, string(code))
-
-	cmd := exec.CommandContext(ctx, jotbotTSPath, args...)
-
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("%w:\n%s", err, out)
-	}
-
-	return out, nil
-}
-
 // Position locates the position of a specified identifier within a given body
-// of code and returns its location as a [Position]. If the identifier cannot be
-// found or another error occurs, an error is returned instead. The search is
-// conducted within the provided context for cancellation and timeout handling.
-func (f *Finder) Position(ctx context.Context, identifier string, code []byte) (Position, error) {
-	raw, err := f.executePosition(ctx, identifier, code)
-	if err != nil {
-		return Position{}, err
-	}
-
-	var pos Position
-	if err := json.Unmarshal(raw, &pos); err != nil {
-		return Position{}, fmt.Errorf("unmarshal position: %w\n%s", err, raw)
-	}
-
-	return pos, nil
+// of code and returns its location as a [Position]. The position is that of the
+// first token of the declaration, including its decorators and modifiers. If
+// the identifier is declared more than once, as overloaded functions are, the
+// position of the first declaration is returned. If the identifier cannot be
+// found, an error is returned instead. Like [*Finder.Find], the code of files
+// with a ".vue" extension is parsed as a Vue single-file component.
+func (f *Finder) Position(ctx context.Context, file, identifier string, code []byte) (Position, error) {
+	return f.position(identifier, code, isVueFile(file))
 }
 
-func (f *Finder) executePosition(ctx context.Context, identifier string, code []byte) ([]byte, error) {
-	args := []string{"pos", identifier, string(code)}
-
-	cmd := exec.CommandContext(ctx, jotbotTSPath, args...)
-
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("%w:\n%s", err, out)
-	}
-
-	return out, nil
-}
-
-func unquote[S ~string](s S) S {
-	return S(strings.Trim(string(s), `"`))
-}
-
-var jotbotTSPath = os.Getenv("JOTBOT_TS_PATH")
-
-func init() {
-	if jotbotTSPath == "" {
-		jotbotTSPath = "jotbot-ts"
+func (f *Finder) position(identifier string, code []byte, vue bool) (Position, error) {
+	for _, d := range parse(code, vue) {
+		if d.identifier == identifier {
+			return Position{Line: d.start.line, Character: d.start.col}, nil
+		}
 	}
+	return Position{}, fmt.Errorf("%q not found in code", identifier)
 }
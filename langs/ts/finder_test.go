@@ -33,7 +33,7 @@ func TestFinder_Find(t *testing.T) {
 
 	f := ts.NewFinder()
 
-	findings, err := f.Find(context.Background(), []byte(code))
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -75,7 +75,7 @@ func TestSymbols(t *testing.T) {
 
 	f := ts.NewFinder(ts.Symbols(ts.Var, ts.Method))
 
-	findings, err := f.Find(context.Background(), []byte(code))
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
 	if err != nil {
 		t.Fatalf("Find() failed: %v", err)
 	}
@@ -87,6 +87,95 @@ func TestSymbols(t *testing.T) {
 	}, findings)
 }
 
+func TestFinder_Find_enums(t *testing.T) {
+	code := heredoc.Doc(`
+		export enum Color {
+			Red,
+			Green = 'green',
+			'Light Blue' = 1 << 2,
+		}
+
+		/** Documented. */
+		export enum Documented { A }
+
+		export const enum Direction { Up = 1, Down }
+
+		export declare enum Declared {}
+
+		enum Internal { A, B }
+
+		export const value = Color.Red
+	`)
+
+	f := ts.NewFinder(ts.Symbols(ts.Enum, ts.Property, ts.Var))
+
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"enum:Color",
+		"prop:Color.Red",
+		"prop:Color.Green",
+		"prop:Color.'Light Blue'",
+		"prop:Documented.A",
+		"enum:Direction",
+		"prop:Direction.Up",
+		"prop:Direction.Down",
+		"enum:Declared",
+		"var:value",
+	}, findings)
+}
+
+func TestFinder_Find_accessors(t *testing.T) {
+	code := heredoc.Doc(`
+		export class Counter {
+			#count = 0
+
+			get count(): number {
+				return this.#count
+			}
+
+			set count(value: number) {
+				this.#count = value
+			}
+
+			/** Documented. */
+			get documented() { return 0 }
+			set documented(v) {}
+
+			static get instance() { return new Counter() }
+
+			private get hidden() { return 1 }
+
+			get() {}
+		}
+
+		export interface Sized {
+			get size(): number
+		}
+
+		class Internal {
+			get value() { return 0 }
+		}
+	`)
+
+	f := ts.NewFinder(ts.Symbols(ts.Accessor, ts.Method))
+
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"accessor:Counter.count",
+		"accessor:Counter.instance",
+		"method:Counter.get",
+		"accessor:Sized.size",
+	}, findings)
+}
+
 func TestFinder_Position(t *testing.T) {
 	code := heredoc.Doc(`
 		export const foo = 'foo'
@@ -96,7 +185,7 @@ func TestFinder_Position(t *testing.T) {
 
 	f := ts.NewFinder()
 
-	pos, err := f.Position(context.Background(), "func:bar", []byte(code))
+	pos, err := f.Position(context.Background(), "foo.ts", "func:bar", []byte(code))
 	if err != nil {
 		t.Fatalf("Position() failed: %v", err)
 	}
@@ -109,3 +198,246 @@ func TestFinder_Position(t *testing.T) {
 		t.Errorf("Position() returned wrong character; want %d; got %d", 9, pos.Character)
 	}
 }
+
+func TestFinder_Find_declarations(t *testing.T) {
+	code := heredoc.Doc(`
+		/** Documented. */
+		export const documented = 'foo'
+
+		export function overloaded(a: string): string
+		export function overloaded(a: number): number
+		export function overloaded(a: any): any {
+			const re = /[{]/
+			return a
+		}
+
+		export function ret(): { a: string } {
+			return { a: '' }
+		}
+
+		export namespace NS {
+			export const inner = ` + "`${'}'}`" + `
+			export namespace Deep {
+				export function deep() {}
+			}
+		}
+
+		export type Alias = {
+			foo: string
+			bar(): void
+			[key: string]: unknown
+			get baz(): string
+		}
+
+		export type Union = { foo: string } | { bar: string }
+
+		@Component({ selector: 'foo' })
+		export class Decorated<T> extends Base<T> {
+			@Input() foo: string
+			private secret = 1
+			constructor(private readonly svc: Service) { super() }
+			get value() { return 1 }
+			static {
+				init()
+			}
+			bar = <T,>(x: T) => x
+		}
+
+		export const Expr = class {
+			baz() {}
+		}
+
+		export const View = () => (
+			<div className="view">
+				<p>Don't /break</p>
+			</div>
+		)
+
+		function local() {}
+	`)
+
+	f := ts.NewFinder(ts.Symbols(ts.Var, ts.Func, ts.Class, ts.Method, ts.Property, ts.Type))
+
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:overloaded",
+		"func:ret",
+		"var:NS.inner",
+		"func:NS.Deep.deep",
+		"type:Alias",
+		"prop:Alias.foo",
+		"method:Alias.bar",
+		"type:Union",
+		"class:Decorated",
+		"prop:Decorated.foo",
+		"prop:Decorated.bar",
+		"var:Expr",
+		"class:Expr",
+		"method:Expr.baz",
+		"var:View",
+	}, findings)
+}
+
+func TestFinder_Position_decorators(t *testing.T) {
+	code := heredoc.Doc(`
+		export class Foo {
+			@Input()
+			foo: string
+		}
+	`)
+
+	f := ts.NewFinder()
+
+	pos, err := f.Position(context.Background(), "foo.ts", "prop:Foo.foo", []byte(code))
+	if err != nil {
+		t.Fatalf("Position() failed: %v", err)
+	}
+
+	if pos.Line != 1 || pos.Character != 1 {
+		t.Errorf("Position() should return the position of the first decorator; want %d:%d; got %d:%d", 1, 1, pos.Line, pos.Character)
+	}
+
+	if _, err := f.Position(context.Background(), "foo.ts", "func:foo", []byte(code)); err == nil {
+		t.Errorf("Position() should fail for an unknown identifier")
+	}
+}
+
+func TestFinder_Find_multipleVariables(t *testing.T) {
+	code := heredoc.Doc(`
+		export const bar = 1, baz = 2;
+
+		export let foo: string, Expr = class {
+			qux() {}
+		}
+
+		const local = 1, other = 2
+	`)
+
+	f := ts.NewFinder(ts.Symbols(ts.Var, ts.Class, ts.Method))
+
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"var:bar",
+		"var:baz",
+		"var:foo",
+		"var:Expr",
+		"class:Expr",
+		"method:Expr.qux",
+	}, findings)
+
+	pos, err := f.Position(context.Background(), "foo.ts", "var:baz", []byte(code))
+	if err != nil {
+		t.Fatalf("Position() failed: %v", err)
+	}
+
+	if pos.Line != 0 || pos.Character != 22 {
+		t.Errorf("Position() should return the position of the name of the variable; want %d:%d; got %d:%d", 0, 22, pos.Line, pos.Character)
+	}
+}
+
+func TestFinder_Find_vue(t *testing.T) {
+	code := heredoc.Doc(`
+		<template>
+			<button @click="increment">{{ label }}</button>
+		</template>
+
+		<script lang="ts">
+		import { defineComponent, ref } from 'vue'
+
+		export function useCounter() {
+			return ref(0)
+		}
+
+		/** Documented. */
+		export const useLabel = () => 'label'
+
+		export default defineComponent({
+			name: 'Counter',
+			props: {
+				start: Number,
+				/** Documented. */
+				step: { type: Number, default: 1 },
+			},
+			data() {
+				return { count: 0 }
+			},
+			computed: {
+				label() {
+					return String(this.count)
+				},
+			},
+			methods: {
+				...mapActions(['reset']),
+				increment() {
+					this.count++
+				},
+				async save() {},
+			},
+		})
+		</script>
+
+		<script setup lang="ts">
+		const count = useCounter()
+		</script>
+
+		<style scoped>
+		button { color: red; }
+		</style>
+	`)
+
+	f := ts.NewFinder()
+
+	findings, err := f.Find(context.Background(), "Counter.vue", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:useCounter",
+		"var:default",
+		"prop:default.start",
+		"prop:default.label",
+		"method:default.increment",
+		"method:default.save",
+	}, findings)
+
+	pos, err := f.Position(context.Background(), "Counter.vue", "method:default.increment", []byte(code))
+	if err != nil {
+		t.Fatalf("Position() failed: %v", err)
+	}
+
+	if pos.Line != 31 || pos.Character != 2 {
+		t.Errorf("Position() should return the position within the component; want %d:%d; got %d:%d", 31, 2, pos.Line, pos.Character)
+	}
+}
+
+func TestFinder_Find_vueExtension(t *testing.T) {
+	// TypeScript code that looks like a Vue single-file component.
+	code := heredoc.Doc(`
+		<any>window;
+
+		export const html = `+"`"+`
+		<template>
+		</template>
+		`+"`"+`
+
+		export function foo() {}
+	`)
+
+	f := ts.NewFinder()
+
+	findings, err := f.Find(context.Background(), "foo.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{"var:html", "func:foo"}, findings)
+}
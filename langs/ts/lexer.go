@@ -0,0 +1,317 @@
+package ts
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokPrivateName
+	tokString
+	tokTemplate
+	tokNumber
+	tokRegexp
+	tokPunct
+)
+
+// token is a token of TypeScript or JavaScript code. Comments and whitespace
+// are not tokens, but are recorded in the token that follows them.
+type token struct {
+	kind tokenKind
+	text string
+
+	// start is the byte offset of the token in the code, and line and col the
+	// line and byte offset within the line.
+	start int
+	line  int
+	col   int
+
+	// comment reports whether the token is preceded by a comment, and newline
+	// whether it is preceded by a line break.
+	comment bool
+	newline bool
+}
+
+func (t token) is(text string) bool {
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == text
+}
+
+// lexer splits code into tokens. It understands just enough of the syntax of
+// TypeScript, JavaScript and JSX to skip over comments, strings, template
+// literals and regular expressions, so that the brackets within them are not
+// mistaken for code.
+type lexer struct {
+	code   string
+	pos    int
+	line   int
+	col    int
+	tokens []token
+
+	// templates holds the brace depth of each template literal whose
+	// substitution ("${...}") is currently being lexed.
+	templates []int
+	braces    int
+}
+
+// tokenize returns the tokens of code. The last token is always of kind
+// tokEOF.
+func tokenize(code string) []token {
+	l := lexer{code: code}
+	for {
+		t := l.next()
+		l.tokens = append(l.tokens, t)
+		if t.kind == tokEOF {
+			return l.tokens
+		}
+	}
+}
+
+// punctuators are the multi-character punctuators, longest first.
+var punctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "**", "<<",
+}
+
+func (l *lexer) next() token {
+	var comment, newline bool
+	for l.pos < len(l.code) {
+		switch c := l.code[l.pos]; {
+		case c == '\n':
+			newline = true
+			l.advance(1)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.advance(1)
+		case strings.HasPrefix(l.code[l.pos:], "//"):
+			comment = true
+			l.skipUntil("\n", false)
+		case strings.HasPrefix(l.code[l.pos:], "/*"):
+			comment = true
+			l.advance(2)
+			l.skipUntil("*/", true)
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(l.code[l.pos:])
+			if !unicode.IsSpace(r) {
+				return l.token(comment, newline)
+			}
+			if r == '\u2028' || r == '\u2029' {
+				newline = true
+			}
+			l.advance(size)
+		default:
+			return l.token(comment, newline)
+		}
+	}
+	return token{kind: tokEOF, start: l.pos, line: l.line, col: l.col, comment: comment, newline: newline}
+}
+
+func (l *lexer) token(comment, newline bool) token {
+	t := token{start: l.pos, line: l.line, col: l.col, comment: comment, newline: newline}
+	c := l.code[l.pos]
+
+	switch {
+	case isIdentStart(c) || c >= utf8.RuneSelf:
+		t.kind = tokIdent
+		l.advanceIdent()
+		if l.pos == t.start {
+			// Symbols such as emojis outside of strings are not valid code,
+			// but must not stop the lexer.
+			_, size := utf8.DecodeRuneInString(l.code[l.pos:])
+			t.kind = tokPunct
+			l.advance(size)
+		}
+	case c == '#' && l.pos+1 < len(l.code) && isIdentStart(l.code[l.pos+1]):
+		t.kind = tokPrivateName
+		l.advance(1)
+		l.advanceIdent()
+	case c >= '0' && c <= '9' || c == '.' && l.pos+1 < len(l.code) && l.code[l.pos+1] >= '0' && l.code[l.pos+1] <= '9':
+		t.kind = tokNumber
+		l.advance(1)
+		for l.pos < len(l.code) && (isIdentPart(l.code[l.pos]) || l.code[l.pos] == '.') {
+			l.advance(1)
+		}
+	case c == '\'' || c == '"':
+		t.kind = tokString
+		l.advance(1)
+		l.skipString(c)
+	case c == '`':
+		t.kind = tokTemplate
+		l.advance(1)
+		l.skipTemplate()
+	case c == '}' && len(l.templates) > 0 && l.templates[len(l.templates)-1] == l.braces:
+		// The end of a substitution continues the template literal.
+		l.templates = l.templates[:len(l.templates)-1]
+		t.kind = tokTemplate
+		l.advance(1)
+		l.skipTemplate()
+	case c == '/' && l.regexpAllowed():
+		t.kind = tokRegexp
+		l.advance(1)
+		l.skipRegexp()
+	default:
+		t.kind = tokPunct
+		n := 1
+		for _, p := range punctuators {
+			if strings.HasPrefix(l.code[l.pos:], p) {
+				n = len(p)
+				break
+			}
+		}
+		switch c {
+		case '{':
+			l.braces++
+		case '}':
+			l.braces--
+		}
+		l.advance(n)
+	}
+
+	t.text = l.code[t.start:l.pos]
+	return t
+}
+
+// regexpAllowed reports whether a slash at the current position starts a
+// regular expression rather than a division, based on the preceding token.
+func (l *lexer) regexpAllowed() bool {
+	if len(l.tokens) == 0 {
+		return true
+	}
+	prev := l.tokens[len(l.tokens)-1]
+	switch prev.kind {
+	case tokNumber, tokString, tokRegexp, tokPrivateName:
+		return false
+	case tokTemplate:
+		return !strings.HasSuffix(prev.text, "`")
+	case tokIdent:
+		switch prev.text {
+		case "return", "typeof", "instanceof", "in", "of", "new", "delete", "void", "throw", "case", "do", "else", "yield", "await":
+			return true
+		}
+		return false
+	default:
+		// "</" closes a JSX element.
+		return prev.text != ")" && prev.text != "]" && prev.text != "}" && prev.text != "<"
+	}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.code); i++ {
+		if l.code[l.pos] == '\n' {
+			l.line++
+			l.col = 0
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) advanceIdent() {
+	for l.pos < len(l.code) {
+		c := l.code[l.pos]
+		if c < utf8.RuneSelf {
+			if !isIdentPart(c) {
+				return
+			}
+			l.advance(1)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(l.code[l.pos:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r) {
+			return
+		}
+		l.advance(size)
+	}
+}
+
+// skipUntil advances to the given end marker. If include is true, the marker
+// is skipped, too.
+func (l *lexer) skipUntil(end string, include bool) {
+	i := strings.Index(l.code[l.pos:], end)
+	if i < 0 {
+		l.advance(len(l.code) - l.pos)
+		return
+	}
+	if include {
+		i += len(end)
+	}
+	l.advance(i)
+}
+
+// skipString advances past the end of a string literal. Strings cannot span
+// lines, so an unterminated string, such as an apostrophe in JSX text, ends at
+// the end of its line.
+func (l *lexer) skipString(quote byte) {
+	for l.pos < len(l.code) {
+		switch l.code[l.pos] {
+		case '\\':
+			l.advance(2)
+		case quote:
+			l.advance(1)
+			return
+		case '\n':
+			return
+		default:
+			l.advance(1)
+		}
+	}
+}
+
+// skipTemplate advances past the end of a template literal, or past the start
+// of its next substitution.
+func (l *lexer) skipTemplate() {
+	for l.pos < len(l.code) {
+		switch {
+		case l.code[l.pos] == '\\':
+			l.advance(2)
+		case l.code[l.pos] == '`':
+			l.advance(1)
+			return
+		case strings.HasPrefix(l.code[l.pos:], "${"):
+			l.advance(2)
+			l.templates = append(l.templates, l.braces)
+			return
+		default:
+			l.advance(1)
+		}
+	}
+}
+
+func (l *lexer) skipRegexp() {
+	var class bool
+	for l.pos < len(l.code) {
+		switch l.code[l.pos] {
+		case '\\':
+			l.advance(2)
+			continue
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '/':
+			if !class {
+				l.advance(1)
+				for l.pos < len(l.code) && isIdentPart(l.code[l.pos]) {
+					l.advance(1)
+				}
+				return
+			}
+		case '\n':
+			return
+		}
+		l.advance(1)
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,62 @@
+package ts
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+)
+
+var (
+	linkTagRE     = regexp.MustCompile(`\{@(?:link|linkcode|linkplain)\s+([^\s|}]+)\s*(?:\|\s*)?([^}]*)\}`)
+	declarationRE = regexp.MustCompile(`\b(?:class|interface|type|enum|function\*?|const|let|var|namespace)\s+([A-Za-z_$][\w$]*)`)
+	importRE      = regexp.MustCompile(`(?s)\bimport\s+(?:type\s+)?([^'"]*?)\s+from\s+['"]`)
+)
+
+// Links returns the {@link} tags in doc, e.g. "{@link Foo}" or
+// "{@link Foo.bar | the bar method}". The target of a link is the symbol that
+// it refers to, without the member, e.g. "Foo" for "Foo.bar". Links to URLs are
+// omitted.
+func (svc *Service) Links(doc string) []generate.Link {
+	var links []generate.Link
+	for _, m := range linkTagRE.FindAllStringSubmatch(doc, -1) {
+		if strings.Contains(m[1], "://") {
+			continue
+		}
+
+		target, _, _ := strings.Cut(m[1], ".")
+		target, _, _ = strings.Cut(target, "#")
+
+		plain := strings.TrimSpace(m[2])
+		if plain == "" {
+			plain = m[1]
+		}
+
+		links = append(links, generate.Link{Text: m[0], Target: target, Plain: plain})
+	}
+	return links
+}
+
+// Names returns the names of the declarations in code and of the symbols that
+// it imports, which {@link} tags can refer to.
+func (svc *Service) Names(file string, code []byte) ([]string, error) {
+	var names []string
+	for _, m := range declarationRE.FindAllSubmatch(importRE.ReplaceAll(code, nil), -1) {
+		names = append(names, string(m[1]))
+	}
+	for _, m := range importRE.FindAllSubmatch(code, -1) {
+		clause := strings.ReplaceAll(string(m[1]), "\n", " ")
+		for _, part := range strings.Split(clause, ",") {
+			part = strings.Trim(strings.TrimSpace(part), "{} ")
+			if part == "" {
+				continue
+			}
+			if _, alias, ok := strings.Cut(part, " as "); ok {
+				part = alias
+			}
+			fields := strings.Fields(part)
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	return names, nil
+}
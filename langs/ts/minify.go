@@ -0,0 +1,64 @@
+package ts
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+const commentPattern = `(?:\/\/[^\n]*|\/\*[\s\S]*?\*\/)\s*`
+
+var (
+	variableCommentsRE  = regexp.MustCompile(commentPattern + `((?:export\s+)?(?:var|let|const)\s+)`)
+	functionCommentsRE  = regexp.MustCompile(commentPattern + `((?:export\s+)?function\s+)`)
+	classCommentsRE     = regexp.MustCompile(commentPattern + `((?:export\s+)?class\s+)`)
+	interfaceCommentsRE = regexp.MustCompile(commentPattern + `((?:export\s+)?interface\s+)`)
+	enumCommentsRE      = regexp.MustCompile(commentPattern + `((?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+)`)
+	typeCommentsRE      = regexp.MustCompile(commentPattern + `((?:export\s+)?(?:declare\s+)?type\s+\w+)`)
+	propertyCommentsRE  = regexp.MustCompile(commentPattern + `(\w+\s*:\s*\w+)`)
+	methodCommentsRE    = regexp.MustCompile(commentPattern + `(\w+\s*\()`)
+	accessorCommentsRE  = regexp.MustCompile(commentPattern + `((?:get|set)\s+\w+\s*\()`)
+)
+
+// minificationSteps are the steps of the minification. Each step removes the
+// comments that precede the matches of its expressions, until the code fits
+// into the context window of the model.
+var minificationSteps = [][]*regexp.Regexp{
+	{variableCommentsRE, propertyCommentsRE},
+	{classCommentsRE, interfaceCommentsRE, enumCommentsRE, typeCommentsRE},
+	{functionCommentsRE, methodCommentsRE, accessorCommentsRE},
+}
+
+// minify removes comments from code until it consists of at most maxTokens
+// tokens. Code that still exceeds maxTokens after all steps is returned as is.
+// If vue is true, code is a Vue single-file component.
+func minify(code []byte, codec tokenizer.Codec, maxTokens int, vue bool) ([]byte, error) {
+	for i := 0; ; i++ {
+		tokens, _, err := codec.Encode(string(code))
+		if err != nil {
+			return nil, fmt.Errorf("encode code: %w", err)
+		}
+
+		if len(tokens) <= maxTokens || i == len(minificationSteps) {
+			return code, nil
+		}
+
+		for _, re := range minificationSteps[i] {
+			code = removeComments(code, re, vue)
+		}
+	}
+}
+
+// removeComments removes the comments that precede the matches of re. If vue
+// is true, comments are only removed from the <script> blocks of the Vue
+// single-file component.
+func removeComments(code []byte, re *regexp.Regexp, vue bool) []byte {
+	remove := func(code []byte) []byte {
+		return re.ReplaceAll(code, []byte("$1"))
+	}
+	if vue {
+		return minifyVue(code, remove)
+	}
+	return remove(code)
+}
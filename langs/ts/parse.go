@@ -0,0 +1,1071 @@
+package ts
+
+import "strings"
+
+// declaration is a declaration that can be documented.
+type declaration struct {
+	identifier string
+	symbol     Symbol
+
+	// start is the first token of the declaration, including its decorators
+	// and modifiers. Comments are inserted before this token.
+	start token
+}
+
+// documented reports whether the declaration is preceded by a comment.
+func (d declaration) documented() bool {
+	return d.start.comment
+}
+
+// parser finds the exported declarations of TypeScript and JavaScript code
+// without building a syntax tree. It follows the rules of the jotbot-ts
+// package:
+//   - functions, classes, interfaces, enums, type aliases and variables are
+//     found if they are exported, or declared in an exported namespace
+//   - methods and properties are found if they are public members of an
+//     exported class or interface, or of the object type of an exported type
+//     alias, and the members of exported enums are found as their properties
+//   - get and set accessors of such classes and types are found once per
+//     name, at the first accessor of the pair
+//   - declarations in namespaces are qualified by the names of the
+//     namespaces, e.g. "func:NS.helper" or "method:NS.Foo.bar"
+//   - declarations in ambient modules with string names, such as
+//     `declare module "foo" {}`, are never found, because they cannot be
+//     qualified and would collide with the declarations of the file
+//   - declarations within the bodies of functions are never found
+//   - the options of a Vue component that is the default export are found as
+//     the variable "default", and the methods, computed properties and props
+//     within the options as its methods and properties
+//   - anonymous functions and classes that are the default export are found
+//     as "func:default" and "class:default"
+//
+// Declarations that cannot be parsed are skipped, so that an unknown syntax
+// never prevents finding the remaining declarations.
+type parser struct {
+	code   string
+	tokens []token
+	pos    int
+	decls  []declaration
+
+	// scope is the qualified name of the namespace that is being parsed, e.g.
+	// "NS.Deep", or empty at the top level.
+	scope string
+
+	// vue reports whether the code is the <script> block of a Vue single-file
+	// component, whose default export is always the component options.
+	vue bool
+}
+
+// parse returns the exported declarations of code in the order of the code.
+// If vue is true, code is a Vue single-file component, and the declarations of
+// its <script> blocks are returned.
+func parse(code []byte, vue bool) []declaration {
+	if vue {
+		return parseVue(code)
+	}
+	return parseScript(code, false)
+}
+
+func parseScript(code []byte, vue bool) []declaration {
+	p := parser{code: string(code), tokens: tokenize(string(code)), vue: vue}
+	for p.tok().kind != tokEOF {
+		p.statements(false)
+		if p.tok().is("}") {
+			// Unbalanced brace at the top level.
+			p.advance()
+		}
+	}
+	return p.decls
+}
+
+func (p *parser) tok() token {
+	return p.peek(0)
+}
+
+func (p *parser) peek(n int) token {
+	if i := p.pos + n; i < len(p.tokens) {
+		return p.tokens[i]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *parser) prev() token {
+	if p.pos == 0 {
+		return token{}
+	}
+	return p.tokens[p.pos-1]
+}
+
+func (p *parser) advance() {
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+}
+
+func (p *parser) add(symbol Symbol, name string, start token) {
+	if p.scope != "" {
+		name = p.scope + "." + name
+	}
+	p.decls = append(p.decls, declaration{
+		identifier: string(symbol) + ":" + name,
+		symbol:     symbol,
+		start:      start,
+	})
+}
+
+// addAccessor adds the get or set accessor name, unless the other accessor of
+// the pair was already added.
+func (p *parser) addAccessor(name string, start token) {
+	identifier := string(Accessor) + ":" + name
+	if p.scope != "" {
+		identifier = string(Accessor) + ":" + p.scope + "." + name
+	}
+	for _, d := range p.decls {
+		if d.identifier == identifier {
+			return
+		}
+	}
+	p.add(Accessor, name, start)
+}
+
+// statements parses the statements of a source file or namespace, up to the
+// closing brace of the namespace. If exported is true, the statements belong
+// to an exported namespace.
+func (p *parser) statements(exported bool) {
+	for {
+		t := p.tok()
+		if t.kind == tokEOF || t.is("}") {
+			return
+		}
+		before := p.pos
+		p.statement(exported)
+		if p.pos == before {
+			p.advance()
+		}
+	}
+}
+
+func (p *parser) statement(exported bool) {
+	first := p.tok()
+	if first.is(";") {
+		p.advance()
+		return
+	}
+
+	p.decorators()
+
+	export, def := exported, false
+	for {
+		t := p.tok()
+		switch {
+		case t.is("export"):
+			export = true
+			p.advance()
+			if p.tok().is("default") {
+				def = true
+				p.advance()
+			}
+			continue
+		case (t.is("declare") || t.is("abstract") || t.is("async")) && p.identOnSameLine(1):
+			p.advance()
+			continue
+		}
+		break
+	}
+
+	// Anonymous default exports are named "default".
+	var anonymous string
+	if def {
+		anonymous = "default"
+	}
+
+	t := p.tok()
+	switch {
+	case def && p.startsComponent():
+		p.component(first)
+	case t.is("function"):
+		p.function(first, export, anonymous)
+	case t.is("class"):
+		p.class(first, export, anonymous)
+	case t.is("interface") && p.identOnSameLine(1):
+		p.iface(first, export)
+	case t.is("type") && p.identOnSameLine(1):
+		p.typeAlias(first, export)
+	case t.is("enum") && p.identOnSameLine(1):
+		p.enum(first, export)
+	case t.is("const") && p.peek(1).is("enum"):
+		p.advance()
+		p.enum(first, export)
+	case (t.is("const") || t.is("let") || t.is("var")) && !p.peek(1).is("enum"):
+		p.variable(first, export)
+	case (t.is("namespace") || t.is("module")) && (p.identOnSameLine(1) || p.peek(1).kind == tokString):
+		p.namespace(export)
+	case t.is("global") && p.peek(1).is("{"):
+		p.namespace(false)
+	default:
+		p.skipStatement()
+	}
+}
+
+// componentFactories are the functions that define Vue components from their
+// options.
+var componentFactories = map[string]bool{
+	"defineComponent":     true,
+	"defineNuxtComponent": true,
+}
+
+// startsComponent reports whether the default export at the current token are
+// the options of a Vue component: an object literal in the <script> block of a
+// single-file component, or a call of defineComponent with an object literal.
+func (p *parser) startsComponent() bool {
+	t := p.tok()
+	if t.is("{") {
+		return p.vue
+	}
+	return t.kind == tokIdent && componentFactories[t.text] && p.peek(1).is("(") && p.peek(2).is("{")
+}
+
+// component parses the options of a Vue component that is the default export.
+// The options are found as the variable "default", and the methods, computed
+// properties and props within them as the members of "default".
+func (p *parser) component(first token) {
+	if p.tok().kind == tokIdent {
+		p.advance()
+	}
+	group := p.pos
+	if p.tok().is("(") {
+		p.advance()
+	}
+
+	p.add(Var, "default", first)
+	p.objectMembers(func(section string, _ token) {
+		var symbol Symbol
+		switch section {
+		case "methods":
+			symbol = Method
+		case "computed", "props":
+			symbol = Property
+		default:
+			return
+		}
+		if !p.peek(1).is(":") || !p.peek(2).is("{") {
+			return
+		}
+		p.advance()
+		p.advance()
+		p.objectMembers(func(name string, start token) {
+			p.add(symbol, "default."+name, start)
+		})
+	})
+
+	p.pos = group
+	p.skipGroup()
+	if p.tok().is(";") {
+		p.advance()
+	}
+}
+
+// objectMembers parses the members of the object literal that starts at the
+// current token. member is called for each named member with the name and the
+// first token of the member, while the current token is the name. Whatever
+// member does not parse of a member is skipped.
+func (p *parser) objectMembers(member func(name string, start token)) {
+	p.advance()
+	for {
+		first := p.tok()
+		switch {
+		case first.kind == tokEOF:
+			return
+		case first.is("}"):
+			p.advance()
+			return
+		case first.is(",") || first.is(";"):
+			p.advance()
+			continue
+		case first.is("..."):
+			p.advance()
+			p.skipExpression()
+			continue
+		}
+
+		before := p.pos
+		for t := p.tok(); (t.is("async") || t.is("get") || t.is("set")) && p.startsMemberName(1); t = p.tok() {
+			p.advance()
+		}
+		if p.tok().is("*") {
+			p.advance()
+		}
+
+		if t := p.tok(); t.kind == tokIdent || t.kind == tokString {
+			name := p.pos
+			member(t.text, first)
+			if p.pos == name {
+				p.advance()
+			}
+		}
+		p.skipMemberValue()
+
+		if p.pos == before {
+			p.advance()
+		}
+	}
+}
+
+// skipMemberValue skips the remainder of a member of an object literal: the
+// parameters and body of a method, or the value of a property.
+func (p *parser) skipMemberValue() {
+	if p.tok().is("(") || p.tok().is("<") {
+		p.signature()
+		if p.tok().is("{") {
+			p.skipGroup()
+		}
+		return
+	}
+	if p.tok().is(":") {
+		p.advance()
+		p.skipExpression()
+	}
+}
+
+// identOnSameLine reports whether the token at the given offset is an
+// identifier on the same line as the current token. It is used to tell
+// contextual keywords such as "type" from identifiers with the same name.
+func (p *parser) identOnSameLine(n int) bool {
+	t := p.peek(n)
+	return t.kind == tokIdent && !t.newline
+}
+
+func (p *parser) decorators() {
+	for p.tok().is("@") {
+		p.advance()
+		for p.tok().kind == tokIdent || p.tok().is(".") {
+			p.advance()
+		}
+		if p.tok().is("<") {
+			p.skipAngles()
+		}
+		if p.tok().is("(") {
+			p.skipGroup()
+		}
+	}
+}
+
+// function parses a function declaration or expression. A function without a
+// name is named name, and not found if name is empty.
+func (p *parser) function(first token, exported bool, name string) {
+	p.advance()
+	if p.tok().is("*") {
+		p.advance()
+	}
+
+	if t := p.tok(); t.kind == tokIdent {
+		name = t.text
+		p.advance()
+	}
+
+	p.signature()
+	if p.tok().is("{") {
+		p.skipGroup()
+	} else if p.tok().is(";") {
+		p.advance()
+	}
+
+	if exported && name != "" {
+		p.add(Func, name, first)
+	}
+}
+
+// signature skips the type parameters, parameters and return type of a
+// function or method.
+func (p *parser) signature() {
+	if p.tok().is("<") {
+		p.skipAngles()
+	}
+	if p.tok().is("(") {
+		p.skipGroup()
+	}
+	if p.tok().is(":") {
+		p.advance()
+		p.skipType(true)
+	}
+}
+
+// class parses a class declaration or expression. A class expression without
+// a name is named after the variable or property it is assigned to.
+func (p *parser) class(first token, exported bool, name string) {
+	p.advance()
+	if t := p.tok(); t.kind == tokIdent && !t.is("extends") && !t.is("implements") {
+		name = t.text
+		p.advance()
+	}
+
+	if p.tok().is("<") {
+		p.skipAngles()
+	}
+	for t := p.tok(); t.kind != tokEOF && !t.is("{") && !t.is(";") && !t.is("}"); t = p.tok() {
+		switch {
+		case t.is("(") || t.is("["):
+			p.skipGroup()
+		case t.is("<"):
+			p.skipAngles()
+		default:
+			p.advance()
+		}
+	}
+
+	exported = exported && name != ""
+	if exported {
+		p.add(Class, name, first)
+	}
+
+	if p.tok().is("{") {
+		p.classBody(name, exported)
+	}
+}
+
+func (p *parser) classBody(owner string, exported bool) {
+	p.advance()
+	for {
+		t := p.tok()
+		if t.kind == tokEOF {
+			return
+		}
+		if t.is("}") {
+			p.advance()
+			return
+		}
+		before := p.pos
+		p.classMember(owner, exported)
+		if p.pos == before {
+			p.advance()
+		}
+	}
+}
+
+var memberModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "static": true,
+	"readonly": true, "abstract": true, "override": true, "declare": true,
+	"async": true, "accessor": true, "get": true, "set": true,
+}
+
+func (p *parser) classMember(owner string, exported bool) {
+	first := p.tok()
+	if first.is(";") {
+		p.advance()
+		return
+	}
+
+	p.decorators()
+
+	var private, accessor bool
+	for t := p.tok(); t.kind == tokIdent && memberModifiers[t.text] && p.startsMemberName(1); t = p.tok() {
+		switch t.text {
+		case "private", "protected":
+			private = true
+		case "get", "set":
+			accessor = true
+		}
+		p.advance()
+	}
+
+	if p.tok().is("static") && p.peek(1).is("{") {
+		p.advance()
+		p.skipGroup()
+		return
+	}
+
+	if p.tok().is("*") {
+		p.advance()
+	}
+
+	name, ok := p.memberName()
+	if !ok {
+		return
+	}
+	if name[0] == '#' {
+		private = true
+	}
+
+	if p.tok().is("?") || p.tok().is("!") {
+		p.advance()
+	}
+
+	if p.tok().is("(") || p.tok().is("<") {
+		p.signature()
+		if p.tok().is("{") {
+			p.skipGroup()
+		} else if p.tok().is(";") {
+			p.advance()
+		}
+		switch {
+		case !exported || private || name == "constructor":
+		case accessor:
+			p.addAccessor(owner+"."+name, first)
+		default:
+			p.add(Method, owner+"."+name, first)
+		}
+		return
+	}
+
+	if p.tok().is(":") {
+		p.advance()
+		p.skipType(false)
+	}
+	if p.tok().is("=") {
+		p.advance()
+		p.skipExpression()
+	}
+	if p.tok().is(";") {
+		p.advance()
+	}
+
+	if exported && !private {
+		p.add(Property, owner+"."+name, first)
+	}
+}
+
+// startsMemberName reports whether the token at the given offset can start
+// the name of a member, so that a modifier is not mistaken for a name, as in
+// "get() {}" or "static {}".
+func (p *parser) startsMemberName(n int) bool {
+	t := p.peek(n)
+	switch t.kind {
+	case tokIdent, tokString, tokNumber, tokPrivateName:
+		return true
+	case tokPunct:
+		return t.is("[") || t.is("*")
+	}
+	return false
+}
+
+// memberName parses the name of a class member or type member. Index
+// signatures and mapped types are skipped, and reported as having no name.
+func (p *parser) memberName() (string, bool) {
+	t := p.tok()
+	switch t.kind {
+	case tokIdent, tokString, tokNumber, tokPrivateName:
+		p.advance()
+		return t.text, true
+	}
+
+	if !t.is("[") {
+		return "", false
+	}
+
+	if next := p.peek(2); p.peek(1).kind == tokIdent && (next.is(":") || next.is("in")) {
+		p.skipGroup()
+		p.skipMember()
+		return "", false
+	}
+
+	p.skipGroup()
+	return p.code[t.start : p.prev().start+len(p.prev().text)], true
+}
+
+// skipMember skips the remainder of a member that is not documented.
+func (p *parser) skipMember() {
+	if p.tok().is("?") {
+		p.advance()
+	}
+	if p.tok().is(":") {
+		p.advance()
+		p.skipType(false)
+	}
+	if p.tok().is(";") || p.tok().is(",") {
+		p.advance()
+	}
+}
+
+func (p *parser) iface(first token, exported bool) {
+	p.advance()
+	name := p.tok().text
+	p.advance()
+
+	if p.tok().is("<") {
+		p.skipAngles()
+	}
+	for t := p.tok(); t.kind != tokEOF && !t.is("{") && !t.is(";") && !t.is("}"); t = p.tok() {
+		if t.is("<") {
+			p.skipAngles()
+			continue
+		}
+		p.advance()
+	}
+
+	if exported {
+		p.add(Interface, name, first)
+	}
+
+	if p.tok().is("{") {
+		p.typeMembers(name, exported)
+	}
+}
+
+// enum parses an enum declaration. The current token is the "enum" keyword.
+func (p *parser) enum(first token, exported bool) {
+	p.advance()
+	name := p.tok().text
+	p.advance()
+
+	if exported {
+		p.add(Enum, name, first)
+	}
+
+	if !p.tok().is("{") {
+		p.skipStatement()
+		return
+	}
+	p.advance()
+
+	for {
+		t := p.tok()
+		if t.kind == tokEOF {
+			return
+		}
+		if t.is("}") {
+			p.advance()
+			return
+		}
+
+		member, ok := p.memberName()
+		if !ok {
+			p.advance()
+			continue
+		}
+		if p.tok().is("=") {
+			p.advance()
+			p.skipExpression()
+		}
+		if p.tok().is(",") {
+			p.advance()
+		}
+
+		if exported {
+			p.add(Property, name+"."+member, t)
+		}
+	}
+}
+
+func (p *parser) typeAlias(first token, exported bool) {
+	p.advance()
+	name := p.tok().text
+	p.advance()
+
+	if p.tok().is("<") {
+		p.skipAngles()
+	}
+
+	if exported {
+		p.add(Type, name, first)
+	}
+
+	if !p.tok().is("=") {
+		p.skipStatement()
+		return
+	}
+	p.advance()
+
+	if p.tok().is("{") {
+		// The members belong to the type alias only if the object type is
+		// the whole type, and not part of a union or intersection.
+		decls := len(p.decls)
+		p.typeMembers(name, exported)
+		if t := p.tok(); !t.is(";") && !t.is("}") && t.kind != tokEOF && (!t.newline || continuesType(p.prev(), t)) {
+			p.decls = p.decls[:decls]
+		}
+	}
+
+	p.skipType(false)
+	if p.tok().is(";") {
+		p.advance()
+	}
+}
+
+func (p *parser) typeMembers(owner string, exported bool) {
+	p.advance()
+	for {
+		t := p.tok()
+		if t.kind == tokEOF {
+			return
+		}
+		if t.is("}") {
+			p.advance()
+			return
+		}
+		before := p.pos
+		p.typeMember(owner, exported)
+		if p.pos == before {
+			p.advance()
+		}
+	}
+}
+
+func (p *parser) typeMember(owner string, exported bool) {
+	first := p.tok()
+	if first.is(";") || first.is(",") {
+		p.advance()
+		return
+	}
+
+	var accessor bool
+	for t := p.tok(); (t.is("readonly") || t.is("get") || t.is("set")) && p.startsMemberName(1); t = p.tok() {
+		accessor = accessor || !t.is("readonly")
+		p.advance()
+	}
+
+	// Call signatures, construct signatures and modifiers of mapped types.
+	if t := p.tok(); t.is("(") || t.is("<") || t.is("new") && (p.peek(1).is("(") || p.peek(1).is("<")) || t.is("-") || t.is("+") {
+		if t.is("new") || t.is("-") || t.is("+") {
+			p.advance()
+		}
+		p.signature()
+		p.skipMember()
+		return
+	}
+
+	name, ok := p.memberName()
+	if !ok {
+		return
+	}
+
+	if p.tok().is("?") {
+		p.advance()
+	}
+
+	if p.tok().is("(") || p.tok().is("<") {
+		p.signature()
+		if p.tok().is(";") || p.tok().is(",") {
+			p.advance()
+		}
+		switch {
+		case !exported:
+		case accessor:
+			p.addAccessor(owner+"."+name, first)
+		default:
+			p.add(Method, owner+"."+name, first)
+		}
+		return
+	}
+
+	p.skipMember()
+	if exported {
+		p.add(Property, owner+"."+name, first)
+	}
+}
+
+// variable parses a variable statement. Each variable of the statement is
+// found. The documentation of the first variable precedes the statement, and
+// that of the other variables precedes their names. Class and function
+// expressions in the initializers of exported variables are exported, too.
+func (p *parser) variable(first token, exported bool) {
+	p.advance()
+
+	for start := first; ; start = p.tok() {
+		var name string
+		switch t := p.tok(); {
+		case t.kind == tokIdent:
+			name = t.text
+			p.advance()
+		case t.is("{") || t.is("["):
+			p.skipGroup()
+		default:
+			p.skipStatement()
+			return
+		}
+
+		if exported && name != "" {
+			p.add(Var, name, start)
+		}
+
+		if p.tok().is("!") {
+			p.advance()
+		}
+		if p.tok().is(":") {
+			p.advance()
+			p.skipType(false)
+		}
+		if p.tok().is("=") {
+			p.advance()
+			p.initializer(name, exported)
+		}
+		if !p.tok().is(",") {
+			break
+		}
+		p.advance()
+	}
+
+	if p.tok().is(";") {
+		p.advance()
+	}
+}
+
+// initializer skips the initializer of a variable. If the variable is
+// exported, the class and function expressions within the initializer are
+// parsed, except those in the bodies of function expressions.
+func (p *parser) initializer(variable string, exported bool) {
+	depth := 0
+	for {
+		t := p.tok()
+		if t.kind == tokEOF {
+			return
+		}
+		if depth == 0 && p.endsExpression(t) {
+			return
+		}
+
+		switch {
+		case t.is("(") || t.is("[") || t.is("{"):
+			depth++
+		case t.is(")") || t.is("]") || t.is("}"):
+			if depth == 0 {
+				return
+			}
+			depth--
+		case t.is("<") && startsExpression(p.prev()):
+			p.skipAngles()
+			continue
+		case exported && t.is("class") && !p.prev().is(".") && !p.peek(1).is(":"):
+			var name string
+			if prev := p.prev(); prev.is("=") && depth == 0 {
+				name = variable
+			} else if prev.is(":") && p.pos >= 2 {
+				if key := p.tokens[p.pos-2]; key.kind == tokIdent || key.kind == tokString {
+					name = key.text
+				}
+			}
+			p.class(t, true, name)
+			continue
+		case exported && t.is("function") && !p.prev().is(".") && !p.peek(1).is(":"):
+			p.function(t, true, "")
+			continue
+		}
+		p.advance()
+	}
+}
+
+// namespace parses a namespace or module declaration. The declarations within
+// the namespace are qualified by its name, e.g. "func:NS.helper". Ambient
+// modules with a string name, such as declare module "foo", and global
+// augmentations do not qualify their declarations.
+func (p *parser) namespace(exported bool) {
+	p.advance()
+	var (
+		names   []string
+		ambient bool
+	)
+	for t := p.tok(); t.kind == tokIdent || t.kind == tokString || t.is("."); t = p.tok() {
+		switch t.kind {
+		case tokIdent:
+			names = append(names, t.text)
+		case tokString:
+			ambient = true
+		}
+		p.advance()
+	}
+
+	if !p.tok().is("{") {
+		p.skipStatement()
+		return
+	}
+	if ambient {
+		p.skipGroup()
+		return
+	}
+	p.advance()
+
+	scope := p.scope
+	if len(names) > 0 {
+		if scope != "" {
+			names = append([]string{scope}, names...)
+		}
+		p.scope = strings.Join(names, ".")
+	}
+	p.statements(exported)
+	p.scope = scope
+
+	if p.tok().is("}") {
+		p.advance()
+	}
+}
+
+// skipStatement skips to the end of the current statement.
+func (p *parser) skipStatement() {
+	if t := p.tok(); t.is(")") || t.is("]") {
+		p.advance()
+		return
+	}
+	p.skipExpression()
+	if p.tok().is(";") {
+		p.advance()
+	}
+}
+
+// skipExpression skips to the end of the current expression, which ends at a
+// semicolon or comma, at a closing bracket that was not opened within the
+// expression, or at a line break that is not followed by a continuation of
+// the expression.
+func (p *parser) skipExpression() {
+	for {
+		t := p.tok()
+		if t.kind == tokEOF || t.is(")") || t.is("]") || t.is("}") || p.endsExpression(t) {
+			return
+		}
+		if t.is("(") || t.is("[") || t.is("{") {
+			p.skipGroup()
+			continue
+		}
+		if t.is("<") && startsExpression(p.prev()) {
+			p.skipAngles()
+			continue
+		}
+		p.advance()
+	}
+}
+
+func (p *parser) endsExpression(t token) bool {
+	if t.is(";") || t.is(",") {
+		return true
+	}
+	return t.newline && !continuesExpression(p.prev(), t)
+}
+
+// skipType skips a type annotation. If body is true, the type is the return
+// type of a function, and an opening brace that cannot start an object type
+// starts the body of the function.
+func (p *parser) skipType(body bool) {
+	for {
+		t := p.tok()
+		switch {
+		case t.kind == tokEOF || t.is(")") || t.is("]") || t.is("}") || t.is(">") || t.is(";") || t.is(",") || t.is("="):
+			return
+		case t.is("{"):
+			if body && !startsType(p.prev()) {
+				return
+			}
+			p.skipGroup()
+		case t.newline && !continuesType(p.prev(), t):
+			return
+		case t.is("(") || t.is("["):
+			p.skipGroup()
+		case t.is("<"):
+			p.skipAngles()
+		default:
+			p.advance()
+		}
+	}
+}
+
+// skipGroup skips the group of tokens that starts with the current opening
+// bracket, up to and including the matching closing bracket.
+func (p *parser) skipGroup() {
+	depth := 0
+	for {
+		t := p.tok()
+		switch {
+		case t.kind == tokEOF:
+			return
+		case t.is("(") || t.is("[") || t.is("{"):
+			depth++
+		case t.is(")") || t.is("]") || t.is("}"):
+			depth--
+		}
+		p.advance()
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// skipAngles skips type parameters or type arguments, up to and including the
+// matching closing angle bracket.
+func (p *parser) skipAngles() {
+	depth := 0
+	for {
+		t := p.tok()
+		switch {
+		case t.kind == tokEOF || t.is(";") || t.is("{") && depth == 0:
+			return
+		case t.is("<"):
+			depth++
+		case t.is(">"):
+			depth--
+		case t.is("(") || t.is("[") || t.is("{"):
+			p.skipGroup()
+			continue
+		}
+		p.advance()
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// startsExpression reports whether a token after the given token starts an
+// expression. An opening angle bracket that starts an expression starts the
+// type parameters of an arrow function or a JSX element, whose commas must not
+// end the expression.
+func startsExpression(prev token) bool {
+	return prev.kind == tokPunct && !prev.is(")") && !prev.is("]") && !prev.is("}")
+}
+
+// startsType reports whether an opening brace after the given token starts
+// an object type rather than the body of a function.
+func startsType(prev token) bool {
+	switch prev.text {
+	case ":", "|", "&", "=>", "<", ",", "(", "[", "?", "=", "keyof", "extends", "readonly", "typeof":
+		return true
+	}
+	return false
+}
+
+// continuesType reports whether a type continues on the line of the given
+// token, after the given previous token.
+func continuesType(prev, t token) bool {
+	switch prev.text {
+	case "|", "&", ":", "=>", ",", "?", ".", "<", "(", "[", "keyof", "typeof", "extends", "infer", "readonly":
+		return true
+	}
+	switch t.text {
+	case "|", "&", "=>", ".", "?", ":", "extends":
+		return true
+	}
+	return false
+}
+
+// continuesExpression reports whether an expression continues on the line of
+// the given token, after the given previous token.
+func continuesExpression(prev, t token) bool {
+	if prev.kind == tokPunct {
+		switch prev.text {
+		case ")", "]", "}", "++", "--":
+		default:
+			return true
+		}
+	}
+	if prev.kind == tokTemplate && prev.text[len(prev.text)-1] != '`' {
+		return true
+	}
+	if prev.kind == tokIdent {
+		switch prev.text {
+		case "new", "typeof", "await", "in", "instanceof", "of", "as", "satisfies", "keyof", "void", "delete", "yield":
+			return true
+		}
+	}
+
+	switch t.kind {
+	case tokPunct:
+		switch t.text {
+		case "(", "[", "{", "@", "#", "*", "!", "++", "--", ";", "}":
+			return t.text == "("
+		}
+		return true
+	case tokTemplate:
+		return t.text[0] == '}'
+	case tokIdent:
+		switch t.text {
+		case "as", "satisfies", "instanceof", "in":
+			return true
+		}
+	}
+	return false
+}
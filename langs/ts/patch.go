@@ -12,7 +12,9 @@ import (
 // inserted in a way that aligns with the indentation of the line at the given
 // position. If successful, it returns the updated code as a []byte and nil
 // error; otherwise, it returns nil and an error describing the invalid
-// position.
+// position. If the exact same comment already directly precedes the position,
+// the code is returned unchanged, so that inserting a comment twice does not
+// duplicate it.
 func InsertComment(comment string, code []byte, pos Position) ([]byte, error) {
 	lines := strings.Split(string(code), "\n")
 	commentLines := strings.Split(comment, "\n")
@@ -26,6 +28,10 @@ func InsertComment(comment string, code []byte, pos Position) ([]byte, error) {
 		return nil, fmt.Errorf("character position %d out of range", pos.Character)
 	}
 
+	if HasComment(comment, code, pos) {
+		return code, nil
+	}
+
 	prefix := ""
 	for _, r := range targetLine {
 		if unicode.IsSpace(r) {
@@ -46,3 +52,50 @@ func InsertComment(comment string, code []byte, pos Position) ([]byte, error) {
 
 	return []byte(strings.Join(lines, "\n")), nil
 }
+
+// HasComment reports whether the given comment directly precedes the specified
+// position in code. Indentation and surrounding whitespace are ignored when
+// comparing the comment to the existing code.
+func HasComment(comment string, code []byte, pos Position) bool {
+	want := trimLines(strings.TrimSpace(comment))
+	if len(want) == 0 || want[0] == "" {
+		return false
+	}
+
+	lines := strings.Split(string(code), "\n")
+	if pos.Line >= len(lines) || pos.Line < 0 {
+		return false
+	}
+
+	targetLine := lines[pos.Line]
+	if pos.Character > len(targetLine) || pos.Character < 0 {
+		return false
+	}
+
+	before := append(append([]string{}, lines[:pos.Line]...), targetLine[:pos.Character])
+	preceding := trimLines(strings.TrimRightFunc(strings.Join(before, "\n"), unicode.IsSpace))
+	if len(preceding) < len(want) {
+		return false
+	}
+	preceding = preceding[len(preceding)-len(want):]
+
+	// The first line of the comment may be preceded by code on the same line.
+	if !strings.HasSuffix(preceding[0], want[0]) {
+		return false
+	}
+	for i := 1; i < len(want); i++ {
+		if preceding[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func trimLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
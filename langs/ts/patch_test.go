@@ -120,3 +120,31 @@ func TestInsertComment_interfaceMethod(t *testing.T) {
 		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
 	}
 }
+
+func TestInsertComment_idempotent(t *testing.T) {
+	code := heredoc.Doc(`
+		export interface Foo {
+			foo: string
+		}
+	`)
+
+	comment := heredoc.Doc(`
+		/**
+		 * This is a comment
+		 */
+	`)
+
+	patched, err := ts.InsertComment(comment, []byte(code), ts.Position{Line: 1, Character: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repatched, err := ts.InsertComment(comment, patched, ts.Position{Line: 4, Character: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(repatched) != string(patched) {
+		t.Fatalf("inserting the same comment twice should not change the code\n\n%s", cmp.Diff(string(patched), string(repatched)))
+	}
+}
@@ -125,9 +125,9 @@ func defaultPrompt(input generate.PromptInput) string {
 // and appending relevant information based on its type, such as the name of a
 // class, the signature of a function, or the association of a method or
 // property with its owner. It handles various identifier types including
-// variables, classes, interfaces, functions, methods, properties, and custom
-// types. If the identifier does not conform to expected patterns or types, it
-// is returned as-is.
+// variables, classes, interfaces, enums, functions, methods, properties,
+// accessors, and custom types. If the identifier does not conform to expected
+// patterns or types, it is returned as-is.
 func Target(identifier string) string {
 	parts := strings.Split(identifier, ":")
 	if len(parts) != 2 {
@@ -138,27 +138,41 @@ func Target(identifier string) string {
 	path := parts[1]
 	name := path
 
+	// Declarations in namespaces are qualified by the names of the namespaces,
+	// and members by the qualified names of their owners.
 	var owner string
-	if parts = strings.Split(path, "."); len(parts) == 2 {
-		owner = parts[0]
-		name = parts[1]
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		owner, name = path[:i], path[i+1:]
 	}
 
 	switch typ {
 	case "var":
-		return fmt.Sprintf("variable %q", name)
+		if path == "default" {
+			return "the options of the default-exported component"
+		}
+		return fmt.Sprintf("variable %q", path)
 	case "class":
-		return fmt.Sprintf("class %q", name)
+		if path == "default" {
+			return "the default-exported class"
+		}
+		return fmt.Sprintf("class %q", path)
 	case "interface":
-		return fmt.Sprintf("interface %q", name)
+		return fmt.Sprintf("interface %q", path)
 	case "func":
-		return fmt.Sprintf(`function "%s()"`, name)
+		if path == "default" {
+			return "the default-exported function"
+		}
+		return fmt.Sprintf(`function "%s()"`, path)
 	case "method":
 		return fmt.Sprintf(`method %q of %q"`, name, owner)
 	case "prop":
 		return fmt.Sprintf(`property %q of %q`, name, owner)
+	case "accessor":
+		return fmt.Sprintf(`accessor %q of %q`, name, owner)
 	case "type":
-		return fmt.Sprintf(`type %q`, name)
+		return fmt.Sprintf(`type %q`, path)
+	case "enum":
+		return fmt.Sprintf(`enum %q`, path)
 	default:
 		return identifier
 	}
@@ -173,9 +187,8 @@ func simpleIdentifier(identifier string) string {
 }
 
 func removeOwner(identifier string) string {
-	parts := strings.Split(identifier, ".")
-	if len(parts) == 2 {
-		return parts[1]
+	if i := strings.LastIndex(identifier, "."); i >= 0 {
+		return identifier[i+1:]
 	}
 	return identifier
 }
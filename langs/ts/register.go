@@ -0,0 +1,7 @@
+package ts
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("ts", func() jotbot.Language { return New() })
+}
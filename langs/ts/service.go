@@ -2,10 +2,11 @@ package ts
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
-	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/internal"
@@ -15,9 +16,10 @@ import (
 
 // FileExtensions holds a list of recognized file extensions for TypeScript and
 // JavaScript source files. It includes extensions for both standard and
-// module-specific file types.
+// module-specific file types, and for Vue single-file components, whose
+// <script> blocks are documented.
 var (
-	FileExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+	FileExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".vue"}
 )
 
 // Service provides a set of operations for working with TypeScript code. It
@@ -25,12 +27,21 @@ var (
 // elements within code, minifying TypeScript source code, generating prompts
 // for code suggestions, and patching existing code with documentation comments.
 // Customization of the service can be achieved using provided options such as
-// specifying a custom finder or model. The service encapsulates functionality
-// that leverages external tools and libraries to process and enhance TypeScript
-// code in various ways.
+// specifying a custom finder or model.
+//
+// Whether code is a Vue single-file component is decided by the extension of
+// the file that is passed to [*Service.Find]. Minify and Patch only receive
+// the code, so the Service remembers the code of Vue files, and the code that
+// Patch returns for them.
 type Service struct {
 	finder *Finder
 	model  string
+
+	vueMux sync.Mutex
+	vue    map[[sha256.Size]byte]bool
+
+	minifiedMux sync.Mutex
+	minified    map[[sha256.Size]byte][]byte
 }
 
 // Option represents a configuration function used to customize the behavior of
@@ -86,27 +97,66 @@ func (svc *Service) Extensions() []string {
 }
 
 // Find retrieves a list of strings based on the provided code slice. It
-// utilizes the service's internal finder to perform the search within a default
-// context. If the search is successful, it returns the results along with a nil
-// error. If it fails, it returns an empty slice and an error detailing what
-// went wrong.
-func (svc *Service) Find(code []byte) ([]string, error) {
-	return svc.finder.Find(context.Background(), code)
+// utilizes the service's internal finder to perform the search within the
+// provided context. If the search is successful, it returns the results along
+// with a nil error. If it fails, it returns an empty slice and an error
+// detailing what went wrong.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	if isVueFile(file) {
+		svc.markVue(code)
+	}
+	return svc.finder.Find(ctx, file, code)
 }
 
-// Minify reduces the size of TypeScript code by removing unnecessary characters
-// without changing its functionality and returns the minified code or an error
-// if the minification fails.
+// markVue remembers code as the code of a Vue single-file component.
+func (svc *Service) markVue(code []byte) {
+	svc.vueMux.Lock()
+	defer svc.vueMux.Unlock()
+	if svc.vue == nil {
+		svc.vue = make(map[[sha256.Size]byte]bool)
+	}
+	svc.vue[sha256.Sum256(code)] = true
+}
+
+// isVue reports whether code was found in a Vue single-file component, or
+// returned by Patch for such a component.
+func (svc *Service) isVue(code []byte) bool {
+	svc.vueMux.Lock()
+	defer svc.vueMux.Unlock()
+	return svc.vue[sha256.Sum256(code)]
+}
+
+// Minify reduces the size of TypeScript code by removing the comments of
+// declarations until the code fits into the context window of the model. The
+// minified code is cached per code content, so that the code of a file is only
+// minified once for all of its symbols.
 func (svc *Service) Minify(code []byte) ([]byte, error) {
-	args := []string{"minify", "-m", svc.model, string(code)}
+	sum := sha256.Sum256(code)
 
-	cmd := exec.Command(jotbotTSPath, args...)
+	svc.minifiedMux.Lock()
+	cached, ok := svc.minified[sum]
+	svc.minifiedMux.Unlock()
+	if ok {
+		return cached, nil
+	}
 
-	out, err := cmd.CombinedOutput()
+	codec, _, err := internal.Tokenizer(svc.model, "")
 	if err != nil {
-		return nil, fmt.Errorf("%w:\n%s", err, out)
+		return nil, fmt.Errorf("create tokenizer: %w", err)
 	}
 
+	out, err := minify(code, codec, openai.MaxTokensForModel(svc.model), svc.isVue(code))
+	if err != nil {
+		return nil, err
+	}
+
+	svc.minifiedMux.Lock()
+	defer svc.minifiedMux.Unlock()
+	if svc.minified == nil {
+		svc.minified = make(map[[sha256.Size]byte][]byte)
+	}
+	svc.minified[sum] = out
+
 	return out, nil
 }
 
@@ -118,6 +168,12 @@ func (svc *Service) Prompt(input generate.PromptInput) string {
 	return Prompt(input)
 }
 
+// Target returns the description of the identifier that is used in prompts.
+// See [Target].
+func (svc *Service) Target(identifier string) string {
+	return Target(identifier)
+}
+
 // Patch applies a documentation patch to the source code at the location of a
 // specified identifier. It creates or updates existing documentation based on
 // the provided doc string. If the identifier cannot be located or if any errors
@@ -125,7 +181,8 @@ func (svc *Service) Prompt(input generate.PromptInput) string {
 // Otherwise, it returns the patched source code as a byte slice. The operation
 // is context-aware and can be cancelled through the provided context.Context.
 func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
-	pos, err := svc.finder.Position(ctx, identifier, code)
+	vue := svc.isVue(code)
+	pos, err := svc.finder.position(identifier, code, vue)
 	if err != nil {
 		return nil, fmt.Errorf("find position of %q in code: %w", identifier, err)
 	}
@@ -134,7 +191,11 @@ func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []by
 		doc = formatDoc(doc, pos.Character)
 	}
 
-	return InsertComment(doc, code, pos)
+	patched, err := InsertComment(doc, code, pos)
+	if err == nil && vue {
+		svc.markVue(patched)
+	}
+	return patched, err
 }
 
 func formatDoc(doc string, indent int) string {
@@ -2,15 +2,20 @@ package ts_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/google/go-cmp/cmp"
 	"github.com/modernice/jotbot"
+	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/langs/ts"
 )
 
-var _ jotbot.Language = (*ts.Service)(nil)
+var (
+	_ jotbot.Language = (*ts.Service)(nil)
+	_ generate.Linker = (*ts.Service)(nil)
+)
 
 func TestService_Patch_interfaceFields(t *testing.T) {
 	code := heredoc.Doc(`
@@ -82,3 +87,351 @@ func TestNormalizeGeneratedComment(t *testing.T) {
 		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, normalized), want, normalized)
 	}
 }
+
+func TestService_Links(t *testing.T) {
+	doc := "Creates a {@link Cart} using {@link CartOptions.items | the items} and {@link https://example.com}."
+
+	want := []generate.Link{
+		{Text: "{@link Cart}", Target: "Cart", Plain: "Cart"},
+		{Text: "{@link CartOptions.items | the items}", Target: "CartOptions", Plain: "the items"},
+	}
+
+	if diff := cmp.Diff(want, ts.New().Links(doc)); diff != "" {
+		t.Fatalf("unexpected links (-want +got):\n%s", diff)
+	}
+}
+
+func TestService_Names(t *testing.T) {
+	code := heredoc.Doc(`
+		import React, { useState, type FC as Component } from 'react'
+		import * as path from 'node:path'
+		import type {
+			Item,
+		} from './item'
+
+		export interface CartOptions {}
+
+		export function createCart() {}
+
+		const total = 0
+	`)
+
+	names, err := ts.New().Names("cart.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Names() failed: %v", err)
+	}
+
+	want := []string{"CartOptions", "createCart", "total", "React", "useState", "Component", "path", "Item"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Fatalf("unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestService_Patch_vue(t *testing.T) {
+	code := heredoc.Doc(`
+		<template>
+			<p>{{ message }}</p>
+		</template>
+
+		<script>
+		export default {
+			methods: {
+				greet() {},
+			},
+		}
+		</script>
+	`)
+
+	svc := ts.New()
+
+	if _, err := svc.Find(context.Background(), "Greeting.vue", []byte(code)); err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	patched, err := svc.Patch(context.Background(), "var:default", "Greets the user.", []byte(code))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "method:default.greet", "Greets the user.", patched)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		<template>
+			<p>{{ message }}</p>
+		</template>
+
+		<script>
+		/** Greets the user. */
+		export default {
+			methods: {
+				/** Greets the user. */
+				greet() {},
+			},
+		}
+		</script>
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
+	}
+}
+
+func TestService_Patch_enum(t *testing.T) {
+	code := heredoc.Doc(`
+		export const enum Color {
+			Red,
+			Green,
+		}
+	`)
+
+	svc := ts.New()
+
+	patched, err := svc.Patch(context.Background(), "enum:Color", "Color is a color.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "prop:Color.Green", "Green is green.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		/** Color is a color. */
+		export const enum Color {
+			Red,
+			/** Green is green. */
+			Green,
+		}
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
+	}
+}
+
+func TestService_Patch_typeAlias(t *testing.T) {
+	code := heredoc.Doc(`
+		export type ID = string
+
+		export type Result<T> = {
+			value: T
+		} | { error: Error }
+	`)
+
+	svc := ts.New()
+
+	patched, err := svc.Patch(context.Background(), "type:ID", "ID identifies a user.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "type:Result", "Result is either a value or an error.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		/** ID identifies a user. */
+		export type ID = string
+
+		/** Result is either a value or an error. */
+		export type Result<T> = {
+			value: T
+		} | { error: Error }
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
+	}
+}
+
+func TestService_Patch_accessor(t *testing.T) {
+	code := heredoc.Doc(`
+		export class Counter {
+			get count(): number {
+				return 0
+			}
+
+			set count(value: number) {}
+		}
+	`)
+
+	svc := ts.New()
+
+	patched, err := svc.Patch(context.Background(), "accessor:Counter.count", "The current count.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		export class Counter {
+			/** The current count. */
+			get count(): number {
+				return 0
+			}
+
+			set count(value: number) {}
+		}
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
+	}
+}
+
+func TestService_Patch_namespace(t *testing.T) {
+	code := heredoc.Doc(`
+		export namespace Utils.Strings {
+			export function helper() {}
+
+			export class Builder {
+				build() {}
+			}
+		}
+
+		declare module "foo" {
+			export function bar(): void
+		}
+	`)
+
+	svc := ts.New()
+
+	findings, err := svc.Find(context.Background(), "utils.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	wantFindings := []string{"func:Utils.Strings.helper", "class:Utils.Strings.Builder", "method:Utils.Strings.Builder.build"}
+	if !cmp.Equal(wantFindings, findings) {
+		t.Fatalf("Find() returned wrong identifiers\n\n%s", cmp.Diff(wantFindings, findings))
+	}
+
+	patched, err := svc.Patch(context.Background(), "func:Utils.Strings.helper", "helper helps.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	patched, err = svc.Patch(context.Background(), "method:Utils.Strings.Builder.build", "build builds.", patched)
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		export namespace Utils.Strings {
+			/** helper helps. */
+			export function helper() {}
+
+			export class Builder {
+				/** build builds. */
+				build() {}
+			}
+		}
+
+		declare module "foo" {
+			export function bar(): void
+		}
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("unexpected result\n\n%s\n\nwant:\n%s\n\ngot:\n%s", cmp.Diff(want, string(patched)), want, string(patched))
+	}
+
+	prompt := svc.Prompt(generate.PromptInput{Input: generate.Input{Code: []byte(code), Language: "ts", Identifier: "method:Utils.Strings.Builder.build"}})
+	if want := `method "build" of "Utils.Strings.Builder"`; !strings.Contains(prompt, want) {
+		t.Errorf("Prompt() should contain %q\n\n%s", want, prompt)
+	}
+}
+
+func TestService_Patch_ambientModule(t *testing.T) {
+	code := heredoc.Doc(`
+		declare module "foo" {
+			export function ambient(): void
+		}
+
+		export function ambient() {}
+	`)
+
+	svc := ts.New()
+
+	findings, err := svc.Find(context.Background(), "index.ts", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	if want := []string{"func:ambient"}; !cmp.Equal(want, findings) {
+		t.Fatalf("Find() returned wrong identifiers\n\n%s", cmp.Diff(want, findings))
+	}
+
+	patched, err := svc.Patch(context.Background(), "func:ambient", "ambient is ambient.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		declare module "foo" {
+			export function ambient(): void
+		}
+
+		/** ambient is ambient. */
+		export function ambient() {}
+	`)
+
+	if string(patched) != want {
+		t.Fatalf("Patch() should document the top-level declaration\n\n%s", cmp.Diff(want, string(patched)))
+	}
+}
+
+func TestService_Patch_defaultExport(t *testing.T) {
+	tests := map[string]struct {
+		code       string
+		identifier string
+		want       string
+	}{
+		"anonymous function": {
+			code:       "export default async function (a: number) {\n\treturn a\n}\n",
+			identifier: "func:default",
+			want:       "/** Doubles a number. */\nexport default async function (a: number) {\n\treturn a\n}\n",
+		},
+		"anonymous class": {
+			code:       "@Injectable()\nexport default class extends Base {\n\tget() {}\n}\n",
+			identifier: "class:default",
+			want:       "/** Doubles a number. */\n@Injectable()\nexport default class extends Base {\n\tget() {}\n}\n",
+		},
+		"named function": {
+			code:       "export default function double(a: number) {}\n",
+			identifier: "func:double",
+			want:       "/** Doubles a number. */\nexport default function double(a: number) {}\n",
+		},
+	}
+
+	svc := ts.New()
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			findings, err := svc.Find(context.Background(), "index.ts", []byte(tt.code))
+			if err != nil {
+				t.Fatalf("Find() failed: %v", err)
+			}
+			if len(findings) == 0 || findings[0] != tt.identifier {
+				t.Fatalf("Find() should find %q; got %v", tt.identifier, findings)
+			}
+
+			patched, err := svc.Patch(context.Background(), tt.identifier, "Doubles a number.", []byte(tt.code))
+			if err != nil {
+				t.Fatalf("Patch() failed: %v", err)
+			}
+
+			if string(patched) != tt.want {
+				t.Fatalf("unexpected result\n\n%s", cmp.Diff(tt.want, string(patched)))
+			}
+		})
+	}
+
+	if got := ts.Target("func:default"); got != "the default-exported function" {
+		t.Errorf("Target() returned %q for an anonymous default export", got)
+	}
+}
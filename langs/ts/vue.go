@@ -0,0 +1,62 @@
+package ts
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+)
+
+// vueScriptRE matches a <script> block of a Vue single-file component. The
+// submatch is the code of the block.
+var vueScriptRE = regexp.MustCompile(`(?is)<script\b[^>]*>(.*?)</script\s*>`)
+
+// isVueFile reports whether file is a Vue single-file component rather than a
+// TypeScript or JavaScript file, which is decided by its ".vue" extension.
+func isVueFile(file string) bool {
+	return filepath.Ext(file) == ".vue"
+}
+
+// vueScripts returns the byte ranges of the code of the <script> blocks of a
+// Vue single-file component, which has a regular <script> block, a <script
+// setup> block, or both.
+func vueScripts(code []byte) [][2]int {
+	var scripts [][2]int
+	for _, m := range vueScriptRE.FindAllSubmatchIndex(code, -1) {
+		scripts = append(scripts, [2]int{m[2], m[3]})
+	}
+	return scripts
+}
+
+// parseVue returns the exported declarations of the <script> blocks of a Vue
+// single-file component. The positions of the declarations are offset by the
+// positions of the blocks, so that they are positions within the component.
+func parseVue(code []byte) []declaration {
+	var decls []declaration
+	for _, script := range vueScripts(code) {
+		line := bytes.Count(code[:script[0]], []byte("\n"))
+		col := script[0] - (bytes.LastIndexByte(code[:script[0]], '\n') + 1)
+
+		for _, d := range parseScript(code[script[0]:script[1]], true) {
+			if d.start.line == 0 {
+				d.start.col += col
+			}
+			d.start.line += line
+			d.start.start += script[0]
+			decls = append(decls, d)
+		}
+	}
+	return decls
+}
+
+// minifyVue applies fn to the code of each <script> block of a Vue
+// single-file component, and leaves the template and styles untouched.
+func minifyVue(code []byte, fn func([]byte) []byte) []byte {
+	var out []byte
+	var last int
+	for _, script := range vueScripts(code) {
+		out = append(out, code[last:script[0]]...)
+		out = append(out, fn(code[script[0]:script[1]])...)
+		last = script[1]
+	}
+	return append(out, code[last:]...)
+}
@@ -0,0 +1,181 @@
+package zig
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/modernice/jotbot/internal/lines"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	functionRE  = regexp.MustCompile(`^\s*pub\s+(?:(?:export|inline|noinline|extern(?:\s+"[^"]*")?)\s+)*fn\s+([A-Za-z_]\w*)\s*\(`)
+	containerRE = regexp.MustCompile(`^\s*pub\s+const\s+([A-Za-z_]\w*)\s*(?::[^=]*)?=\s*(?:(?:extern|packed)\s+)?(?:struct|enum|union|opaque)\b`)
+	constantRE  = regexp.MustCompile(`^\s*pub\s+const\s+([A-Za-z_]\w*)\b`)
+)
+
+// Finder searches Zig source code for public functions, structs and constants
+// that have no doc comment.
+type Finder struct{}
+
+// NewFinder returns a Finder.
+func NewFinder() *Finder {
+	return &Finder{}
+}
+
+// Find returns the sorted identifiers of the public functions ("func:name"),
+// container types such as structs ("type:Name") and constants ("var:NAME") in
+// code that have no doc comment. Members of public containers are identified
+// by their path, e.g. "func:Point.init" or "var:Color.default".
+func (f *Finder) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	src := lines.Split(code)
+
+	var findings []string
+	for _, d := range declarations(src) {
+		if !documented(src, d.line) {
+			findings = append(findings, d.identifier)
+		}
+	}
+	slices.Sort(findings)
+
+	return findings, nil
+}
+
+type declaration struct {
+	identifier string
+	line       int
+}
+
+type container struct {
+	path  string
+	depth int
+}
+
+// declarations returns the public declarations at the top level of the file
+// and at the top level of public containers.
+func declarations(src []string) []declaration {
+	var (
+		decls      []declaration
+		containers []container
+		depth      int
+		multiline  bool
+	)
+	for i, line := range src {
+		code := stripLine(line)
+
+		// Multiline string literals begin each line with "\\".
+		if strings.HasPrefix(strings.TrimSpace(line), `\\`) {
+			multiline = true
+			continue
+		} else if multiline {
+			multiline = false
+		}
+
+		for len(containers) > 0 && containers[len(containers)-1].depth > depth {
+			containers = containers[:len(containers)-1]
+		}
+
+		var owner string
+		member := depth == 0
+		if len(containers) > 0 && containers[len(containers)-1].depth == depth {
+			owner, member = containers[len(containers)-1].path, true
+		}
+
+		if member {
+			if d, name, isContainer, ok := parseDeclaration(code, owner); ok {
+				decls = append(decls, declaration{identifier: d, line: i})
+				if isContainer && strings.Contains(code, "{") {
+					path := name
+					if owner != "" {
+						path = owner + "." + name
+					}
+					containers = append(containers, container{path: path, depth: depth + 1})
+				}
+			}
+		}
+
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+	}
+
+	return decls
+}
+
+// parseDeclaration parses the public declaration in the given line of code.
+func parseDeclaration(code, owner string) (identifier, name string, isContainer, ok bool) {
+	prefix := ""
+	if owner != "" {
+		prefix = owner + "."
+	}
+
+	if m := functionRE.FindStringSubmatch(code); m != nil {
+		return "func:" + prefix + m[1], m[1], false, true
+	}
+	if m := containerRE.FindStringSubmatch(code); m != nil {
+		return "type:" + prefix + m[1], m[1], true, true
+	}
+	if m := constantRE.FindStringSubmatch(code); m != nil {
+		return "var:" + prefix + m[1], m[1], false, true
+	}
+
+	return "", "", false, false
+}
+
+// stripLine removes comments, string literals and character literals from a
+// line of code, so that the braces within them are not counted.
+func stripLine(line string) string {
+	var (
+		out   strings.Builder
+		quote rune
+		esc   bool
+	)
+	for i, r := range line {
+		if quote != 0 {
+			switch {
+			case esc:
+				esc = false
+			case r == '\\':
+				esc = true
+			case r == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '/' && strings.HasPrefix(line[i:], "//"):
+			return out.String()
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func findDeclaration(src []string, identifier string) (int, bool) {
+	for _, d := range declarations(src) {
+		if d.identifier == identifier {
+			return d.line, true
+		}
+	}
+	return 0, false
+}
+
+// documented reports whether the declaration at the given line is preceded by
+// a doc comment.
+func documented(src []string, line int) bool {
+	return docStart(src, line) < line
+}
+
+// docStart returns the index of the first line of the doc comment that
+// directly precedes the given line, or line if there is none.
+func docStart(src []string, line int) int {
+	return lines.BlockStart(src, line, isDocComment)
+}
+
+func isDocComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "///") && !strings.HasPrefix(trimmed, "////")
+}
@@ -0,0 +1,73 @@
+package zig_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/internal/tests"
+	"github.com/modernice/jotbot/langs/zig"
+)
+
+func TestFinder_Find(t *testing.T) {
+	code := heredoc.Doc(`
+		//! Geometry primitives.
+		const std = @import("std");
+
+		pub const max_points = 1024;
+
+		/// The origin of the coordinate system.
+		pub const origin = Point{ .x = 0, .y = 0 };
+
+		pub const Point = struct {
+		    x: f64,
+		    y: f64,
+
+		    pub const zero: Point = .{ .x = 0, .y = 0 };
+
+		    pub fn init(x: f64, y: f64) Point {
+		        const brace = '{';
+		        _ = brace;
+		        return .{ .x = x, .y = y };
+		    }
+
+		    /// Returns the distance between two points.
+		    pub fn distance(a: Point, b: Point) f64 {
+		        return std.math.hypot(a.x - b.x, a.y - b.y);
+		    }
+
+		    fn helper() void {}
+		};
+
+		const Internal = struct {
+		    pub fn hidden() void {}
+		};
+
+		pub const Shape = union(enum) {
+		    circle: f64,
+		    square: f64,
+		};
+
+		pub export fn add(a: i32, b: i32) i32 {
+		    const s =
+		        \\pub fn notAFunction() {
+		    ;
+		    _ = s;
+		    return a + b;
+		}
+	`)
+
+	findings, err := zig.NewFinder().Find(context.Background(), "geometry.zig", []byte(code))
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	tests.ExpectIdentifiers(t, []string{
+		"func:Point.init",
+		"func:add",
+		"type:Point",
+		"type:Shape",
+		"var:Point.zero",
+		"var:max_points",
+	}, findings)
+}
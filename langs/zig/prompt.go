@@ -0,0 +1,44 @@
+package zig
+
+import (
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/modernice/jotbot/generate"
+)
+
+// Prompt returns the prompt that asks for the doc comment of the declaration
+// identified by the input.
+func Prompt(input generate.PromptInput) string {
+	kind, name, _ := strings.Cut(input.Identifier, ":")
+	switch kind {
+	case "func":
+		kind = "function"
+	case "var":
+		kind = "constant"
+	}
+
+	return heredoc.Docf(`
+		Write a doc comment for the public Zig %s %q. Do not include any external links, source code, or (code) examples.
+
+		Describe what %s does but not what it _technically_ is. For example, if %s is a function that adds two integers, you must not describe it as a "function that adds two integers." Instead, you must describe it as "Adds two integers.".
+
+		Refer to parameters, fields and other declarations by enclosing them in backticks, and keep the writing style consistent with the documentation of the Zig standard library. Mention the errors that a function may return, if any.
+
+		Output only the unquoted comment, do not include comment markers (///).
+
+		Keep the comment as short as possible while still being descriptive.
+
+		Here is the source code for reference:
+		---
+		// %s
+		%s
+	`,
+		kind,
+		name,
+		name,
+		name,
+		input.File,
+		input.Code,
+	)
+}
@@ -0,0 +1,7 @@
+package zig
+
+import "github.com/modernice/jotbot"
+
+func init() {
+	jotbot.Register("zig", func() jotbot.Language { return New() })
+}
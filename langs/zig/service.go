@@ -0,0 +1,87 @@
+package zig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/lines"
+	"github.com/modernice/jotbot/internal/slice"
+)
+
+// FileExtensions are the file extensions of Zig source files.
+var FileExtensions = []string{".zig"}
+
+// Service documents public functions, structs and constants of Zig code using
+// doc comments ("///").
+type Service struct {
+	finder *Finder
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// WithFinder configures the [*Finder] that is used to find undocumented
+// declarations.
+func WithFinder(f *Finder) Option {
+	return func(s *Service) {
+		s.finder = f
+	}
+}
+
+// New returns a Service for Zig code.
+func New(opts ...Option) *Service {
+	var svc Service
+	for _, opt := range opts {
+		opt(&svc)
+	}
+	if svc.finder == nil {
+		svc.finder = NewFinder()
+	}
+	return &svc
+}
+
+// Extensions returns the file extensions of Zig source files.
+func (svc *Service) Extensions() []string {
+	return FileExtensions
+}
+
+// Find returns the identifiers of the undocumented public declarations in
+// code.
+func (svc *Service) Find(ctx context.Context, file string, code []byte) ([]string, error) {
+	return svc.finder.Find(ctx, file, code)
+}
+
+// Prompt returns the prompt for the given input. See [Prompt].
+func (svc *Service) Prompt(input generate.PromptInput) string {
+	return Prompt(input)
+}
+
+// Patch writes doc as the doc comment of the declaration identified by
+// identifier, replacing its existing doc comment.
+func (svc *Service) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	src := lines.Split(code)
+
+	line, ok := findDeclaration(src, identifier)
+	if !ok {
+		return nil, fmt.Errorf("%q not found in code", identifier)
+	}
+
+	var comment []string
+	if doc = NormalizeGeneratedComment(doc); doc != "" {
+		comment = lines.Comment(doc, lines.Indent(src[line]), "/// ", "", 100)
+	}
+
+	return lines.Join(lines.Replace(src, docStart(src, line), line, comment)), nil
+}
+
+// NormalizeGeneratedComment removes comment markers from a generated doc
+// comment and joins the lines of its paragraphs.
+func NormalizeGeneratedComment(doc string) string {
+	docLines := slice.Map(strings.Split(strings.TrimSpace(doc), "\n"), func(l string) string {
+		return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "///"))
+	})
+	return internal.RemoveColumns(strings.TrimSpace(strings.Join(docLines, "\n")))
+}
@@ -0,0 +1,45 @@
+package zig_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/langs/zig"
+)
+
+func TestService_Patch(t *testing.T) {
+	code := heredoc.Doc(`
+		pub const Point = struct {
+		    x: f64,
+		    y: f64,
+
+		    /// Outdated.
+		    pub fn init(x: f64, y: f64) Point {
+		        return .{ .x = x, .y = y };
+		    }
+		};
+	`)
+
+	patched, err := zig.New().Patch(context.Background(), "func:Point.init", "/// Creates a point at the given\n/// coordinates.", []byte(code))
+	if err != nil {
+		t.Fatalf("Patch() failed: %v", err)
+	}
+
+	want := heredoc.Doc(`
+		pub const Point = struct {
+		    x: f64,
+		    y: f64,
+
+		    /// Creates a point at the given coordinates.
+		    pub fn init(x: f64, y: f64) Point {
+		        return .{ .x = x, .y = y };
+		    }
+		};
+	`)
+
+	if string(patched) != want {
+		t.Errorf("Patch() returned invalid code:\n\n%s\n\n%s", cmp.Diff(want, string(patched)), string(patched))
+	}
+}
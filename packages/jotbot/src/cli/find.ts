@@ -1,6 +1,13 @@
 import type { Command } from 'commander'
 import type { FinderOptions, SymbolType } from '..'
-import { createFinder, printFindings, readSource } from '..'
+import {
+  createFinder,
+  findNode,
+  getInsertPosition,
+  parseCode,
+  printFindings,
+  readSource,
+} from '..'
 import { isSymbol, symbolTypes } from '../symbols'
 import { createLogger } from './logger'
 import { commaSeparated } from './utils'
@@ -18,6 +25,7 @@ interface Options
     WithSourceOption,
     WithVerboseOption {
   documented: boolean
+  positions: boolean
 }
 
 /**
@@ -42,6 +50,11 @@ export function withFindCmd(program: Command) {
       [] as SymbolType[],
     )
     .option('--documented', 'Also find documented symbols', false)
+    .option(
+      '--positions',
+      'Include the insert positions of the comments (JSON only)',
+      false,
+    )
     .option(...verboseOption)
     .addHelpText(
       'after',
@@ -100,6 +113,19 @@ function run(code: string, options: Options) {
     return
   }
 
+  if (format === 'json' && options.positions) {
+    const file = parseCode(code)
+    const positions = findings.map((identifier) => {
+      const node = findNode(file, identifier)
+      return {
+        identifier,
+        position: node ? getInsertPosition(node.commentTarget) : null,
+      }
+    })
+    print(JSON.stringify(positions, null, 2))
+    return
+  }
+
   if (format === 'json') {
     print(printFindings(findings))
   }
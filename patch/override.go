@@ -0,0 +1,79 @@
+package patch
+
+import (
+	"strings"
+)
+
+// Override is an existing documentation comment that was replaced by a patch,
+// which lets reviewers see what human-written documentation would be lost.
+type Override struct {
+	File       string `json:"file"`
+	Identifier string `json:"identifier"`
+
+	// Before are the lines of code that were removed by the patch, which
+	// usually are the lines of the replaced comment.
+	Before string `json:"before"`
+
+	// After are the lines of code that were inserted by the patch.
+	After string `json:"after"`
+}
+
+// Diff returns the before/after diff of the replaced comment. Removed lines
+// are prefixed with "- " and inserted lines with "+ ".
+func (o Override) Diff() string {
+	var b strings.Builder
+	for _, l := range strings.Split(o.Before, "\n") {
+		b.WriteString(strings.TrimRight("- "+l, " ") + "\n")
+	}
+	if o.After != "" {
+		for _, l := range strings.Split(o.After, "\n") {
+			b.WriteString(strings.TrimRight("+ "+l, " ") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Overrides returns the existing comments that were replaced by [*Patch.DryRun]
+// or [*Patch.Apply], in the order in which they were replaced.
+func (p *Patch) Overrides() []Override {
+	p.overridesMux.Lock()
+	defer p.overridesMux.Unlock()
+	return append([]Override(nil), p.overrides...)
+}
+
+// recordOverride records the change from before to after as an [Override] if
+// the change removed any lines of code, i.e., if it replaced an existing
+// comment instead of only inserting a new one.
+func (p *Patch) recordOverride(file, identifier string, before, after []byte) {
+	removed, added := changedLines(string(before), string(after))
+	if len(removed) == 0 {
+		return
+	}
+
+	p.overridesMux.Lock()
+	defer p.overridesMux.Unlock()
+	p.overrides = append(p.overrides, Override{
+		File:       file,
+		Identifier: identifier,
+		Before:     strings.Join(removed, "\n"),
+		After:      strings.Join(added, "\n"),
+	})
+}
+
+// changedLines returns the lines that differ between before and after,
+// ignoring their common leading and trailing lines.
+func changedLines(before, after string) (removed, added []string) {
+	a, b := strings.Split(before, "\n"), strings.Split(after, "\n")
+
+	var prefix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	var suffix int
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return a[prefix : len(a)-suffix], b[prefix : len(b)-suffix]
+}
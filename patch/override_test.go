@@ -0,0 +1,65 @@
+package patch_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/patch"
+	"github.com/spf13/afero"
+)
+
+type lineLanguage struct{}
+
+// Patch replaces the comment line above the line "<identifier>" with doc.
+func (lineLanguage) Patch(_ context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	lines := strings.Split(string(code), "\n")
+	for i, l := range lines {
+		if l != identifier {
+			continue
+		}
+		if i > 0 && strings.HasPrefix(lines[i-1], "//") {
+			lines[i-1] = "// " + doc
+		} else {
+			lines = append(lines[:i], append([]string{"// " + doc}, lines[i:]...)...)
+		}
+		break
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func TestPatch_Overrides(t *testing.T) {
+	repo := afero.NewMemMapFs()
+	afero.WriteFile(repo, "foo.txt", []byte("// Foo is written by a human.\nfoo\n\nbar\n"), 0o644)
+
+	files := make(chan generate.File, 1)
+	files <- generate.File{Path: "foo.txt", Docs: []generate.Documentation{
+		{Input: generate.Input{Identifier: "foo"}, Text: "Foo is generated."},
+		{Input: generate.Input{Identifier: "bar"}, Text: "Bar is generated."},
+	}}
+	close(files)
+
+	p := patch.New(files)
+	if _, err := p.DryRun(context.Background(), repo, func(string) (patch.Language, error) {
+		return lineLanguage{}, nil
+	}); err != nil {
+		t.Fatalf("DryRun() failed: %v", err)
+	}
+
+	want := []patch.Override{{
+		File:       "foo.txt",
+		Identifier: "foo",
+		Before:     "// Foo is written by a human.",
+		After:      "// Foo is generated.",
+	}}
+
+	if diff := cmp.Diff(want, p.Overrides()); diff != "" {
+		t.Fatalf("unexpected overrides (-want +got):\n%s", diff)
+	}
+
+	if got, want := p.Overrides()[0].Diff(), "- // Foo is written by a human.\n+ // Foo is generated.\n"; got != want {
+		t.Fatalf("Diff() should return %q; got %q", want, got)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 
 	"github.com/modernice/jotbot/generate"
 	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/internal/tracing"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/exp/slog"
 )
 
@@ -29,6 +32,17 @@ type Language interface {
 	Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error)
 }
 
+// Verifier is implemented by Languages that can verify that a patch changed
+// nothing but the documentation of the code. [*Patch] verifies each patch and
+// refuses to write files that were changed in any other way, as a safety net
+// against bugs in the patching of a Language.
+type Verifier interface {
+	// Verify returns an error if patched, the code patched with the
+	// documentation of the given identifier, differs from code in anything
+	// other than documentation.
+	Verify(identifier string, code, patched []byte) error
+}
+
 // Patch represents a process for modifying files with documentation updates. It
 // listens for file generation events and applies text patches to the content of
 // these files based on language-specific rules provided by a Language service.
@@ -41,6 +55,9 @@ type Patch struct {
 	files <-chan generate.File
 	errs  <-chan error
 	log   *slog.Logger
+
+	overridesMux sync.Mutex
+	overrides    []Override
 }
 
 // Option configures a [*Patch] by setting optional parameters.
@@ -81,6 +98,13 @@ func New(files <-chan generate.File, opts ...Option) *Patch {
 	return p
 }
 
+// Files drains the generated files of the Patch without applying them. It
+// returns the files that were generated before the first generation error, along
+// with that error.
+func (p *Patch) Files() ([]generate.File, error) {
+	return internal.Drain(p.files, p.errs)
+}
+
 // DryRun simulates the patching process by applying modifications to files in
 // memory and returns the resulting content without writing changes to the file
 // system. It accepts a context, a file system abstraction, and a function to
@@ -155,19 +179,32 @@ func (p *Patch) Apply(ctx context.Context, repo afero.Fs, getLanguage func(strin
 	}
 }
 
-func (p *Patch) applyFile(ctx context.Context, repo afero.Fs, svc Language, file generate.File, write bool) ([]byte, error) {
+func (p *Patch) applyFile(ctx context.Context, repo afero.Fs, svc Language, file generate.File, write bool) (_ []byte, err error) {
+	ctx, span := tracing.Start(ctx, "patch.ApplyFile",
+		attribute.String("file", file.Path),
+		attribute.Int("docs", len(file.Docs)),
+		attribute.Bool("write", write),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	code, err := readFile(repo, file.Path)
 	if err != nil {
 		return code, err
 	}
 
 	for _, doc := range file.Docs {
-		if patched, err := svc.Patch(ctx, doc.Identifier, doc.Text, code); err != nil {
+		patched, err := svc.Patch(ctx, doc.Identifier, doc.Text, code)
+		if err != nil {
 			p.log.Debug(fmt.Sprintf("failed to patch %q: %v", doc.Identifier, err), "documentation", doc.Text)
 			return code, fmt.Errorf("apply patch to %q: %w", doc.Identifier, err)
-		} else {
-			code = patched
 		}
+		if v, ok := svc.(Verifier); ok {
+			if err := v.Verify(doc.Identifier, code, patched); err != nil {
+				return code, fmt.Errorf("refusing to write %s: patch of %q changed more than documentation: %w", file.Path, doc.Identifier, err)
+			}
+		}
+		p.recordOverride(file.Path, doc.Identifier, code, patched)
+		code = patched
 	}
 
 	if !write {
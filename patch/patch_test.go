@@ -0,0 +1,76 @@
+package patch_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/patch"
+	"github.com/spf13/afero"
+)
+
+// verifyingLanguage is a lineLanguage that also upper-cases the code of
+// identifiers whose documentation mentions "uppercase", and verifies that
+// patches change only comment lines.
+type verifyingLanguage struct{ lineLanguage }
+
+func (l verifyingLanguage) Patch(ctx context.Context, identifier, doc string, code []byte) ([]byte, error) {
+	patched, err := l.lineLanguage.Patch(ctx, identifier, doc, code)
+	if err != nil || !strings.Contains(doc, "uppercase") {
+		return patched, err
+	}
+	return []byte(strings.ReplaceAll(string(patched), "\n"+identifier+"\n", "\n"+strings.ToUpper(identifier)+"\n")), nil
+}
+
+func (verifyingLanguage) Verify(_ string, code, patched []byte) error {
+	if codeLines(code) != codeLines(patched) {
+		return errors.New("code lines changed")
+	}
+	return nil
+}
+
+func codeLines(code []byte) string {
+	var lines []string
+	for _, l := range strings.Split(string(code), "\n") {
+		if !strings.HasPrefix(l, "//") {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestPatch_Apply_verify(t *testing.T) {
+	repo := afero.NewMemMapFs()
+	afero.WriteFile(repo, "foo.txt", []byte("\nfoo\n"), 0o644)
+	afero.WriteFile(repo, "bar.txt", []byte("\nbar\n"), 0o644)
+
+	files := make(chan generate.File, 2)
+	files <- generate.File{Path: "foo.txt", Docs: []generate.Documentation{
+		{Input: generate.Input{Identifier: "foo"}, Text: "Foo is generated."},
+	}}
+	files <- generate.File{Path: "bar.txt", Docs: []generate.Documentation{
+		{Input: generate.Input{Identifier: "bar"}, Text: "Bar is generated in uppercase."},
+	}}
+	close(files)
+
+	if err := patch.New(files).Apply(context.Background(), repo, func(string) (patch.Language, error) {
+		return verifyingLanguage{}, nil
+	}); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"foo.txt": "\n// Foo is generated.\nfoo\n",
+		"bar.txt": "\nbar\n",
+	} {
+		got, err := afero.ReadFile(repo, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s should contain %q; got %q", file, want, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package jotbot
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	registryMux sync.RWMutex
+	registry    = make(map[string]func() Language)
+)
+
+// Register makes a language available by name to [WithAllLanguages]. The
+// factory creates the language with its default configuration. Language
+// packages register themselves when they are imported, so that importing a
+// language package is enough to make it available:
+//
+//	import _ "github.com/modernice/jotbot/langs/sh"
+//
+// Register panics if the name is empty, if the factory is nil, or if a
+// language with the same name is already registered.
+func Register(name string, factory func() Language) {
+	if name == "" {
+		panic("jotbot: Register: empty language name")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("jotbot: Register: nil factory for language %q", name))
+	}
+
+	registryMux.Lock()
+	defer registryMux.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("jotbot: Register: language %q is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Registered returns the sorted names of the registered languages.
+func Registered() []string {
+	registryMux.RLock()
+	defer registryMux.RUnlock()
+
+	names := maps.Keys(registry)
+	slices.Sort(names)
+	return names
+}
+
+// WithAllLanguages configures a JotBot instance to use all registered
+// languages, each created by its factory. Languages are configured in the order
+// of their names, so if two languages report the same file extension, the
+// language whose name sorts last handles it. Options that configure languages
+// after WithAllLanguages replace the registered languages of the same name.
+func WithAllLanguages() Option {
+	return func(bot *JotBot) {
+		for _, name := range Registered() {
+			registryMux.RLock()
+			factory := registry[name]
+			registryMux.RUnlock()
+
+			bot.ConfigureLanguage(name, factory())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/slog"
+)
+
+// Service caches the documentation generated by another [generate.Service] on
+// disk, keyed by a hash of the model and the prompt. Generations with a prompt
+// that was already answered by the same model are served from the cache, so
+// that re-running JotBot after a partial failure or on CI retries never pays
+// twice for identical prompts. Failed generations are not cached.
+type Service struct {
+	next  generate.Service
+	model string
+	dir   string
+	log   *slog.Logger
+}
+
+// Fingerprinter is implemented by services whose generations depend on
+// parameters other than the model and the prompt, such as the sampling options
+// or the maximum number of tokens. The fingerprint should be part of the model
+// that is passed to [New], so that documentation that was generated with other
+// parameters is not served from the cache.
+type Fingerprinter interface {
+	// Fingerprint returns a string that changes whenever one of the
+	// parameters of the generations changes.
+	Fingerprint() string
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Dir configures the directory of the cache. Defaults to [DefaultDir].
+func Dir(dir string) Option {
+	return func(s *Service) {
+		s.dir = dir
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// DefaultDir returns the default cache directory, which is the "jotbot"
+// directory within the user's cache directory (e.g. "~/.cache/jotbot").
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("get user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "jotbot"), nil
+}
+
+// New returns a Service that caches the documentation generated by next.
+// model identifies the model that is used by next and is part of the cache key.
+// If next implements [Fingerprinter], its fingerprint should be part of model.
+func New(next generate.Service, model string, opts ...Option) (*Service, error) {
+	svc := Service{next: next, model: model}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.dir == "" {
+		dir, err := DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+		svc.dir = dir
+	}
+
+	return &svc, nil
+}
+
+// GenerateDoc returns the cached documentation for the prompt of the given
+// context, or generates the documentation using the underlying service and
+// caches the result.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	identifier := ctx.Input().Identifier
+	file := svc.file(generate.SystemPromptOf(ctx), ctx.Prompt())
+
+	if b, err := os.ReadFile(file); err == nil {
+		svc.log.Debug(fmt.Sprintf("[Cache] Using cached documentation for %s", identifier))
+		return string(b), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		svc.log.Warn(fmt.Sprintf("[Cache] Failed to read cached documentation for %s", identifier), "error", err)
+	}
+
+	doc, err := svc.next.GenerateDoc(ctx)
+	if err != nil || doc == "" {
+		return doc, err
+	}
+
+	if err := svc.write(file, doc); err != nil {
+		svc.log.Warn(fmt.Sprintf("[Cache] Failed to cache documentation for %s", identifier), "error", err)
+	}
+
+	return doc, nil
+}
+
+// StructuredOutput reports whether the underlying service returns
+// documentation decoded from structured output. It implements
+// [generate.Structured].
+func (svc *Service) StructuredOutput() bool {
+	s, ok := svc.next.(generate.Structured)
+	return ok && s.StructuredOutput()
+}
+
+// Model returns the model of the underlying service, if it implements
+// [generate.Modeler].
+func (svc *Service) Model() string {
+	if m, ok := svc.next.(generate.Modeler); ok {
+		return m.Model()
+	}
+	return ""
+}
+
+// file returns the path of the cache entry for the given system prompt and
+// prompt. Entries without a system prompt keep the key of the model and
+// prompt alone.
+func (svc *Service) file(system, prompt string) string {
+	h := sha256.New()
+	h.Write([]byte(svc.model))
+	h.Write([]byte{0})
+	if system != "" {
+		h.Write([]byte(system))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(prompt))
+	key := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(svc.dir, key[:2], key)
+}
+
+// write writes the documentation to a temporary file and renames it, so that
+// concurrent or interrupted runs never read partially written entries.
+func (svc *Service) write(file, doc string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(doc); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
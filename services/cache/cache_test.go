@@ -0,0 +1,65 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/cache"
+)
+
+type service struct{ calls int }
+
+func (s *service) GenerateDoc(ctx generate.Context) (string, error) {
+	s.calls++
+	return "Docs for " + ctx.Prompt(), nil
+}
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: "func:Foo"}}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
+
+func TestService_GenerateDoc(t *testing.T) {
+	dir := t.TempDir()
+	svc := &service{}
+
+	c, err := cache.New(svc, "gpt-4o", cache.Dir(dir))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		doc, err := c.GenerateDoc(genCtx{Context: context.Background(), prompt: "foo"})
+		if err != nil {
+			t.Fatalf("GenerateDoc() failed: %v", err)
+		}
+		if doc != "Docs for foo" {
+			t.Fatalf("GenerateDoc() should return %q; got %q", "Docs for foo", doc)
+		}
+	}
+
+	if svc.calls != 1 {
+		t.Fatalf("identical prompts should be served from the cache; service was called %d times", svc.calls)
+	}
+
+	other, err := cache.New(svc, "gpt-4o-mini", cache.Dir(dir))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, err := other.GenerateDoc(genCtx{Context: context.Background(), prompt: "foo"}); err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if svc.calls != 2 {
+		t.Fatalf("prompts of other models should not be served from the cache")
+	}
+}
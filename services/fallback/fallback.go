@@ -0,0 +1,178 @@
+package fallback
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/slog"
+)
+
+// DefaultCooldown is the default duration for which a provider is skipped
+// after it failed because of a rate limit or an outage.
+const DefaultCooldown = time.Minute
+
+// Provider is a [generate.Service] in a fallback chain.
+type Provider struct {
+	// Service generates the documentation.
+	Service generate.Service
+
+	// Name identifies the provider in log messages, e.g. "openai".
+	Name string
+}
+
+// Service sends each generation to the first available provider of an ordered
+// list of providers. If a provider fails with an error that matches
+// [generate.ErrUnavailable], such as a rate limit or an outage, the generation
+// is retried using the next provider, and the failed provider is skipped for
+// the configured cooldown, so that long runs survive an incident of a single
+// provider. Other errors are returned as-is.
+type Service struct {
+	providers []Provider
+	cooldown  time.Duration
+	log       *slog.Logger
+
+	mux  sync.Mutex
+	down map[int]time.Time
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Cooldown configures the duration for which a provider is skipped after it
+// failed because of a rate limit or an outage. Defaults to [DefaultCooldown].
+// A cooldown of zero tries each provider in order for every generation.
+func Cooldown(d time.Duration) Option {
+	return func(s *Service) {
+		s.cooldown = d
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// New returns a Service that falls back to the given providers in order.
+func New(providers []Provider, opts ...Option) (*Service, error) {
+	svc := Service{
+		providers: providers,
+		cooldown:  DefaultCooldown,
+		down:      make(map[int]time.Time),
+	}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if len(svc.providers) == 0 {
+		return nil, errors.New("no providers")
+	}
+
+	for i, p := range svc.providers {
+		if p.Service == nil {
+			return nil, fmt.Errorf("provider #%d has no service", i+1)
+		}
+	}
+
+	return &svc, nil
+}
+
+// GenerateDoc sends the generation to the first provider that is not cooling
+// down and falls back to the next provider if it is unavailable. If all
+// providers are cooling down, they are tried anyway. The error of the last
+// provider is returned if no provider succeeds.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	identifier := ctx.Input().Identifier
+
+	var lastErr error
+	for _, i := range svc.order() {
+		p := svc.providers[i]
+
+		if lastErr != nil {
+			svc.log.Warn(fmt.Sprintf("[Fallback] Falling back to %s for %s", p.Name, identifier))
+		}
+
+		doc, err := p.Service.GenerateDoc(ctx)
+		if err == nil {
+			svc.up(i)
+			return doc, nil
+		}
+
+		if ctx.Err() != nil || !errors.Is(err, generate.ErrUnavailable) {
+			return "", err
+		}
+
+		svc.log.Warn(fmt.Sprintf("[Fallback] %s is unavailable", p.Name), "error", err)
+		svc.markDown(i)
+		lastErr = err
+	}
+
+	return "", lastErr
+}
+
+// order returns the indexes of the providers in the order they should be
+// tried: available providers first, then providers that are cooling down.
+func (svc *Service) order() []int {
+	svc.mux.Lock()
+	defer svc.mux.Unlock()
+
+	now := time.Now()
+	available := make([]int, 0, len(svc.providers))
+	var cooling []int
+	for i := range svc.providers {
+		if until, ok := svc.down[i]; ok && now.Before(until) {
+			cooling = append(cooling, i)
+			continue
+		}
+		available = append(available, i)
+	}
+
+	return append(available, cooling...)
+}
+
+func (svc *Service) markDown(i int) {
+	if svc.cooldown <= 0 {
+		return
+	}
+
+	svc.mux.Lock()
+	defer svc.mux.Unlock()
+	svc.down[i] = time.Now().Add(svc.cooldown)
+}
+
+func (svc *Service) up(i int) {
+	svc.mux.Lock()
+	defer svc.mux.Unlock()
+	delete(svc.down, i)
+}
+
+// StructuredOutput reports whether all providers return documentation decoded
+// from structured output. It implements [generate.Structured].
+func (svc *Service) StructuredOutput() bool {
+	for _, p := range svc.providers {
+		s, ok := p.Service.(generate.Structured)
+		if !ok || !s.StructuredOutput() {
+			return false
+		}
+	}
+	return true
+}
+
+// Model returns the model of the primary provider, if it implements
+// [generate.Modeler]. Generations that fall back to another provider are not
+// reflected.
+func (svc *Service) Model() string {
+	if m, ok := svc.providers[0].Service.(generate.Modeler); ok {
+		return m.Model()
+	}
+	return ""
+}
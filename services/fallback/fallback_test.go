@@ -0,0 +1,79 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/fallback"
+)
+
+type service struct {
+	doc   string
+	err   error
+	calls int
+}
+
+func (s *service) GenerateDoc(generate.Context) (string, error) {
+	s.calls++
+	return s.doc, s.err
+}
+
+type genCtx struct{ context.Context }
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: "func:Foo"}}
+}
+
+func (genCtx) Prompt() string { return "" }
+
+func TestService_GenerateDoc(t *testing.T) {
+	primary := &service{err: generate.Unavailable(errors.New("429 Too Many Requests"))}
+	secondary := &service{doc: "secondary"}
+
+	svc, err := fallback.New([]fallback.Provider{
+		{Name: "primary", Service: primary},
+		{Name: "secondary", Service: secondary},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		doc, err := svc.GenerateDoc(genCtx{context.Background()})
+		if err != nil {
+			t.Fatalf("GenerateDoc() failed: %v", err)
+		}
+
+		if doc != "secondary" {
+			t.Fatalf("GenerateDoc() should return %q; got %q", "secondary", doc)
+		}
+	}
+
+	if primary.calls != 1 {
+		t.Fatalf("unavailable provider should be skipped during cooldown; was called %d times", primary.calls)
+	}
+}
+
+func TestService_GenerateDoc_otherError(t *testing.T) {
+	mockErr := errors.New("invalid prompt")
+	primary := &service{err: mockErr}
+	secondary := &service{doc: "secondary"}
+
+	svc, err := fallback.New([]fallback.Provider{
+		{Name: "primary", Service: primary},
+		{Name: "secondary", Service: secondary},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, err := svc.GenerateDoc(genCtx{context.Background()}); !errors.Is(err, mockErr) {
+		t.Fatalf("GenerateDoc() should fail with %q; got %v", mockErr, err)
+	}
+
+	if secondary.calls != 0 {
+		t.Fatalf("should not fall back on errors that are not caused by an unavailable provider")
+	}
+}
@@ -0,0 +1,330 @@
+package huggingface
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// DefaultModel is the model used by the Service when neither a model nor a
+	// custom endpoint is configured.
+	DefaultModel = "mistralai/Mistral-7B-Instruct-v0.2"
+
+	// DefaultMaxTokens is the default maximum number of new tokens the Service
+	// generates for a single documentation.
+	DefaultMaxTokens = 512
+
+	// DefaultBaseURL is the base URL of the serverless Hugging Face Inference
+	// API. Models are served at "<base URL>/<model>".
+	DefaultBaseURL = "https://api-inference.huggingface.co/models"
+)
+
+// Service generates documentation using the Hugging Face Inference API. It
+// either uses the text-generation task, which sends the raw prompt to the model,
+// or the chat completion route, which applies the chat template of the model to
+// the prompt before generation.
+type Service struct {
+	token     string
+	endpoint  string
+	client    *http.Client
+	model     string
+	maxTokens int
+	chat      bool
+	stop      []string
+	log       *slog.Logger
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Model configures the Hugging Face model, e.g. "mistralai/Mistral-7B-Instruct-v0.2".
+func Model(model string) Option {
+	return func(s *Service) {
+		s.model = model
+	}
+}
+
+// Endpoint configures the URL of a dedicated Inference Endpoint. If set, the
+// Service sends its requests to this URL instead of the serverless Inference
+// API, and the configured model is only used for logging.
+func Endpoint(url string) Option {
+	return func(s *Service) {
+		s.endpoint = url
+	}
+}
+
+// MaxTokens sets the maximum number of new tokens to generate for a single
+// documentation.
+func MaxTokens(max int) Option {
+	return func(s *Service) {
+		s.maxTokens = max
+	}
+}
+
+// Chat configures whether the Service uses the chat completion route of the
+// Inference API, which formats the prompt using the chat template of the model.
+// By default, the plain text-generation task is used.
+func Chat(chat bool) Option {
+	return func(s *Service) {
+		s.chat = chat
+	}
+}
+
+// Stop configures sequences that stop the generation when produced by the
+// model. Stop sequences are removed from the generated documentation.
+func Stop(sequences ...string) Option {
+	return func(s *Service) {
+		s.stop = append(s.stop, sequences...)
+	}
+}
+
+// HTTPClient configures the HTTP client used to send requests to the Inference
+// API. By default, [http.DefaultClient] is used.
+func HTTPClient(c *http.Client) Option {
+	return func(s *Service) {
+		s.client = c
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// New returns a Service that authenticates against the Hugging Face Inference
+// API using the provided access token. If no model is configured,
+// [DefaultModel] is used.
+func New(token string, opts ...Option) *Service {
+	svc := Service{
+		token:     token,
+		maxTokens: DefaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.client == nil {
+		svc.client = http.DefaultClient
+	}
+
+	if svc.model == "" {
+		svc.log.Debug(fmt.Sprintf("[HuggingFace] No model provided. Using default model %q", DefaultModel))
+		svc.model = DefaultModel
+	}
+
+	if svc.endpoint == "" {
+		svc.endpoint = DefaultBaseURL + "/" + svc.model
+	}
+	svc.endpoint = strings.TrimSuffix(svc.endpoint, "/")
+
+	svc.log.Debug(fmt.Sprintf("[HuggingFace] Using model %q", svc.model), "endpoint", svc.endpoint)
+
+	return &svc
+}
+
+// Model returns the Hugging Face model that generates the documentation. It
+// implements [generate.Modeler].
+func (svc *Service) Model() string {
+	return svc.model
+}
+
+// Fingerprint returns the parameters of the requests of the Service other than
+// the model and the prompt. It implements
+// [github.com/modernice/jotbot/services/cache.Fingerprinter].
+func (svc *Service) Fingerprint() string {
+	return fmt.Sprintf("max_tokens=%d chat=%t stop=%q", svc.maxTokens, svc.chat, svc.stop)
+}
+
+// GenerateDoc sends the prompt of the given context to the Inference API and
+// returns the generated documentation.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	svc.log.Debug(fmt.Sprintf("[HuggingFace] Generating docs for %s (%s)", ctx.Input().Identifier, ctx.Input().Language))
+
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var (
+		text string
+		err  error
+	)
+	system := generate.SystemPromptOf(ctx)
+	if svc.chat {
+		text, err = svc.createWithChat(timeout, system, ctx.Prompt())
+	} else {
+		// Text generation has no system messages, so the system prompt is
+		// prepended to the prompt.
+		prompt := ctx.Prompt()
+		if system != "" {
+			prompt = system + "\n\n" + prompt
+		}
+		text, err = svc.createWithTextGeneration(timeout, prompt)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return svc.normalize(text), nil
+}
+
+func (svc *Service) createWithTextGeneration(ctx context.Context, prompt string) (string, error) {
+	req := textGenerationRequest{
+		Inputs: prompt,
+		Parameters: textGenerationParameters{
+			MaxNewTokens:   svc.maxTokens,
+			Temperature:    0.618,
+			TopP:           0.3,
+			Stop:           svc.stop,
+			ReturnFullText: false,
+		},
+		Options: requestOptions{WaitForModel: true},
+	}
+
+	var resp []struct {
+		GeneratedText string `json:"generated_text"`
+	}
+	if err := svc.post(ctx, svc.endpoint, req, &resp); err != nil {
+		return "", err
+	}
+
+	if len(resp) == 0 {
+		return "", fmt.Errorf("huggingface: no text generated")
+	}
+
+	return resp[0].GeneratedText, nil
+}
+
+func (svc *Service) createWithChat(ctx context.Context, system, prompt string) (string, error) {
+	var messages []message
+	if system != "" {
+		messages = append(messages, message{Role: "system", Content: system})
+	}
+	messages = append(messages, message{Role: "user", Content: prompt})
+
+	req := chatRequest{
+		Model:       svc.model,
+		Messages:    messages,
+		MaxTokens:   svc.maxTokens,
+		Temperature: 0.618,
+		TopP:        0.3,
+		Stop:        svc.stop,
+	}
+
+	var resp struct {
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := svc.post(ctx, svc.endpoint+"/v1/chat/completions", req, &resp); err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("huggingface: no choices returned")
+	}
+
+	svc.log.Debug("[HuggingFace] Usage info", "prompt", resp.Usage.PromptTokens, "completion", resp.Usage.CompletionTokens, "total", resp.Usage.TotalTokens)
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (svc *Service) post(ctx context.Context, url string, body, v any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if svc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+svc.token)
+	}
+
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return generate.Unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("huggingface: %s:\n%s", resp.Status, raw)
+		if internal.UnavailableStatus(resp.StatusCode) {
+			err = generate.Unavailable(err)
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal response: %w\n%s", err, raw)
+	}
+
+	return nil
+}
+
+func (svc *Service) normalize(text string) string {
+	text = strings.TrimSpace(text)
+	for _, stop := range svc.stop {
+		text = strings.TrimSuffix(text, stop)
+	}
+	return strings.TrimSpace(text)
+}
+
+type requestOptions struct {
+	WaitForModel bool `json:"wait_for_model"`
+}
+
+type textGenerationParameters struct {
+	MaxNewTokens   int      `json:"max_new_tokens,omitempty"`
+	Temperature    float32  `json:"temperature,omitempty"`
+	TopP           float32  `json:"top_p,omitempty"`
+	Stop           []string `json:"stop,omitempty"`
+	ReturnFullText bool     `json:"return_full_text"`
+}
+
+type textGenerationRequest struct {
+	Inputs     string                   `json:"inputs"`
+	Parameters textGenerationParameters `json:"parameters"`
+	Options    requestOptions           `json:"options"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+}
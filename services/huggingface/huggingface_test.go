@@ -0,0 +1,170 @@
+package huggingface_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/huggingface"
+)
+
+func TestService_GenerateDoc(t *testing.T) {
+	var (
+		req struct {
+			Inputs     string `json:"inputs"`
+			Parameters struct {
+				MaxNewTokens   int      `json:"max_new_tokens"`
+				Stop           []string `json:"stop"`
+				ReturnFullText bool     `json:"return_full_text"`
+			} `json:"parameters"`
+			Options struct {
+				WaitForModel bool `json:"wait_for_model"`
+			} `json:"options"`
+		}
+		auth string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `[{"generated_text":"  Foo returns foo.\n</s>"}]`)
+	}))
+	defer srv.Close()
+
+	svc := huggingface.New("token", huggingface.Endpoint(srv.URL+"/"), huggingface.MaxTokens(128), huggingface.Stop("</s>"))
+
+	doc, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo.", system: "You are a technical writer."})
+	if err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("GenerateDoc() should return the trimmed documentation %q; got %q", want, doc)
+	}
+
+	if auth != "Bearer token" {
+		t.Errorf("request should be authenticated with the access token; got Authorization %q", auth)
+	}
+
+	if want := "You are a technical writer.\n\nDocument Foo."; req.Inputs != want {
+		t.Errorf("inputs should be the system prompt followed by the prompt %q; got %q", want, req.Inputs)
+	}
+
+	if req.Parameters.MaxNewTokens != 128 {
+		t.Errorf("request should limit the new tokens to %d; got %d", 128, req.Parameters.MaxNewTokens)
+	}
+
+	if len(req.Parameters.Stop) != 1 || req.Parameters.Stop[0] != "</s>" {
+		t.Errorf("request should contain the stop sequences; got %v", req.Parameters.Stop)
+	}
+
+	if req.Parameters.ReturnFullText {
+		t.Errorf("request should not return the full text")
+	}
+
+	if !req.Options.WaitForModel {
+		t.Errorf("request should wait for the model to be loaded")
+	}
+}
+
+func TestService_GenerateDoc_chat(t *testing.T) {
+	var (
+		path string
+		req  struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+			Messages  []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"\nFoo returns foo.  "}}]}`)
+	}))
+	defer srv.Close()
+
+	svc := huggingface.New("token", huggingface.Endpoint(srv.URL), huggingface.Model("foo/bar"), huggingface.Chat(true))
+
+	doc, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo.", system: "You are a technical writer."})
+	if err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("GenerateDoc() should return the trimmed documentation %q; got %q", want, doc)
+	}
+
+	if path != "/v1/chat/completions" {
+		t.Errorf("request should be sent to the chat completion route; got %q", path)
+	}
+
+	if req.Model != "foo/bar" || req.MaxTokens != huggingface.DefaultMaxTokens {
+		t.Errorf("request should use model %q and %d max tokens; got %q and %d", "foo/bar", huggingface.DefaultMaxTokens, req.Model, req.MaxTokens)
+	}
+
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Role != "user" {
+		t.Errorf("request should contain a system and a user message; got %+v", req.Messages)
+	}
+}
+
+func TestService_GenerateDoc_error(t *testing.T) {
+	tests := map[string]struct {
+		status      int
+		body        string
+		unavailable bool
+	}{
+		"bad request":   {status: http.StatusBadRequest, body: `{"error":"invalid parameters"}`},
+		"unauthorized":  {status: http.StatusUnauthorized, body: `{"error":"invalid token"}`},
+		"loading":       {status: http.StatusServiceUnavailable, body: `{"error":"Model is currently loading","estimated_time":20}`, unavailable: true},
+		"rate limited":  {status: http.StatusTooManyRequests, body: `{"error":"rate limit reached"}`, unavailable: true},
+		"empty results": {status: http.StatusOK, body: `[]`},
+		"invalid json":  {status: http.StatusOK, body: `{`},
+	}
+
+	for name, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+
+		_, err := huggingface.New("token", huggingface.Endpoint(srv.URL)).GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."})
+		srv.Close()
+
+		if err == nil {
+			t.Fatalf("%s: GenerateDoc() should fail", name)
+		}
+		if got := errors.Is(err, generate.ErrUnavailable); got != tt.unavailable {
+			t.Errorf("%s: errors.Is(err, generate.ErrUnavailable) should return %v; got %v", name, tt.unavailable, got)
+		}
+	}
+}
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+	system string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: "func:Foo", Language: "go"}}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
+
+func (ctx genCtx) SystemPrompt() string { return ctx.system }
@@ -0,0 +1,257 @@
+package llamacpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// DefaultURL is the address the llama.cpp server listens on by default.
+	DefaultURL = "http://localhost:8080"
+
+	// DefaultContextWindow is the default size of the context window of the model
+	// that is served by the llama.cpp server.
+	DefaultContextWindow = 4096
+
+	// DefaultMaxTokens is the default maximum number of tokens the Service
+	// generates for a single documentation.
+	DefaultMaxTokens = 512
+)
+
+// Service generates documentation using the "/completion" endpoint of a
+// llama.cpp HTTP server. Because the server runs locally, it allows JotBot to
+// be used in air-gapped environments. Prompt tokens are counted using the
+// "/tokenize" endpoint of the server, so that the number of generated tokens
+// never exceeds the configured context window.
+type Service struct {
+	url           string
+	client        *http.Client
+	contextWindow int
+	maxTokens     int
+	grammar       string
+	stop          []string
+	log           *slog.Logger
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// URL configures the base URL of the llama.cpp server.
+func URL(url string) Option {
+	return func(s *Service) {
+		s.url = url
+	}
+}
+
+// ContextWindow configures the size of the context window of the served model,
+// which should match the "--ctx-size" the server was started with.
+func ContextWindow(tokens int) Option {
+	return func(s *Service) {
+		s.contextWindow = tokens
+	}
+}
+
+// MaxTokens sets the maximum number of tokens to generate for a single
+// documentation. The limit is lowered automatically if the prompt leaves less
+// room in the context window.
+func MaxTokens(max int) Option {
+	return func(s *Service) {
+		s.maxTokens = max
+	}
+}
+
+// Grammar constrains the output of the model to the provided GBNF grammar.
+func Grammar(grammar string) Option {
+	return func(s *Service) {
+		s.grammar = grammar
+	}
+}
+
+// Stop configures sequences that stop the generation when produced by the
+// model.
+func Stop(sequences ...string) Option {
+	return func(s *Service) {
+		s.stop = append(s.stop, sequences...)
+	}
+}
+
+// HTTPClient configures the HTTP client used to send requests to the server.
+// By default, [http.DefaultClient] is used.
+func HTTPClient(c *http.Client) Option {
+	return func(s *Service) {
+		s.client = c
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// New returns a Service that sends its requests to a llama.cpp server. If no
+// URL is configured, [DefaultURL] is used.
+func New(opts ...Option) *Service {
+	svc := Service{
+		url:           DefaultURL,
+		contextWindow: DefaultContextWindow,
+		maxTokens:     DefaultMaxTokens,
+	}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.client == nil {
+		svc.client = http.DefaultClient
+	}
+
+	if svc.url == "" {
+		svc.url = DefaultURL
+	}
+	svc.url = strings.TrimSuffix(svc.url, "/")
+
+	if svc.contextWindow <= 0 {
+		svc.contextWindow = DefaultContextWindow
+	}
+
+	svc.log.Debug(fmt.Sprintf("[llama.cpp] Using server at %s", svc.url), "context", svc.contextWindow)
+
+	return &svc
+}
+
+// Fingerprint returns the parameters of the requests of the Service other than
+// the prompt. It implements
+// [github.com/modernice/jotbot/services/cache.Fingerprinter].
+func (svc *Service) Fingerprint() string {
+	return fmt.Sprintf("max_tokens=%d stop=%q grammar=%q", svc.maxTokens, svc.stop, svc.grammar)
+}
+
+// GenerateDoc sends the prompt of the given context to the "/completion"
+// endpoint of the llama.cpp server and returns the generated documentation.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	svc.log.Debug(fmt.Sprintf("[llama.cpp] Generating docs for %s (%s)", ctx.Input().Identifier, ctx.Input().Language))
+
+	// The completion endpoint has no system messages, so the system prompt is
+	// prepended to the prompt.
+	prompt := ctx.Prompt()
+	if system := generate.SystemPromptOf(ctx); system != "" {
+		prompt = system + "\n\n" + prompt
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	maxTokens, err := svc.maxCompletionTokens(timeout, prompt)
+	if err != nil {
+		return "", fmt.Errorf("max tokens: %w", err)
+	}
+
+	var resp completionResponse
+	if err := svc.post(timeout, "/completion", completionRequest{
+		Prompt:      prompt,
+		NPredict:    maxTokens,
+		Temperature: 0.618,
+		TopP:        0.3,
+		Stop:        svc.stop,
+		Grammar:     svc.grammar,
+		CachePrompt: true,
+	}, &resp); err != nil {
+		return "", err
+	}
+
+	svc.log.Debug("[llama.cpp] Usage info", "prompt", resp.TokensEvaluated, "completion", resp.TokensPredicted)
+
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func (svc *Service) maxCompletionTokens(ctx context.Context, prompt string) (int, error) {
+	var resp tokenizeResponse
+	if err := svc.post(ctx, "/tokenize", tokenizeRequest{Content: prompt}, &resp); err != nil {
+		return 0, fmt.Errorf("tokenize prompt: %w", err)
+	}
+
+	remaining := svc.contextWindow - len(resp.Tokens)
+	if remaining <= 0 {
+		return 0, fmt.Errorf("prompt exceeds context window of %d tokens (%d tokens)", svc.contextWindow, len(resp.Tokens))
+	}
+
+	return int(math.Min(float64(svc.maxTokens), float64(remaining))), nil
+}
+
+func (svc *Service) post(ctx context.Context, path string, body, v any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.url+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return generate.Unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("llama.cpp: %s:\n%s", resp.Status, raw)
+		if internal.UnavailableStatus(resp.StatusCode) {
+			err = generate.Unavailable(err)
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal response: %w\n%s", err, raw)
+	}
+
+	return nil
+}
+
+type completionRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict"`
+	Temperature float32  `json:"temperature"`
+	TopP        float32  `json:"top_p"`
+	Stop        []string `json:"stop,omitempty"`
+	Grammar     string   `json:"grammar,omitempty"`
+	CachePrompt bool     `json:"cache_prompt"`
+}
+
+type completionResponse struct {
+	Content         string `json:"content"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+}
+
+type tokenizeRequest struct {
+	Content string `json:"content"`
+}
+
+type tokenizeResponse struct {
+	Tokens []int `json:"tokens"`
+}
@@ -0,0 +1,168 @@
+package llamacpp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/llamacpp"
+)
+
+func TestService_GenerateDoc(t *testing.T) {
+	var req struct {
+		Prompt   string   `json:"prompt"`
+		NPredict int      `json:"n_predict"`
+		Stop     []string `json:"stop"`
+		Grammar  string   `json:"grammar"`
+	}
+
+	srv := newServer(t, 1000, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"content":"  Foo returns foo.\n","tokens_predicted":5,"tokens_evaluated":10}`)
+	})
+
+	svc := llamacpp.New(
+		llamacpp.URL(srv.URL+"/"),
+		llamacpp.ContextWindow(4096),
+		llamacpp.MaxTokens(256),
+		llamacpp.Grammar(`root ::= [A-Z] [^\n]*`),
+		llamacpp.Stop("\n\n"),
+	)
+
+	doc, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo.", system: "You are a technical writer."})
+	if err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("GenerateDoc() should return the trimmed documentation %q; got %q", want, doc)
+	}
+
+	if want := "You are a technical writer.\n\nDocument Foo."; req.Prompt != want {
+		t.Errorf("prompt should be the system prompt followed by the prompt %q; got %q", want, req.Prompt)
+	}
+
+	if req.NPredict != 256 {
+		t.Errorf("n_predict should be the configured maximum of %d tokens; got %d", 256, req.NPredict)
+	}
+
+	if want := `root ::= [A-Z] [^\n]*`; req.Grammar != want {
+		t.Errorf("request should contain the grammar %q; got %q", want, req.Grammar)
+	}
+
+	if len(req.Stop) != 1 || req.Stop[0] != "\n\n" {
+		t.Errorf("request should contain the stop sequences; got %q", req.Stop)
+	}
+}
+
+func TestService_GenerateDoc_remainingTokens(t *testing.T) {
+	var nPredict int
+	srv := newServer(t, 4000, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			NPredict int `json:"n_predict"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		nPredict = req.NPredict
+		fmt.Fprint(w, `{"content":"Foo returns foo."}`)
+	})
+
+	svc := llamacpp.New(llamacpp.URL(srv.URL), llamacpp.ContextWindow(4096), llamacpp.MaxTokens(512))
+
+	if _, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."}); err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if nPredict != 96 {
+		t.Errorf("n_predict should be limited to the remaining %d tokens of the context window; got %d", 96, nPredict)
+	}
+}
+
+func TestService_GenerateDoc_contextWindowExceeded(t *testing.T) {
+	var completions int
+	srv := newServer(t, 5000, func(w http.ResponseWriter, r *http.Request) {
+		completions++
+	})
+
+	svc := llamacpp.New(llamacpp.URL(srv.URL), llamacpp.ContextWindow(4096))
+
+	if _, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."}); err == nil {
+		t.Fatalf("GenerateDoc() should fail if the prompt exceeds the context window")
+	}
+
+	if completions != 0 {
+		t.Errorf("no completion should be requested if the prompt exceeds the context window; got %d requests", completions)
+	}
+}
+
+func TestService_GenerateDoc_error(t *testing.T) {
+	tests := map[string]struct {
+		status      int
+		unavailable bool
+	}{
+		"bad request": {status: http.StatusBadRequest},
+		"loading":     {status: http.StatusServiceUnavailable, unavailable: true},
+		"overloaded":  {status: http.StatusTooManyRequests, unavailable: true},
+	}
+
+	for name, tt := range tests {
+		srv := newServer(t, 10, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":{"message":"nope"}}`, tt.status)
+		})
+
+		_, err := llamacpp.New(llamacpp.URL(srv.URL)).GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."})
+		if err == nil {
+			t.Fatalf("%s: GenerateDoc() should fail", name)
+		}
+		if got := errors.Is(err, generate.ErrUnavailable); got != tt.unavailable {
+			t.Errorf("%s: errors.Is(err, generate.ErrUnavailable) should return %v; got %v", name, tt.unavailable, got)
+		}
+	}
+}
+
+func TestService_GenerateDoc_unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	_, err := llamacpp.New(llamacpp.URL(url)).GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."})
+	if !errors.Is(err, generate.ErrUnavailable) {
+		t.Fatalf("GenerateDoc() should fail with %q if the server is unreachable; got %v", generate.ErrUnavailable, err)
+	}
+}
+
+// newServer returns a fake llama.cpp server that tokenizes every prompt into
+// the given number of tokens and handles completions using the given handler.
+func newServer(t *testing.T, promptTokens int, completion http.HandlerFunc) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tokenize", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string][]int{"tokens": make([]int, promptTokens)})
+	})
+	mux.HandleFunc("/completion", completion)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+	system string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: "func:Foo", Language: "go"}}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
+
+func (ctx genCtx) SystemPrompt() string { return ctx.system }
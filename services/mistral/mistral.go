@@ -0,0 +1,339 @@
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/tiktoken-go/tokenizer"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// DefaultModel is the Mistral model used by the Service when no model is
+	// configured.
+	DefaultModel = "mistral-small-latest"
+
+	// DefaultMaxTokens is the default maximum number of tokens the Service
+	// generates for a single documentation.
+	DefaultMaxTokens = 512
+
+	// DefaultBaseURL is the base URL of the Mistral API.
+	DefaultBaseURL = "https://api.mistral.ai/v1"
+
+	// DefaultTimeout is the default maximum duration of a single generation.
+	DefaultTimeout = 30 * time.Second
+)
+
+// Service generates documentation using the chat completion API of Mistral AI.
+// Before each request, it computes the number of tokens in the prompt and
+// limits the number of generated tokens to what remains of the model's context
+// window, capped by the configured maximum.
+type Service struct {
+	apiKey        string
+	baseURL       string
+	client        *http.Client
+	model         string
+	maxTokens     int
+	contextWindow int
+	timeout       time.Duration
+	encoding      string
+	codec         tokenizer.Codec
+	log           *slog.Logger
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Model configures the Mistral model used to generate documentation.
+func Model(model string) Option {
+	return func(s *Service) {
+		s.model = model
+	}
+}
+
+// MaxTokens sets the maximum number of tokens to generate for a single
+// documentation. The limit is lowered automatically if the prompt leaves less
+// room in the model's context window.
+func MaxTokens(max int) Option {
+	return func(s *Service) {
+		s.maxTokens = max
+	}
+}
+
+// ContextWindow configures the size of the context window of the model in
+// tokens, overriding the known context window of the model.
+func ContextWindow(tokens int) Option {
+	return func(s *Service) {
+		s.contextWindow = tokens
+	}
+}
+
+// Timeout configures the maximum duration of a single generation. Defaults to
+// [DefaultTimeout].
+func Timeout(d time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = d
+	}
+}
+
+// Encoding configures the tokenizer encoding (e.g. "cl100k_base") that is used
+// to approximate the number of tokens in a prompt. By default, the encoding of
+// the model is used if known to the tokenizer, "cl100k_base" otherwise.
+func Encoding(encoding string) Option {
+	return func(s *Service) {
+		s.encoding = encoding
+	}
+}
+
+// HTTPClient configures the HTTP client used to send requests to the Mistral
+// API. By default, [http.DefaultClient] is used.
+func HTTPClient(c *http.Client) Option {
+	return func(s *Service) {
+		s.client = c
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// New returns a Service that authenticates against the Mistral API using the
+// provided API key. If no model is configured, [DefaultModel] is used.
+func New(apiKey string, opts ...Option) (*Service, error) {
+	svc := Service{
+		apiKey:    apiKey,
+		baseURL:   DefaultBaseURL,
+		maxTokens: DefaultMaxTokens,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.timeout <= 0 {
+		svc.timeout = DefaultTimeout
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.client == nil {
+		svc.client = http.DefaultClient
+	}
+
+	if svc.model == "" {
+		svc.log.Debug(fmt.Sprintf("[Mistral] No model provided. Using default model %q", DefaultModel))
+		svc.model = DefaultModel
+	}
+	svc.log.Debug(fmt.Sprintf("[Mistral] Using model %q", svc.model))
+
+	// Mistral models are unknown to the tokenizer, so falling back to the
+	// default encoding is expected and not worth a warning.
+	codec, _, err := internal.Tokenizer(svc.model, svc.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("get tokenizer for model %q: %w", svc.model, err)
+	}
+	svc.codec = codec
+
+	return &svc, nil
+}
+
+// Model returns the Mistral model that generates the documentation. It
+// implements [generate.Modeler].
+func (svc *Service) Model() string {
+	return svc.model
+}
+
+// Fingerprint returns the parameters of the requests of the Service other than
+// the model and the prompt. It implements
+// [github.com/modernice/jotbot/services/cache.Fingerprinter].
+func (svc *Service) Fingerprint() string {
+	return fmt.Sprintf("max_tokens=%d", svc.maxTokens)
+}
+
+// GenerateDoc sends the prompt of the given context to the Mistral chat
+// completion API and returns the generated documentation.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	svc.log.Debug(fmt.Sprintf("[Mistral] Generating docs for %s (%s)", ctx.Input().Identifier, ctx.Input().Language))
+
+	var messages []message
+	if system := generate.SystemPromptOf(ctx); system != "" {
+		messages = append(messages, message{Role: "system", Content: system})
+	}
+	messages = append(messages, message{Role: "user", Content: ctx.Prompt()})
+
+	maxTokens, err := svc.maxChatTokens(messages)
+	if err != nil {
+		return "", fmt.Errorf("max tokens: %w", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, svc.timeout)
+	defer cancel()
+
+	resp, err := svc.createChatCompletion(timeout, chatRequest{
+		Model:       svc.model,
+		Messages:    messages,
+		Temperature: 0.618,
+		TopP:        0.3,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("mistral: no choices returned")
+	}
+
+	svc.printUsage(resp.Usage)
+
+	choice := resp.Choices[0]
+	if choice.Message.Role != "assistant" {
+		return "", fmt.Errorf("mistral: unexpected message role in answer: %q", choice.Message.Role)
+	}
+
+	return strings.TrimSpace(choice.Message.Content), nil
+}
+
+func (svc *Service) createChatCompletion(ctx context.Context, req chatRequest) (chatResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return chatResponse{}, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return chatResponse{}, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+svc.apiKey)
+
+	httpResp, err := svc.client.Do(httpReq)
+	if err != nil {
+		return chatResponse{}, generate.Unavailable(err)
+	}
+	defer httpResp.Body.Close()
+
+	raw, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return chatResponse{}, fmt.Errorf("read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("mistral: %s:\n%s", httpResp.Status, raw)
+		if internal.UnavailableStatus(httpResp.StatusCode) {
+			err = generate.Unavailable(err)
+		}
+		return chatResponse{}, err
+	}
+
+	var resp chatResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return chatResponse{}, fmt.Errorf("unmarshal response: %w\n%s", err, raw)
+	}
+
+	return resp, nil
+}
+
+func (svc *Service) maxChatTokens(messages []message) (int, error) {
+	promptTokens, err := svc.chatTokens(messages)
+	if err != nil {
+		return 0, fmt.Errorf("compute tokens for chat messages: %w", err)
+	}
+
+	maxTokensForModel := svc.contextWindow
+	if maxTokensForModel <= 0 {
+		maxTokensForModel = MaxTokensForModel(svc.model)
+	}
+	remaining := maxTokensForModel - promptTokens
+
+	if remaining <= 0 {
+		return 0, fmt.Errorf("prompt of ~%d tokens exceeds the context window of %d tokens", promptTokens, maxTokensForModel)
+	}
+
+	maxTokens := int(math.Min(float64(svc.maxTokens), float64(maxTokensForModel)))
+	maxTokens = int(math.Min(float64(maxTokens), float64(remaining)))
+
+	return maxTokens, nil
+}
+
+// chatTokens approximates the number of prompt tokens. Mistral does not
+// publish its tokenizer for Go, so the tiktoken codec of the model (or
+// cl100k_base as a fallback) is used together with the per-message overhead
+// of the chat template.
+func (svc *Service) chatTokens(messages []message) (int, error) {
+	tokens := 3
+	for _, msg := range messages {
+		toks, _, err := svc.codec.Encode(msg.Content)
+		if err != nil {
+			return tokens, fmt.Errorf("encode message: %w", err)
+		}
+		tokens += 3 + len(toks)
+	}
+	return tokens + 1, nil
+}
+
+func (svc *Service) printUsage(u usage) {
+	svc.log.Debug("[Mistral] Usage info", "prompt", u.PromptTokens, "completion", u.CompletionTokens, "total", u.TotalTokens)
+}
+
+// MaxTokensForModel returns the size of the context window of the given
+// Mistral model. Unknown models fall back to a conservative default.
+func MaxTokensForModel(model string) int {
+	if t, ok := modelMaxTokens[model]; ok {
+		return t
+	}
+	return modelMaxTokens["default"]
+}
+
+var modelMaxTokens = map[string]int{
+	"default":               32000,
+	"open-mistral-7b":       32000,
+	"open-mixtral-8x7b":     32000,
+	"open-mixtral-8x22b":    64000,
+	"mistral-small-latest":  32000,
+	"mistral-medium-latest": 32000,
+	"mistral-large-latest":  128000,
+	"codestral-latest":      32000,
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float32   `json:"temperature,omitempty"`
+	TopP        float32   `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Usage usage `json:"usage"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
@@ -0,0 +1,186 @@
+package mistral_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/mistral"
+)
+
+func TestService_GenerateDoc(t *testing.T) {
+	var req struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		Messages  []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	var auth string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"  Foo returns foo.\n"}}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	svc := newService(t, srv, mistral.Model("open-mistral-7b"), mistral.MaxTokens(256))
+
+	doc, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo.", system: "You are a technical writer."})
+	if err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("GenerateDoc() should return %q; got %q", want, doc)
+	}
+
+	if auth != "Bearer key" {
+		t.Errorf("request should be authenticated with the API key; got Authorization %q", auth)
+	}
+
+	if req.Model != "open-mistral-7b" {
+		t.Errorf("request should use model %q; got %q", "open-mistral-7b", req.Model)
+	}
+
+	if req.MaxTokens != 256 {
+		t.Errorf("request should limit the tokens to %d; got %d", 256, req.MaxTokens)
+	}
+
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Content != "Document Foo." {
+		t.Errorf("request should contain the system prompt and the prompt; got %+v", req.Messages)
+	}
+}
+
+func TestService_GenerateDoc_contextWindowExceeded(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	svc := newService(t, srv, mistral.ContextWindow(10))
+
+	if _, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: strings.Repeat("foo ", 100)}); err == nil {
+		t.Fatalf("GenerateDoc() should fail if the prompt exceeds the context window")
+	}
+
+	if requests != 0 {
+		t.Errorf("no request should be sent if the prompt exceeds the context window; got %d requests", requests)
+	}
+}
+
+func TestService_GenerateDoc_error(t *testing.T) {
+	tests := map[int]bool{
+		http.StatusBadRequest:         false,
+		http.StatusUnauthorized:       false,
+		http.StatusTooManyRequests:    true,
+		http.StatusServiceUnavailable: true,
+	}
+
+	for status, unavailable := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message":"nope"}`, status)
+		}))
+
+		svc := newService(t, srv)
+
+		_, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."})
+		srv.Close()
+
+		if err == nil {
+			t.Fatalf("GenerateDoc() should fail for status %d", status)
+		}
+		if got := errors.Is(err, generate.ErrUnavailable); got != unavailable {
+			t.Errorf("status %d: errors.Is(err, generate.ErrUnavailable) should return %v; got %v", status, unavailable, got)
+		}
+	}
+}
+
+func TestService_GenerateDoc_noChoices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[]}`)
+	}))
+	defer srv.Close()
+
+	if _, err := newService(t, srv).GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."}); err == nil {
+		t.Fatalf("GenerateDoc() should fail if no choices are returned")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	svc := newService(t, srv, mistral.Timeout(10*time.Millisecond))
+
+	start := time.Now()
+	if _, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."}); err == nil {
+		t.Fatalf("GenerateDoc() should fail if the timeout is exceeded")
+	}
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("GenerateDoc() should be canceled after the timeout; took %s", elapsed)
+	}
+}
+
+func newService(t *testing.T, srv *httptest.Server, opts ...mistral.Option) *mistral.Service {
+	t.Helper()
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse server URL: %v", err)
+	}
+
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	svc, err := mistral.New("key", append([]mistral.Option{mistral.HTTPClient(client)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return svc
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return fn(req) }
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+	system string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: "func:Foo", Language: "go"}}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
+
+func (ctx genCtx) SystemPrompt() string { return ctx.system }
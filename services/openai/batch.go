@@ -0,0 +1,392 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultPollInterval is the default interval in which a [*Batch] polls the
+	// status of a submitted batch.
+	DefaultPollInterval = 30 * time.Second
+
+	defaultBaseURL = "https://api.openai.com/v1"
+)
+
+// Batch generates documentation using the OpenAI Batch API, which processes
+// requests asynchronously at roughly half the cost of the regular API.
+//
+// A Batch is used in two phases. In the first phase, GenerateDoc only collects
+// the requests of all documentations to generate and returns empty
+// documentations. Run then submits the collected requests as a single batch
+// and waits until OpenAI has processed it. In the second phase, GenerateDoc
+// returns the results of the batch, so that the same inputs can be passed to
+// the generator again to feed the results into the patch pipeline.
+type Batch struct {
+	svc      *Service
+	interval time.Duration
+
+	mux      sync.Mutex
+	requests []batchRequest
+	results  map[string]string
+	failed   map[string]string
+	done     bool
+}
+
+// BatchOption configures a [*Batch].
+type BatchOption func(*Batch)
+
+// PollInterval configures the interval in which a [*Batch] polls the status of
+// a submitted batch. Defaults to [DefaultPollInterval].
+func PollInterval(d time.Duration) BatchOption {
+	return func(b *Batch) {
+		b.interval = d
+	}
+}
+
+// Batch returns a [*Batch] that submits its requests using the configuration
+// of the Service. Batches only support chat models.
+func (svc *Service) Batch(opts ...BatchOption) *Batch {
+	b := &Batch{
+		svc:      svc,
+		interval: DefaultPollInterval,
+		results:  make(map[string]string),
+		failed:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.interval <= 0 {
+		b.interval = DefaultPollInterval
+	}
+	return b
+}
+
+// StructuredOutput reports whether the documentations of the batch are decoded
+// from structured output. It implements [generate.Structured].
+func (b *Batch) StructuredOutput() bool {
+	return b.svc.jsonMode
+}
+
+// Model returns the model of the batch. It implements [generate.Modeler].
+func (b *Batch) Model() string {
+	return b.svc.model
+}
+
+// Deferred reports whether the batch has not been run yet, so that
+// [*Batch.GenerateDoc] only collects requests. It implements
+// [generate.Deferred].
+func (b *Batch) Deferred() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return !b.done
+}
+
+// GenerateDoc collects the request for the given context if the batch has not
+// been run yet, and returns an empty documentation. Once [*Batch.Run] has
+// completed, it returns the documentation that was generated by the batch.
+// If the request of the context failed within the batch, GenerateDoc returns
+// an error that matches [generate.ErrSkipped].
+func (b *Batch) GenerateDoc(ctx generate.Context) (string, error) {
+	id := batchRequestID(ctx.Input())
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.done {
+		if reason, ok := b.failed[id]; ok {
+			return "", fmt.Errorf("openai: batch request %s failed: %s: %w", id, reason, generate.ErrSkipped)
+		}
+		text, ok := b.results[id]
+		if !ok {
+			return "", fmt.Errorf("openai: no batch result for %s", id)
+		}
+		return text, nil
+	}
+
+	if !isChatModel(b.svc.model) {
+		return "", fmt.Errorf("openai: batch mode requires a chat model, got %q", b.svc.model)
+	}
+
+	req, err := b.svc.makeChatRequest(b.svc.makeBaseRequest(ctx), ctx)
+	if err != nil {
+		return "", err
+	}
+
+	b.requests = append(b.requests, batchRequest{
+		CustomID: id,
+		Method:   http.MethodPost,
+		URL:      "/v1/chat/completions",
+		Body:     req,
+	})
+
+	return "", nil
+}
+
+// Run submits the collected requests as a single batch, waits until the batch
+// has been processed, and stores its results. Run blocks until the batch is
+// completed, failed, expired or cancelled, or until ctx is canceled. Once Run
+// has been called, [*Batch.GenerateDoc] no longer collects requests, and it
+// only returns results after Run has completed.
+func (b *Batch) Run(ctx context.Context) error {
+	b.mux.Lock()
+	if b.done {
+		b.mux.Unlock()
+		return fmt.Errorf("openai: batch has already been run")
+	}
+	b.done = true
+	requests := b.requests
+	b.mux.Unlock()
+
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, req := range requests {
+		if err := enc.Encode(req); err != nil {
+			return fmt.Errorf("encode batch request: %w", err)
+		}
+	}
+
+	file, err := b.svc.client.CreateFileBytes(ctx, openai.FileBytesRequest{
+		Name:    "jotbot-batch.jsonl",
+		Bytes:   input.Bytes(),
+		Purpose: openai.PurposeType("batch"),
+	})
+	if err != nil {
+		return fmt.Errorf("upload batch input: %w", err)
+	}
+
+	b.svc.log.Info(fmt.Sprintf("[OpenAI] Submitting batch of %d requests ...", len(requests)))
+
+	var status batchStatus
+	if err := b.request(ctx, http.MethodPost, "/batches", batchCreateRequest{
+		InputFileID:      file.ID,
+		Endpoint:         "/v1/chat/completions",
+		CompletionWindow: "24h",
+	}, &status); err != nil {
+		return fmt.Errorf("create batch: %w", err)
+	}
+
+	if status, err = b.wait(ctx, status); err != nil {
+		return err
+	}
+
+	if status.OutputFileID == "" && status.ErrorFileID == "" {
+		return fmt.Errorf("openai: batch %s has no output file", status.ID)
+	}
+
+	results := make(map[string]string)
+	failed := make(map[string]string)
+
+	// Successful requests are written to the output file, failed requests to
+	// the error file.
+	for _, fileID := range []string{status.OutputFileID, status.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := b.download(ctx, fileID, results, failed); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		b.svc.log.Warn(fmt.Sprintf("[OpenAI] %d of %d batch requests failed. Their symbols are skipped.", len(failed), len(requests)))
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	for id, text := range results {
+		b.results[id] = text
+	}
+	for id, reason := range failed {
+		b.failed[id] = reason
+	}
+
+	return nil
+}
+
+func (b *Batch) wait(ctx context.Context, status batchStatus) (batchStatus, error) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.svc.log.Debug(fmt.Sprintf("[OpenAI] Batch %s is %s", status.ID, status.Status), "completed", status.RequestCounts.Completed, "failed", status.RequestCounts.Failed, "total", status.RequestCounts.Total)
+
+		switch status.Status {
+		case "completed":
+			return status, nil
+		case "failed", "expired", "cancelled":
+			return status, fmt.Errorf("openai: batch %s %s", status.ID, status.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := b.request(ctx, http.MethodGet, "/batches/"+status.ID, nil, &status); err != nil {
+			return status, fmt.Errorf("get batch status: %w", err)
+		}
+	}
+}
+
+func (b *Batch) download(ctx context.Context, fileID string, results, failed map[string]string) error {
+	output, err := b.svc.client.GetFileContent(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("download batch output: %w", err)
+	}
+	defer output.Close()
+
+	if err := b.readResults(output, results, failed); err != nil {
+		return fmt.Errorf("read batch output: %w", err)
+	}
+
+	return nil
+}
+
+func (b *Batch) readResults(r io.Reader, results, failed map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var res batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			return fmt.Errorf("unmarshal batch result: %w", err)
+		}
+
+		if res.Error != nil {
+			failed[res.CustomID] = res.Error.Message
+			continue
+		}
+
+		if code := res.Response.StatusCode; code != 0 && code != http.StatusOK {
+			failed[res.CustomID] = fmt.Sprintf("status %d", code)
+			continue
+		}
+
+		if len(res.Response.Body.Choices) == 0 {
+			failed[res.CustomID] = "no choices returned"
+			continue
+		}
+
+		b.svc.addUsage(res.Response.Body.Usage, true)
+		b.svc.addFingerprint(res.Response.Body.SystemFingerprint, true)
+
+		result := result{text: res.Response.Body.Choices[0].Message.Content}
+		if b.svc.jsonMode {
+			if err := result.decodeJSON(""); err != nil {
+				failed[res.CustomID] = err.Error()
+				continue
+			}
+		}
+		result.normalize()
+
+		results[res.CustomID] = result.text
+	}
+
+	return scanner.Err()
+}
+
+func (b *Batch) request(ctx context.Context, method, path string, body, v any) error {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		r = bytes.NewReader(raw)
+	}
+
+	baseURL := b.svc.baseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(baseURL, "/")+path, r)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.svc.apiKey)
+
+	resp, err := b.svc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("openai: %s:\n%s", resp.Status, raw)
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal response: %w\n%s", err, raw)
+	}
+
+	return nil
+}
+
+func batchRequestID(input generate.PromptInput) string {
+	return fmt.Sprintf("%s@%s", input.File, input.Identifier)
+}
+
+type batchRequest struct {
+	CustomID string                       `json:"custom_id"`
+	Method   string                       `json:"method"`
+	URL      string                       `json:"url"`
+	Body     openai.ChatCompletionRequest `json:"body"`
+}
+
+type batchCreateRequest struct {
+	InputFileID      string `json:"input_file_id"`
+	Endpoint         string `json:"endpoint"`
+	CompletionWindow string `json:"completion_window"`
+}
+
+type batchStatus struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+type batchResult struct {
+	CustomID string `json:"custom_id"`
+	Response struct {
+		StatusCode int                           `json:"status_code"`
+		Body       openai.ChatCompletionResponse `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
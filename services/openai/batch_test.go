@@ -0,0 +1,254 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/langs/golang"
+	"github.com/modernice/jotbot/services/openai"
+)
+
+const batchCode = "package foo\n\nfunc Foo() string { return \"foo\" }\n\nfunc Bar() string { return \"bar\" }\n"
+
+func TestBatch(t *testing.T) {
+	srv := newBatchServer(t, "in_progress", "completed")
+	srv.output = []string{batchLine("foo.go@func:Foo", "Returns foo.")}
+	srv.errors = []string{`{"custom_id":"foo.go@func:Bar","response":{"status_code":500,"body":{}}}`}
+
+	batch := newBatch(t, srv)
+	g := generate.New(batch, generate.WithLanguage("go", golang.Must()))
+
+	// The collected documentations are placeholders that must not be
+	// regenerated or conformed.
+	for _, identifier := range []string{"func:Foo", "func:Bar"} {
+		doc, err := g.Generate(context.Background(), batchInput(identifier))
+		if err != nil {
+			t.Fatalf("Generate(%q) failed while collecting requests: %v", identifier, err)
+		}
+		if doc != "" {
+			t.Fatalf("Generate(%q) should return an empty documentation while collecting requests; got %q", identifier, doc)
+		}
+	}
+
+	if err := batch.Run(context.Background()); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+
+	if got := srv.input(); len(got) != 2 {
+		t.Fatalf("batch should contain 2 requests; got %d\n%s", len(got), strings.Join(got, "\n"))
+	}
+
+	if got := srv.header.Get("OpenAI-Project"); got != "proj" {
+		t.Errorf("batch requests should be sent with the configured headers; got OpenAI-Project %q", got)
+	}
+
+	if n := srv.polls(); n != 1 {
+		t.Errorf("batch status should be polled once; got %d polls", n)
+	}
+
+	doc, err := g.Generate(context.Background(), batchInput("func:Foo"))
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("Generate() should return the conformed result of the batch %q; got %q", want, doc)
+	}
+
+	if _, err := g.Generate(context.Background(), batchInput("func:Bar")); !errors.Is(err, generate.ErrSkipped) {
+		t.Errorf("Generate() should return %q for failed batch requests; got %v", generate.ErrSkipped, err)
+	}
+}
+
+func TestBatch_Run_failed(t *testing.T) {
+	srv := newBatchServer(t, "in_progress", "failed")
+
+	batch := newBatch(t, srv)
+	g := generate.New(batch, generate.WithLanguage("go", golang.Must()))
+
+	if _, err := g.Generate(context.Background(), batchInput("func:Foo")); err != nil {
+		t.Fatalf("Generate() failed while collecting requests: %v", err)
+	}
+
+	err := batch.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("Run() should fail if the batch fails; got %v", err)
+	}
+}
+
+func TestBatch_Run_invalidOutput(t *testing.T) {
+	srv := newBatchServer(t, "completed")
+	srv.output = []string{"{"}
+
+	batch := newBatch(t, srv)
+	g := generate.New(batch, generate.WithLanguage("go", golang.Must()))
+
+	if _, err := g.Generate(context.Background(), batchInput("func:Foo")); err != nil {
+		t.Fatalf("Generate() failed while collecting requests: %v", err)
+	}
+
+	if err := batch.Run(context.Background()); err == nil {
+		t.Fatalf("Run() should fail if the batch output is invalid")
+	}
+}
+
+func TestBatch_Run_unlocked(t *testing.T) {
+	srv := newBatchServer(t, "in_progress")
+
+	batch := newBatch(t, srv)
+	g := generate.New(batch, generate.WithLanguage("go", golang.Must()))
+
+	if _, err := g.Generate(context.Background(), batchInput("func:Foo")); err != nil {
+		t.Fatalf("Generate() failed while collecting requests: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() { errc <- batch.Run(ctx) }()
+
+	for srv.polls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	deferred := make(chan bool, 1)
+	go func() { deferred <- batch.Deferred() }()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatalf("Deferred() should not block while the batch is running")
+	case d := <-deferred:
+		if d {
+			t.Errorf("Deferred() should return false once the batch is running")
+		}
+	}
+
+	cancel()
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() should return %q; got %v", context.Canceled, err)
+	}
+}
+
+func newBatch(t *testing.T, srv *batchServer) *openai.Batch {
+	t.Helper()
+
+	svc, err := openai.New("key", openai.BaseURL(srv.URL), openai.Model("gpt-4o"), openai.Project("proj"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	return svc.Batch(openai.PollInterval(time.Millisecond))
+}
+
+func batchInput(identifier string) generate.PromptInput {
+	return generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte(batchCode),
+			Language:   "go",
+			Identifier: identifier,
+		},
+	}
+}
+
+func batchLine(id, content string) string {
+	return fmt.Sprintf(`{"custom_id":%q,"response":{"status_code":200,"body":{"choices":[{"message":{"role":"assistant","content":%q}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}}}`, id, content)
+}
+
+// batchServer is a fake of the OpenAI Files and Batch APIs. It reports the
+// given statuses of the batch, one per request, and the configured output and
+// error files.
+type batchServer struct {
+	*httptest.Server
+
+	output []string
+	errors []string
+
+	mux      sync.Mutex
+	statuses []string
+	requests []string
+	header   http.Header
+	gets     int
+}
+
+func newBatchServer(t *testing.T, statuses ...string) *batchServer {
+	srv := &batchServer{statuses: statuses}
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.handle))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *batchServer) handle(w http.ResponseWriter, r *http.Request) {
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/files":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		b, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		srv.requests = strings.Split(strings.TrimSpace(string(b)), "\n")
+
+		json.NewEncoder(w).Encode(map[string]any{"id": "file-input", "object": "file"})
+	case r.Method == http.MethodPost && r.URL.Path == "/batches":
+		srv.header = r.Header.Clone()
+		json.NewEncoder(w).Encode(srv.status())
+	case r.Method == http.MethodGet && r.URL.Path == "/batches/batch-1":
+		srv.gets++
+		json.NewEncoder(w).Encode(srv.status())
+	case r.Method == http.MethodGet && r.URL.Path == "/files/file-output/content":
+		fmt.Fprint(w, strings.Join(srv.output, "\n"))
+	case r.Method == http.MethodGet && r.URL.Path == "/files/file-errors/content":
+		fmt.Fprint(w, strings.Join(srv.errors, "\n"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (srv *batchServer) status() map[string]any {
+	status := srv.statuses[0]
+	if len(srv.statuses) > 1 {
+		srv.statuses = srv.statuses[1:]
+	}
+
+	res := map[string]any{"id": "batch-1", "status": status}
+	if status == "completed" {
+		res["output_file_id"] = "file-output"
+		if len(srv.errors) > 0 {
+			res["error_file_id"] = "file-errors"
+		}
+	}
+	return res
+}
+
+func (srv *batchServer) input() []string {
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+	return srv.requests
+}
+
+func (srv *batchServer) polls() int {
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+	return srv.gets
+}
@@ -0,0 +1,104 @@
+package openai
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultContextWindow is the context window that is assumed for models that
+// are unknown to the model registry and for which no context window is
+// configured using [ContextWindow].
+const DefaultContextWindow = 8192
+
+var (
+	modelsMux sync.RWMutex
+
+	// contextWindows are the context windows of known models in tokens. The
+	// context windows of model snapshots (e.g. "gpt-4o-2024-08-06") are looked
+	// up by the longest matching prefix.
+	contextWindows = map[string]int{
+		"gpt-5":                  400000,
+		"gpt-4.1":                1047576,
+		"gpt-4o":                 128000,
+		"chatgpt-4o":             128000,
+		"gpt-4-turbo":            128000,
+		"gpt-4-1106":             128000,
+		"gpt-4-0125":             128000,
+		"gpt-4-vision":           128000,
+		"gpt-4-32k":              32768,
+		"gpt-4":                  8192,
+		"gpt-3.5-turbo":          16385,
+		"gpt-3.5-turbo-0301":     4096,
+		"gpt-3.5-turbo-0613":     4096,
+		"gpt-3.5-turbo-16k":      16385,
+		"gpt-3.5-turbo-instruct": 4096,
+		"o1":                     200000,
+		"o1-mini":                128000,
+		"o1-preview":             128000,
+		"o3":                     200000,
+		"o3-mini":                200000,
+		"o4-mini":                200000,
+		"davinci-002":            16384,
+		"babbage-002":            16384,
+	}
+
+	// reasoningModels are the prefixes of reasoning models, which reject
+	// sampling parameters and limit their output using max_completion_tokens.
+	reasoningModels = []string{"o1", "o3", "o4", "gpt-5"}
+)
+
+// RegisterModel registers the context window of a model in tokens, so that
+// generations with the model are limited correctly. Registered models also
+// match their snapshots, e.g. "my-model" matches "my-model-2025-01-01".
+// RegisterModel can be used to add new or fine-tuned models, or to override
+// the context window of known models.
+func RegisterModel(model string, contextWindow int) {
+	modelsMux.Lock()
+	defer modelsMux.Unlock()
+	contextWindows[model] = contextWindow
+}
+
+// LookupContextWindow returns the context window of the given model in tokens.
+// LookupContextWindow returns false if the model is unknown.
+func LookupContextWindow(model string) (int, bool) {
+	modelsMux.RLock()
+	defer modelsMux.RUnlock()
+
+	var (
+		match  string
+		window int
+	)
+	for prefix, w := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			match, window = prefix, w
+		}
+	}
+
+	return window, match != ""
+}
+
+// MaxTokensForModel retrieves the maximum number of tokens allowed for a given
+// model. If the specified model is unknown to the model registry, it returns
+// [DefaultContextWindow]. This limit is crucial for ensuring that token
+// generation stays within the bounds set by the model's capabilities.
+func MaxTokensForModel(model string) int {
+	if w, ok := LookupContextWindow(model); ok {
+		return w
+	}
+	return DefaultContextWindow
+}
+
+// isReasoningModel reports whether the given model is a reasoning model of the
+// o1/o3 family. "gpt-5-chat" models are not reasoning models, although the
+// other gpt-5 models are.
+func isReasoningModel(model string) bool {
+	if strings.HasPrefix(model, "gpt-5-chat") {
+		return false
+	}
+	for _, prefix := range reasoningModels {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
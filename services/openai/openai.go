@@ -2,8 +2,13 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,6 +16,8 @@ import (
 	"github.com/modernice/jotbot/internal"
 	"github.com/sashabaranov/go-openai"
 	"github.com/tiktoken-go/tokenizer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
 )
 
@@ -27,6 +34,32 @@ const (
 	// ensures that the output from token generation does not exceed a predefined
 	// length, providing a balance between performance and output detail.
 	DefaultMaxTokens = 512
+
+	// DefaultTimeout is the maximum duration of a single generation if no timeout
+	// is configured using [Timeout].
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultTemperature is the sampling temperature used by the Service if no
+	// temperature is configured using [Temperature].
+	DefaultTemperature = 0.618
+
+	// DefaultTopP is the nucleus sampling probability used by the Service if
+	// none is configured using [TopP].
+	DefaultTopP = 0.3
+
+	// DefaultPresencePenalty is the presence penalty used by the Service if no
+	// penalties are configured using [Penalties].
+	DefaultPresencePenalty = 0.2
+
+	// DefaultFrequencyPenalty is the frequency penalty used by the Service if no
+	// penalties are configured using [Penalties].
+	DefaultFrequencyPenalty = 0.3
+
+	// DefaultReasoningTokens is the number of tokens that reasoning models (o1,
+	// o3, …) may spend on reasoning in addition to [MaxTokens]. Reasoning
+	// tokens are not part of the output but count toward the completion
+	// tokens of a request.
+	DefaultReasoningTokens = 4096
 )
 
 // Service orchestrates the generation of textual content using a specified
@@ -39,11 +72,26 @@ const (
 // adheres to specified constraints such as token limits, while also handling
 // error scenarios and logging usage information.
 type Service struct {
-	client    *openai.Client
-	model     string
-	maxTokens int
-	codec     tokenizer.Codec
-	log       *slog.Logger
+	client        *openai.Client
+	httpClient    *http.Client
+	apiKey        string
+	baseURL       string
+	header        http.Header
+	model         string
+	maxTokens     int
+	contextWindow int
+	timeout       time.Duration
+	retry         retryConfig
+	sampling      sampling
+	seed          *int
+	stream        bool
+	jsonMode      bool
+	progress      func(generate.PromptInput, string)
+	usage         *UsageTracker
+	maxCost       float64
+	encoding      string
+	codec         tokenizer.Codec
+	log           *slog.Logger
 }
 
 // Option represents a configuration setting that can be applied to customize
@@ -74,6 +122,52 @@ func Client(c *openai.Client) Option {
 	}
 }
 
+// BaseURL configures the base URL of the API that the Service sends its
+// requests to. It allows the Service to target OpenAI-compatible endpoints,
+// such as LM Studio, vLLM or OpenRouter, instead of the official OpenAI API.
+// BaseURL has no effect if a custom client is provided using [Client].
+func BaseURL(url string) Option {
+	return func(s *Service) {
+		s.baseURL = url
+	}
+}
+
+// Organization configures the OpenAI organization that requests are billed to,
+// using the "OpenAI-Organization" header. Organization has no effect if a
+// custom client is provided using [Client].
+func Organization(id string) Option {
+	return withHeader("OpenAI-Organization", id)
+}
+
+// Project configures the OpenAI project that requests are billed to, using the
+// "OpenAI-Project" header. Project has no effect if a custom client is
+// provided using [Client].
+func Project(id string) Option {
+	return withHeader("OpenAI-Project", id)
+}
+
+func withHeader(key, value string) Option {
+	return func(s *Service) {
+		if value == "" {
+			return
+		}
+		if s.header == nil {
+			s.header = make(http.Header)
+		}
+		s.header.Set(key, value)
+	}
+}
+
+// Encoding configures the tokenizer encoding (e.g. "cl100k_base") that is used
+// to count tokens, instead of the encoding of the model. This is useful for
+// custom or fine-tuned models that are unknown to the tokenizer, which would
+// otherwise fall back to the "cl100k_base" encoding.
+func Encoding(encoding string) Option {
+	return func(s *Service) {
+		s.encoding = encoding
+	}
+}
+
 // MaxTokens sets the maximum number of tokens to use for generating content
 // with the service. It configures the service instance by applying a limit on
 // the token count for output generation, which can affect the verbosity and
@@ -88,6 +182,142 @@ func MaxTokens(max int) Option {
 	}
 }
 
+// ContextWindow configures the size of the context window of the model in
+// tokens, overriding the context window that is known to the model registry.
+// This is required for models that are unknown to the registry, such as models
+// served by OpenAI-compatible APIs. See [RegisterModel].
+func ContextWindow(tokens int) Option {
+	return func(s *Service) {
+		s.contextWindow = tokens
+	}
+}
+
+// MaxCost limits the estimated cost of the generations of the Service to the
+// given amount in USD. The spend is tracked by the [*UsageTracker] of the
+// Service, so that the limit applies to all Services that share a tracker
+// through [TrackUsage]. Once the limit is reached, the Service stops sending
+// new requests and fails with [generate.ErrBudgetExceeded], while requests
+// that are already in flight are finished. Because the cost is estimated from
+// the known prices of OpenAI models, [New] fails if a limit is configured for a
+// model whose price is unknown.
+func MaxCost(usd float64) Option {
+	return func(s *Service) {
+		s.maxCost = usd
+	}
+}
+
+// Timeout configures the maximum duration of a single generation. Large
+// prompts on slower models may need more than [DefaultTimeout] to complete.
+func Timeout(d time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = d
+	}
+}
+
+// Temperature configures the sampling temperature of the model. Lower values
+// make the generated documentation more deterministic, higher values make it
+// more creative. Defaults to [DefaultTemperature]. Reasoning models do not
+// support sampling parameters, so Temperature, [TopP] and [Penalties] have no
+// effect on them.
+func Temperature(t float32) Option {
+	return func(s *Service) {
+		s.sampling.temperature = t
+	}
+}
+
+// TopP configures the nucleus sampling probability of the model, so that only
+// the tokens within the top p probability mass are considered. Defaults to
+// [DefaultTopP].
+func TopP(p float32) Option {
+	return func(s *Service) {
+		s.sampling.topP = p
+	}
+}
+
+// Penalties configures the presence and frequency penalties of the model,
+// which discourage the model from repeating itself. Defaults to
+// [DefaultPresencePenalty] and [DefaultFrequencyPenalty].
+func Penalties(presence, frequency float32) Option {
+	return func(s *Service) {
+		s.sampling.presencePenalty = presence
+		s.sampling.frequencyPenalty = frequency
+	}
+}
+
+// Seed configures the seed of the generations. With a seed, the API makes a
+// best effort to sample deterministically, so that repeated runs with the same
+// prompts and parameters return the same documentation. Determinism is only
+// guaranteed while the system fingerprint of the model stays the same, which
+// is recorded in the [Usage] of the Service.
+func Seed(seed int) Option {
+	return func(s *Service) {
+		s.seed = &seed
+	}
+}
+
+// Stream configures whether the Service uses streaming chat completions. When
+// streaming, the partial output of a generation is reported to the callback
+// that is configured using [Progress] while the model is still generating.
+// Streaming is only supported by chat models.
+func Stream(stream bool) Option {
+	return func(s *Service) {
+		s.stream = stream
+	}
+}
+
+// JSONMode configures whether the Service requests structured output from chat
+// models. In JSON mode, the model responds with a JSON object of the form
+// {"identifier": "...", "doc": "..."}, from which the documentation is taken
+// as-is. This avoids the heuristic trimming of quotes that is necessary for
+// plain text responses. JSON mode is only supported by chat models.
+func JSONMode(enabled bool) Option {
+	return func(s *Service) {
+		s.jsonMode = enabled
+	}
+}
+
+// StructuredOutput reports whether the Service returns documentation that was
+// decoded from structured output, which implements [generate.Structured].
+func (svc *Service) StructuredOutput() bool {
+	return svc.jsonMode
+}
+
+// Model returns the model that generates the documentation. It implements
+// [generate.Modeler].
+func (svc *Service) Model() string {
+	return svc.model
+}
+
+// Fingerprint returns the parameters of the requests of the Service other than
+// the model and the prompt. It implements
+// [github.com/modernice/jotbot/services/cache.Fingerprinter].
+func (svc *Service) Fingerprint() string {
+	seed := "none"
+	if svc.seed != nil {
+		seed = strconv.Itoa(*svc.seed)
+	}
+	return fmt.Sprintf(
+		"max_tokens=%d temperature=%g top_p=%g presence_penalty=%g frequency_penalty=%g seed=%s json=%t",
+		svc.maxTokens,
+		svc.sampling.temperature,
+		svc.sampling.topP,
+		svc.sampling.presencePenalty,
+		svc.sampling.frequencyPenalty,
+		seed,
+		svc.jsonMode,
+	)
+}
+
+// Progress configures a callback that receives the partial output of
+// streaming generations. The callback is called with the input of the
+// generation and the text that has been generated so far, each time a new chunk
+// is received. It may be called concurrently for different inputs.
+func Progress(fn func(input generate.PromptInput, partial string)) Option {
+	return func(s *Service) {
+		s.progress = fn
+	}
+}
+
 // WithLogger configures a logging handler for the service, allowing the service
 // to log its activities. It accepts a logging handler and returns an option
 // that can be passed to the service constructor.
@@ -106,12 +336,51 @@ func WithLogger(h slog.Handler) Option {
 // that an appropriate tokenizer and a non-nil logger are set up for the service
 // before returning.
 func New(apiKey string, opts ...Option) (*Service, error) {
-	svc := Service{maxTokens: DefaultMaxTokens}
+	svc := Service{
+		apiKey:    apiKey,
+		maxTokens: DefaultMaxTokens,
+		timeout:   DefaultTimeout,
+		retry: retryConfig{
+			attempts:   DefaultMaxRetries,
+			backoff:    DefaultBackoff,
+			maxBackoff: DefaultMaxBackoff,
+			jitter:     DefaultJitter,
+		},
+		sampling: sampling{
+			temperature:      DefaultTemperature,
+			topP:             DefaultTopP,
+			presencePenalty:  DefaultPresencePenalty,
+			frequencyPenalty: DefaultFrequencyPenalty,
+		},
+	}
 	for _, opt := range opts {
 		opt(&svc)
 	}
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.usage == nil {
+		svc.usage = NewUsageTracker()
+	}
+
+	if svc.maxCost > 0 {
+		svc.usage.SetBudget(svc.maxCost)
+	}
+
+	svc.httpClient = &http.Client{Transport: retryAfterTransport{base: headerTransport{header: svc.header}}}
+
 	if svc.client == nil {
-		svc.client = openai.NewClient(apiKey)
+		cfg := openai.DefaultConfig(apiKey)
+		if svc.baseURL != "" {
+			cfg.BaseURL = svc.baseURL
+		}
+		cfg.HTTPClient = svc.httpClient
+		svc.client = openai.NewClientWithConfig(cfg)
+	}
+
+	if svc.timeout <= 0 {
+		svc.timeout = DefaultTimeout
 	}
 
 	if svc.model == "" {
@@ -120,14 +389,26 @@ func New(apiKey string, opts ...Option) (*Service, error) {
 	}
 	svc.log.Debug(fmt.Sprintf("[OpenAI] Using model %q", svc.model))
 
-	codec, err := internal.OpenAITokenizer(svc.model)
+	if _, ok := priceOf(svc.model); svc.maxCost > 0 && !ok {
+		return nil, fmt.Errorf("cannot enforce max cost of $%.2f: price of model %q is unknown", svc.maxCost, svc.model)
+	}
+
+	codec, fallback, err := internal.Tokenizer(svc.model, svc.encoding)
 	if err != nil {
 		return nil, fmt.Errorf("get tokenizer for model %q: %w", svc.model, err)
 	}
+	if fallback {
+		svc.log.Warn(fmt.Sprintf("[OpenAI] Model %q is unknown to the tokenizer. Falling back to %q encoding, so token counts may be inaccurate. Configure the encoding explicitly to silence this warning.", svc.model, internal.DefaultEncoding))
+	}
 	svc.codec = codec
 
-	if svc.log == nil {
-		svc.log = internal.NopLogger()
+	if svc.contextWindow <= 0 {
+		window, ok := LookupContextWindow(svc.model)
+		if !ok {
+			svc.log.Warn(fmt.Sprintf("[OpenAI] Context window of model %q is unknown. Assuming %d tokens. Configure the context window explicitly to silence this warning.", svc.model, DefaultContextWindow))
+			window = DefaultContextWindow
+		}
+		svc.contextWindow = window
 	}
 
 	return &svc, nil
@@ -138,21 +419,34 @@ func New(apiKey string, opts ...Option) (*Service, error) {
 // context, and invokes the appropriate model to generate content. The function
 // returns the generated text or an error if the generation process fails. The
 // operation respects a timeout and ensures that the size of the generated
-// content does not exceed predefined token limits.
+// content does not exceed predefined token limits. Generations that fail
+// because of rate limits, server errors or timeouts are retried with an
+// exponential backoff, as configured by [MaxRetries] and [Backoff].
 func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
 	svc.log.Debug(fmt.Sprintf("[OpenAI] Generating docs for %s (%s)", ctx.Input().Identifier, ctx.Input().Language))
 
+	if svc.usage.BudgetExceeded() {
+		return "", fmt.Errorf("openai: %w (~$%.4f)", generate.ErrBudgetExceeded, svc.usage.Cost())
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("model", svc.model))
+
 	req := svc.makeBaseRequest(ctx)
 
 	generate := svc.useModel(req.Model)
 
-	timeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	result, err := generate(timeout, req)
+	result, err := svc.withRetry(ctx, ctx.Input().Identifier, func(attempt context.Context) (result, error) {
+		return generate(attempt, req, ctx)
+	})
 	if err != nil {
 		return "", err
 	}
+
+	if svc.jsonMode {
+		if err := result.decodeJSON(ctx.Input().Identifier); err != nil {
+			return "", err
+		}
+	}
 	result.normalize()
 
 	return result.text, nil
@@ -161,24 +455,54 @@ func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
 func (svc *Service) makeBaseRequest(ctx generate.Context) openai.CompletionRequest {
 	req := openai.CompletionRequest{
 		Model:            string(svc.model),
-		Temperature:      0.618,
-		TopP:             0.3,
-		PresencePenalty:  0.2,
-		FrequencyPenalty: 0.3,
+		Temperature:      nonZero(svc.sampling.temperature),
+		TopP:             nonZero(svc.sampling.topP),
+		PresencePenalty:  svc.sampling.presencePenalty,
+		FrequencyPenalty: svc.sampling.frequencyPenalty,
+		Seed:             svc.seed,
 		Prompt:           ctx.Prompt(),
 	}
 
 	return req
 }
 
-func (svc *Service) useModel(model string) func(context.Context, openai.CompletionRequest) (result, error) {
-	if isChatModel(model) {
+type sampling struct {
+	temperature      float32
+	topP             float32
+	presencePenalty  float32
+	frequencyPenalty float32
+}
+
+// nonZero returns the smallest positive float32 if v is zero. The OpenAI client
+// omits zero values from requests, which would make the API fall back to its
+// own defaults instead of the requested zero.
+func nonZero(v float32) float32 {
+	if v == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return v
+}
+
+func (svc *Service) useModel(model string) func(context.Context, openai.CompletionRequest, generate.Context) (result, error) {
+	if isChatModel(model) && svc.stream {
+		return svc.createWithChatStream
+	}
+
+	// OpenAI-compatible APIs name their models freely, so chat completions are
+	// used whenever a custom base URL is configured.
+	if isChatModel(model) || svc.baseURL != "" {
 		return svc.createWithChat
 	}
 	return svc.createWithGPT
 }
 
-func (svc *Service) createWithGPT(ctx context.Context, req openai.CompletionRequest) (result, error) {
+func (svc *Service) createWithGPT(ctx context.Context, req openai.CompletionRequest, gen generate.Context) (result, error) {
+	// Completion models have no system messages, so the system prompt is
+	// prepended to the prompt.
+	if system := generate.SystemPromptOf(gen); system != "" {
+		req.Prompt = system + "\n\n" + req.Prompt.(string)
+	}
+
 	maxTokens, err := svc.maxGPTTokens(req.Prompt.(string))
 	if err != nil {
 		return result{}, fmt.Errorf("max tokens: %w", err)
@@ -194,7 +518,9 @@ func (svc *Service) createWithGPT(ctx context.Context, req openai.CompletionRequ
 		return result{}, fmt.Errorf("openai: no choices returned")
 	}
 
-	svc.printUsage(resp.Usage)
+	if resp.Usage != nil {
+		svc.addUsage(*resp.Usage, false)
+	}
 
 	choice := resp.Choices[0]
 
@@ -204,32 +530,89 @@ func (svc *Service) createWithGPT(ctx context.Context, req openai.CompletionRequ
 	}, nil
 }
 
-func (svc *Service) createWithChat(ctx context.Context, req openai.CompletionRequest) (result, error) {
-	messages := []openai.ChatCompletionMessage{
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: req.Prompt.(string),
-		},
+func (svc *Service) makeChatRequest(req openai.CompletionRequest, gen generate.Context) (openai.ChatCompletionRequest, error) {
+	var messages []openai.ChatCompletionMessage
+
+	reasoning := isReasoningModel(req.Model)
+
+	// Reasoning models receive instructions as developer messages instead of
+	// system messages.
+	role := openai.ChatMessageRoleSystem
+	if reasoning {
+		role = openai.ChatMessageRoleDeveloper
 	}
 
+	if system := generate.SystemPromptOf(gen); system != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    role,
+			Content: system,
+		})
+	}
+
+	if svc.jsonMode {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    role,
+			Content: jsonModeInstruction(gen.Input().Identifier),
+		})
+	}
+
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: req.Prompt.(string),
+	})
+
 	maxTokens, err := svc.maxChatTokens(messages)
 	if err != nil {
-		return result{}, fmt.Errorf("max tokens: %w", err)
+		return openai.ChatCompletionRequest{}, fmt.Errorf("max tokens: %w", err)
 	}
 
-	resp, err := svc.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:            req.Model,
-		Temperature:      req.Temperature,
-		MaxTokens:        maxTokens,
-		PresencePenalty:  req.PresencePenalty,
-		FrequencyPenalty: req.FrequencyPenalty,
-		Messages:         messages,
-	})
+	var chatReq openai.ChatCompletionRequest
+	if reasoning {
+		chatReq = openai.ChatCompletionRequest{
+			Model:               req.Model,
+			MaxCompletionTokens: svc.reasoningTokens(messages, maxTokens),
+			Seed:                req.Seed,
+			Messages:            messages,
+		}
+	} else {
+		chatReq = openai.ChatCompletionRequest{
+			Model:            req.Model,
+			Temperature:      req.Temperature,
+			TopP:             req.TopP,
+			MaxTokens:        maxTokens,
+			PresencePenalty:  req.PresencePenalty,
+			FrequencyPenalty: req.FrequencyPenalty,
+			Seed:             req.Seed,
+			Messages:         messages,
+		}
+	}
+
+	if svc.jsonMode {
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+
+	return chatReq, nil
+}
+
+func (svc *Service) createWithChat(ctx context.Context, req openai.CompletionRequest, gen generate.Context) (result, error) {
+	chatReq, err := svc.makeChatRequest(req, gen)
+	if err != nil {
+		return result{}, err
+	}
+
+	resp, err := svc.client.CreateChatCompletion(ctx, chatReq)
 	if err != nil {
 		return result{}, err
 	}
 
-	svc.printUsage(resp.Usage)
+	if len(resp.Choices) == 0 {
+		return result{}, fmt.Errorf("openai: no choices returned")
+	}
+
+	svc.addUsage(resp.Usage, false)
+	svc.addFingerprint(resp.SystemFingerprint, false)
 
 	choice := resp.Choices[0]
 	res := result{
@@ -244,20 +627,89 @@ func (svc *Service) createWithChat(ctx context.Context, req openai.CompletionReq
 	return res, nil
 }
 
-func (svc *Service) maxGPTTokens(prompt string) (int, error) {
-	promptTokens, err := PromptTokens(svc.model, prompt)
+func (svc *Service) createWithChatStream(ctx context.Context, req openai.CompletionRequest, gen generate.Context) (result, error) {
+	chatReq, err := svc.makeChatRequest(req, gen)
 	if err != nil {
-		return 0, fmt.Errorf("compute tokens for prompt: %w", err)
+		return result{}, err
+	}
+	chatReq.Stream = true
+
+	stream, err := svc.client.CreateChatCompletionStream(ctx, chatReq)
+	if err != nil {
+		return result{}, err
+	}
+	defer stream.Close()
+
+	var (
+		text         strings.Builder
+		finishReason string
+		fingerprint  string
+	)
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result{}, err
+		}
+
+		if resp.SystemFingerprint != "" {
+			fingerprint = resp.SystemFingerprint
+		}
+
+		if len(resp.Choices) == 0 {
+			continue
+		}
+
+		choice := resp.Choices[0]
+		if choice.FinishReason != "" {
+			finishReason = string(choice.FinishReason)
+		}
+
+		if choice.Delta.Content == "" {
+			continue
+		}
+
+		text.WriteString(choice.Delta.Content)
+
+		if svc.progress != nil {
+			svc.progress(gen.Input(), text.String())
+		}
 	}
 
-	maxTokensForModel, ok := modelMaxTokens[string(svc.model)]
-	if !ok {
-		maxTokensForModel = modelMaxTokens["default"]
+	// Streamed responses don't report their usage, so it is estimated using
+	// the tokenizer of the model.
+	svc.addUsage(svc.estimateUsage(chatReq.Messages, text.String()), false)
+	svc.addFingerprint(fingerprint, false)
+
+	return result{
+		finishReason: finishReason,
+		text:         text.String(),
+	}, nil
+}
+
+func (svc *Service) estimateUsage(messages []openai.ChatCompletionMessage, completion string) openai.Usage {
+	var usage openai.Usage
+	if tokens, err := countChatTokens(svc.codec, svc.model, messages); err == nil {
+		usage.PromptTokens = tokens
+	}
+	if tokens, _, err := svc.codec.Encode(completion); err == nil {
+		usage.CompletionTokens = len(tokens)
 	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
 
-	remaining := maxTokensForModel - promptTokens
+func (svc *Service) maxGPTTokens(prompt string) (int, error) {
+	promptTokens, err := countTokens(svc.codec, prompt)
+	if err != nil {
+		return 0, fmt.Errorf("compute tokens for prompt: %w", err)
+	}
+
+	remaining := svc.contextWindow - promptTokens
 
-	maxTokens := int(math.Min(float64(svc.maxTokens), float64(maxTokensForModel)))
+	maxTokens := int(math.Min(float64(svc.maxTokens), float64(svc.contextWindow)))
 	maxTokens = int(math.Min(float64(maxTokens), float64(remaining)))
 	if maxTokens < 0 {
 		maxTokens = 0
@@ -267,19 +719,14 @@ func (svc *Service) maxGPTTokens(prompt string) (int, error) {
 }
 
 func (svc *Service) maxChatTokens(messages []openai.ChatCompletionMessage) (int, error) {
-	promptTokens, err := ChatTokens(svc.model, messages)
+	promptTokens, err := countChatTokens(svc.codec, svc.model, messages)
 	if err != nil {
 		return 0, fmt.Errorf("compute tokens for chat messages: %w", err)
 	}
 
-	maxTokensForModel, ok := modelMaxTokens[string(svc.model)]
-	if !ok {
-		maxTokensForModel = modelMaxTokens["default"]
-	}
-
-	remaining := maxTokensForModel - promptTokens
+	remaining := svc.contextWindow - promptTokens
 
-	maxTokens := int(math.Min(float64(svc.maxTokens), float64(maxTokensForModel)))
+	maxTokens := int(math.Min(float64(svc.maxTokens), float64(svc.contextWindow)))
 	maxTokens = int(math.Min(float64(maxTokens), float64(remaining)))
 	if maxTokens < 0 {
 		maxTokens = 0
@@ -288,36 +735,21 @@ func (svc *Service) maxChatTokens(messages []openai.ChatCompletionMessage) (int,
 	return maxTokens, nil
 }
 
-func (svc *Service) printUsage(usage openai.Usage) {
-	svc.log.Debug("[OpenAI] Usage info", "prompt", usage.PromptTokens, "completion", usage.CompletionTokens, "total", usage.TotalTokens)
-}
-
-func isChatModel(model string) bool {
-	return strings.HasPrefix(model, "gpt-")
-}
-
-// MaxTokensForModel retrieves the maximum number of tokens allowed for a given
-// model. If the specified model is not found in the predefined list, it returns
-// the default maximum token count. This limit is crucial for ensuring that
-// token generation stays within the bounds set by the model's capabilities.
-func MaxTokensForModel(model string) int {
-	if t, ok := modelMaxTokens[model]; ok {
-		return t
+// reasoningTokens returns the maximum number of completion tokens of a request
+// to a reasoning model, which includes [DefaultReasoningTokens] for reasoning
+// in addition to maxTokens for the output, limited by the remaining context
+// window.
+func (svc *Service) reasoningTokens(messages []openai.ChatCompletionMessage, maxTokens int) int {
+	promptTokens, err := countChatTokens(svc.codec, svc.model, messages)
+	if err != nil {
+		return maxTokens
 	}
-	return modelMaxTokens["default"]
+	remaining := svc.contextWindow - promptTokens
+	return int(math.Max(float64(maxTokens), math.Min(float64(maxTokens+DefaultReasoningTokens), float64(remaining))))
 }
 
-var modelMaxTokens = map[string]int{
-	"default":                2049,
-	openai.GPT4TurboPreview:  128000,
-	openai.GPT4VisionPreview: 128000,
-	openai.GPT432K0314:       32768,
-	openai.GPT432K:           32768,
-	openai.GPT40314:          8192,
-	openai.GPT4:              8192,
-	openai.GPT3Dot5Turbo16K:  16384,
-	openai.GPT3Dot5Turbo0301: 4096,
-	openai.GPT3Dot5Turbo:     4096,
+func isChatModel(model string) bool {
+	return strings.HasPrefix(model, "gpt-") || isReasoningModel(model)
 }
 
 type result struct {
@@ -328,3 +760,55 @@ type result struct {
 func (r *result) normalize() {
 	r.text = strings.TrimSpace(r.text)
 }
+
+// decodeJSON replaces the text of the result with the documentation that is
+// contained in the JSON object of a JSON mode response. If identifier is not
+// empty, the identifier in the response must match it.
+func (r *result) decodeJSON(identifier string) error {
+	var payload struct {
+		Identifier string `json:"identifier"`
+		Doc        string `json:"doc"`
+	}
+	if err := json.Unmarshal([]byte(r.text), &payload); err != nil {
+		return fmt.Errorf("openai: decode JSON response: %w\n%s", err, r.text)
+	}
+
+	if identifier != "" && payload.Identifier != "" && payload.Identifier != identifier {
+		return fmt.Errorf("openai: JSON response is for %q, expected %q", payload.Identifier, identifier)
+	}
+
+	r.text = payload.Doc
+
+	return nil
+}
+
+func jsonModeInstruction(identifier string) string {
+	return fmt.Sprintf(`Respond only with a JSON object of the form {"identifier": %q, "doc": "<comment>"}, where <comment> is the requested comment.`, identifier)
+}
+
+// headerTransport adds the configured headers, such as the organization and
+// project headers, to each request.
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if len(t.header) > 0 {
+		req = req.Clone(req.Context())
+		setHeader(req, t.header)
+	}
+
+	return base.RoundTrip(req)
+}
+
+func setHeader(req *http.Request, header http.Header) {
+	for key, values := range header {
+		req.Header[key] = values
+	}
+}
@@ -0,0 +1,226 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultMaxRetries is the default number of times a [*Service] retries a
+	// generation that failed because of a transient error.
+	DefaultMaxRetries = 3
+
+	// DefaultBackoff is the default delay before the first retry. The delay is
+	// doubled for each subsequent retry.
+	DefaultBackoff = time.Second
+
+	// DefaultMaxBackoff is the default upper limit of the delay between two
+	// retries.
+	DefaultMaxBackoff = 30 * time.Second
+
+	// DefaultJitter is the default fraction by which the delay between two
+	// retries is randomly reduced.
+	DefaultJitter = 0.5
+)
+
+// MaxRetries configures how often the Service retries a generation that failed
+// because of a transient error, such as a rate limit (429), a server error
+// (5xx) or a timeout. A value of zero disables retries. Defaults to
+// [DefaultMaxRetries].
+func MaxRetries(n int) Option {
+	return func(s *Service) {
+		s.retry.attempts = n
+	}
+}
+
+// Backoff configures the delay before the first retry and the upper limit of
+// the delay between two retries. The delay is doubled for each subsequent
+// retry. If the API responds with a "Retry-After" header, the delay requested
+// by the API is used instead, up to the upper limit. Defaults to
+// [DefaultBackoff] and [DefaultMaxBackoff].
+func Backoff(initial, max time.Duration) Option {
+	return func(s *Service) {
+		s.retry.backoff = initial
+		s.retry.maxBackoff = max
+	}
+}
+
+// Jitter configures the fraction (between 0 and 1) by which the delay between
+// two retries is randomly reduced, so that concurrent workers don't retry at
+// the same time. Defaults to [DefaultJitter].
+func Jitter(fraction float64) Option {
+	return func(s *Service) {
+		s.retry.jitter = math.Max(0, math.Min(1, fraction))
+	}
+}
+
+type retryConfig struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	jitter     float64
+}
+
+// delay returns the delay before the given retry (starting at 0). If the API
+// requested a delay using the "Retry-After" header, that delay is returned,
+// limited to the maximum backoff.
+func (cfg retryConfig) delay(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if cfg.maxBackoff > 0 && retryAfter > cfg.maxBackoff {
+			return cfg.maxBackoff
+		}
+		return retryAfter
+	}
+
+	d := float64(cfg.backoff) * math.Pow(2, float64(retry))
+	if cfg.maxBackoff > 0 {
+		d = math.Min(d, float64(cfg.maxBackoff))
+	}
+	d -= d * cfg.jitter * rand.Float64()
+
+	return time.Duration(d)
+}
+
+// withRetry calls fn until it succeeds, fails with a non-transient error, or
+// the configured number of retries is exhausted. Each attempt gets its own
+// timeout. If the retries are exhausted, the last error is marked as
+// [generate.ErrUnavailable].
+func (svc *Service) withRetry(ctx context.Context, identifier string, fn func(context.Context) (result, error)) (result, error) {
+	for retry := 0; ; retry++ {
+		hint := &retryHint{}
+		attemptCtx, cancel := context.WithTimeout(context.WithValue(ctx, retryHintKey{}, hint), svc.timeout)
+		res, err := fn(attemptCtx)
+		cancel()
+
+		if err == nil || ctx.Err() != nil || !isTransient(err) {
+			return res, err
+		}
+
+		if retry >= svc.retry.attempts {
+			return res, generate.Unavailable(err)
+		}
+
+		delay := svc.retry.delay(retry, hint.get())
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", retry+1),
+			attribute.String("error", err.Error()),
+			attribute.String("delay", delay.String()),
+		))
+		svc.log.Warn(fmt.Sprintf("[OpenAI] Generation of %s failed. Retrying in %s (%d/%d) ...", identifier, delay.Round(time.Millisecond), retry+1, svc.retry.attempts), "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, err
+		case <-timer.C:
+		}
+	}
+}
+
+// isTransient reports whether err is caused by a rate limit, a server error, a
+// network failure or a timeout, so that the request may succeed when it is
+// retried.
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		// An exhausted quota is reported as a rate limit, but retrying won't help.
+		if code, ok := apiErr.Code.(string); ok && code == "insufficient_quota" {
+			return false
+		}
+		return isTransientStatus(apiErr.HTTPStatusCode)
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return isTransientStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isTransientStatus(code int) bool {
+	return internal.UnavailableStatus(code)
+}
+
+type retryHintKey struct{}
+
+// retryHint carries the delay that was requested by the API using the
+// "Retry-After" header from the HTTP transport back to the Service.
+type retryHint struct {
+	mux   sync.Mutex
+	after time.Duration
+}
+
+func (h *retryHint) set(d time.Duration) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.after = d
+}
+
+func (h *retryHint) get() time.Duration {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.after
+}
+
+// retryAfterTransport records the "Retry-After" header of responses into the
+// [*retryHint] of the request context.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			hint.set(d)
+		}
+	}
+
+	return resp, nil
+}
+
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"deadline":           {err: context.DeadlineExceeded, want: true},
+		"rate limit":         {err: &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, want: true},
+		"server error":       {err: &openai.APIError{HTTPStatusCode: http.StatusBadGateway}, want: true},
+		"insufficient quota": {err: &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, want: false},
+		"bad request":        {err: &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, want: false},
+		"request error":      {err: &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable}, want: true},
+		"wrapped":            {err: fmt.Errorf("generate: %w", &openai.APIError{HTTPStatusCode: http.StatusInternalServerError}), want: true},
+		"other":              {err: errors.New("foo"), want: false},
+	}
+
+	for name, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("%s: isTransient() should return %v; got %v", name, tt.want, got)
+		}
+	}
+}
+
+func TestRetryConfig_delay(t *testing.T) {
+	cfg := retryConfig{backoff: time.Second, maxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		retry      int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{retry: 0, want: time.Second},
+		{retry: 1, want: 2 * time.Second},
+		{retry: 2, want: 4 * time.Second},
+		{retry: 3, want: 5 * time.Second},
+		{retry: 0, retryAfter: 3 * time.Second, want: 3 * time.Second},
+		{retry: 0, retryAfter: time.Hour, want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.delay(tt.retry, tt.retryAfter); got != tt.want {
+			t.Errorf("delay(%d, %s) should return %s; got %s", tt.retry, tt.retryAfter, tt.want, got)
+		}
+	}
+}
+
+func TestRetryConfig_delay_jitter(t *testing.T) {
+	cfg := retryConfig{backoff: time.Second, maxBackoff: 5 * time.Second, jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		if got := cfg.delay(1, 0); got < time.Second || got > 2*time.Second {
+			t.Fatalf("delay(1, 0) should be between 1s and 2s with a jitter of 0.5; got %s", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if _, ok := parseRetryAfter(""); ok {
+		t.Errorf("parseRetryAfter() should not parse an empty header")
+	}
+
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Errorf("parseRetryAfter() should not parse an invalid header")
+	}
+
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("parseRetryAfter(%q) should return %s; got %s (%v)", "3", 3*time.Second, d, ok)
+	}
+
+	header := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(header); !ok || d <= 50*time.Second || d > time.Minute {
+		t.Errorf("parseRetryAfter(%q) should return about a minute; got %s (%v)", header, d, ok)
+	}
+}
+
+func TestService_GenerateDoc_retry(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Foo returns foo."},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	svc, err := New("key", BaseURL(srv.URL), Model("gpt-4o"), Backoff(time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	start := time.Now()
+	doc, err := svc.GenerateDoc(genCtx{Context: context.Background(), prompt: "Document Foo."})
+	if err != nil {
+		t.Fatalf("GenerateDoc() failed: %v", err)
+	}
+
+	if want := "Foo returns foo."; doc != want {
+		t.Errorf("GenerateDoc() should return %q; got %q", want, doc)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("request should be sent twice; got %d requests", n)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Retry-After should be limited to the maximum backoff; retry took %s", elapsed)
+	}
+}
+
+type genCtx struct {
+	context.Context
+
+	prompt string
+}
+
+func (genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{
+		File: "foo.go",
+		Input: generate.Input{
+			Code:       []byte("package foo\n\nfunc Foo() string { return \"foo\" }\n"),
+			Language:   "go",
+			Identifier: "func:Foo",
+		},
+	}
+}
+
+func (ctx genCtx) Prompt() string { return ctx.prompt }
@@ -28,7 +28,10 @@ func ChatTokens(model string, messages []openai.ChatCompletionMessage) (int, err
 	if err != nil {
 		return 0, err
 	}
+	return countChatTokens(codec, model, messages)
+}
 
+func countChatTokens(codec tokenizer.Codec, model string, messages []openai.ChatCompletionMessage) (int, error) {
 	var (
 		perMessage = 3
 		perName    int
@@ -82,6 +85,10 @@ func PromptTokens(model string, prompt string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	return countTokens(codec, prompt)
+}
+
+func countTokens(codec tokenizer.Codec, prompt string) (int, error) {
 	toks, _, err := codec.Encode(prompt)
 	return len(toks), err
 }
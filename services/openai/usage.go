@@ -0,0 +1,231 @@
+package openai
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Usage is the accumulated token usage of a model.
+type Usage struct {
+	// Model is the model that processed the requests.
+	Model string
+
+	// Batch reports whether the requests were processed by the Batch API,
+	// which is billed at half the regular price.
+	Batch bool
+
+	// Requests is the number of requests.
+	Requests int
+
+	// PromptTokens is the number of tokens of all prompts.
+	PromptTokens int
+
+	// CompletionTokens is the number of tokens of all completions.
+	CompletionTokens int
+
+	// Fingerprints are the distinct system fingerprints that were returned
+	// with the completions, in the order in which they were first seen. The
+	// system fingerprint identifies the backend configuration of the model;
+	// runs with the same [Seed] are only reproducible if their fingerprints
+	// match.
+	Fingerprints []string `json:",omitempty"`
+}
+
+// Cost returns the estimated cost of the usage in USD. Cost returns false if
+// the price of the model is unknown.
+func (u Usage) Cost() (float64, bool) {
+	p, ok := priceOf(u.Model)
+	if !ok {
+		return 0, false
+	}
+
+	cost := float64(u.PromptTokens)*p.prompt/1e6 + float64(u.CompletionTokens)*p.completion/1e6
+	if u.Batch {
+		cost /= 2
+	}
+
+	return cost, true
+}
+
+// UsageTracker accumulates the token usage of one or more Services. A
+// UsageTracker can be shared between Services using [TrackUsage], to get the
+// summarized usage of all models that were used during a run.
+type UsageTracker struct {
+	mux    sync.Mutex
+	usage  map[usageKey]*Usage
+	budget float64
+}
+
+type usageKey struct {
+	model string
+	batch bool
+}
+
+// NewUsageTracker returns an empty UsageTracker.
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{usage: make(map[usageKey]*Usage)}
+}
+
+// TrackUsage configures the Service to record its token usage into the
+// provided tracker instead of its own.
+func TrackUsage(t *UsageTracker) Option {
+	return func(s *Service) {
+		s.usage = t
+	}
+}
+
+// Add records the usage of a single request to the given model.
+func (t *UsageTracker) Add(model string, batch bool, usage openai.Usage) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	key := usageKey{model: model, batch: batch}
+	u, ok := t.usage[key]
+	if !ok {
+		u = &Usage{Model: model, Batch: batch}
+		t.usage[key] = u
+	}
+
+	u.Requests++
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+}
+
+// AddFingerprint records the system fingerprint of a completion of the given
+// model. Empty fingerprints are ignored.
+func (t *UsageTracker) AddFingerprint(model string, batch bool, fingerprint string) {
+	if fingerprint == "" {
+		return
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	key := usageKey{model: model, batch: batch}
+	u, ok := t.usage[key]
+	if !ok {
+		u = &Usage{Model: model, Batch: batch}
+		t.usage[key] = u
+	}
+
+	for _, fp := range u.Fingerprints {
+		if fp == fingerprint {
+			return
+		}
+	}
+	u.Fingerprints = append(u.Fingerprints, fingerprint)
+}
+
+// Usage returns the accumulated usage per model, sorted by model.
+func (t *UsageTracker) Usage() []Usage {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	out := make([]Usage, 0, len(t.usage))
+	for _, u := range t.usage {
+		c := *u
+		c.Fingerprints = append([]string(nil), u.Fingerprints...)
+		out = append(out, c)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return !out[i].Batch && out[j].Batch
+	})
+
+	return out
+}
+
+// Cost returns the estimated total cost of all recorded usage in USD. Usage of
+// models with an unknown price is not included.
+func (t *UsageTracker) Cost() float64 {
+	var total float64
+	for _, u := range t.Usage() {
+		if cost, ok := u.Cost(); ok {
+			total += cost
+		}
+	}
+	return total
+}
+
+// SetBudget limits the estimated total cost of the tracked usage to the given
+// amount in USD. Once the budget is exceeded, Services that record their usage
+// into the tracker stop sending requests and fail with
+// [generate.ErrBudgetExceeded]. Requests that are already in flight are
+// finished. A budget of zero removes the limit.
+func (t *UsageTracker) SetBudget(usd float64) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.budget = usd
+}
+
+// BudgetExceeded reports whether the estimated total cost of the tracked usage
+// has reached the budget configured by [*UsageTracker.SetBudget].
+func (t *UsageTracker) BudgetExceeded() bool {
+	t.mux.Lock()
+	budget := t.budget
+	t.mux.Unlock()
+	return budget > 0 && t.Cost() >= budget
+}
+
+// Usage returns the accumulated token usage of the Service per model.
+func (svc *Service) Usage() []Usage {
+	return svc.usage.Usage()
+}
+
+func (svc *Service) addUsage(usage openai.Usage, batch bool) {
+	svc.log.Debug("[OpenAI] Usage info", "prompt", usage.PromptTokens, "completion", usage.CompletionTokens, "total", usage.TotalTokens)
+	svc.usage.Add(svc.model, batch, usage)
+}
+
+func (svc *Service) addFingerprint(fingerprint string, batch bool) {
+	if fingerprint != "" {
+		svc.log.Debug("[OpenAI] System fingerprint", "fingerprint", fingerprint)
+	}
+	svc.usage.AddFingerprint(svc.model, batch, fingerprint)
+}
+
+type price struct {
+	prompt     float64
+	completion float64
+}
+
+// modelPrices are the prices of models in USD per 1M tokens. Prices of model
+// snapshots are looked up by the longest matching prefix.
+var modelPrices = map[string]price{
+	"o1":                     {prompt: 15, completion: 60},
+	"o1-mini":                {prompt: 1.1, completion: 4.4},
+	"o3":                     {prompt: 2, completion: 8},
+	"o3-mini":                {prompt: 1.1, completion: 4.4},
+	"o4-mini":                {prompt: 1.1, completion: 4.4},
+	"gpt-4o-mini":            {prompt: 0.15, completion: 0.6},
+	"gpt-4o":                 {prompt: 5, completion: 15},
+	"gpt-4-turbo":            {prompt: 10, completion: 30},
+	"gpt-4-1106":             {prompt: 10, completion: 30},
+	"gpt-4-0125":             {prompt: 10, completion: 30},
+	"gpt-4-vision":           {prompt: 10, completion: 30},
+	"gpt-4-32k":              {prompt: 60, completion: 120},
+	"gpt-4":                  {prompt: 30, completion: 60},
+	"gpt-3.5-turbo-instruct": {prompt: 1.5, completion: 2},
+	"gpt-3.5-turbo":          {prompt: 0.5, completion: 1.5},
+	"davinci-002":            {prompt: 2, completion: 2},
+	"babbage-002":            {prompt: 0.4, completion: 0.4},
+}
+
+func priceOf(model string) (price, bool) {
+	var (
+		match string
+		p     price
+	)
+	for prefix, mp := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			match, p = prefix, mp
+		}
+	}
+	return p, match != ""
+}
@@ -0,0 +1,78 @@
+package openai_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/modernice/jotbot/services/openai"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+func TestMaxCost_unknownPrice(t *testing.T) {
+	if _, err := openai.New("key", openai.Model("my-custom-model"), openai.MaxCost(1)); err == nil {
+		t.Fatalf("New() should fail if a max cost is configured for a model with an unknown price")
+	}
+
+	if _, err := openai.New("key", openai.Model("my-custom-model")); err != nil {
+		t.Fatalf("New() should not fail without a max cost: %v", err)
+	}
+
+	if _, err := openai.New("key", openai.Model("gpt-4o"), openai.MaxCost(1)); err != nil {
+		t.Fatalf("New() should not fail for a model with a known price: %v", err)
+	}
+}
+
+func TestUsage_Cost(t *testing.T) {
+	u := openai.Usage{Model: "gpt-4o-2024-05-13", PromptTokens: 2e6, CompletionTokens: 1e6}
+
+	cost, ok := u.Cost()
+	if !ok || cost != 25 {
+		t.Errorf("Cost() should return $25 for a snapshot of gpt-4o; got $%v (%v)", cost, ok)
+	}
+
+	u.Batch = true
+	if cost, _ := u.Cost(); cost != 12.5 {
+		t.Errorf("Cost() should return half the price for batches; got $%v", cost)
+	}
+
+	if _, ok := (openai.Usage{Model: "my-model", PromptTokens: 1}).Cost(); ok {
+		t.Errorf("Cost() should report false for models with unknown prices")
+	}
+}
+
+func TestUsageTracker(t *testing.T) {
+	tracker := openai.NewUsageTracker()
+	tracker.Add("gpt-4o", false, goopenai.Usage{PromptTokens: 1e6, CompletionTokens: 1e6})
+	tracker.Add("gpt-4o", false, goopenai.Usage{PromptTokens: 1e6})
+	tracker.Add("gpt-4o", true, goopenai.Usage{PromptTokens: 2e6})
+	tracker.Add("my-model", false, goopenai.Usage{PromptTokens: 1e6, CompletionTokens: 1e6})
+	tracker.AddFingerprint("gpt-4o", false, "fp_1")
+	tracker.AddFingerprint("gpt-4o", false, "fp_1")
+	tracker.AddFingerprint("gpt-4o", false, "fp_2")
+
+	want := []openai.Usage{
+		{Model: "gpt-4o", Requests: 2, PromptTokens: 2e6, CompletionTokens: 1e6, Fingerprints: []string{"fp_1", "fp_2"}},
+		{Model: "gpt-4o", Batch: true, Requests: 1, PromptTokens: 2e6, Fingerprints: []string{}},
+		{Model: "my-model", Requests: 1, PromptTokens: 1e6, CompletionTokens: 1e6, Fingerprints: []string{}},
+	}
+	if got := tracker.Usage(); !cmp.Equal(want, got, cmpopts.EquateEmpty()) {
+		t.Errorf("Usage() returned wrong usage\n%s", cmp.Diff(want, got, cmpopts.EquateEmpty()))
+	}
+
+	// 2M prompt and 1M completion tokens of gpt-4o, and 2M prompt tokens of
+	// gpt-4o in a batch. The usage of my-model is not included.
+	if cost := tracker.Cost(); cost != 30 {
+		t.Errorf("Cost() should return $30; got $%v", cost)
+	}
+
+	tracker.SetBudget(40)
+	if tracker.BudgetExceeded() {
+		t.Errorf("BudgetExceeded() should report false below the budget")
+	}
+
+	tracker.SetBudget(30)
+	if !tracker.BudgetExceeded() {
+		t.Errorf("BudgetExceeded() should report true once the budget is reached")
+	}
+}
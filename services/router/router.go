@@ -0,0 +1,177 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/internal"
+	"github.com/modernice/jotbot/services/openai"
+	"golang.org/x/exp/slog"
+)
+
+// Rule routes the generations of matching symbols to a [generate.Service]. A
+// symbol matches a rule if its kind is one of the rule's kinds and the number
+// of tokens of its prompt is within the rule's token range. Empty or zero
+// fields of a Rule match any symbol.
+type Rule struct {
+	// Kinds are the kinds of symbols that match the rule, e.g. "func", "type"
+	// or "var", as found in the prefix of identifiers.
+	Kinds []string
+
+	// MinTokens is the minimum number of prompt tokens of matching symbols.
+	MinTokens int
+
+	// MaxTokens is the maximum number of prompt tokens of matching symbols.
+	MaxTokens int
+
+	// Service generates the documentation of matching symbols.
+	Service generate.Service
+
+	// Name identifies the rule in log messages, e.g. the name of the model
+	// that is used by Service.
+	Name string
+}
+
+func (r Rule) matches(kind string, tokens int) bool {
+	if len(r.Kinds) > 0 {
+		var ok bool
+		for _, k := range r.Kinds {
+			if k == kind {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if r.MinTokens > 0 && tokens < r.MinTokens {
+		return false
+	}
+
+	if r.MaxTokens > 0 && tokens > r.MaxTokens {
+		return false
+	}
+
+	return true
+}
+
+// Service routes each generation to the [generate.Service] of the first
+// matching [Rule], so that small or simple symbols can be documented by a
+// cheap model while large or complex ones are documented by a premium model.
+// Generations that match no rule are sent to the fallback service.
+type Service struct {
+	fallback generate.Service
+	rules    []Rule
+	tokens   func(string) (int, error)
+	log      *slog.Logger
+}
+
+// Option configures a [*Service].
+type Option func(*Service)
+
+// Tokens configures the function that counts the tokens of prompts. By
+// default, prompts are counted using the tokenizer of [openai.DefaultModel].
+func Tokens(count func(prompt string) (int, error)) Option {
+	return func(s *Service) {
+		s.tokens = count
+	}
+}
+
+// WithLogger configures the logging handler of the Service.
+func WithLogger(h slog.Handler) Option {
+	return func(s *Service) {
+		s.log = slog.New(h)
+	}
+}
+
+// New returns a Service that routes generations using the given rules, which
+// are evaluated in order. Generations that match no rule are sent to fallback.
+func New(fallback generate.Service, rules []Rule, opts ...Option) (*Service, error) {
+	svc := Service{fallback: fallback, rules: rules}
+	for _, opt := range opts {
+		opt(&svc)
+	}
+
+	if svc.log == nil {
+		svc.log = internal.NopLogger()
+	}
+
+	if svc.tokens == nil {
+		codec, err := internal.OpenAITokenizer(openai.DefaultModel)
+		if err != nil {
+			return nil, fmt.Errorf("create tokenizer: %w", err)
+		}
+		svc.tokens = func(prompt string) (int, error) {
+			tokens, _, err := codec.Encode(prompt)
+			return len(tokens), err
+		}
+	}
+
+	for i, rule := range svc.rules {
+		if rule.Service == nil {
+			return nil, fmt.Errorf("rule #%d has no service", i+1)
+		}
+	}
+
+	return &svc, nil
+}
+
+// GenerateDoc sends the generation to the service of the first rule that
+// matches the kind and prompt size of the symbol, or to the fallback service if
+// no rule matches.
+func (svc *Service) GenerateDoc(ctx generate.Context) (string, error) {
+	if len(svc.rules) == 0 {
+		return svc.fallback.GenerateDoc(ctx)
+	}
+
+	input := ctx.Input()
+	kind, _, _ := strings.Cut(input.Identifier, ":")
+
+	tokens, err := svc.tokens(ctx.Prompt())
+	if err != nil {
+		return "", fmt.Errorf("count prompt tokens: %w", err)
+	}
+
+	for i, rule := range svc.rules {
+		if rule.matches(kind, tokens) {
+			svc.log.Debug(fmt.Sprintf("[Router] Routing %s to rule #%d %s", input.Identifier, i+1, rule.Name), "tokens", tokens)
+			return rule.Service.GenerateDoc(ctx)
+		}
+	}
+
+	svc.log.Debug(fmt.Sprintf("[Router] Routing %s to fallback service", input.Identifier), "tokens", tokens)
+
+	return svc.fallback.GenerateDoc(ctx)
+}
+
+// StructuredOutput reports whether all services of the router return
+// documentation decoded from structured output. It implements
+// [generate.Structured].
+func (svc *Service) StructuredOutput() bool {
+	if !structured(svc.fallback) {
+		return false
+	}
+	for _, rule := range svc.rules {
+		if !structured(rule.Service) {
+			return false
+		}
+	}
+	return true
+}
+
+func structured(svc generate.Service) bool {
+	s, ok := svc.(generate.Structured)
+	return ok && s.StructuredOutput()
+}
+
+// Model returns the model of the fallback service, which documents the symbols
+// that match no rule, if it implements [generate.Modeler].
+func (svc *Service) Model() string {
+	if m, ok := svc.fallback.(generate.Modeler); ok {
+		return m.Model()
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package router_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modernice/jotbot/generate"
+	"github.com/modernice/jotbot/services/router"
+)
+
+type service string
+
+func (s service) GenerateDoc(generate.Context) (string, error) {
+	return string(s), nil
+}
+
+type genCtx struct {
+	context.Context
+
+	identifier string
+	prompt     string
+}
+
+func (ctx genCtx) Input() generate.PromptInput {
+	return generate.PromptInput{Input: generate.Input{Identifier: ctx.identifier}}
+}
+
+func (ctx genCtx) Prompt() string {
+	return ctx.prompt
+}
+
+func TestService_GenerateDoc(t *testing.T) {
+	svc, err := router.New(service("default"), []router.Rule{
+		{Kinds: []string{"var"}, Service: service("cheap")},
+		{MinTokens: 10, Service: service("premium")},
+	}, router.Tokens(func(prompt string) (int, error) {
+		return len(strings.Fields(prompt)), nil
+	}))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		identifier string
+		prompt     string
+		want       string
+	}{
+		{identifier: "var:Foo", prompt: strings.Repeat("word ", 20), want: "cheap"},
+		{identifier: "func:Foo", prompt: strings.Repeat("word ", 20), want: "premium"},
+		{identifier: "func:Foo", prompt: "word", want: "default"},
+	}
+
+	for _, tt := range tests {
+		got, err := svc.GenerateDoc(genCtx{Context: context.Background(), identifier: tt.identifier, prompt: tt.prompt})
+		if err != nil {
+			t.Fatalf("GenerateDoc() failed: %v", err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s should be routed to %q; was routed to %q", tt.identifier, tt.want, got)
+		}
+	}
+}
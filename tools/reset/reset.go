@@ -1,10 +1,14 @@
 package reset
 
-import "github.com/dave/dst"
+import (
+	"github.com/dave/dst"
+	"github.com/modernice/jotbot/internal/nodes"
+)
 
 // Comments removes all comments from the specified [dst.Node] and its
 // descendants in the abstract syntax tree, including package, file, and
-// declaration-level comments.
+// declaration-level comments. The cgo preambles of `import "C"` declarations
+// are kept, because the code would not compile without them.
 func Comments(node dst.Node) {
 	dst.Inspect(node, func(node dst.Node) bool {
 		switch node := node.(type) {
@@ -12,7 +16,9 @@ func Comments(node dst.Node) {
 			node.Decs.Package.Clear()
 			node.Decs.Start.Clear()
 		case *dst.GenDecl:
-			node.Decs.Start.Clear()
+			if !nodes.IsCgoImport(node) {
+				node.Decs.Start.Clear()
+			}
 		case *dst.FuncDecl:
 			node.Decs.Start.Clear()
 		case *dst.ValueSpec: